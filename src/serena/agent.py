@@ -28,6 +28,8 @@ from sensai.util.string import dict_string
 from interprompt.jinja_template import JinjaTemplate
 from serena import serena_version
 from serena.analytics import RegisteredTokenCountEstimator, ToolUsageStats
+from serena.approval import ApprovalPolicy, PendingOperationRegistry
+from serena.background_indexer import BackgroundIndexer
 from serena.config.context_mode import SerenaAgentContext, SerenaAgentMode
 from serena.config.serena_config import (
     LanguageBackend,
@@ -44,11 +46,16 @@ from serena.dashboard import SerenaDashboardAPI, SerenaDashboardTrayManager, Ser
 from serena.jetbrains import jetbrains_plugin_client
 from serena.ls_manager import LanguageServerManager
 from serena.memories.memory_manager import MemoryManager
+from serena.memories.session_record import LAST_SESSION_MEMORY_NAME, SessionRecord
 from serena.project import Project
 from serena.prompt_factory import SerenaPromptFactory
 from serena.task_executor import TaskExecutor
 from serena.tools import (
+    TERRAFORM_TOOLS,
     ActivateProjectTool,
+    ActivateToolsTool,
+    ApprovePendingOperationTool,
+    DeactivateToolsTool,
     GetCurrentConfigTool,
     OnboardingTool,
     OpenDashboardTool,
@@ -262,6 +269,13 @@ class ActiveModes:
             cls._mode_instances[mode_name] = SerenaAgentMode.load(mode_name)
         return cls._mode_instances[mode_name]
 
+    @classmethod
+    def clear_mode_instance_cache(cls) -> None:
+        """
+        Clears the cache of loaded modes, such that mode definitions are subsequently reloaded from their YAML files.
+        """
+        cls._mode_instances.clear()
+
     def get_modes(self, include_background_base_modes: bool = False) -> Sequence[SerenaAgentMode]:
         result: list[SerenaAgentMode] = []
         if include_background_base_modes:
@@ -537,6 +551,11 @@ class DashboardManager:
 
 
 class SerenaAgent:
+    TOOL_NAMES_NOT_DEACTIVATABLE = (ActivateToolsTool.get_name_from_cls(), DeactivateToolsTool.get_name_from_cls())
+    """
+    the names of the tools which cannot be deactivated at runtime (as the deactivation could not be reverted)
+    """
+
     def __init__(
         self,
         project: str | None = None,
@@ -551,13 +570,15 @@ class SerenaAgent:
             an already registered project;
         :param project_activation_callback: a callback function to be called when a project is activated.
         :param serena_config: the Serena configuration or None to read the configuration from the default location.
-        :param context: the context in which the agent is operating, None for default context.
+        :param context: the context in which the agent is operating, None for the configured default context.
             The context may adjust prompts, tool availability, and tool descriptions.
         :param modes: mode selection definition to apply for this session
         :param memory_log_handler: a MemoryLogHandler instance from which to read log messages; if None, a new one will be created
             if necessary.
         """
         self._active_project: Project | None = None
+        self._background_indexer: BackgroundIndexer | None = None
+        """indexes the active project's files in the background (if enabled)"""
         self._project_activation_callback = project_activation_callback
         self._gui_log_viewer: Optional["GuiLogViewer"] = None
         self._dashboard_manager: DashboardManager | None = None
@@ -565,6 +586,12 @@ class SerenaAgent:
         self._session_mode_selection_definition = modes
         self.version = serena_version()
         self._config_changed_callbacks: list[Callable[[], None]] = []
+        self._pending_operations = PendingOperationRegistry()
+        self._exposed_tools_changed_callbacks: list[Callable[[], None]] = []
+        self._exposed_tools_changed_flag = False
+        self._session_activated_tools: set[str] = set()
+        self._session_deactivated_tools: set[str] = set()
+        self._session_record = SessionRecord()
 
         # obtain serena configuration using the decoupled factory function
         self.serena_config = serena_config or SerenaConfig.from_config_file()
@@ -612,7 +639,7 @@ class SerenaAgent:
 
         # set the agent context
         if context is None:
-            context = SerenaAgentContext.load_default()
+            context = SerenaAgentContext.load(self.serena_config.default_context)
         self._context = context
 
         # instantiate all tool classes
@@ -671,7 +698,9 @@ class SerenaAgent:
         self._update_active_modes()
 
         # determine the base toolset defining the set of exposed tools (which e.g. the MCP shall see),
-        self._base_toolset = self._create_base_toolset(self.serena_config, self._context, self._active_modes, self._active_project)
+        self._base_toolset = self._create_base_toolset(
+            self.serena_config, self._context, self._active_modes, self._active_project, self._get_session_tool_selection()
+        )
         self._exposed_tools = self._base_toolset.to_available_tools(self._all_tools)
         log.info(f"Number of exposed tools: {len(self._exposed_tools)}. Exposed tools: {self._exposed_tools.tool_names}")
 
@@ -738,16 +767,19 @@ class SerenaAgent:
         context: SerenaAgentContext,
         modes: ActiveModes,
         project: Project | None,
+        session_tool_selection: ToolInclusionDefinition | None = None,
     ) -> ToolSet:
         """
         Determines the base toolset defining the set of exposed tools (which e.g. the MCP shall see).
         It depends on ...
            * dashboard availability/opening on launch
+           * the languages of the project (Terraform-specific tools)
            * Serena config
            * the context (which is fixed for the session)
            * the base modes (including background base modes like JetBrains mode)
            * the optional tools enabled by initial dynamic modes
            * single-project mode reductions (if applicable)
+           * the tools (de)activated during the session (if any)
         """
         # determine whether to include the OpenDashboardTool based on the Serena configuration
         tool_inclusion_definitions: list[ToolInclusionDefinition] = []
@@ -756,6 +788,22 @@ class SerenaAgent:
                 NamedToolInclusionDefinition(name="OpenDashboard", included_optional_tools=[OpenDashboardTool.get_name_from_cls()])
             )
 
+        # include the ApprovePendingOperationTool only if there are operations which require approval
+        if not cls._create_approval_policy(serena_config).is_empty():
+            tool_inclusion_definitions.append(
+                NamedToolInclusionDefinition(
+                    name="ApprovalPolicy", included_optional_tools=[ApprovePendingOperationTool.get_name_from_cls()]
+                )
+            )
+
+        # include the tools which support only Terraform if the project uses Terraform
+        if project is not None and LanguageServerId.TERRAFORM in project.project_config.language_servers:
+            tool_inclusion_definitions.append(
+                NamedToolInclusionDefinition(
+                    name="TerraformTools", included_optional_tools=[t.get_name_from_cls() for t in TERRAFORM_TOOLS]
+                )
+            )
+
         # consider Serena configuration and the active context
         tool_inclusion_definitions.append(serena_config)
         tool_inclusion_definitions.append(context)
@@ -803,6 +851,10 @@ class SerenaAgent:
             )
             tool_inclusion_definitions.append(project.project_config)
 
+        # tools (de)activated during the session take precedence over all other definitions
+        if session_tool_selection is not None:
+            tool_inclusion_definitions.append(session_tool_selection)
+
         # compute the resulting tool set
         base_toolset = ToolSet.default().apply(*tool_inclusion_definitions)
         log.info(f"Number of exposed tools: {len(base_toolset)}")
@@ -863,9 +915,15 @@ class SerenaAgent:
                 os.environ["COMSPEC"] = ""  # force use of default shell
                 log.info("Adjusting COMSPEC environment variable to use the default shell instead of '%s'", comspec)
 
-    def record_tool_usage(self, input_kwargs: dict, tool_result: str | dict, tool: Tool) -> None:
+    def record_tool_usage(self, input_kwargs: dict, tool_result: str | dict, tool: Tool, edited_files: Sequence[str] = ()) -> None:
         """
         Record the usage of a tool with the given input and output strings if tool usage statistics recording is enabled.
+
+        :param input_kwargs: the parameters of the tool call
+        :param tool_result: the result of the tool call
+        :param tool: the tool
+        :param edited_files: the relative paths of the files which were actually mutated by the tool call
+            (as recorded in the edit history), which are added to the session record
         """
         tool_name = tool.get_name()
         input_str = str(input_kwargs)
@@ -873,6 +931,10 @@ class SerenaAgent:
         log.debug(f"Recording tool usage for tool '{tool_name}'")
         self._tool_usage_stats.record_tool_usage(tool_name, input_str, output_str)
 
+        # keep track of edited files for the session record
+        for relative_path in edited_files:
+            self._session_record.add_edited_file(relative_path)
+
     def get_dashboard_url(self) -> str | None:
         """
         :return: the URL of the web dashboard, or None if the dashboard is not running
@@ -959,6 +1021,19 @@ class SerenaAgent:
             tool_names=self._prompt_tool_names_mapping,
         )
 
+    def create_mode_prompt(self, mode: SerenaAgentMode, task: str = "") -> str:
+        """
+        Returns the prompt of the given mode, which can be requested separately from the system prompt.
+
+        :param mode: the mode whose prompt to create
+        :param task: an optional description of the task to be solved in the mode, which is appended to the prompt
+        :return: the prompt
+        """
+        prompt = self._format_prompt(mode.prompt).strip()
+        if task.strip():
+            prompt += f"\n\nThe task: {task.strip()}"
+        return prompt
+
     def create_connection_prompt(self) -> str:
         """
         Returns the bootstrap prompt to be sent at MCP connection time.
@@ -1043,6 +1118,11 @@ class SerenaAgent:
                 )
             elif self._active_tools.contains_tool_class(OnboardingTool):
                 msg += "Onboarding has not been performed yet, you should call Serena's `onboarding` tool now to set up project memories."
+            if LAST_SESSION_MEMORY_NAME in project_memories.get_full_list():
+                msg += (
+                    f"\nThe memory `{LAST_SESSION_MEMORY_NAME}` summarises the previous session (tasks, edited files, pending follow-ups). "
+                    "Read it if the user wants to continue previous work."
+                )
 
         # add prompts for modes that were dynamically activated by the project
         modes_with_prompts = self._project_prompt_status.get_modes_with_prompts_to_be_provided_for_project_activation(session_id)
@@ -1090,8 +1170,12 @@ class SerenaAgent:
         # apply active project configuration (if any)
         if self._active_project is not None:
             tool_set = tool_set.apply(self._active_project.project_config)
-            if self._active_project.project_config.read_only:
-                tool_set = tool_set.without_editing_tools()
+
+        # apply the tools (de)activated during the session, which take precedence
+        tool_set = tool_set.apply(self._get_session_tool_selection())
+
+        if self._active_project is not None and self._active_project.project_config.read_only:
+            tool_set = tool_set.without_editing_tools()
 
         self._active_tools = tool_set.to_available_tools(self._all_tools)
         log.info(f"Active tools ({len(self._active_tools)}): {', '.join(self._active_tools.tool_names)}")
@@ -1106,19 +1190,21 @@ class SerenaAgent:
             )
 
     def issue_task(
-        self, task: Callable[[], T], name: str | None = None, logged: bool = True, timeout: float | None = None
+        self, task: Callable[[], T], name: str | None = None, logged: bool = True, timeout: float | None = None, concurrent: bool = False
     ) -> TaskExecutor.Task[T]:
         """
         Issue a task to the executor for asynchronous execution.
-        It is ensured that tasks are executed in the order they are issued, one after another.
+        It is ensured that tasks are executed in the order they are issued, one after another, except for
+        consecutive concurrent tasks, which may run concurrently.
 
         :param task: the task to execute
         :param name: the name of the task for logging purposes; if None, use the task function's name
         :param logged: whether to log management of the task; if False, only errors will be logged
         :param timeout: the maximum time to wait for task completion in seconds, or None to wait indefinitely
+        :param concurrent: whether the task may run concurrently with other concurrent tasks
         :return: the task object, through which the task's future result can be accessed
         """
-        return self._task_executor.issue_task(task, name=name, logged=logged, timeout=timeout)
+        return self._task_executor.issue_task(task, name=name, logged=logged, timeout=timeout, concurrent=concurrent)
 
     def execute_task(self, task: Callable[[], T], name: str | None = None, logged: bool = True, timeout: float | None = None) -> T:
         """
@@ -1154,6 +1240,184 @@ class SerenaAgent:
         """
         self._config_changed_callbacks.append(callback)
 
+    def register_exposed_tools_changed_callback(self, callback: Callable[[], None]) -> None:
+        """
+        Registers a callback to be called when the set of exposed tools has changed.
+
+        :param callback: the callback function to register
+        """
+        self._exposed_tools_changed_callbacks.append(callback)
+
+    def pop_exposed_tools_changed_flag(self) -> bool:
+        """
+        :return: whether the set of exposed tools has changed since the last call of this method
+        """
+        flag = self._exposed_tools_changed_flag
+        self._exposed_tools_changed_flag = False
+        return flag
+
+    def _get_session_tool_selection(self) -> NamedToolInclusionDefinition:
+        return NamedToolInclusionDefinition(
+            name="SessionToolSelection",
+            included_optional_tools=sorted(self._session_activated_tools),
+            excluded_tools=sorted(self._session_deactivated_tools),
+        )
+
+    def _update_exposed_tools(self) -> tuple[list[str], list[str]]:
+        """
+        Recomputes the sets of exposed and active tools, notifying listeners if the set of exposed tools has changed.
+
+        :return: a pair (names of newly exposed tools, names of tools which are no longer exposed)
+        """
+        exposed_tool_names_before = set(self._exposed_tools.tool_names)
+        self._base_toolset = self._create_base_toolset(
+            self.serena_config, self._context, self._active_modes, self._active_project, self._get_session_tool_selection()
+        )
+        self._exposed_tools = self._base_toolset.to_available_tools(self._all_tools)
+        self._update_active_tools()
+
+        exposed_tool_names = set(self._exposed_tools.tool_names)
+        added_tool_names = sorted(exposed_tool_names - exposed_tool_names_before)
+        removed_tool_names = sorted(exposed_tool_names_before - exposed_tool_names)
+        if added_tool_names or removed_tool_names:
+            self._notify_exposed_tools_changed()
+        return added_tool_names, removed_tool_names
+
+    def _notify_exposed_tools_changed(self) -> None:
+        self._exposed_tools_changed_flag = True
+        for callback in self._exposed_tools_changed_callbacks:
+            try:
+                callback()
+            except Exception as e:
+                log.error(f"Error in exposed tools changed callback {callback}: {e}", exc_info=e)
+
+    def _get_explicitly_excluded_tool_names(self) -> set[str]:
+        """
+        :return: the names of the tools which are explicitly excluded by the Serena configuration, the context, the active modes
+            or the active project's configuration (including the tools not contained in a fixed tool set)
+        """
+        from serena.tools import ToolRegistry
+
+        definitions: list[ToolInclusionDefinition] = [self.serena_config, self._context]
+        definitions.extend(self._active_modes.get_modes(include_background_base_modes=True))
+        if self._active_project is not None:
+            definitions.append(self._active_project.project_config)
+        excluded_tool_names: set[str] = set()
+        for definition in definitions:
+            if definition.is_fixed_tool_set():
+                excluded_tool_names.update(set(ToolRegistry().get_tool_names()) - set(definition.fixed_tools))
+            else:
+                excluded_tool_names.update(definition.excluded_tools)
+        return excluded_tool_names
+
+    def set_tools_activated(self, tool_names: Sequence[str], activated: bool) -> str:
+        """
+        Activates or deactivates tools for the remainder of the session, such that e.g. optional tools can be used
+        without restarting Serena. Only optional tools and tools which were deactivated during the session can be activated,
+        and tools which are explicitly excluded by the configuration, the context, the modes or the project configuration
+        cannot be activated. Session (de)activations take precedence over the inclusions of these definitions
+        (and deactivations are furthermore subject to a project's read-only setting).
+
+        :param tool_names: the names of the tools to activate or deactivate
+        :param activated: whether to activate (True) or deactivate (False) the tools
+        :return: a summary of the changes
+        """
+        registry = ToolRegistry()
+        unknown_tool_names = [name for name in tool_names if name not in registry.get_tool_names()]
+        if unknown_tool_names:
+            raise ValueError(f"Unknown tools: {', '.join(unknown_tool_names)}")
+        if activated:
+            activatable_tool_names = set(registry.get_tool_names_optional()) | self._session_deactivated_tools
+            non_activatable_tool_names = [name for name in tool_names if name not in activatable_tool_names]
+            if non_activatable_tool_names:
+                raise ValueError(
+                    "Only optional tools (or tools deactivated during the session) can be activated: "
+                    f"{', '.join(non_activatable_tool_names)}"
+                )
+            excluded_tool_names = [name for name in tool_names if name in self._get_explicitly_excluded_tool_names()]
+            if excluded_tool_names:
+                raise ValueError(
+                    "The following tools are excluded by the configuration, the context, the modes or the project "
+                    f"and cannot be activated: {', '.join(excluded_tool_names)}"
+                )
+        else:
+            protected_tool_names = [name for name in tool_names if name in self.TOOL_NAMES_NOT_DEACTIVATABLE]
+            if protected_tool_names:
+                raise ValueError(f"The following tools cannot be deactivated: {', '.join(protected_tool_names)}")
+
+        for tool_name in tool_names:
+            if activated:
+                self._session_activated_tools.add(tool_name)
+                self._session_deactivated_tools.discard(tool_name)
+            else:
+                self._session_deactivated_tools.add(tool_name)
+                self._session_activated_tools.discard(tool_name)
+        log.info(f"{'Activated' if activated else 'Deactivated'} tools for the session: {', '.join(tool_names)}")
+        added_tool_names, removed_tool_names = self._update_exposed_tools()
+
+        result = ""
+        if added_tool_names:
+            result += f"Newly exposed tools: {', '.join(added_tool_names)}\n"
+        if removed_tool_names:
+            result += f"No longer exposed tools: {', '.join(removed_tool_names)}\n"
+        result += f"Active tools: {', '.join(self.get_active_tool_names())}"
+        return result
+
+    def reload_context_and_modes(self) -> str:
+        """
+        Reloads the definitions of the active context and modes from their YAML files, such that changes take effect
+        without a restart: the sets of exposed and active tools are recomputed and subsequently generated
+        system prompts are based on the new definitions.
+
+        :return: a summary of the changes
+        """
+        # reload the context
+        context_path = SerenaAgentContext.get_path(self._context.name, instance=self._context)
+        self._context = SerenaAgentContext.from_yaml(context_path)
+
+        # reload the modes and recompute the tool sets
+        ActiveModes.clear_mode_instance_cache()
+        self._update_active_modes()
+        added_tool_names, removed_tool_names = self._update_exposed_tools()
+
+        result = f"Reloaded context '{self._context.name}' and modes {self._active_modes.get_mode_names()}.\n"
+        if added_tool_names:
+            result += f"Newly exposed tools: {', '.join(added_tool_names)}\n"
+        if removed_tool_names:
+            result += f"No longer exposed tools: {', '.join(removed_tool_names)}\n"
+        result += f"Active tools: {', '.join(self.get_active_tool_names())}"
+        return result
+
+    def get_session_record(self) -> SessionRecord:
+        """
+        :return: the record of the current session (for the active project)
+        """
+        return self._session_record
+
+    def save_session_record(self, force: bool = False) -> str | None:
+        """
+        Saves the session record as the `last_session` memory of the active project, provided that recording is enabled
+        and memories are in use.
+
+        :param force: whether to save the record even if it was not modified since it was last saved
+        :return: the name of the memory that was written, or None if nothing was written
+        """
+        if not self.serena_config.record_last_session or self._active_project is None:
+            return None
+        if not self._active_tools.contains_tool_class(ReadMemoryTool):
+            return None
+        if not force and not self._session_record.is_modified():
+            return None
+        try:
+            self._active_project.memory_manager.save_memory(
+                LAST_SESSION_MEMORY_NAME, self._session_record.create_memory_content(), is_tool_context=False
+            )
+            log.info(f"Saved session record to memory '{LAST_SESSION_MEMORY_NAME}'")
+            return LAST_SESSION_MEMORY_NAME
+        except Exception as e:
+            log.error(f"Failed to save session record: {e}", exc_info=e)
+            return None
+
     def is_using_language_server(self) -> bool:
         """
         :return: whether this agent uses language server-based code analysis
@@ -1180,8 +1444,13 @@ class SerenaAgent:
                 f"(2) Configure one MCP server per backend in your client."
             )
 
+        parameter_defaults_before = self._active_project.project_config.tool_parameter_defaults if self._active_project else {}
+
         # shut down the previously active project to release its language server processes
+        self._cancel_background_indexing()
         if self._active_project is not None:
+            self.save_session_record()
+            self._session_record = SessionRecord()
             log.info(f"Shutting down previously active project '{self._active_project.project_name}' before switching")
             self._active_project.shutdown()
 
@@ -1200,9 +1469,15 @@ class SerenaAgent:
         if update_active_tools:
             self._update_active_tools()
 
+        # the exposed tool definitions include the project's parameter defaults
+        if project.project_config.tool_parameter_defaults != parameter_defaults_before:
+            self._notify_exposed_tools_changed()
+
         def init_project_services() -> None:
             self._run_project_activation_command(project)
             self._init_active_project_language_backend()
+            if self.serena_config.background_indexing and self.get_language_backend().is_lsp():
+                self._start_background_indexing(project)
 
         # initialise the project's language backend in the background
         self.issue_task(init_project_services)
@@ -1216,6 +1491,17 @@ class SerenaAgent:
 
         return True
 
+    def _start_background_indexing(self, project: Project) -> None:
+        if self._active_project is not project:
+            return  # another project was activated in the meantime
+        self._background_indexer = BackgroundIndexer(project, lambda task: self.issue_task(task, name="BackgroundIndexing", logged=False))
+        self._background_indexer.start()
+
+    def _cancel_background_indexing(self) -> None:
+        if self._background_indexer is not None:
+            self._background_indexer.cancel()
+            self._background_indexer = None
+
     @staticmethod
     def _run_project_activation_command(project: Project) -> None:
         """
@@ -1339,13 +1625,41 @@ class SerenaAgent:
         result_str += f"Serena version: {self.version}\n"
         result_str += f"Loglevel: {self.serena_config.log_level}, trace_lsp_communication={self.serena_config.trace_lsp_communication}\n"
         if self._active_project is not None:
-            result_str += f"Active project: {self._active_project.project_name}\n"
+            result_str += f"Active project: {self._active_project.project_name} ({self._active_project.project_root})\n"
         else:
             result_str += "No active project\n"
         result_str += f"Language backend: {self._language_backend.value}"
         if self._active_project and self._active_project.project_config.language_backend is not None:
             result_str += " (project override)"
         result_str += f" (global default: {self.serena_config.language_backend.value})\n"
+
+        # language servers and memories of the active project
+        ls_manager = self.get_language_server_manager()
+        if ls_manager is not None:
+            ls_states = [
+                f"{ls_id.value} ({'running' if is_running else 'not running'})"
+                for ls_id, is_running in ls_manager.get_language_server_running_states().items()
+            ]
+            result_str += "Language servers: {}\n".format(", ".join(ls_states))
+            for ls_id, version_info in ls_manager.get_version_info().items():
+                if version_info:
+                    versions = ", ".join(f"{component} {version}" for component, version in version_info.items())
+                    result_str += f"  {ls_id.value} versions: {versions}\n"
+            for ls_id, cache_stats in ls_manager.get_symbol_cache_stats().items():
+                result_str += f"  {ls_id.value} symbol cache: {cache_stats.to_string()}\n"
+        if self._active_project is not None and LanguageServerId.TERRAFORM in self._active_project.project_config.language_servers:
+            from solidlsp.language_servers.terraform_ls import TerraformLS
+
+            result_str += f"Terraform workspace: {TerraformLS.determine_workspace(self._active_project.project_root)}\n"
+        if self._active_project is not None:
+            result_str += f"Number of memories: {len(self._active_project.memory_manager.list_memories())}\n"
+        result_str += f"Tool timeout: {self.serena_config.tool_timeout}s\n"
+        pending_operations = self._pending_operations.get_operations()
+        if pending_operations:
+            result_str += "Operations pending user approval:\n"
+            for operation in pending_operations:
+                result_str += f"  {operation.operation_id}: {operation.get_description()}\n"
+
         result_str += "Available projects:\n" + "\n".join(list(self.serena_config.project_names)) + "\n"
         result_str += f"Active context: {self._context.name}\n"
 
@@ -1418,7 +1732,9 @@ class SerenaAgent:
         Shutdown handler of the agent, freeing resources and stopping background tasks.
         """
         log.info("SerenaAgent is shutting down ...")
+        self._cancel_background_indexing()
         if self._active_project is not None:
+            self.save_session_record()
             log.info(f"Shutting down active project '{self._active_project.project_name}' ...")
             self._active_project.shutdown(timeout=timeout)
             self._active_project = None
@@ -1444,6 +1760,28 @@ class SerenaAgent:
         tool_class = ToolRegistry().get_tool_class_by_name(tool_name)
         return self.get_tool(tool_class)
 
+    @staticmethod
+    def _create_approval_policy(serena_config: SerenaConfig) -> ApprovalPolicy:
+        # the approval of a pending operation is not itself subject to the policy (the operation having been subject to it)
+        destructive_tool_names = [
+            t.get_name_from_cls()
+            for t in ToolRegistry().get_all_tool_classes()
+            if t.can_edit() and t is not ApprovePendingOperationTool
+        ]
+        return ApprovalPolicy.from_serena_config(serena_config, destructive_tool_names=destructive_tool_names)
+
+    def get_approval_policy(self) -> ApprovalPolicy:
+        """
+        :return: the policy determining which tool calls require explicit user approval (as per the current configuration)
+        """
+        return self._create_approval_policy(self.serena_config)
+
+    def get_pending_operations(self) -> PendingOperationRegistry:
+        """
+        :return: the registry of operations awaiting user approval
+        """
+        return self._pending_operations
+
     def get_active_language_server_ids(self) -> list[LanguageServerId]:
         ls_manager = self.get_language_server_manager()
         if ls_manager is None:
@@ -0,0 +1,221 @@
+"""
+Public API for embedding Serena in other Python programs (e.g. internal platforms, CLIs or bots),
+which can thus use Serena's capabilities without running the MCP server.
+
+The names exported by this module (see `__all__`) constitute Serena's stable API: their signatures are kept
+backward-compatible across minor versions. All other modules are considered internal.
+
+Example::
+
+    from serena.api import create_agent, call_tool
+
+    agent = create_agent("/path/to/project")
+    try:
+        print(call_tool(agent, "get_symbols_overview", relative_path="main.tf"))
+    finally:
+        agent.shutdown()
+"""
+
+from abc import ABC, abstractmethod
+from collections.abc import Sequence
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, TypeVar
+
+from serena.agent import SerenaAgent
+from serena.config.context_mode import SerenaAgentContext, SerenaAgentMode
+from serena.config.serena_config import ModeSelectionDefinition, ProjectConfigAutoGenerationMode, SerenaConfig
+from serena.project import Project
+from serena.tools import (
+    StructuredToolResult,
+    Tool,
+    ToolCallError,
+    ToolMarkerCanEdit,
+    ToolMarkerConcurrent,
+    ToolMarkerDoesNotRequireActiveProject,
+    ToolMarkerOpenWorld,
+    ToolMarkerOptional,
+    ToolMarkerSymbolicRead,
+    ToolRegistry,
+)
+
+__all__ = [
+    "Project",
+    "SerenaAgent",
+    "SerenaAgentContext",
+    "SerenaAgentMode",
+    "SerenaConfig",
+    "StructuredToolResult",
+    "Tool",
+    "ToolCallError",
+    "ToolDescriptor",
+    "ToolMarkerCanEdit",
+    "ToolMarkerConcurrent",
+    "ToolMarkerDoesNotRequireActiveProject",
+    "ToolMarkerOpenWorld",
+    "ToolMarkerOptional",
+    "ToolMarkerSymbolicRead",
+    "ToolRegistry",
+    "ToolTransport",
+    "call_tool",
+    "create_agent",
+    "create_serena_config",
+    "describe_tools",
+    "get_tool_registry",
+    "load_project",
+    "register_tool",
+]
+
+TTool = TypeVar("TTool", bound=type[Tool])
+
+DEFAULT_EMBEDDED_CONTEXT = "agent"
+"""the context used by default for embedded agents (which are not tied to a particular client application)"""
+
+
+def create_serena_config(from_config_file: bool = True, headless: bool = True) -> SerenaConfig:
+    """
+    Creates the Serena configuration for an embedded agent.
+
+    :param from_config_file: whether to read the user's configuration file (`serena_config.yml`), creating it if it does
+        not exist; if False, the default configuration is used
+    :param headless: whether to disable all features requiring user interaction (GUI log window, web dashboard, etc.)
+    :return: the configuration
+    """
+    serena_config = SerenaConfig.from_config_file() if from_config_file else SerenaConfig()
+    if headless:
+        serena_config.with_headless_mode_overrides()
+    return serena_config
+
+
+def create_agent(
+    project: str | Path | None = None,
+    *,
+    serena_config: SerenaConfig | None = None,
+    context: str | SerenaAgentContext = DEFAULT_EMBEDDED_CONTEXT,
+    modes: Sequence[str] | None = None,
+) -> SerenaAgent:
+    """
+    Creates an agent, which provides access to all of Serena's tools.
+    The agent should be shut down via `SerenaAgent.shutdown` when it is no longer needed (stopping language servers).
+
+    :param project: the path to the root of the project to activate or the name of a registered project; if None,
+        no project is activated initially (a project can be activated later via the `activate_project` tool)
+    :param serena_config: the configuration; if None, use the (headless) configuration created by `create_serena_config`
+    :param context: the context (or the name of/path to the context's YAML file), which may adjust prompts and the set of tools
+    :param modes: the names of the modes to activate; if None, use the default modes
+    :return: the agent
+    """
+    if serena_config is None:
+        serena_config = create_serena_config()
+    if isinstance(context, str):
+        context = SerenaAgentContext.load(context)
+    mode_selection = ModeSelectionDefinition(default_modes=list(modes)) if modes is not None else None
+    return SerenaAgent(
+        project=str(project) if project is not None else None, serena_config=serena_config, context=context, modes=mode_selection
+    )
+
+
+def load_project(project_root: str | Path, serena_config: SerenaConfig | None = None, autogenerate_config: bool = True) -> Project:
+    """
+    Loads a project independently of an agent, e.g. in order to read or search its files.
+
+    :param project_root: the path to the project's root directory
+    :param serena_config: the configuration; if None, use the (headless) configuration created by `create_serena_config`
+    :param autogenerate_config: whether to generate the project configuration (`project.yml`) if it does not exist yet
+    :return: the project
+    """
+    if serena_config is None:
+        serena_config = create_serena_config()
+    autogen = ProjectConfigAutoGenerationMode.SYNCHRONOUS if autogenerate_config else ProjectConfigAutoGenerationMode.NONE
+    return Project.load(project_root, serena_config=serena_config, autogen=autogen)
+
+
+def get_tool_registry() -> ToolRegistry:
+    """
+    :return: the registry of all tools (built-in and custom)
+    """
+    return ToolRegistry()
+
+
+def register_tool(tool_class: TTool) -> TTool:
+    """
+    Registers a custom tool, which must be a subclass of `Tool` implementing the `apply` method, whose docstring
+    serves as the tool's description. Can be used as a class decorator.
+    Tools must be registered before agents are created.
+
+    :param tool_class: the tool class
+    :return: the tool class
+    """
+    get_tool_registry().register_tool_class(tool_class)
+    return tool_class
+
+
+def call_tool(agent: SerenaAgent, tool_name: str, **kwargs: Any) -> str:
+    """
+    Calls a tool of the given agent.
+
+    :param agent: the agent
+    :param tool_name: the name of the tool
+    :param kwargs: the tool's parameters
+    :return: the tool's result
+    :raises ToolCallError: if the tool call fails
+    """
+    return agent.get_tool_by_name(tool_name).apply_ex(log_call=True, catch_exceptions=False, **kwargs)
+
+
+@dataclass(kw_only=True)
+class ToolDescriptor:
+    """
+    Describes a tool for clients, analogously to the description provided via MCP
+    """
+
+    name: str
+    description: str
+    input_schema: dict[str, Any]
+    """the JSON schema of the tool's parameters"""
+    can_edit: bool
+
+
+def describe_tools(agent: SerenaAgent) -> list[ToolDescriptor]:
+    """
+    :param agent: the agent
+    :return: descriptors of the tools exposed by the agent (considering the agent's context and modes)
+    """
+    from serena.mcp import SerenaMCPFactory
+
+    descriptors = []
+    for tool in agent.get_exposed_tool_instances():
+        mcp_tool = SerenaMCPFactory.make_mcp_tool(tool, openai_tool_compatible=False)
+        descriptors.append(
+            ToolDescriptor(
+                name=mcp_tool.name, description=mcp_tool.description or "", input_schema=mcp_tool.parameters, can_edit=tool.can_edit()
+            )
+        )
+    return descriptors
+
+
+class ToolTransport(ABC):
+    """
+    Makes the tools of an agent available to clients via a custom protocol (e.g. a chat bot's command interface or an
+    internal RPC framework), as an alternative to the MCP server.
+    Implementations typically announce the tools via `describe_tools` and handle incoming requests via `call_tool`.
+    """
+
+    @abstractmethod
+    def serve(self, agent: SerenaAgent) -> None:
+        """
+        Serves the agent's tools, blocking until the transport is closed.
+
+        :param agent: the agent whose tools to serve
+        """
+
+    def run(self, agent: SerenaAgent) -> None:
+        """
+        Serves the agent's tools (see `serve`) and shuts down the agent afterwards.
+
+        :param agent: the agent whose tools to serve
+        """
+        try:
+            self.serve(agent)
+        finally:
+            agent.shutdown()
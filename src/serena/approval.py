@@ -0,0 +1,205 @@
+"""
+Support for operations which require explicit user approval before they are executed
+"""
+
+import logging
+import re
+import threading
+import uuid
+from collections.abc import Sequence
+from dataclasses import dataclass, field
+from datetime import datetime
+from typing import TYPE_CHECKING, Any
+
+if TYPE_CHECKING:
+    from serena.config.serena_config import SerenaConfig
+
+log = logging.getLogger(__name__)
+
+DESTRUCTIVE_SHELL_COMMAND_PATTERNS = (
+    r"\bterraform(\s+-\S+)*\s+(apply|destroy)\b",
+    r"\bterragrunt(\s+-\S+)*\s+(run-all\s+)?(apply|destroy)\b",
+)
+"""
+regular expressions matching shell commands which are considered destructive (e.g. `terraform -chdir=infra apply`
+or `terragrunt run-all destroy`)
+"""
+_DESTRUCTIVE_SHELL_COMMAND_REGEXES = tuple(re.compile(p) for p in DESTRUCTIVE_SHELL_COMMAND_PATTERNS)
+
+
+def get_operation_description(tool_name: str, kwargs: dict[str, Any]) -> str:
+    """
+    :param tool_name: the name of the tool being called
+    :param kwargs: the parameters of the tool call
+    :return: a short, human-readable description of the operation (tool name and parameters)
+    """
+    params = ", ".join(f"{k}={v!r}" for k, v in kwargs.items() if k != "session_id")
+    return f"{tool_name}({params})"
+
+
+@dataclass(kw_only=True)
+class PendingOperation:
+    """
+    Represents a tool call that was deferred because it requires explicit user approval.
+    """
+
+    operation_id: str
+    tool_name: str
+    apply_kwargs: dict[str, Any]
+    """
+    the keyword arguments with which the tool's apply method is to be called once the operation is approved
+    """
+    reason: str
+    """
+    the reason why the operation requires approval
+    """
+    created_at: datetime = field(default_factory=datetime.now)
+
+    def get_description(self) -> str:
+        """
+        :return: a short, human-readable description of the operation (tool name and parameters)
+        """
+        return get_operation_description(self.tool_name, self.apply_kwargs)
+
+    def get_approval_request_message(self) -> str:
+        """
+        :return: the message to be returned to the client in place of the operation's result
+        """
+        return (
+            f"The operation {self.get_description()} was NOT executed, because it requires explicit user approval ({self.reason}).\n"
+            f"Pending operation id: {self.operation_id}\n"
+            "Describe the operation to the user and ask for approval. Only if the user explicitly approves, "
+            "execute it by calling the `approve_pending_operation` tool with the operation id."
+        )
+
+
+class ApprovalPolicy:
+    """
+    Determines which tool calls require explicit user approval before being executed.
+    """
+
+    SHELL_COMMAND_TOOL_NAME = "execute_shell_command"
+    SHELL_COMMAND_PARAM_NAME = "command"
+
+    def __init__(
+        self,
+        tool_names: Sequence[str] = (),
+        shell_command_patterns: Sequence[str | re.Pattern[str]] = (),
+        destructive_tool_names: Sequence[str] = (),
+        destructive_shell_command_patterns: Sequence[str | re.Pattern[str]] = (),
+    ):
+        """
+        :param tool_names: the names of tools whose every execution requires approval
+        :param shell_command_patterns: regular expressions (strings or compiled patterns); shell commands in which any of
+            the expressions can be found require approval
+        :param destructive_tool_names: the names of destructive tools, whose every execution requires approval
+        :param destructive_shell_command_patterns: regular expressions matching destructive shell commands, which require approval
+        """
+        self._tool_names = set(tool_names)
+        self._shell_command_patterns = [re.compile(p) for p in shell_command_patterns]
+        self._destructive_tool_names = set(destructive_tool_names)
+        self._destructive_shell_command_patterns = [re.compile(p) for p in destructive_shell_command_patterns]
+
+    @classmethod
+    def from_serena_config(cls, serena_config: "SerenaConfig", destructive_tool_names: Sequence[str] = ()) -> "ApprovalPolicy":
+        """
+        :param serena_config: the configuration
+        :param destructive_tool_names: the names of the tools which are marked as destructive (i.e. which can edit);
+            they require approval only if `confirm_destructive_operations` is enabled
+        :return: the policy
+        """
+        if serena_config.confirm_destructive_operations:
+            # shell commands are considered destructive only if they match one of the destructive command patterns
+            destructive_tool_names = [name for name in destructive_tool_names if name != cls.SHELL_COMMAND_TOOL_NAME]
+            destructive_shell_command_patterns: Sequence[re.Pattern[str]] = _DESTRUCTIVE_SHELL_COMMAND_REGEXES
+        else:
+            destructive_tool_names = ()
+            destructive_shell_command_patterns = ()
+        return cls(
+            tool_names=serena_config.tools_requiring_approval,
+            shell_command_patterns=serena_config.shell_command_approval_regexes,
+            destructive_tool_names=destructive_tool_names,
+            destructive_shell_command_patterns=destructive_shell_command_patterns,
+        )
+
+    def is_empty(self) -> bool:
+        return (
+            not self._tool_names
+            and not self._shell_command_patterns
+            and not self._destructive_tool_names
+            and not self._destructive_shell_command_patterns
+        )
+
+    def get_approval_reason(self, tool_name: str, kwargs: dict[str, Any]) -> str | None:
+        """
+        :param tool_name: the name of the tool being called
+        :param kwargs: the parameters of the tool call
+        :return: the reason why the tool call requires approval or None if it can be executed right away
+        """
+        if tool_name in self._tool_names:
+            return f"the tool '{tool_name}' is configured to require approval"
+        if tool_name in self._destructive_tool_names:
+            return f"the tool '{tool_name}' performs a destructive operation"
+        if tool_name == self.SHELL_COMMAND_TOOL_NAME:
+            command = str(kwargs.get(self.SHELL_COMMAND_PARAM_NAME, ""))
+            for pattern in self._shell_command_patterns:
+                if pattern.search(command):
+                    return f"the shell command matches the approval pattern '{pattern.pattern}'"
+            for pattern in self._destructive_shell_command_patterns:
+                if pattern.search(command):
+                    return "the shell command performs a destructive operation"
+        return None
+
+
+class PendingOperationRegistry:
+    """
+    Holds the operations which are awaiting user approval.
+    """
+
+    def __init__(self) -> None:
+        self._operations: dict[str, PendingOperation] = {}
+        self._lock = threading.Lock()
+
+    def add(self, tool_name: str, apply_kwargs: dict[str, Any], reason: str) -> PendingOperation:
+        """
+        Registers a new pending operation.
+
+        :param tool_name: the name of the deferred tool
+        :param apply_kwargs: the keyword arguments for the tool's apply method
+        :param reason: the reason why approval is required
+        :return: the pending operation
+        """
+        operation = PendingOperation(operation_id=uuid.uuid4().hex[:8], tool_name=tool_name, apply_kwargs=dict(apply_kwargs), reason=reason)
+        with self._lock:
+            self._operations[operation.operation_id] = operation
+        log.info("Deferred operation %s pending approval: %s", operation.operation_id, operation.get_description())
+        return operation
+
+    def get(self, operation_id: str) -> PendingOperation | None:
+        """
+        :param operation_id: the id of the operation
+        :return: the pending operation with the given id (which remains registered) or None if there is no such operation
+        """
+        with self._lock:
+            return self._operations.get(operation_id.strip())
+
+    def pop(self, operation_id: str) -> PendingOperation:
+        """
+        Removes the pending operation with the given id from the registry.
+
+        :param operation_id: the id of the operation
+        :return: the operation
+        :raises ValueError: if there is no pending operation with the given id
+        """
+        with self._lock:
+            operation = self._operations.pop(operation_id.strip(), None)
+            if operation is None:
+                raise ValueError(f"No pending operation with id '{operation_id}'. Pending operation ids: {list(self._operations.keys())}")
+            return operation
+
+    def get_operations(self) -> list[PendingOperation]:
+        """
+        :return: the list of pending operations, in the order in which they were registered
+        """
+        with self._lock:
+            return list(self._operations.values())
@@ -0,0 +1,87 @@
+"""
+Indexing of a project's source files in the background, such that symbolic queries can be answered from the symbol cache
+"""
+
+import logging
+import threading
+from collections import deque
+from collections.abc import Callable
+from typing import TYPE_CHECKING, Any
+
+if TYPE_CHECKING:
+    from .project import Project
+
+log = logging.getLogger(__name__)
+
+
+class BackgroundIndexer:
+    """
+    Indexes the symbols of all source files of a project after its activation, such that subsequent symbolic queries
+    are answered from the symbol cache rather than requiring (cold) requests to the language servers.
+
+    The files are indexed in small batches, each of which is executed as a task of the agent's task executor, such that
+    the language servers are never accessed concurrently with tool calls. Since each batch issues the next batch only
+    upon completion, tool calls issued in the meantime are executed first, i.e. a tool call is delayed by at most one batch.
+    """
+
+    BATCH_SIZE = 10
+
+    def __init__(self, project: "Project", issue_task: Callable[[Callable[[], None]], Any]) -> None:
+        """
+        :param project: the project whose files to index
+        :param issue_task: the function with which to issue a task for asynchronous execution (after the tasks issued before)
+        """
+        self._project = project
+        self._issue_task = issue_task
+        self._pending_files: deque[str] | None = None
+        """the relative paths of the files which remain to be indexed; None if indexing has not started yet"""
+        self._num_indexed_files = 0
+        self._cancelled = threading.Event()
+
+    def start(self) -> None:
+        """
+        Starts indexing by issuing the task for the first batch (in which the project's source files are gathered).
+        """
+        self._issue_task(self._index_next_batch)
+
+    def cancel(self) -> None:
+        """
+        Cancels indexing, such that no further files are indexed (e.g. because the project is being deactivated).
+        """
+        self._cancelled.set()
+
+    def is_done(self) -> bool:
+        """
+        :return: whether all files were indexed or indexing was cancelled
+        """
+        return self._cancelled.is_set() or (self._pending_files is not None and not self._pending_files)
+
+    def _index_next_batch(self) -> None:
+        if self._cancelled.is_set():
+            return
+        language_server_manager = self._project.language_server_manager
+        if language_server_manager is None:
+            log.info("Background indexing stopped, because the project's language servers are not running")
+            self.cancel()
+            return
+        if self._pending_files is None:
+            self._pending_files = deque(self._project.gather_source_files())
+            log.info(f"Indexing {len(self._pending_files)} files of project '{self._project.project_name}' in the background")
+
+        for _ in range(self.BATCH_SIZE):
+            if not self._pending_files or self._cancelled.is_set():
+                break
+            relative_path = self._pending_files.popleft()
+            try:
+                language_server_manager.get_language_server(relative_path).request_document_symbols(relative_path)
+                self._num_indexed_files += 1
+            except Exception as e:
+                log.warning(f"Failed to index {relative_path} in the background: {e}")
+
+        if self._cancelled.is_set():
+            log.info(f"Background indexing cancelled after {self._num_indexed_files} files")
+        elif self._pending_files:
+            self._issue_task(self._index_next_batch)
+        else:
+            log.info(f"Background indexing complete: {self._num_indexed_files} files indexed")
+            language_server_manager.save_all_caches()
@@ -5,6 +5,7 @@ import os
 import shutil
 import subprocess
 import sys
+import textwrap
 import time
 from collections.abc import Iterator, Sequence
 from logging import Logger
@@ -41,6 +42,7 @@ from serena.util.cli_util import AutoRegisteringGroup
 from serena.util.logging import MemoryLogHandler
 from solidlsp.ls_config import LanguageServerId
 from solidlsp.ls_types import SymbolKind
+from solidlsp.settings import SolidLSPSettings
 from solidlsp.util.subprocess_util import subprocess_kwargs
 
 if TYPE_CHECKING:
@@ -62,8 +64,17 @@ For details on mode configuration, see
 """
 
 
-def find_project_root(root: str | Path | None = None) -> str | None:
-    """Find project root by walking up from CWD.
+def _is_configured_project(serena_config: SerenaConfig, path: str) -> bool:
+    """
+    :param serena_config: the Serena configuration
+    :param path: the path of a directory
+    :return: whether the directory is a project in its own right, i.e. a registered project or a directory with a project configuration
+    """
+    return serena_config.get_registered_project(path) is not None or os.path.isfile(serena_config.get_project_yml_location(path))
+
+
+def find_project_root(root: str | Path | None = None, start: str | Path | None = None) -> str | None:
+    """Find project root by walking up from CWD (or the given start directory).
 
     Returns the nearest ancestor that is either an explicit Serena project
     (contains .serena/project.yml) or a git root (contains .git, which may be a
@@ -78,9 +89,10 @@ def find_project_root(root: str | Path | None = None) -> str | None:
 
     :param root: If provided, constrains the search to this directory and below
                  (acts as a virtual filesystem root). Search stops at this boundary.
+    :param start: the directory from which to start the search; if None, use the CWD
     :return: absolute path to project root or None if not suitable root is found
     """
-    current = Path.cwd().resolve()
+    current = Path(start).resolve() if start is not None else Path.cwd().resolve()
     boundary = Path(root).resolve() if root is not None else None
 
     def ancestors() -> Iterator[Path]:
@@ -234,7 +246,11 @@ class TopLevelCommands(AutoRegisteringGroup):
     @click.option("--project-file", "project", type=PROJECT_TYPE, default=None, help="[DEPRECATED] Use --project instead.")
     @click.argument("project_file_arg", type=PROJECT_TYPE, required=False, default=None, metavar="")
     @click.option(
-        "--context", type=str, default=DEFAULT_CONTEXT, show_default=True, help="Built-in context name or path to custom context YAML."
+        "--context",
+        type=str,
+        default=None,
+        help="Built-in context name or path to custom context YAML. "
+        f"Defaults to the configured default_context (initially '{DEFAULT_CONTEXT}').",
     )
     @click.option(
         "--mode",
@@ -262,10 +278,11 @@ class TopLevelCommands(AutoRegisteringGroup):
     )
     @click.option(
         "--transport",
-        type=click.Choice(["stdio", "sse", "streamable-http"]),
+        type=click.Choice(["stdio", "sse", "streamable-http", "http"]),
         default="stdio",
         show_default=True,
-        help="Transport protocol.",
+        help="Transport protocol, where http serves the Streamable HTTP transport (at /mcp) with a fallback to the legacy SSE transport "
+        "(at /sse) on the same port.",
     )
     @click.option(
         "--host",
@@ -308,21 +325,37 @@ class TopLevelCommands(AutoRegisteringGroup):
     )
     @click.option("--trace-lsp-communication", type=bool, is_flag=False, default=None, help="Whether to trace LSP communication.")
     @click.option("--tool-timeout", type=float, default=None, help="Override tool execution timeout in config.")
+    @click.option(
+        "--idle-timeout",
+        type=float,
+        default=None,
+        help="Shut down the server after the given number of seconds without tool calls, e.g. for a long-running per-project server "
+        "using an HTTP-based transport (not supported for stdio, where the client controls the server's lifetime).",
+    )
     @click.option(
         "--project-from-cwd",
         is_flag=True,
         default=False,
         help="Auto-detect project from current working directory (searches for .serena/project.yml or .git, falls back to CWD). Intended for CLI-based agents like Claude Code, Gemini and Codex.",
     )
+    @click.option(
+        "--project-root-discovery/--no-project-root-discovery",
+        default=True,
+        show_default=True,
+        help="If the given project path is a directory within a project (e.g. a module subdirectory), use the project's root "
+        "directory instead, i.e. the nearest ancestor containing .serena/project.yml or .git. "
+        "Paths which are projects in their own right (registered or containing a project configuration) are used as they are.",
+    )
     def start_mcp_server(
         project: str | None,
         project_file_arg: str | None,
         project_from_cwd: bool | None,
-        context: str,
+        project_root_discovery: bool,
+        context: str | None,
         default_modes: Sequence[str],
         added_modes: Sequence[str],
         language_backend: str | None,
-        transport: Literal["stdio", "sse", "streamable-http"],
+        transport: Literal["stdio", "sse", "streamable-http", "http"],
         host: str,
         port: int,
         enable_web_dashboard: bool | None,
@@ -331,9 +364,16 @@ class TopLevelCommands(AutoRegisteringGroup):
         log_level: Literal["DEBUG", "INFO", "WARNING", "ERROR", "CRITICAL"] | None,
         trace_lsp_communication: bool | None,
         tool_timeout: float | None,
+        idle_timeout: float | None,
     ) -> None:
         from serena.mcp import SerenaMCPFactory
 
+        if idle_timeout is not None:
+            if transport == "stdio":
+                raise click.UsageError("--idle-timeout is not supported for the stdio transport")
+            if idle_timeout <= 0:
+                raise click.UsageError("--idle-timeout must be positive")
+
         # initialize logging, using INFO level initially (will later be adjusted by SerenaAgent according to the config)
         #   * memory log handler (for use by GUI/Dashboard)
         #   * stream handler for stderr (for direct console output, which will also be captured by clients like Claude Desktop)
@@ -365,6 +405,19 @@ class TopLevelCommands(AutoRegisteringGroup):
                 log.warning("No project root found from %s; not activating any project", os.getcwd())
 
         project_file = project_file_arg or project
+        serena_config = SerenaConfig.from_config_file()
+        if context is None:
+            context = serena_config.default_context
+        if (
+            project_file is not None
+            and project_root_discovery
+            and os.path.isdir(project_file)
+            and not _is_configured_project(serena_config, project_file)
+        ):
+            project_root = find_project_root(start=project_file)
+            if project_root is not None and not os.path.samefile(project_root, project_file):
+                log.info("Project path %s is located within project %s; using the project's root directory", project_file, project_root)
+                project_file = project_root
 
         mode_selection_def: ModeSelectionDefinition | None = None
         if default_modes or added_modes:
@@ -382,6 +435,8 @@ class TopLevelCommands(AutoRegisteringGroup):
             log_level=log_level,
             trace_lsp_communication=trace_lsp_communication,
             tool_timeout=tool_timeout,
+            idle_timeout=idle_timeout,
+            serena_config=serena_config,
         )
         if project_file_arg:
             log.warning(
@@ -389,7 +444,7 @@ class TopLevelCommands(AutoRegisteringGroup):
                 project_file,
             )
         log.info("Starting MCP server …")
-        server.run(transport=transport)
+        factory.run_mcp_server(server)
 
     @staticmethod
     @click.command(
@@ -398,7 +453,11 @@ class TopLevelCommands(AutoRegisteringGroup):
     @click.argument("project", type=click.Path(exists=True), default=None, required=False)
     @click.option("--only-instructions", is_flag=True, help="Print only the initial instructions, without prefix/postfix.")
     @click.option(
-        "--context", type=str, default=DEFAULT_CONTEXT, show_default=True, help="Built-in context name or path to custom context YAML."
+        "--context",
+        type=str,
+        default=None,
+        help="Built-in context name or path to custom context YAML. "
+        f"Defaults to the configured default_context (initially '{DEFAULT_CONTEXT}').",
     )
     @click.option(
         "--mode",
@@ -409,17 +468,19 @@ class TopLevelCommands(AutoRegisteringGroup):
         show_default=False,
         help=_MODES_EXPLANATION,
     )
-    def print_system_prompt(project: str | None, only_instructions: bool, context: str, modes: Sequence[str] | None = None) -> None:
+    def print_system_prompt(
+        project: str | None, only_instructions: bool, context: str | None, modes: Sequence[str] | None = None
+    ) -> None:
         from serena.agent import SerenaAgent
 
         prefix = "You will receive access to Serena's symbolic tools. Below are instructions for using them, take them into account."
         postfix = "You begin by acknowledging that you understood the above instructions and are ready to receive tasks."
 
-        context_instance = SerenaAgentContext.load(context)
+        serena_config = SerenaConfig.from_config_file().with_headless_mode_overrides()
+        context_instance = SerenaAgentContext.load(context if context is not None else serena_config.default_context)
         modes_selection_def: ModeSelectionDefinition | None = None
         if modes:
             modes_selection_def = ModeSelectionDefinition(default_modes=modes)
-        serena_config = SerenaConfig.from_config_file().with_headless_mode_overrides()
         agent = SerenaAgent(
             project=os.path.abspath(project) if project is not None else None,
             serena_config=serena_config,
@@ -502,6 +563,36 @@ class TopLevelCommands(AutoRegisteringGroup):
         viewer = SerenaDashboardViewer(url, width=width, height=height)
         viewer.run()
 
+    @staticmethod
+    @click.command(
+        "doctor",
+        help="Check the environment in which Serena is run (configuration, project configuration, external commands, "
+        "symbol caches and language server startup), printing hints for resolving any problems.",
+        context_settings={"max_content_width": _MAX_CONTENT_WIDTH},
+    )
+    @click.argument("project", type=click.Path(exists=True, file_okay=False), default=None, required=False)
+    @click.option(
+        "--no-ls-startup", is_flag=True, default=False, help="Do not check whether the project's language servers can be started."
+    )
+    @click.pass_context
+    def doctor(ctx: click.Context, project: str | None, no_ls_startup: bool) -> None:
+        """
+        :param project: the root directory of the project to check; if not given, the project containing the current
+            working directory is checked (if any)
+        """
+        from serena.doctor import CheckStatus, Doctor
+
+        logging.configure(level=logging.ERROR)
+        project_root = os.path.abspath(project) if project is not None else find_project_root()
+        if project_root is None:
+            click.echo("No project found in the current working directory; performing project-independent checks only.")
+        has_errors = False
+        for result in Doctor(project_root, start_language_servers=not no_ls_startup).run_checks():
+            click.echo(result.to_string())
+            has_errors = has_errors or result.status == CheckStatus.ERROR
+        if has_errors:
+            ctx.exit(1)
+
 
 class ModeCommands(AutoRegisteringGroup):
     """Group for 'mode' subcommands."""
@@ -683,6 +774,38 @@ class ProjectCommands(AutoRegisteringGroup):
             name="project", help="Manage Serena projects. You can run `project <command> --help` for more info on each command."
         )
 
+    @staticmethod
+    def _get_language_server_status(ls_id: LanguageServerId, serena_config: SerenaConfig) -> str:
+        """
+        Determines the status of a language server without starting it.
+
+        :param ls_id: the language server
+        :param serena_config: the Serena configuration
+        :return: the status, i.e. "ok" or a short description of what is missing
+        """
+        from serena.doctor import EXTERNAL_COMMANDS
+
+        missing_commands = [command.name for command in EXTERNAL_COMMANDS.get(ls_id, []) if shutil.which(command.name) is None]
+        if missing_commands:
+            return f"{', '.join(missing_commands)} not found"
+        if ls_id == LanguageServerId.TERRAFORM:
+            from solidlsp.language_servers.terraform_ls import TerraformLS
+
+            solidlsp_settings = TerraformLSCommands._create_solidlsp_settings(serena_config)
+            if TerraformLS.get_custom_executable_path(solidlsp_settings) is not None:
+                return "custom executable"
+            terraform_settings = solidlsp_settings.get_ls_specific_settings(LanguageServerId.TERRAFORM)
+            if (
+                terraform_settings.get("terraform_ls_version") is None
+                and terraform_settings.get("use_system_terraform_ls", True)
+                and shutil.which("terraform-ls") is not None
+            ):
+                return "system executable"
+            configured_version = TerraformLS.get_configured_version(solidlsp_settings)
+            if configured_version not in TerraformLS.get_installed_versions(solidlsp_settings):
+                return f"terraform-ls {configured_version} not installed yet"
+        return "ok"
+
     @staticmethod
     def _create_project(project_path: str, name: str | None, language: tuple[str, ...]) -> RegisteredProject:
         """
@@ -757,6 +880,42 @@ class ProjectCommands(AutoRegisteringGroup):
         except ValueError as e:
             raise click.ClickException(str(e))
 
+    @staticmethod
+    @click.command(
+        "list",
+        help="List the projects registered in the Serena configuration.",
+        context_settings={"max_content_width": _MAX_CONTENT_WIDTH},
+    )
+    def list() -> None:
+        serena_config = SerenaConfig.from_config_file()
+        if not serena_config.projects:
+            click.echo("No projects registered. Use 'serena project create' to create one.")
+            return
+        for registered_project in sorted(serena_config.projects, key=lambda p: p.project_name):
+            language_servers = registered_project.project_config.language_servers
+            languages_str = (
+                ", ".join(f"{ls.value} [{ProjectCommands._get_language_server_status(ls, serena_config)}]" for ls in language_servers)
+                if language_servers
+                else "N/A"
+            )
+            status = "" if registered_project.project_root.is_dir() else " [root directory not found]"
+            click.echo(f"{registered_project.project_name}: {registered_project.project_root} (language servers: {languages_str}){status}")
+
+    @staticmethod
+    @click.command(
+        "remove",
+        help="Remove a project from the Serena configuration (the project's files, including its .serena folder, are kept).",
+        context_settings={"max_content_width": _MAX_CONTENT_WIDTH},
+    )
+    @click.argument("project_name", type=str)
+    def remove(project_name: str) -> None:
+        serena_config = SerenaConfig.from_config_file()
+        try:
+            serena_config.remove_project(project_name)
+        except ValueError as e:
+            raise click.ClickException(str(e))
+        click.echo(f"Removed project '{project_name}' from the Serena configuration.")
+
     @staticmethod
     @click.command(
         "index",
@@ -888,7 +1047,7 @@ class ProjectCommands(AutoRegisteringGroup):
             exit(1)
         ls_mgr = proj.create_language_server_manager()
         try:
-            for ls in ls_mgr.iter_language_servers():
+            for ls in ls_mgr.iter_language_servers(symbolic_only=True):
                 click.echo(f"Indexing for language {ls.ls_id.value} …")
                 document_symbols = ls.request_document_symbols(file)
                 symbols, _ = document_symbols.get_all_symbols_and_roots()
@@ -1111,6 +1270,57 @@ class ToolCommands(AutoRegisteringGroup):
         mcp_tool = SerenaMCPFactory.make_mcp_tool(tool)
         click.echo(mcp_tool.description)
 
+    @staticmethod
+    @click.command(
+        "inspect",
+        help="Print all registered tools along with their markers and whether they are active for the given context and modes "
+        "(considering the global configuration), optionally including their JSON schemas.",
+        context_settings={"max_content_width": _MAX_CONTENT_WIDTH},
+    )
+    @click.option(
+        "--context",
+        type=str,
+        default=None,
+        help="Built-in context name or path to custom context YAML. "
+        f"Defaults to the configured default_context (initially '{DEFAULT_CONTEXT}').",
+    )
+    @click.option(
+        "--mode",
+        "modes",
+        type=str,
+        multiple=True,
+        default=(),
+        show_default=False,
+        help=_MODES_EXPLANATION,
+    )
+    @click.option("--only-active", is_flag=True, help="List only the tools which are active.")
+    @click.option("--schema", is_flag=True, help="Print the JSON schema of each tool's parameters.")
+    def inspect(context: str | None, modes: Sequence[str], only_active: bool, schema: bool) -> None:
+        from serena.agent import SerenaAgent
+        from serena.mcp import SerenaMCPFactory
+        from serena.tools import ToolRegistry
+
+        serena_config = SerenaConfig.from_config_file().with_headless_mode_overrides()
+        serena_config.log_level = logging.ERROR
+        context_instance = SerenaAgentContext.load(context if context is not None else serena_config.default_context)
+        modes_selection_def = ModeSelectionDefinition(default_modes=modes) if modes else None
+        agent = SerenaAgent(project=None, serena_config=serena_config, context=context_instance, modes=modes_selection_def)
+        try:
+            mode_names = ", ".join(agent.get_active_modes().get_mode_names())
+            click.echo(f"Context: {context_instance.name}; modes: {mode_names or '(none)'}\n")
+            for tool_name in sorted(ToolRegistry().get_tool_names()):
+                is_active = agent.tool_is_active(tool_name)
+                if only_active and not is_active:
+                    continue
+                tool = agent.get_tool_by_name(tool_name)
+                markers_str = ", ".join(tool.get_marker_names())
+                click.echo(f" * `{tool_name}` [{'active' if is_active else 'inactive'}]" + (f" ({markers_str})" if markers_str else ""))
+                if schema:
+                    mcp_tool = SerenaMCPFactory.make_mcp_tool(tool, openai_tool_compatible=False)
+                    click.echo(textwrap.indent(json.dumps(mcp_tool.parameters, indent=2), "     "))
+        finally:
+            agent.shutdown()
+
 
 class MemoryCommands(AutoRegisteringGroup):
     """Group for 'memories' subcommands; manage and inspect a project's memory files."""
@@ -1507,6 +1717,166 @@ class PromptCommands(AutoRegisteringGroup):
         click.echo(SerenaPromptFactory().create_cc_system_prompt_override())
 
 
+class CacheCommands(AutoRegisteringGroup):
+    """Group for 'cache' subcommands; inspect and clear a project's symbol caches."""
+
+    def __init__(self) -> None:
+        super().__init__(
+            name="cache",
+            help="Inspect and clear the symbol caches of a project. "
+            "You can run `serena cache <command> --help` for more info on each command.",
+        )
+
+    @staticmethod
+    def _get_symbol_cache_dirs(project: str) -> list[Path]:
+        """
+        :param project: the project's root directory or name
+        :return: the language server-specific symbol cache directories of the project
+        """
+        from solidlsp.ls import SolidLanguageServer
+
+        serena_config = SerenaConfig.from_config_file()
+        registered_project = serena_config.get_registered_project(project)
+        if registered_project is None:
+            raise click.UsageError(f"No Serena project found for '{project}'. Create one first.")
+        proj = registered_project.get_project_instance(serena_config=serena_config)
+        cache_root = Path(proj.path_to_serena_data_folder()) / SolidLanguageServer.CACHE_FOLDER_NAME
+        if not cache_root.is_dir():
+            return []
+        return sorted(path for path in cache_root.iterdir() if path.is_dir())
+
+    @staticmethod
+    @click.command(
+        "stats",
+        help="Print statistics on the persisted symbol caches of a project (number of entries and size on disk). "
+        "Cache hit/miss ratios are collected by running language servers and are reported by the `clear_symbol_cache` tool.",
+        context_settings={"max_content_width": _MAX_CONTENT_WIDTH},
+    )
+    @click.argument("project", type=PROJECT_TYPE, default=os.getcwd(), required=False)
+    def stats(project: str) -> None:
+        from solidlsp.ls import SolidLanguageServer
+
+        cache_dirs = CacheCommands._get_symbol_cache_dirs(project)
+        if not cache_dirs:
+            click.echo("No symbol caches found.")
+            return
+        for cache_dir in cache_dirs:
+            cache_stats = SolidLanguageServer.read_persisted_symbol_cache_stats(cache_dir)
+            click.echo(f"{cache_dir.name}: {cache_stats.to_string()}")
+
+    @staticmethod
+    @click.command(
+        "clear",
+        help="Delete the persisted symbol caches of a project, such that symbols are re-requested from the language servers. "
+        "Running Serena instances keep their in-memory caches; use the `clear_symbol_cache` tool for clearing these.",
+        context_settings={"max_content_width": _MAX_CONTENT_WIDTH},
+    )
+    @click.argument("project", type=PROJECT_TYPE, default=os.getcwd(), required=False)
+    def clear(project: str) -> None:
+        from solidlsp.ls import SolidLanguageServer
+
+        cache_dirs = CacheCommands._get_symbol_cache_dirs(project)
+        num_deleted_files = 0
+        for cache_dir in cache_dirs:
+            for cache_file in SolidLanguageServer.get_symbol_cache_files(cache_dir):
+                if cache_file.exists():
+                    cache_file.unlink()
+                    num_deleted_files += 1
+        click.echo(f"Deleted {num_deleted_files} symbol cache files.")
+
+
+class TerraformLSCommands(AutoRegisteringGroup):
+    """Group for 'terraform-ls' subcommands; manage the terraform-ls versions installed by Serena."""
+
+    def __init__(self) -> None:
+        super().__init__(
+            name="terraform-ls",
+            help="Install, update and inspect the terraform-ls language server managed by Serena. "
+            "You can run `serena terraform-ls <command> --help` for more info on each command.",
+        )
+
+    @staticmethod
+    def _create_solidlsp_settings(serena_config: SerenaConfig) -> SolidLSPSettings:
+        return SolidLSPSettings(solidlsp_dir=SerenaPaths().serena_user_home_dir, ls_specific_settings=serena_config.ls_specific_settings)
+
+    @staticmethod
+    def _install(serena_config: SerenaConfig, version: str, force: bool, pin: bool) -> None:
+        from solidlsp.language_servers.terraform_ls import TerraformLS
+
+        solidlsp_settings = TerraformLSCommands._create_solidlsp_settings(serena_config)
+        click.echo(f"Installing terraform-ls {version} ...")
+        try:
+            executable_path = TerraformLS.install(solidlsp_settings, version, force=force)
+        except Exception as e:
+            raise click.ClickException(f"Failed to install terraform-ls {version}: {e}")
+        click.echo(f"terraform-ls {version} is installed at {executable_path}.")
+        if pin:
+            serena_config.set_ls_specific_setting(LanguageServerId.TERRAFORM.value, "terraform_ls_version", version)
+            click.echo(f"Pinned terraform-ls {version} via `ls_specific_settings` in {SerenaConfig.CONFIG_FILE}.")
+        elif version != TerraformLS.get_configured_version(solidlsp_settings):
+            click.echo("The version is not used unless it is pinned (pass --pin).")
+
+    @staticmethod
+    @click.command(
+        "install",
+        help="Install the given terraform-ls version (default: the configured version), optionally pinning it in the global configuration.",
+        context_settings={"max_content_width": _MAX_CONTENT_WIDTH},
+    )
+    @click.argument("version", type=str, required=False, default=None)
+    @click.option("--force", is_flag=True, help="Reinstall the version even if it is already installed.")
+    @click.option("--pin", is_flag=True, help="Configure Serena to use the installed version.")
+    def install(version: str | None, force: bool, pin: bool) -> None:
+        from solidlsp.language_servers.terraform_ls import TerraformLS
+
+        serena_config = SerenaConfig.from_config_file()
+        if version is None:
+            version = TerraformLS.get_configured_version(TerraformLSCommands._create_solidlsp_settings(serena_config))
+        TerraformLSCommands._install(serena_config, version, force=force, pin=pin)
+
+    @staticmethod
+    @click.command(
+        "update",
+        help="Install the latest terraform-ls release and pin it in the global configuration.",
+        context_settings={"max_content_width": _MAX_CONTENT_WIDTH},
+    )
+    def update() -> None:
+        from solidlsp.language_servers.terraform_ls import TerraformLS
+
+        serena_config = SerenaConfig.from_config_file()
+        try:
+            latest_version = TerraformLS.get_latest_version()
+        except Exception as e:
+            raise click.ClickException(f"Failed to determine the latest terraform-ls release: {e}")
+        configured_version = TerraformLS.get_configured_version(TerraformLSCommands._create_solidlsp_settings(serena_config))
+        if latest_version == configured_version:
+            click.echo(f"terraform-ls {configured_version} is the latest release.")
+        TerraformLSCommands._install(serena_config, latest_version, force=False, pin=latest_version != configured_version)
+
+    @staticmethod
+    @click.command(
+        "version",
+        help="Print the configured terraform-ls version and the versions installed by Serena.",
+        context_settings={"max_content_width": _MAX_CONTENT_WIDTH},
+    )
+    def version() -> None:
+        from solidlsp.language_servers.terraform_ls import DEFAULT_TERRAFORM_LS_VERSION, TerraformLS
+
+        solidlsp_settings = TerraformLSCommands._create_solidlsp_settings(SerenaConfig.from_config_file())
+        configured_version = TerraformLS.get_configured_version(solidlsp_settings)
+        installed_versions = TerraformLS.get_installed_versions(solidlsp_settings)
+        custom_executable_path = TerraformLS.get_custom_executable_path(solidlsp_settings)
+        if custom_executable_path is not None:
+            click.echo(f"Custom executable: {custom_executable_path} (the configured version is not used)")
+        system_executable = TerraformLS.find_system_executable()
+        if system_executable is not None:
+            system_path, system_version = system_executable
+            click.echo(f"System executable: {system_path} ({system_version}; used unless a version is configured explicitly)")
+        installed_str = "" if configured_version in installed_versions else " (not installed yet)"
+        click.echo(f"Configured version: {configured_version}{installed_str}")
+        click.echo(f"Default version: {DEFAULT_TERRAFORM_LS_VERSION}")
+        click.echo(f"Installed versions: {', '.join(installed_versions) if installed_versions else '(none)'}")
+
+
 _mode = ModeCommands()
 _context = ContextCommands()
 _project = ProjectCommands()
@@ -1514,10 +1884,12 @@ _config = SerenaConfigCommands()
 _tools = ToolCommands()
 _prompts = PromptCommands()
 _memories = MemoryCommands()
+_cache = CacheCommands()
+_terraform_ls = TerraformLSCommands()
 
 # Expose so we can use this as an entrypoint
 top_level = TopLevelCommands()
 
 # needed for the help script to work - register all subcommands to the top-level group
-for subgroup in (_mode, _context, _project, _config, _tools, _prompts, _memories):
+for subgroup in (_mode, _context, _project, _config, _tools, _prompts, _memories, _cache, _terraform_ls):
     top_level.add_command(subgroup)
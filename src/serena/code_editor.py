@@ -1,29 +1,83 @@
+import difflib
 import json
 import logging
 import os
+import re
+import shutil
 from abc import ABC, abstractmethod
 from collections.abc import Iterable, Iterator, Reversible
 from contextlib import contextmanager
+from dataclasses import dataclass
 from typing import Generic, TypeVar, cast
 
 from serena.jetbrains.jetbrains_plugin_client import JetBrainsPluginClient
 from serena.symbol import JetBrainsSymbol, LanguageServerSymbol, LanguageServerSymbolRetriever, PositionInFile, Symbol
 from solidlsp import SolidLanguageServer, ls_types
 from solidlsp.ls import LSPFileBuffer
-from solidlsp.ls_utils import PathUtils, TextStepper, TextUtils
+from solidlsp.ls_utils import FileUtils, PathUtils, TextStepper, TextUtils
+from solidlsp.lsp_protocol_handler.lsp_types import FileChangeType
+from solidlsp.util.hcl import HclAttribute, HclBlockBody, parse_hcl_block_body
 
+from .edit_history import EditHistory
 from .project import Project
 from .util.file_proxy import FileProxy
+from .util.file_system import is_path_within_directory
 
 log = logging.getLogger(__name__)
 TSymbol = TypeVar("TSymbol", bound=Symbol)
 
 
 class CodeEditor(Generic[TSymbol], ABC):
+    _edit_history: EditHistory | None = None
+    _preview_contents: dict[str, tuple[str, str]] | None = None
+    """
+    while in preview mode (see :meth:`preview_mode`), maps the relative paths of edited files to their original and new contents
+    """
+
     def __init__(self, project: Project) -> None:
+        self._project = project
         self.project_root = project.project_root
         self.encoding = project.project_config.encoding
         self.newline = project.line_ending.newline_str
+        self._edit_history = project.edit_history
+
+    @dataclass
+    class EditPreview:
+        relative_path: str
+        num_locations: int
+        """the number of locations changed in the file; 0 for file operations (e.g. renaming the file)"""
+        description: str
+        """a unified diff of the changes to the file's contents or a description of the file operation"""
+
+        @classmethod
+        def from_contents(
+            cls, relative_path: str, contents: str, new_contents: str, num_locations: int | None = None
+        ) -> "CodeEditor.EditPreview":
+            """
+            :param relative_path: the relative path of the file
+            :param contents: the original contents of the file
+            :param new_contents: the new contents of the file
+            :param num_locations: the number of changed locations; if None, use the number of hunks of the diff
+            :return: a preview containing a unified diff of the changes
+            """
+            diff_lines = list(
+                difflib.unified_diff(
+                    contents.splitlines(),
+                    new_contents.splitlines(),
+                    fromfile=f"a/{relative_path}",
+                    tofile=f"b/{relative_path}",
+                    n=1,
+                    lineterm="",
+                )
+            )
+            if num_locations is None:
+                num_locations = sum(1 for line in diff_lines if line.startswith("@@"))
+            return cls(relative_path, num_locations, "\n".join(diff_lines))
+
+        def to_string(self) -> str:
+            if self.num_locations == 0:
+                return f"{self.relative_path}: {self.description}"
+            return f"{self.relative_path} ({self.num_locations} location(s)):\n{self.description}"
 
     class EditedFile(ABC):
         def __init__(self, relative_path: str) -> None:
@@ -74,48 +128,125 @@ class CodeEditor(Generic[TSymbol], ABC):
                 first_line, last_line = lines
                 return TextUtils.get_text_in_lines_range(contents, first_line, last_line)
 
+    @staticmethod
+    def _preserve_final_newline(original_contents: str, new_contents: str) -> str:
+        """
+        :param original_contents: the contents of a file prior to an edit
+        :param new_contents: the contents of the file after the edit
+        :return: the new contents, adjusted such that they end with a newline if and only if the original contents did
+            (unless either of them is empty)
+        """
+        if not original_contents or not new_contents:
+            return new_contents
+        if original_contents.endswith("\n") and not new_contents.endswith("\n"):
+            return new_contents + "\n"
+        if not original_contents.endswith("\n") and new_contents.endswith("\n"):
+            return new_contents[:-1]
+        return new_contents
+
     @contextmanager
     def edited_file_context(self, relative_path: str) -> Iterator["CodeEditor.EditedFile"]:
         """
         Context manager for editing a file.
+        The edit does not change whether the file ends with a newline.
         """
         if FileProxy.is_external_path(relative_path):
             raise ValueError(f"Cannot edit external file: {relative_path}")
         with self._open_file_context(relative_path) as edited_file:
+            original_contents = edited_file.get_contents()
+            if self._preview_contents is not None and relative_path in self._preview_contents:
+                # continue from the state resulting from the preceding edits in preview mode
+                edited_file.set_contents(self._preview_contents[relative_path][1])
             yield edited_file
+            new_contents = self._preserve_final_newline(original_contents, edited_file.get_contents())
+            if new_contents != edited_file.get_contents():
+                edited_file.set_contents(new_contents)
+            if self._preview_contents is not None:
+                # record the change for the preview and revert it instead of saving the file
+                if new_contents != original_contents:
+                    self._preview_contents[relative_path] = (original_contents, new_contents)
+                    edited_file.set_contents(original_contents)
+                else:
+                    self._preview_contents.pop(relative_path, None)
+                return
             # save the file
             self._save_edited_file(edited_file)
+            self._record_file_mutation(relative_path, original_contents, edited_file.get_contents())
+
+    @contextmanager
+    def preview_mode(self) -> Iterator[list["CodeEditor.EditPreview"]]:
+        """
+        Context manager within which edits of files (performed via :meth:`edited_file_context`) are not saved but previewed:
+        the changes are reverted, and the yielded list is populated with previews of the changes when the context is exited.
+        """
+        previews: list[CodeEditor.EditPreview] = []
+        self._preview_contents = {}
+        try:
+            yield previews
+        finally:
+            preview_contents, self._preview_contents = self._preview_contents, None
+        for relative_path, (contents, new_contents) in preview_contents.items():
+            previews.append(self.EditPreview.from_contents(relative_path, contents, new_contents))
+
+    def _record_file_mutation(self, relative_path: str, before: str | None, after: str | None) -> None:
+        """
+        Records a mutation of a file which was not performed via :meth:`edited_file_context` (e.g. the creation,
+        renaming or deletion of a file) in the edit history, such that it can be undone.
+
+        :param relative_path: the relative path of the file
+        :param before: the contents of the file before the mutation; None if the file did not exist
+        :param after: the contents of the file after the mutation; None if the file was deleted
+        """
+        if self._edit_history is not None:
+            self._edit_history.record_mutation(relative_path, before, after)
 
     def _save_edited_file(self, edited_file: "CodeEditor.EditedFile") -> None:
         abs_path = os.path.join(self.project_root, edited_file.relative_path)
         new_contents = edited_file.get_contents()
-        with open(abs_path, "w", encoding=self.encoding, newline=self.newline) as f:
-            f.write(new_contents)
+        FileUtils.write_file(abs_path, new_contents, self.encoding, newline=self.newline)
+        self._project.notify_file_changes([(edited_file.relative_path, FileChangeType.Changed)])
 
     @abstractmethod
-    def _find_unique_symbol(self, name_path: str, relative_file_path: str) -> TSymbol:
+    def _find_unique_symbol(self, name_path: str, relative_file_path: str, occurrence_index: int | None = None) -> TSymbol:
         """
         Finds the unique symbol with the given name in the given file.
         If no such symbol exists, raises a ValueError.
 
         :param name_path: the name path
         :param relative_file_path: the relative path of the file in which to search for the symbol.
+        :param occurrence_index: the index of the symbol to select if multiple symbols match; if None, the match must be unique
+            (otherwise, a ValueError listing the candidates along with their indices is raised)
         :return: the unique symbol
         """
 
-    def replace_body(self, name_path: str, relative_file_path: str, body: str) -> None:
+    def replace_body(
+        self,
+        name_path: str,
+        relative_file_path: str,
+        body: str,
+        occurrence_index: int | None = None,
+        include_leading_comments: bool = False,
+    ) -> None:
         """
         Replaces the body of the symbol with the given name_path in the given file.
 
         :param name_path: the name path of the symbol to replace.
         :param relative_file_path: the relative path of the file in which the symbol is defined.
         :param body: the new body
+        :param occurrence_index: the index of the symbol to select if multiple symbols match the name path
+        :param include_leading_comments: whether the block of comment lines immediately preceding the symbol is part of
+            the body to be replaced
         """
-        symbol = self._find_unique_symbol(name_path, relative_file_path)
+        symbol = self._find_unique_symbol(name_path, relative_file_path, occurrence_index=occurrence_index)
         start_pos = symbol.get_body_start_position_or_raise()
         end_pos = symbol.get_body_end_position_or_raise()
 
         with self.edited_file_context(relative_file_path) as edited_file:
+            if include_leading_comments:
+                lines = TextUtils.split_lines(edited_file.get_contents())
+                start_line, start_col = TextUtils.find_leading_comment_start_position(lines, start_pos.line, start_pos.col)
+                start_pos = PositionInFile(line=start_line, col=start_col)
+
             # make sure the replacement adds no additional newlines (before or after) - all newlines
             # and whitespace before/after should remain the same, so we strip it entirely
             body = body.strip()
@@ -139,11 +270,56 @@ class CodeEditor(Generic[TSymbol], ABC):
     def _count_trailing_newlines(cls, text: Reversible) -> int:
         return cls._count_leading_newlines(reversed(text))
 
-    def insert_after_symbol(self, name_path: str, relative_file_path: str, body: str) -> None:
+    @staticmethod
+    def _get_line_indentation(contents: str, line: int) -> str:
+        """
+        :return: the leading whitespace of the given (0-based) line
+        """
+        lines = TextUtils.split_lines(contents)
+        if line >= len(lines):
+            return ""
+        line_contents = lines[line]
+        return line_contents[: len(line_contents) - len(line_contents.lstrip())]
+
+    @staticmethod
+    def _indent_like_anchor(body: str, indentation: str) -> str:
+        """
+        Re-indents content to be inserted next to an anchor symbol, such that it is indented like the anchor
+        (preserving the relative indentation of its lines).
+        Content whose first line is unindented while all subsequent lines are already indented more deeply than the anchor
+        (i.e. content whose lines after the first are indented absolutely) only has its first line indented;
+        the last line may be indented exactly like the anchor if it closes a block (e.g. a closing brace).
+
+        :param body: the content to be inserted
+        :param indentation: the indentation of the anchor symbol's first line
+        :return: the re-indented content
+        """
+        lines = body.split("\n")
+        non_blank_lines = [line for line in lines if line.strip()]
+        if not non_blank_lines:
+            return body
+        common_indentation = os.path.commonprefix([line[: len(line) - len(line.lstrip())] for line in non_blank_lines])
+        if common_indentation == indentation:
+            return body
+        first_line_index = lines.index(non_blank_lines[0])
+        if common_indentation == "" and indentation and len(non_blank_lines) > 1:
+
+            def is_indented_more_deeply(line: str) -> bool:
+                return line.startswith(indentation) and line[len(indentation) :][:1].isspace()
+
+            *inner_lines, last_line = non_blank_lines[1:]
+            is_closing_line = last_line.startswith(indentation) and last_line.lstrip()[:1] in ")]}"
+            if all(is_indented_more_deeply(line) for line in inner_lines) and (is_indented_more_deeply(last_line) or is_closing_line):
+                lines[first_line_index] = indentation + lines[first_line_index]
+                return "\n".join(lines)
+        return "\n".join(indentation + line[len(common_indentation) :] if line.strip() else line for line in lines)
+
+    def insert_after_symbol(self, name_path: str, relative_file_path: str, body: str, occurrence_index: int | None = None) -> None:
         """
         Inserts content after the symbol with the given name in the given file.
+        The content is re-indented to match the indentation of the symbol.
         """
-        symbol = self._find_unique_symbol(name_path, relative_file_path)
+        symbol = self._find_unique_symbol(name_path, relative_file_path, occurrence_index=occurrence_index)
         # Note: for body to be available, the symbol dto that the symbol instance is built from
         # must have been retrieved either with body or at least with location.
         # since _find_unique_symbol passes include_location=True, it works here
@@ -178,13 +354,21 @@ class CodeEditor(Generic[TSymbol], ABC):
         body = body.rstrip("\r\n") + "\n"
 
         with self.edited_file_context(relative_file_path) as edited_file:
+            contents = edited_file.get_contents()
+            start_pos = symbol.get_body_start_position_or_raise()
+            body = self._indent_like_anchor(body, self._get_line_indentation(contents, start_pos.line))
+            # separate the inserted content from a directly succeeding definition
+            lines = TextUtils.split_lines(contents)
+            if min_empty_lines and line < len(lines) and lines[line].strip():
+                body += "\n"
             edited_file.insert_text_at_position(PositionInFile(line, col), body)
 
-    def insert_before_symbol(self, name_path: str, relative_file_path: str, body: str) -> None:
+    def insert_before_symbol(self, name_path: str, relative_file_path: str, body: str, occurrence_index: int | None = None) -> None:
         """
         Inserts content before the symbol with the given name in the given file.
+        The content is re-indented to match the indentation of the symbol.
         """
-        symbol = self._find_unique_symbol(name_path, relative_file_path)
+        symbol = self._find_unique_symbol(name_path, relative_file_path, occurrence_index=occurrence_index)
         symbol_start_pos = symbol.get_body_start_position_or_raise()
 
         # insert position is the start of line where the symbol is defined
@@ -206,44 +390,217 @@ class CodeEditor(Generic[TSymbol], ABC):
 
         # apply edit
         with self.edited_file_context(relative_file_path) as edited_file:
+            contents = edited_file.get_contents()
+            body = self._indent_like_anchor(body, self._get_line_indentation(contents, line))
+            # separate the inserted content from a directly preceding block (e.g. a Terraform block ending in `}`);
+            # other preceding lines (e.g. comments or decorators) may belong to the symbol and are therefore not separated
+            lines = TextUtils.split_lines(contents)
+            if min_trailing_empty_lines and line > 0 and lines[line - 1].strip() == "}":
+                body = "\n" + body
             edited_file.insert_text_at_position(PositionInFile(line=line, col=col), body)
 
+    @staticmethod
+    def resolve_line_index(contents: str, line: int, allow_end_of_file: bool = False) -> int:
+        """
+        Resolves a line index which may be negative, counting from the end of the file.
+
+        :param contents: the contents of the file
+        :param line: the 0-based line index; negative indices count from the end of the file, i.e. -1 refers to the last line or,
+            if `allow_end_of_file` is True, to the end of the file
+        :param allow_end_of_file: whether the index refers to a position between lines (as for insertions), such that
+            the end of the file (the position after the last line) can be referred to
+        :return: the resolved (non-negative) line index
+        """
+        if line >= 0:
+            return line
+        lines = TextUtils.split_lines(contents)
+        # a trailing newline does not start another line
+        num_lines = len(lines) - 1 if lines[-1] == "" else len(lines)
+        resolved_line = num_lines + line + (1 if allow_end_of_file else 0)
+        if resolved_line < 0:
+            raise ValueError(f"Line index {line} is out of range for a file with {num_lines} lines")
+        return resolved_line
+
     def insert_at_line(self, relative_path: str, line: int, content: str) -> None:
         """
         Inserts content at the given line in the given file.
 
         :param relative_path: the relative path of the file in which to insert content
-        :param line: the 0-based index of the line to insert content at
+        :param line: the 0-based index of the line to insert content at; negative indices count from the end of the file,
+            with -1 referring to the end of the file (i.e. the content is appended)
         :param content: the content to insert
         """
         with self.edited_file_context(relative_path) as edited_file:
+            line = self.resolve_line_index(edited_file.get_contents(), line, allow_end_of_file=True)
             edited_file.insert_text_at_position(PositionInFile(line, 0), content)
 
-    def delete_lines(self, relative_path: str, start_line: int, end_line: int) -> None:
+    def append_to_file(self, relative_path: str, content: str) -> None:
+        """
+        Appends content to the given file, separated from the file's existing content (if any) by an empty line.
+
+        :param relative_path: the relative path of the file
+        :param content: the content to append
+        """
+        with self.edited_file_context(relative_path) as edited_file:
+            contents = edited_file.get_contents().rstrip("\r\n")
+            separator = "\n\n" if contents else ""
+            edited_file.set_contents(contents + separator + content.strip("\r\n") + "\n")
+
+    @staticmethod
+    def find_matching_line(contents: str, pattern: str, occurrence_index: int = 0) -> int:
+        """
+        Finds a line matching the given regular expression.
+
+        :param contents: the contents of the file
+        :param pattern: the regular expression (Python syntax) to search for within each line
+        :param occurrence_index: the index of the line to select among all matching lines (0 for the first);
+            negative indices count from the last matching line (-1 for the last)
+        :return: the 0-based index of the line
+        """
+        regex = re.compile(pattern)
+        matching_lines = [i for i, line in enumerate(TextUtils.split_lines(contents)) if regex.search(line)]
+        if not matching_lines:
+            raise ValueError(f"No line matches the pattern {pattern!r}")
+        if not -len(matching_lines) <= occurrence_index < len(matching_lines):
+            raise ValueError(f"Invalid occurrence_index {occurrence_index}: {len(matching_lines)} lines match the pattern {pattern!r}")
+        return matching_lines[occurrence_index]
+
+    def insert_relative_to_pattern(
+        self, relative_path: str, pattern: str, content: str, occurrence_index: int = 0, before: bool = False
+    ) -> None:
+        """
+        Inserts content before or after a line matching the given regular expression.
+
+        :param relative_path: the relative path of the file in which to insert content
+        :param pattern: the regular expression (Python syntax) identifying the anchor line (searched within each line)
+        :param content: the content to insert
+        :param occurrence_index: the index of the anchor line among all matching lines (see :meth:`find_matching_line`)
+        :param before: whether to insert before the anchor line rather than after it
+        """
+        with self.edited_file_context(relative_path) as edited_file:
+            line = self.find_matching_line(edited_file.get_contents(), pattern, occurrence_index=occurrence_index)
+            if not before:
+                line += 1
+            edited_file.insert_text_at_position(PositionInFile(line, 0), content)
+
+    @staticmethod
+    def _check_expected_line_content(lines: list[str], line: int, expected_content: str | None, description: str) -> None:
+        if expected_content is None:
+            return
+        actual_content = lines[line] if line < len(lines) else ""
+        if actual_content.strip() != expected_content.strip():
+            raise ValueError(
+                f"The {description} line ({line}) does not have the expected content; the file was NOT modified "
+                f"(the line indices are probably stale; re-read the file). Expected: {expected_content.strip()!r}; "
+                f"actual: {actual_content.strip()!r}"
+            )
+
+    def _delete_lines(
+        self,
+        edited_file: "CodeEditor.EditedFile",
+        start_line: int,
+        end_line: int,
+        expected_first_line: str | None = None,
+        expected_last_line: str | None = None,
+    ) -> int:
+        """
+        :return: the resolved index of the first deleted line
+        """
+        contents = edited_file.get_contents()
+        start_line = self.resolve_line_index(contents, start_line)
+        end_line = self.resolve_line_index(contents, end_line)
+        if end_line < start_line:
+            raise ValueError(f"The end line ({end_line}) must not precede the start line ({start_line})")
+        if expected_first_line is not None or expected_last_line is not None:
+            lines = TextUtils.split_lines(contents)
+            self._check_expected_line_content(lines, start_line, expected_first_line, "first")
+            self._check_expected_line_content(lines, end_line, expected_last_line, "last")
+        start_pos = PositionInFile(line=start_line, col=0)
+        end_pos = PositionInFile(line=end_line + 1, col=0)
+        edited_file.delete_text_between_positions(start_pos, end_pos)
+        return start_line
+
+    def delete_lines(
+        self,
+        relative_path: str,
+        start_line: int,
+        end_line: int,
+        expected_first_line: str | None = None,
+        expected_last_line: str | None = None,
+    ) -> None:
         """
         Deletes lines in the given file.
 
         :param relative_path: the relative path of the file in which to delete lines
-        :param start_line: the 0-based index of the first line to delete (inclusive)
-        :param end_line: the 0-based index of the last line to delete (inclusive)
+        :param start_line: the 0-based index of the first line to delete (inclusive); negative indices count from the end of the file,
+            with -1 referring to the last line
+        :param end_line: the 0-based index of the last line to delete (inclusive); negative indices as for `start_line`
+        :param expected_first_line: if given, the expected content of the first line (ignoring leading and trailing whitespace);
+            if the actual content differs, a ValueError is raised and nothing is deleted
+        :param expected_last_line: if given, the expected content of the last line (analogous to `expected_first_line`)
         """
-        start_col = 0
-        end_line_for_delete = end_line + 1
-        end_col = 0
         with self.edited_file_context(relative_path) as edited_file:
-            start_pos = PositionInFile(line=start_line, col=start_col)
-            end_pos = PositionInFile(line=end_line_for_delete, col=end_col)
-            edited_file.delete_text_between_positions(start_pos, end_pos)
+            self._delete_lines(
+                edited_file, start_line, end_line, expected_first_line=expected_first_line, expected_last_line=expected_last_line
+            )
+
+    def replace_lines(self, relative_path: str, start_line: int, end_line: int, content: str) -> None:
+        """
+        Replaces lines in the given file with the given content.
+
+        :param relative_path: the relative path of the file in which to replace lines
+        :param start_line: the 0-based index of the first line to replace (inclusive); negative indices count from the end of the file,
+            with -1 referring to the last line
+        :param end_line: the 0-based index of the last line to replace (inclusive); negative indices as for `start_line`
+        :param content: the content to insert in place of the lines
+        """
+        with self.edited_file_context(relative_path) as edited_file:
+            start_line = self._delete_lines(edited_file, start_line, end_line)
+            edited_file.insert_text_at_position(PositionInFile(start_line, 0), content)
+
+    def apply_text_edits(self, text_edits_by_file: dict[str, list[ls_types.TextEdit]]) -> None:
+        """
+        Applies the given text edits atomically: the edits of all files are validated before any file is changed,
+        such that either all edits are applied or (if any edit is invalid) none are.
+        The ranges of the edits of a file all refer to the file's original contents (as for LSP text edits) and must not overlap.
 
-    def delete_symbol(self, name_path: str, relative_file_path: str) -> None:
+        :param text_edits_by_file: a mapping from the relative paths of the files to be edited to the respective text edits
+        :raises ValueError: if any of the edits cannot be applied (listing all problems); no file is changed in this case
+        """
+        new_contents_by_file: dict[str, str] = {}
+        problems: list[str] = []
+        for relative_path, text_edits in text_edits_by_file.items():
+            try:
+                new_contents_by_file[relative_path] = TextUtils.apply_text_edits(self.read_file(relative_path), text_edits)
+            except Exception as e:
+                problems.append(f"{relative_path}: {e.__class__.__name__}: {e}")
+        if problems:
+            problem_lines = "\n".join(f"  {problem}" for problem in problems)
+            raise ValueError(
+                f"{len(problems)} of {len(text_edits_by_file)} file(s) cannot be edited - NO changes were applied:\n{problem_lines}"
+            )
+        for relative_path, new_contents in new_contents_by_file.items():
+            with self.edited_file_context(relative_path) as edited_file:
+                edited_file.set_contents(new_contents)
+
+    def delete_symbol(self, name_path: str, relative_file_path: str, include_leading_comments: bool = False) -> None:
         """
         Deletes the symbol with the given name in the given file.
+
+        :param name_path: the name path of the symbol to delete
+        :param relative_file_path: the relative path of the file in which the symbol is defined
+        :param include_leading_comments: whether to also delete the block of comment lines immediately preceding the symbol
         """
         symbol = self._find_unique_symbol(name_path, relative_file_path)
         start_pos = symbol.get_body_start_position_or_raise()
         end_pos = symbol.get_body_end_position_or_raise()
 
         with self.edited_file_context(relative_file_path) as edited_file:
+            if include_leading_comments:
+                lines = TextUtils.split_lines(edited_file.get_contents())
+                start_line, start_col = TextUtils.find_leading_comment_start_position(lines, start_pos.line, start_pos.col)
+                start_pos = PositionInFile(line=start_line, col=start_col)
+
             # do the actual deletion
             edited_file.delete_text_between_positions(start_pos, end_pos)
 
@@ -267,6 +624,110 @@ class CodeEditor(Generic[TSymbol], ABC):
             if apply_empty_line_deletion:
                 edited_file.delete_text_between_positions(start_pos, end_pos)
 
+    def _parse_hcl_block(
+        self, name_path: str, relative_file_path: str, contents: str, occurrence_index: int | None
+    ) -> tuple[int, HclBlockBody]:
+        """
+        :return: a pair (offset, body) comprising the offset of the block in the file's contents and the parsed block body
+        """
+        symbol = self._find_unique_symbol(name_path, relative_file_path, occurrence_index=occurrence_index)
+        start_pos = symbol.get_body_start_position_or_raise()
+        end_pos = symbol.get_body_end_position_or_raise()
+        start = TextUtils.get_index_from_line_col(contents, start_pos.line, start_pos.col)
+        end = TextUtils.get_index_from_line_col(contents, end_pos.line, end_pos.col)
+        block_body = parse_hcl_block_body(contents[start:end])
+        if block_body is None:
+            raise ValueError(f"Symbol {name_path} in {relative_file_path} is not a block")
+        return start, block_body
+
+    @staticmethod
+    def _find_hcl_attribute(block_body: HclBlockBody, attribute: str) -> HclAttribute | None:
+        for hcl_attribute in block_body.attributes:
+            if hcl_attribute.name == attribute:
+                return hcl_attribute
+        return None
+
+    def get_block_attribute(self, name_path: str, relative_file_path: str, attribute: str, occurrence_index: int | None = None) -> str:
+        """
+        Reads the value of an attribute of an HCL block (e.g. of a Terraform resource).
+
+        :param name_path: the name path of the block
+        :param relative_file_path: the relative path of the file in which the block is defined
+        :param attribute: the name of the attribute, which must be directly contained in the block
+        :param occurrence_index: the index of the block to select if multiple symbols match the name path
+        :return: the attribute's value expression (as written in the file)
+        """
+        with self._open_file_context(relative_file_path) as file:
+            contents = file.get_contents()
+        block_offset, block_body = self._parse_hcl_block(name_path, relative_file_path, contents, occurrence_index)
+        hcl_attribute = self._find_hcl_attribute(block_body, attribute)
+        if hcl_attribute is None:
+            raise ValueError(f"Block {name_path} in {relative_file_path} has no attribute '{attribute}'")
+        return contents[block_offset + hcl_attribute.value_start : block_offset + hcl_attribute.value_end]
+
+    def set_block_attribute(
+        self, name_path: str, relative_file_path: str, attribute: str, value: str, occurrence_index: int | None = None
+    ) -> bool:
+        """
+        Sets the value of an attribute of an HCL block (e.g. of a Terraform resource), leaving the remainder of the block
+        (including comments and formatting) unchanged.
+        If the block does not contain the attribute yet, it is added after the block's last attribute.
+
+        :param name_path: the name path of the block
+        :param relative_file_path: the relative path of the file in which the block is defined
+        :param attribute: the name of the attribute
+        :param value: the new value expression, e.g. `"t3.micro"` (including the quotes) or `var.instance_type`
+        :param occurrence_index: the index of the block to select if multiple symbols match the name path
+        :return: whether the attribute was added (rather than replaced)
+        """
+        value = value.strip()
+        with self.edited_file_context(relative_file_path) as edited_file:
+            contents = edited_file.get_contents()
+            block_offset, block_body = self._parse_hcl_block(name_path, relative_file_path, contents, occurrence_index)
+            hcl_attribute = self._find_hcl_attribute(block_body, attribute)
+            if hcl_attribute is not None:
+                start = block_offset + hcl_attribute.value_start
+                end = block_offset + hcl_attribute.value_end
+                new_text = value
+            else:
+                start, end, new_text = self._get_hcl_attribute_insertion(contents, block_offset, block_body, f"{attribute} = {value}")
+            edited_file.set_contents(contents[:start] + new_text + contents[end:])
+        return hcl_attribute is None
+
+    @staticmethod
+    def _get_hcl_attribute_insertion(
+        contents: str, block_offset: int, block_body: HclBlockBody, attribute_definition: str
+    ) -> tuple[int, int, str]:
+        """
+        :return: a triple (start, end, text) describing the replacement of the text between the offsets start and end
+            which adds the given attribute definition to the block
+        """
+        opening_brace = block_offset + block_body.opening_brace_offset
+        if block_body.attributes:
+            # insert after the line of the last attribute (if it is on a line of its own and not followed by the closing brace),
+            # using the same indentation
+            last_attribute = block_body.attributes[-1]
+            attribute_start = block_offset + last_attribute.start
+            line_start = contents.rfind("\n", 0, attribute_start) + 1
+            line_end = contents.find("\n", block_offset + last_attribute.value_end)
+            closing_brace_on_line = block_body.closing_brace_offset is None or block_offset + block_body.closing_brace_offset < line_end
+            if line_start > opening_brace and line_end != -1 and not closing_brace_on_line:
+                return line_end, line_end, "\n" + contents[line_start:attribute_start] + attribute_definition
+        if block_body.closing_brace_offset is None:
+            raise ValueError("Cannot add an attribute to a block which is not terminated")
+        closing_brace = block_offset + block_body.closing_brace_offset
+        line_start = contents.rfind("\n", 0, closing_brace) + 1
+        closing_line_prefix = contents[line_start:closing_brace]
+        if not closing_line_prefix.strip():
+            # insert before the line containing the closing brace, indenting relative to the brace
+            return line_start, line_start, closing_line_prefix + "  " + attribute_definition + "\n"
+        if not contents[opening_brace + 1 : closing_brace].strip():
+            # empty single-line block (`{}`): expand it to a multi-line block
+            block_line_start = contents.rfind("\n", 0, block_offset) + 1
+            block_indent = contents[block_line_start:block_offset]
+            return opening_brace + 1, closing_brace, "\n" + block_indent + "  " + attribute_definition + "\n" + block_indent
+        raise ValueError("Cannot add an attribute to a non-empty single-line block; replace the block's body instead")
+
     @abstractmethod
     def rename_symbol(self, name_path: str, relative_path: str, new_name: str) -> str:
         pass
@@ -290,7 +751,7 @@ class LanguageServerCodeEditor(CodeEditor[LanguageServerSymbol]):
             return self._file_buffer.contents
 
         def set_contents(self, contents: str) -> None:
-            self._file_buffer.contents = contents
+            self._file_buffer.update_contents(contents)
 
         def delete_text_between_positions(self, start_pos: PositionInFile, end_pos: PositionInFile) -> None:
             self._lang_server.delete_text_between_positions(self.relative_path, start_pos.to_lsp_position(), end_pos.to_lsp_position())
@@ -312,17 +773,34 @@ class LanguageServerCodeEditor(CodeEditor[LanguageServerSymbol]):
         lang_server = self._get_language_server(relative_path)
         return lang_server.language_server.retrieve_full_file_content(relative_path)
 
-    def _find_unique_symbol(self, name_path: str, relative_file_path: str) -> LanguageServerSymbol:
-        return self._symbol_retriever.find_unique(name_path, within_relative_path=relative_file_path)
+    def _find_unique_symbol(self, name_path: str, relative_file_path: str, occurrence_index: int | None = None) -> LanguageServerSymbol:
+        return self._symbol_retriever.find_unique(name_path, within_relative_path=relative_file_path, occurrence_index=occurrence_index)
 
     def _relative_path_from_uri(self, uri: str) -> str:
-        return os.path.relpath(PathUtils.uri_to_path(uri), self.project_root)
+        """
+        :param uri: the URI of a file to be edited as part of a workspace edit provided by the language server
+        :return: the path of the file relative to the project root
+        :raises ValueError: if the file lies outside of the project or is ignored, in which case it must not be edited
+        """
+        abs_path = PathUtils.uri_to_path(uri)
+        if not is_path_within_directory(abs_path, self.project_root):
+            raise ValueError(f"Refusing to apply workspace edit: {abs_path} lies outside of the project root ({self.project_root})")
+        relative_path = os.path.relpath(abs_path, self.project_root)
+        if self._project.is_ignored_path(relative_path):
+            raise ValueError(f"Refusing to apply workspace edit: {relative_path} is ignored")
+        return relative_path
 
     class EditOperation(ABC):
         @abstractmethod
         def apply(self) -> None:
             pass
 
+        @abstractmethod
+        def preview(self) -> "LanguageServerCodeEditor.EditPreview":
+            """
+            :return: a preview of the changes the operation would make (without applying them)
+            """
+
     class EditOperationFileTextEdits(EditOperation):
         def __init__(self, code_editor: "LanguageServerCodeEditor", file_uri: str, text_edits: list[ls_types.TextEdit]):
             self._code_editor = code_editor
@@ -334,6 +812,13 @@ class LanguageServerCodeEditor(CodeEditor[LanguageServerSymbol]):
                 edited_file = cast(LanguageServerCodeEditor.EditedFile, edited_file)
                 edited_file.apply_text_edits(self._text_edits)
 
+        def preview(self) -> "LanguageServerCodeEditor.EditPreview":
+            contents = self._code_editor.read_file(self._relative_path)
+            new_contents = TextUtils.apply_text_edits(contents, self._text_edits)
+            return LanguageServerCodeEditor.EditPreview.from_contents(
+                self._relative_path, contents, new_contents, num_locations=len(self._text_edits)
+            )
+
     class EditOperationRenameFile(EditOperation):
         def __init__(self, code_editor: "LanguageServerCodeEditor", old_uri: str, new_uri: str):
             self._code_editor = code_editor
@@ -343,26 +828,95 @@ class LanguageServerCodeEditor(CodeEditor[LanguageServerSymbol]):
         def apply(self) -> None:
             old_abs_path = os.path.join(self._code_editor.project_root, self._old_relative_path)
             new_abs_path = os.path.join(self._code_editor.project_root, self._new_relative_path)
+            contents = None if os.path.isdir(old_abs_path) else FileUtils.read_file(old_abs_path, self._code_editor.encoding)
             os.rename(old_abs_path, new_abs_path)
+            if contents is not None:
+                self._code_editor._record_file_mutation(self._old_relative_path, contents, None)
+                self._code_editor._record_file_mutation(self._new_relative_path, None, contents)
+            else:
+                log.warning(f"The renaming of directory {self._old_relative_path} is not recorded in the edit history")
+            self._code_editor._project.notify_file_changes(
+                [(self._old_relative_path, FileChangeType.Deleted), (self._new_relative_path, FileChangeType.Created)]
+            )
+
+        def preview(self) -> "LanguageServerCodeEditor.EditPreview":
+            return LanguageServerCodeEditor.EditPreview(self._old_relative_path, 0, f"renamed to {self._new_relative_path}")
+
+    class EditOperationCreateFile(EditOperation):
+        def __init__(self, code_editor: "LanguageServerCodeEditor", uri: str, options: dict | None):
+            self._code_editor = code_editor
+            self._relative_path = code_editor._relative_path_from_uri(uri)
+            self._options = options or {}
+
+        def apply(self) -> None:
+            abs_path = os.path.join(self._code_editor.project_root, self._relative_path)
+            exists = os.path.exists(abs_path)
+            if exists and not self._options.get("overwrite", False):
+                if self._options.get("ignoreIfExists", False):
+                    return
+                raise FileExistsError(f"Cannot create file {self._relative_path}: the file already exists")
+            previous_contents = FileUtils.read_file(abs_path, self._code_editor.encoding) if exists else None
+            os.makedirs(os.path.dirname(abs_path), exist_ok=True)
+            FileUtils.write_file(abs_path, "", self._code_editor.encoding, newline=self._code_editor.newline)
+            self._code_editor._record_file_mutation(self._relative_path, previous_contents, "")
+            change_type = FileChangeType.Changed if exists else FileChangeType.Created
+            self._code_editor._project.notify_file_changes([(self._relative_path, change_type)])
+
+        def preview(self) -> "LanguageServerCodeEditor.EditPreview":
+            return LanguageServerCodeEditor.EditPreview(self._relative_path, 0, "created")
+
+    class EditOperationDeleteFile(EditOperation):
+        def __init__(self, code_editor: "LanguageServerCodeEditor", uri: str, options: dict | None):
+            self._code_editor = code_editor
+            self._relative_path = code_editor._relative_path_from_uri(uri)
+            self._options = options or {}
+
+        def apply(self) -> None:
+            abs_path = os.path.join(self._code_editor.project_root, self._relative_path)
+            if not os.path.exists(abs_path):
+                if self._options.get("ignoreIfNotExists", False):
+                    return
+                raise FileNotFoundError(f"Cannot delete file {self._relative_path}: the file does not exist")
+            if os.path.isdir(abs_path):
+                if not self._options.get("recursive", False):
+                    raise IsADirectoryError(f"Cannot delete directory {self._relative_path} non-recursively")
+                shutil.rmtree(abs_path)
+                log.warning(f"The deletion of directory {self._relative_path} is not recorded in the edit history")
+            else:
+                contents = FileUtils.read_file(abs_path, self._code_editor.encoding)
+                os.remove(abs_path)
+                self._code_editor._record_file_mutation(self._relative_path, contents, None)
+            self._code_editor._project.notify_file_changes([(self._relative_path, FileChangeType.Deleted)])
+
+        def preview(self) -> "LanguageServerCodeEditor.EditPreview":
+            return LanguageServerCodeEditor.EditPreview(self._relative_path, 0, "deleted")
 
     def _workspace_edit_to_edit_operations(self, workspace_edit: ls_types.WorkspaceEdit) -> list["LanguageServerCodeEditor.EditOperation"]:
         operations: list[LanguageServerCodeEditor.EditOperation] = []
 
-        if "changes" in workspace_edit:
-            for uri, edits in workspace_edit["changes"].items():
-                operations.append(self.EditOperationFileTextEdits(self, uri, edits))
-
+        # As per the LSP specification, documentChanges are preferred over changes if both are present
+        # (servers may send both for the benefit of clients which do not support documentChanges).
         if "documentChanges" in workspace_edit:
             for change in workspace_edit["documentChanges"]:
                 if "textDocument" in change and "edits" in change:
+                    # note: the version of a versioned text document edit refers to the state of the file the server is aware of,
+                    # which is the state the edits are applied to
                     operations.append(self.EditOperationFileTextEdits(self, change["textDocument"]["uri"], change["edits"]))
                 elif "kind" in change:
-                    if change["kind"] == "rename":
-                        operations.append(self.EditOperationRenameFile(self, change["oldUri"], change["newUri"]))
-                    else:
-                        raise ValueError(f"Unhandled document change kind: {change}; Please report to Serena developers.")
+                    match change["kind"]:
+                        case "rename":
+                            operations.append(self.EditOperationRenameFile(self, change["oldUri"], change["newUri"]))
+                        case "create":
+                            operations.append(self.EditOperationCreateFile(self, change["uri"], change.get("options")))
+                        case "delete":
+                            operations.append(self.EditOperationDeleteFile(self, change["uri"], change.get("options")))
+                        case _:
+                            raise ValueError(f"Unhandled document change kind: {change}; Please report to Serena developers.")
                 else:
                     raise ValueError(f"Unhandled document change format: {change}; Please report to Serena developers.")
+        elif "changes" in workspace_edit:
+            for uri, edits in workspace_edit["changes"].items():
+                operations.append(self.EditOperationFileTextEdits(self, uri, edits))
 
         return operations
 
@@ -378,16 +932,46 @@ class LanguageServerCodeEditor(CodeEditor[LanguageServerSymbol]):
             operation.apply()
         return len(operations)
 
-    def rename_symbol(self, name_path: str, relative_path: str, new_name: str) -> str:
+    def rename_symbol(self, name_path: str, relative_path: str, new_name: str, occurrence_index: int | None = None) -> str:
         """
         Renames a symbol, file, or directory throughout the codebase.
 
         :param name_path: the name path of the symbol to rename
         :param relative_path: the relative path of the file containing the symbol.
         :param new_name: the new name
+        :param occurrence_index: the index of the symbol to select if multiple symbols match the name path
         :return: a status message
         """
-        symbol = self._find_unique_symbol(name_path, relative_path)
+        rename_result = self._request_rename_symbol_edit(name_path, relative_path, new_name, occurrence_index)
+        num_changes = self._apply_workspace_edit(rename_result)
+
+        if num_changes == 0:
+            raise ValueError(
+                f"Renaming symbol '{name_path}' to '{new_name}' resulted in no changes being applied; renaming may not be supported."
+            )
+
+        msg = f"Successfully renamed '{name_path}' to '{new_name}' ({num_changes} changes applied)"
+        return msg
+
+    def preview_rename_symbol(
+        self, name_path: str, relative_path: str, new_name: str, occurrence_index: int | None = None
+    ) -> list["LanguageServerCodeEditor.EditPreview"]:
+        """
+        Determines the changes which renaming a symbol would make throughout the codebase, without applying them.
+
+        :param name_path: the name path of the symbol to rename
+        :param relative_path: the relative path of the file containing the symbol.
+        :param new_name: the new name
+        :param occurrence_index: the index of the symbol to select if multiple symbols match the name path
+        :return: the previews of the changes (one per affected file)
+        """
+        rename_result = self._request_rename_symbol_edit(name_path, relative_path, new_name, occurrence_index)
+        return [operation.preview() for operation in self._workspace_edit_to_edit_operations(rename_result)]
+
+    def _request_rename_symbol_edit(
+        self, name_path: str, relative_path: str, new_name: str, occurrence_index: int | None
+    ) -> ls_types.WorkspaceEdit:
+        symbol = self._find_unique_symbol(name_path, relative_path, occurrence_index=occurrence_index)
         if not symbol.location.has_position_in_file():
             raise ValueError(f"Symbol '{name_path}' does not have a valid position in file for renaming")
 
@@ -404,20 +988,51 @@ class LanguageServerCodeEditor(CodeEditor[LanguageServerSymbol]):
                 f"Language server for {lang_server.language_id} returned no rename edits for symbol '{name_path}'. "
                 f"The symbol might not support renaming."
             )
-        num_changes = self._apply_workspace_edit(rename_result)
+        return rename_result
 
-        if num_changes == 0:
-            raise ValueError(
-                f"Renaming symbol '{name_path}' to '{new_name}' resulted in no changes being applied; renaming may not be supported."
-            )
+    def format_file(self, relative_path: str) -> bool:
+        """
+        Formats the given file using the formatting provided by the language server.
 
-        msg = f"Successfully renamed '{name_path}' to '{new_name}' ({num_changes} changes applied)"
-        return msg
+        :param relative_path: the relative path of the file
+        :return: whether the file was changed
+        """
+        lang_server = self._get_language_server(relative_path)
+        edits = lang_server.request_formatting_edits(relative_path)
+        if edits is None:
+            raise ValueError(f"Language server for {lang_server.language_id} cannot format {relative_path}")
+        if not edits:
+            return False
+        with self.edited_file_context(relative_path) as edited_file:
+            edited_file = cast(LanguageServerCodeEditor.EditedFile, edited_file)
+            original_contents = edited_file.get_contents()
+            edited_file.apply_text_edits(edits)
+            return edited_file.get_contents() != original_contents
+
+    def apply_code_action(self, relative_path: str, title: str, start_line: int = 0, end_line: int = -1) -> str:
+        """
+        Applies the code action with the given title, which the language server offers for the given range of lines.
+
+        :param relative_path: the relative path of the file
+        :param title: the title of the code action
+        :param start_line: the first 0-based line of the range for which the code action is offered
+        :param end_line: the last 0-based line of the range; -1 for the last line of the file
+        :return: a status message
+        """
+        lang_server = self._get_language_server(relative_path)
+        code_actions = lang_server.request_code_actions(relative_path, start_line=start_line, end_line=end_line)
+        matching_code_actions = [code_action for code_action in code_actions if code_action["title"] == title]
+        if len(matching_code_actions) != 1:
+            if matching_code_actions:
+                raise ValueError(f"Multiple code actions titled '{title}' are offered; restrict the range of lines to select one of them.")
+            available_titles = [code_action["title"] for code_action in code_actions]
+            raise ValueError(f"No code action titled '{title}' is offered for the given lines; available code actions: {available_titles}")
+        num_changes = self._apply_workspace_edit(cast(ls_types.WorkspaceEdit, matching_code_actions[0]["edit"]))
+        return f"Successfully applied code action '{title}' ({num_changes} changes applied)"
 
 
 class JetBrainsCodeEditor(CodeEditor[JetBrainsSymbol]):
     def __init__(self, project: Project) -> None:
-        self._project = project
         super().__init__(project)
 
     class EditedFile(CodeEditor.EditedFile):
@@ -450,16 +1065,21 @@ class JetBrainsCodeEditor(CodeEditor[JetBrainsSymbol]):
         with JetBrainsPluginClient.from_project(self._project) as client:
             client.refresh_file(edited_file.relative_path)
 
-    def _find_unique_symbol(self, name_path: str, relative_file_path: str) -> JetBrainsSymbol:
+    def _find_unique_symbol(self, name_path: str, relative_file_path: str, occurrence_index: int | None = None) -> JetBrainsSymbol:
         with JetBrainsPluginClient.from_project(self._project) as client:
             result = client.find_symbol(name_path, relative_path=relative_file_path, include_body=False, depth=0, include_location=True)
             symbols = result["symbols"]
             if not symbols:
                 raise ValueError(f"No symbol with name {name_path} found in file {relative_file_path}")
+            if occurrence_index is not None:
+                if not 0 <= occurrence_index < len(symbols):
+                    raise ValueError(f"Invalid occurrence_index {occurrence_index}: found {len(symbols)} symbols with name {name_path}")
+                return JetBrainsSymbol(symbols[occurrence_index], self._project)
             if len(symbols) > 1:
+                candidates = [{"occurrence_index": i, **symbol} for i, symbol in enumerate(symbols)]
                 raise ValueError(
-                    f"Found multiple {len(symbols)} symbols with name {name_path} in file {relative_file_path}: "
-                    + json.dumps(symbols, indent=2)
+                    f"Found multiple {len(symbols)} symbols with name {name_path} in file {relative_file_path}. "
+                    "Pass the occurrence_index of the intended symbol to resolve the ambiguity: " + json.dumps(candidates, indent=2)
                 )
             return JetBrainsSymbol(symbols[0], self._project)
 
@@ -0,0 +1,183 @@
+"""
+Validation of configuration data loaded from YAML files (contexts, modes, project and Serena configuration),
+producing errors which cite the file, the line and the allowed keys
+"""
+
+import dataclasses
+import difflib
+import types
+import typing
+from collections.abc import Collection, Mapping, Sequence
+from enum import Enum
+from typing import Any, Literal, Union
+
+import yaml
+from ruamel.yaml.comments import CommentedMap
+
+from serena.constants import SERENA_FILE_ENCODING
+from serena.util.yaml import load_yaml
+
+
+class ConfigValidationError(ValueError):
+    """
+    Indicates that a configuration file contains invalid YAML, unknown keys or values of the wrong type
+    """
+
+    def __init__(self, yaml_path: str, problems: list[str], allowed_keys: Collection[str] | None = None) -> None:
+        """
+        :param yaml_path: the path of the configuration file
+        :param problems: descriptions of the individual problems (each prefixed with the line number, if known)
+        :param allowed_keys: the keys which are allowed in the configuration file; if None, they are not listed
+        """
+        self.yaml_path = yaml_path
+        self.problems = problems
+        message = f"Invalid configuration in {yaml_path}:\n" + "\n".join(f"  * {p}" for p in problems)
+        if allowed_keys is not None:
+            message += f"\nAllowed keys: {', '.join(sorted(allowed_keys))}"
+        super().__init__(message)
+
+
+def get_config_field_types(cls: type) -> dict[str, Any]:
+    """
+    :param cls: a dataclass representing a configuration
+    :return: a mapping from the names of the fields that are mapped to the configuration file (i.e. which do not
+        start with an underscore) to their types
+    """
+    type_hints = typing.get_type_hints(cls)
+    return {f.name: type_hints.get(f.name, Any) for f in dataclasses.fields(cls) if not f.name.startswith("_")}
+
+
+def load_yaml_mapping(yaml_path: str) -> dict[str, Any]:
+    """
+    Loads a YAML file whose top-level element must be a mapping (an empty file yields an empty mapping).
+
+    :param yaml_path: the path of the YAML file
+    :return: the loaded mapping
+    :raises ConfigValidationError: if the file is not valid YAML or does not contain a mapping
+    """
+    with open(yaml_path, encoding=SERENA_FILE_ENCODING) as f:
+        try:
+            data = yaml.safe_load(f)
+        except yaml.YAMLError as e:
+            mark = getattr(e, "problem_mark", None)
+            location = f"line {mark.line + 1}, column {mark.column + 1}: " if mark is not None else ""
+            problem = getattr(e, "problem", None) or str(e)
+            raise ConfigValidationError(yaml_path, [f"{location}invalid YAML ({problem})"]) from e
+    if data is None:
+        return {}
+    if not isinstance(data, dict):
+        raise ConfigValidationError(yaml_path, [f"the top-level element must be a mapping of keys to values, got {type(data).__name__}"])
+    return data
+
+
+def validate_config_dict(
+    data: Mapping[str, Any],
+    field_types: Mapping[str, Any],
+    yaml_path: str,
+    legacy_keys: Collection[str] = (),
+) -> None:
+    """
+    Checks that the given configuration data contains only known keys and that the values are of the expected types.
+    `None` values are accepted for all keys, as missing values are handled by the respective configuration classes.
+
+    :param data: the configuration data loaded from the YAML file
+    :param field_types: a mapping from allowed keys to the expected types (see `get_config_field_types`)
+    :param yaml_path: the path of the YAML file (for error messages and for the determination of line numbers)
+    :param legacy_keys: keys which are no longer documented but still accepted (e.g. because they are migrated)
+    :raises ConfigValidationError: if there are unknown keys or values of the wrong type
+    """
+    problems: list[tuple[str, str]] = []
+    has_unknown_keys = False
+    for key, value in data.items():
+        if key in legacy_keys:
+            continue
+        if key not in field_types:
+            has_unknown_keys = True
+            message = f"unknown key '{key}'"
+            close_matches = difflib.get_close_matches(str(key), list(field_types), n=1)
+            if close_matches:
+                message += f" (did you mean '{close_matches[0]}'?)"
+            problems.append((key, message))
+        elif value is not None and not _is_value_of_type(value, field_types[key]):
+            problems.append((key, f"invalid value for '{key}': expected {_type_to_str(field_types[key])}, got {value!r}"))
+    if not problems:
+        return
+
+    if not isinstance(data, CommentedMap):
+        # determine line numbers by re-loading the file with ruamel (only required in the error case)
+        try:
+            data = load_yaml(yaml_path)
+        except Exception:
+            pass
+    problem_strings = []
+    for key, message in problems:
+        line = _get_key_line(data, key)
+        problem_strings.append(f"line {line}: {message}" if line is not None else message)
+    raise ConfigValidationError(yaml_path, problem_strings, allowed_keys=field_types.keys() if has_unknown_keys else None)
+
+
+def _get_key_line(data: Mapping[str, Any], key: str) -> int | None:
+    """
+    :return: the (1-based) line number of the given key if it can be determined
+    """
+    if isinstance(data, CommentedMap):
+        try:
+            return data.lc.key(key)[0] + 1
+        except (KeyError, TypeError):
+            return None
+    return None
+
+
+def _is_value_of_type(value: Any, expected_type: Any) -> bool:
+    """
+    Checks whether a value loaded from YAML is compatible with the given type annotation.
+    Types which are converted from primitive values when the configuration is instantiated (such as enums) as well
+    as types which are not supported are considered compatible with any value.
+    """
+    if expected_type is Any:
+        return True
+    if expected_type is type(None):
+        return value is None
+    origin = typing.get_origin(expected_type)
+    args = typing.get_args(expected_type)
+    if origin in (Union, types.UnionType):
+        return any(_is_value_of_type(value, arg) for arg in args)
+    if origin is Literal:
+        return value in args
+    if expected_type is bool:
+        return isinstance(value, bool)
+    if expected_type is int:
+        return isinstance(value, int) and not isinstance(value, bool)
+    if expected_type is float:
+        return isinstance(value, int | float) and not isinstance(value, bool)
+    if expected_type is str:
+        return isinstance(value, str)
+    if isinstance(expected_type, type) and issubclass(expected_type, Enum):
+        return isinstance(value, str)
+    if expected_type in (list, Sequence) or origin in (list, Sequence):
+        if not isinstance(value, list):
+            return False
+        return not args or all(_is_value_of_type(item, args[0]) for item in value)
+    if expected_type is dict or origin is dict:
+        if not isinstance(value, dict):
+            return False
+        return not args or all(_is_value_of_type(k, args[0]) and _is_value_of_type(v, args[1]) for k, v in value.items())
+    return True
+
+
+def _type_to_str(t: Any) -> str:
+    origin = typing.get_origin(t)
+    args = typing.get_args(t)
+    if origin in (Union, types.UnionType):
+        return " or ".join("null" if arg is type(None) else _type_to_str(arg) for arg in args)
+    if origin is Literal:
+        return " or ".join(repr(arg) for arg in args)
+    if t in (list, Sequence) or origin in (list, Sequence):
+        return f"a list of {_type_to_str(args[0])} values" if args else "a list"
+    if t is dict or origin is dict:
+        return "a mapping"
+    if isinstance(t, type) and issubclass(t, Enum):
+        return "one of " + ", ".join(repr(item.value) for item in t)
+    if isinstance(t, type):
+        return t.__name__
+    return str(t)
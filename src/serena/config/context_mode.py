@@ -7,15 +7,14 @@ from dataclasses import dataclass, field
 from pathlib import Path
 from typing import TYPE_CHECKING, Self
 
-import yaml
 from sensai.util import logging
 from sensai.util.string import ToStringMixin
 
+from serena.config.config_validation import get_config_field_types, load_yaml_mapping, validate_config_dict
 from serena.config.serena_config import SerenaPaths, ToolInclusionDefinition
 from serena.constants import (
     DEFAULT_CONTEXT,
     INTERNAL_MODE_YAMLS_DIR,
-    SERENA_FILE_ENCODING,
     SERENAS_OWN_CONTEXT_YAMLS_DIR,
     SERENAS_OWN_MODE_YAMLS_DIR,
 )
@@ -69,8 +68,8 @@ class SerenaAgentMode(ToolInclusionDefinition, ToStringMixin):
     def from_yaml(cls, yaml_path: str | Path) -> Self:
         """Load a mode from a YAML file."""
         yaml_as_path = Path(yaml_path).resolve()
-        with Path(yaml_as_path).open(encoding=SERENA_FILE_ENCODING) as f:
-            data = yaml.safe_load(f)
+        data = load_yaml_mapping(str(yaml_as_path))
+        validate_config_dict(data, get_config_field_types(cls), str(yaml_as_path))
         name = data.pop("name", yaml_as_path.stem)
         return cls(name=name, _yaml_path=yaml_as_path, **data)
 
@@ -189,6 +188,11 @@ class SerenaAgentContext(ToolInclusionDefinition, ToStringMixin):
     whether to use structured output for tools (None = auto)
     """
 
+    default_output_format: str = "json"
+    """
+    the default format ("json", "markdown" or "plain") of the results of read-oriented tools which support an `output_format` parameter
+    """
+
     def _tostring_includes(self) -> list[str]:
         return ["name"]
 
@@ -196,8 +200,8 @@ class SerenaAgentContext(ToolInclusionDefinition, ToStringMixin):
     def from_yaml(cls, yaml_path: str | Path) -> Self:
         """Load a context from a YAML file."""
         yaml_as_path = Path(yaml_path).resolve()
-        with yaml_as_path.open(encoding=SERENA_FILE_ENCODING) as f:
-            data = yaml.safe_load(f)
+        data = load_yaml_mapping(str(yaml_as_path))
+        validate_config_dict(data, get_config_field_types(cls), str(yaml_as_path))
         name = data.pop("name", yaml_as_path.stem)
         # Ensure backwards compatibility for tool_description_overrides
         if "tool_description_overrides" not in data:
@@ -2,6 +2,7 @@
 The Serena Model Context Protocol (MCP) Server
 """
 
+import codecs
 import dataclasses
 import os
 import re
@@ -23,6 +24,7 @@ from sensai.util.logging import LogTime, datetime_tag
 from sensai.util.string import ToStringMixin
 
 from serena.constants import (
+    DEFAULT_CONTEXT,
     DEFAULT_SOURCE_FILE_ENCODING,
     PROJECT_LOCAL_TEMPLATE_FILE,
     PROJECT_TEMPLATE_FILE,
@@ -32,6 +34,7 @@ from serena.constants import (
     SERENA_FILE_ENCODING,
     SERENA_MANAGED_DIR_NAME,
 )
+from serena.config.config_validation import get_config_field_types, validate_config_dict
 from serena.util.inspection import compute_language_server_support_composition
 from serena.util.text_utils import GlobMatcher
 from serena.util.yaml import YamlCommentNormalisation, load_yaml, normalise_yaml_comments, save_yaml, transfer_yaml_comments
@@ -49,6 +52,7 @@ if TYPE_CHECKING:
 log = logging.getLogger(__name__)
 T = TypeVar("T")
 DEFAULT_TOOL_TIMEOUT: float = 240
+DEFAULT_SHELL_ENV_DENIED_PATTERNS = ("AWS_*", "GOOGLE_*", "ARM_*")
 DictType = dict | CommentedMap
 TDict = TypeVar("TDict", bound=DictType)
 
@@ -331,6 +335,14 @@ class ProjectConfig(SharedConfig, ModeSelectionDefinitionWithAddedModes):
     encoding: str = DEFAULT_SOURCE_FILE_ENCODING
     activation_command: str | None = None
     activation_command_timeout: float = 180.0
+    tool_parameter_defaults: dict[str, dict[str, Any]] = field(default_factory=dict)
+    """
+    maps tool names to overrides of the default values of the tools' parameters (see `Tool.apply_ex`)
+    """
+    backup_retention: int = 20
+    """
+    the number of file backups (taken prior to destructive edits) to retain; 0 disables backups
+    """
 
     # internal fields which are not mapped to/from the configuration file (must start with "_")
     _local_override_keys: list[str] = field(default_factory=list)
@@ -550,6 +562,9 @@ class ProjectConfig(SharedConfig, ModeSelectionDefinitionWithAddedModes):
                 del data[old_key]
                 was_complete = False
 
+        # check for unknown keys and values of the wrong type (legacy keys are accepted)
+        validate_config_dict(data, get_config_field_types(cls), yml_path, legacy_keys={"language", "base_modes", *cls.RENAMED_FIELDS})
+
         # apply defaults
         if apply_defaults:
             for field_info in dataclasses.fields(cls):
@@ -603,6 +618,28 @@ class ProjectConfig(SharedConfig, ModeSelectionDefinitionWithAddedModes):
         if activation_command_timeout <= 0:
             raise ValueError(f"activation_command_timeout must be positive, got: {activation_command_timeout}")
 
+        # Validate backup_retention
+        backup_retention = data.get("backup_retention", 20)
+        if not isinstance(backup_retention, int) or isinstance(backup_retention, bool) or backup_retention < 0:
+            raise ValueError(f"backup_retention must be a non-negative integer, got: {backup_retention}")
+
+        # Validate tool_parameter_defaults
+        tool_parameter_defaults = data.get("tool_parameter_defaults") or {}
+        if not isinstance(tool_parameter_defaults, dict) or not all(
+            isinstance(tool_name, str) and isinstance(parameter_defaults, dict)
+            for tool_name, parameter_defaults in tool_parameter_defaults.items()
+        ):
+            raise ValueError(
+                f"tool_parameter_defaults must map tool names to dictionaries of parameter default values, got: {tool_parameter_defaults}"
+            )
+
+        # Validate encoding
+        encoding = data["encoding"] or DEFAULT_SOURCE_FILE_ENCODING
+        try:
+            codecs.lookup(encoding)
+        except (LookupError, TypeError) as e:
+            raise ValueError(f"encoding must be the name of a Python codec (e.g. 'utf-8' or 'latin-1'), got: {encoding}") from e
+
         # Validate symbol_info_budget
         symbol_info_budget_raw = data["symbol_info_budget"]
         symbol_info_budget = symbol_info_budget_raw
@@ -644,7 +681,7 @@ class ProjectConfig(SharedConfig, ModeSelectionDefinitionWithAddedModes):
             ignored_memory_patterns=data.get("ignored_memory_patterns", []),
             ignore_all_files_in_gitignore=data["ignore_all_files_in_gitignore"],
             initial_prompt=data["initial_prompt"],
-            encoding=data["encoding"],
+            encoding=encoding,
             line_ending=line_ending,
             language_backend=language_backend,
             added_modes=data["added_modes"],
@@ -653,6 +690,8 @@ class ProjectConfig(SharedConfig, ModeSelectionDefinitionWithAddedModes):
             ls_specific_settings=data.get("ls_specific_settings", {}),
             activation_command=data.get("activation_command"),
             activation_command_timeout=activation_command_timeout,
+            tool_parameter_defaults=tool_parameter_defaults,
+            backup_retention=backup_retention,
             _local_override_keys=local_override_keys,
         )
 
@@ -887,6 +926,10 @@ class SerenaConfig(SharedConfig, ModeSelectionDefinitionWithBaseModes):
     """
     JetBrains IDE launch command, which can be used to auto-start an IDE instance on demand.
     """
+    default_context: str = DEFAULT_CONTEXT
+    """
+    the name of (or path to) the context to use if no context is specified at startup
+    """
     tool_timeout: float = DEFAULT_TOOL_TIMEOUT
     """
     timeout for tool calls in seconds; if a tool takes longer than this, it is aborted and an error is returned.
@@ -934,6 +977,68 @@ class SerenaConfig(SharedConfig, ModeSelectionDefinitionWithBaseModes):
     mapping from language server keys to their priority (higher number = higher priority).
     """
 
+    tools_requiring_approval: list[str] = field(default_factory=list)
+    """
+    names of tools whose execution is deferred until the user has explicitly approved it
+    (via the `approve_pending_operation` tool).
+    """
+
+    shell_command_approval_patterns: list[str] = field(default_factory=list)
+    """
+    regular expressions; shell commands (executed via `execute_shell_command`) containing a match for any of these
+    expressions are deferred until the user has explicitly approved them.
+    """
+
+    confirm_destructive_operations: bool = False
+    """
+    whether calls of destructive tools (i.e. tools which can edit, such as `replace_symbol_body` or `delete_lines`) and
+    destructive shell commands (e.g. `terraform apply` or `terraform destroy`) require explicit user approval.
+    If the client supports MCP elicitation, the user is asked for confirmation directly.
+    """
+
+    shell_env_allowed_patterns: list[str] = field(default_factory=list)
+    """
+    glob patterns of environment variable names which are always passed on to shell commands (taking precedence over
+    `shell_env_denied_patterns`).
+    """
+
+    shell_env_denied_patterns: list[str] = field(default_factory=lambda: list(DEFAULT_SHELL_ENV_DENIED_PATTERNS))
+    """
+    glob patterns of environment variable names which are not passed on to shell commands (unless explicitly allowed),
+    e.g. in order to prevent cloud credentials from being exposed to commands executed by the LLM.
+    """
+
+    shell_command_allowed_cwd_paths: list[str] = field(default_factory=list)
+    """
+    absolute paths of directories outside of the project root which may be used as working directories of shell commands
+    (including their subdirectories); by default, shell commands can only be executed within the project root.
+    """
+
+    record_last_session: bool = True
+    """
+    whether to maintain the `last_session` memory (recording the tasks worked on, the files edited and pending follow-ups)
+    for the active project, such that subsequent sessions can resume smoothly.
+    """
+
+    background_indexing: bool = True
+    """
+    whether to index the symbols of all source files of a project in the background after its activation, such that
+    symbolic queries are answered from the symbol cache (rather than requiring cold language server requests) early on.
+    Indexing is performed in small batches which give precedence to tool calls.
+    """
+
+    http_compression: bool = True
+    """
+    whether to compress the responses of the MCP server when using an HTTP-based transport (sse or streamable-http),
+    provided that the client accepts a supported encoding (zstd, if the `zstandard` package is installed, or gzip).
+    Server-sent event streams are never compressed.
+    """
+
+    http_compression_min_size: int = 1024
+    """
+    the minimum size (in bytes) of a (non-streamed) response for it to be compressed
+    """
+
     # settings with overridden defaults
 
     language_backend: LanguageBackend = LanguageBackend.LSP
@@ -963,6 +1068,10 @@ class SerenaConfig(SharedConfig, ModeSelectionDefinitionWithBaseModes):
 
     CONFIG_FILE = "serena_config.yml"
     CONFIG_FIELDS_WITH_TYPE_CONVERSION = {"projects", "language_backend", "line_ending"}
+    LEGACY_CONFIG_KEYS = {"jetbrains", "gui_log_level", "edit_global_memories", "record_tool_usage_stats"}
+    """
+    keys which are no longer supported but are accepted in configuration files (being either migrated or ignored)
+    """
 
     # *** methods ***
     @classmethod
@@ -1043,6 +1152,7 @@ class SerenaConfig(SharedConfig, ModeSelectionDefinitionWithBaseModes):
             loaded_commented_yaml = load_yaml(config_file_path)
         except Exception as e:
             raise ValueError(f"Error loading Serena configuration from {config_file_path}: {e}") from e
+        validate_config_dict(loaded_commented_yaml, get_config_field_types(cls), config_file_path, legacy_keys=cls.LEGACY_CONFIG_KEYS)
 
         # create the configuration instance
         instance = cls(_loaded_commented_yaml=loaded_commented_yaml, _config_file_path=config_file_path)
@@ -1133,6 +1243,12 @@ class SerenaConfig(SharedConfig, ModeSelectionDefinitionWithBaseModes):
                 instance.read_only_memory_patterns.append("global/.*")
             del loaded_commented_yaml["edit_global_memories"]
 
+        # compile the regular expressions of the approval policy, such that invalid expressions are reported right away
+        try:
+            _ = instance.shell_command_approval_regexes
+        except re.error as e:
+            raise SerenaConfigError(f"Invalid regular expression in shell_command_approval_patterns: {e}") from e
+
         # re-save the configuration file if any migrations were performed
         if num_migrations > 0:
             log.info("Legacy configuration was migrated; re-saving configuration file")
@@ -1189,8 +1305,18 @@ class SerenaConfig(SharedConfig, ModeSelectionDefinitionWithBaseModes):
         self.gui_log_window = False
         self.web_dashboard = False
         self.jetbrains_launch_command = None
+        self.record_last_session = False
+        self.background_indexing = False
         return self
 
+    @cached_property
+    def shell_command_approval_regexes(self) -> list[re.Pattern[str]]:
+        """
+        :return: the compiled regular expressions of `shell_command_approval_patterns`, which are compiled only once
+            (and validated when the configuration is loaded)
+        """
+        return [re.compile(p) for p in self.shell_command_approval_patterns]
+
     @cached_property
     def project_paths(self) -> list[str]:
         return sorted(str(project.project_root) for project in self.projects)
@@ -1310,6 +1436,28 @@ class SerenaConfig(SharedConfig, ModeSelectionDefinitionWithBaseModes):
         persisted.projects = list(self.projects)
         persisted._save()
 
+    def set_ls_specific_setting(self, ls_id: str, key: str, value: Any) -> None:
+        """
+        Sets a language server-specific setting (see `ls_specific_settings`) and persists it, leaving every other
+        setting at its on-disk value (see `_persist_projects` for the rationale).
+
+        :param ls_id: the language server identifier (e.g. "terraform")
+        :param key: the name of the setting
+        :param value: the value to set
+        """
+        self.ls_specific_settings = self._with_ls_specific_setting(self.ls_specific_settings, ls_id, key, value)
+        if self.config_file_path is None:
+            return
+        persisted = SerenaConfig.from_config_file()
+        persisted.ls_specific_settings = self._with_ls_specific_setting(persisted.ls_specific_settings, ls_id, key, value)
+        persisted._save()
+
+    @staticmethod
+    def _with_ls_specific_setting(ls_specific_settings: dict, ls_id: str, key: str, value: Any) -> dict:
+        result = dict(ls_specific_settings)
+        result[ls_id] = {**(result.get(ls_id) or {}), key: value}
+        return result
+
     def _save(self) -> None:
         """
         Saves the full configuration to the file from which it was loaded (if any)
@@ -0,0 +1,290 @@
+"""
+Diagnostics of the environment in which Serena is run (configuration files, project configuration, external commands,
+symbol caches and language servers), as performed by the `serena doctor` command
+"""
+
+import logging
+import os
+import shutil
+import subprocess
+from collections.abc import Iterator
+from dataclasses import dataclass
+from enum import Enum
+from pathlib import Path
+
+from sensai.util.pickle import load_pickle
+
+from serena.config.serena_config import ProjectConfigAutoGenerationMode, SerenaConfig, SerenaPaths
+from serena.project import Project
+from solidlsp.ls import SolidLanguageServer
+from solidlsp.ls_config import LanguageServerId
+from solidlsp.util.subprocess_util import subprocess_kwargs
+
+log = logging.getLogger(__name__)
+
+
+class CheckStatus(Enum):
+    OK = "ok"
+    WARNING = "warning"
+    ERROR = "error"
+
+    def get_symbol(self) -> str:
+        match self:
+            case CheckStatus.OK:
+                return "✅"
+            case CheckStatus.WARNING:
+                return "⚠️ "
+            case CheckStatus.ERROR:
+                return "❌"
+
+
+@dataclass(kw_only=True)
+class CheckResult:
+    name: str
+    status: CheckStatus
+    message: str
+    hint: str | None = None
+    """
+    an actionable suggestion for resolving the problem (if any)
+    """
+
+    def to_string(self) -> str:
+        result = f"{self.status.get_symbol()} {self.name}: {self.message}"
+        if self.hint is not None:
+            result += f"\n   → {self.hint}"
+        return result
+
+
+@dataclass(kw_only=True)
+class ExternalCommand:
+    """
+    An external command which is required (or recommended) by a language server
+    """
+
+    name: str
+    version_args: list[str]
+    hint: str
+
+
+EXTERNAL_COMMANDS: dict[LanguageServerId, list[ExternalCommand]] = {
+    LanguageServerId.TERRAFORM: [
+        ExternalCommand(
+            name="terraform",
+            version_args=["version"],
+            hint="Install the Terraform CLI and add it to the PATH; terraform-ls requires it e.g. for validation and module installation.",
+        )
+    ],
+}
+"""
+maps language server ids to the external commands which the respective language server requires
+"""
+
+
+class Doctor:
+    """
+    Checks the environment in which Serena is run, reporting problems along with hints for resolving them.
+    """
+
+    EXTERNAL_COMMAND_TIMEOUT = 30
+
+    def __init__(self, project_root: str | None, start_language_servers: bool = True):
+        """
+        :param project_root: the root directory of the project to check; if None, only project-independent checks are performed
+        :param start_language_servers: whether to check that the project's language servers can be started
+        """
+        self._project_root = project_root
+        self._start_language_servers = start_language_servers
+
+    def run_checks(self) -> Iterator[CheckResult]:
+        """
+        Performs the checks, where checks which depend on a failed check are skipped.
+
+        :return: the results of the checks (generated as the checks are performed)
+        """
+        yield self._check_serena_home()
+        config_result, serena_config = self._check_serena_config()
+        yield config_result
+        if serena_config is None or self._project_root is None:
+            return
+
+        project_result, project = self._check_project(serena_config, self._project_root)
+        yield project_result
+        if project is None:
+            return
+        yield from self._check_external_commands(project.project_config.language_servers)
+        yield from self._check_caches(project)
+        if self._start_language_servers:
+            yield from self._check_language_servers(project)
+
+    @staticmethod
+    def _check_serena_home() -> CheckResult:
+        name = "Serena home directory"
+        home_dir = SerenaPaths().serena_user_home_dir
+        if not os.path.exists(home_dir):
+            return CheckResult(name=name, status=CheckStatus.WARNING, message=f"{home_dir} does not exist (it is created on first use)")
+        if not os.access(home_dir, os.W_OK):
+            return CheckResult(
+                name=name,
+                status=CheckStatus.ERROR,
+                message=f"{home_dir} is not writable",
+                hint="Fix the directory's permissions or use another directory by setting the SERENA_HOME environment variable.",
+            )
+        return CheckResult(name=name, status=CheckStatus.OK, message=home_dir)
+
+    @staticmethod
+    def _check_serena_config() -> tuple[CheckResult, SerenaConfig | None]:
+        name = "Serena configuration"
+        config_path = os.path.join(SerenaPaths().serena_user_home_dir, SerenaConfig.CONFIG_FILE)
+        if not os.path.exists(config_path):
+            return (
+                CheckResult(
+                    name=name,
+                    status=CheckStatus.WARNING,
+                    message=f"{config_path} does not exist; using the default configuration",
+                    hint="The file is generated from the template when Serena is first started (or via `serena config edit`).",
+                ),
+                SerenaConfig().with_headless_mode_overrides(),
+            )
+        try:
+            serena_config = SerenaConfig.from_config_file(generate_if_missing=False).with_headless_mode_overrides()
+        except Exception as e:
+            return (
+                CheckResult(
+                    name=name, status=CheckStatus.ERROR, message=f"{e}", hint="Fix the configuration file, e.g. via `serena config edit`."
+                ),
+                None,
+            )
+        message = f"{config_path} ({len(serena_config.projects)} registered projects)"
+        return CheckResult(name=name, status=CheckStatus.OK, message=message), serena_config
+
+    @staticmethod
+    def _check_project(serena_config: SerenaConfig, project_root: str) -> tuple[CheckResult, Project | None]:
+        name = "Project configuration"
+        project_yml_path = serena_config.get_project_yml_location(project_root)
+        if not os.path.exists(project_yml_path):
+            return (
+                CheckResult(
+                    name=name,
+                    status=CheckStatus.ERROR,
+                    message=f"{project_yml_path} does not exist",
+                    hint=f"Create the project configuration via `serena project create {project_root}`.",
+                ),
+                None,
+            )
+        try:
+            project = Project.load(project_root, serena_config=serena_config, autogen=ProjectConfigAutoGenerationMode.NONE)
+        except Exception as e:
+            return (
+                CheckResult(
+                    name=name,
+                    status=CheckStatus.ERROR,
+                    message=f"{e}",
+                    hint=f"Fix the project configuration file {project_yml_path}.",
+                ),
+                None,
+            )
+        language_servers = project.project_config.language_servers
+        if not language_servers:
+            return (
+                CheckResult(
+                    name=name,
+                    status=CheckStatus.WARNING,
+                    message=f"project '{project.project_name}' has no language servers configured",
+                    hint=f"Add the project's languages to `language_servers` in {project_yml_path}.",
+                ),
+                project,
+            )
+        languages_str = ", ".join(ls.value for ls in language_servers)
+        return CheckResult(name=name, status=CheckStatus.OK, message=f"project '{project.project_name}' ({languages_str})"), project
+
+    def _check_external_commands(self, language_servers: list[LanguageServerId]) -> Iterator[CheckResult]:
+        for ls_id in language_servers:
+            for command in EXTERNAL_COMMANDS.get(ls_id, []):
+                name = f"Command '{command.name}' (required by {ls_id.value})"
+                executable = shutil.which(command.name)
+                if executable is None:
+                    yield CheckResult(name=name, status=CheckStatus.ERROR, message="not found on the PATH", hint=command.hint)
+                    continue
+                try:
+                    completed_process = subprocess.run(
+                        [executable, *command.version_args],
+                        capture_output=True,
+                        text=True,
+                        timeout=self.EXTERNAL_COMMAND_TIMEOUT,
+                        **subprocess_kwargs(),
+                    )
+                except Exception as e:
+                    yield CheckResult(name=name, status=CheckStatus.ERROR, message=f"{executable} could not be run: {e}", hint=command.hint)
+                    continue
+                if completed_process.returncode != 0:
+                    yield CheckResult(
+                        name=name,
+                        status=CheckStatus.ERROR,
+                        message=f"{executable} failed with exit code {completed_process.returncode}: {completed_process.stderr.strip()}",
+                        hint=command.hint,
+                    )
+                    continue
+                version_lines = completed_process.stdout.strip().splitlines()
+                version_str = version_lines[0] if version_lines else "unknown version"
+                yield CheckResult(name=name, status=CheckStatus.OK, message=f"{executable} ({version_str})")
+
+    @staticmethod
+    def _check_caches(project: Project) -> Iterator[CheckResult]:
+        cache_dir = Path(project.path_to_serena_data_folder()) / SolidLanguageServer.CACHE_FOLDER_NAME
+        if not cache_dir.exists():
+            yield CheckResult(
+                name="Symbol cache",
+                status=CheckStatus.WARNING,
+                message="no symbol cache exists yet; the first symbolic operations may be slow for large projects",
+                hint=f"Index the project via `serena project index {project.project_root}`.",
+            )
+            return
+        if not os.access(cache_dir, os.W_OK):
+            yield CheckResult(
+                name="Symbol cache",
+                status=CheckStatus.ERROR,
+                message=f"{cache_dir} is not writable",
+                hint="Fix the directory's permissions, as symbol caches cannot be updated otherwise.",
+            )
+            return
+        for cache_file in sorted(cache_dir.glob("*/*.pkl")):
+            name = f"Symbol cache {cache_file.parent.name}/{cache_file.name}"
+            try:
+                load_pickle(str(cache_file))
+            except Exception as e:
+                yield CheckResult(
+                    name=name,
+                    status=CheckStatus.ERROR,
+                    message=f"the cache file cannot be loaded: {e}",
+                    hint=f"Delete {cache_file} and re-index the project via `serena project index {project.project_root}`.",
+                )
+                continue
+            size_mb = cache_file.stat().st_size / (1024 * 1024)
+            yield CheckResult(name=name, status=CheckStatus.OK, message=f"{size_mb:.1f} MB")
+
+    @staticmethod
+    def _check_language_servers(project: Project) -> Iterator[CheckResult]:
+        name = "Language server startup"
+        try:
+            ls_manager = project.create_language_server_manager()
+        except Exception as e:
+            yield CheckResult(
+                name=name,
+                status=CheckStatus.ERROR,
+                message=f"{e}",
+                hint=f"Check the language server installation; for details, run `serena project health-check {project.project_root}`.",
+            )
+            return
+        try:
+            for ls_id, is_running in ls_manager.get_language_server_running_states().items():
+                if is_running:
+                    yield CheckResult(name=f"{name} ({ls_id.value})", status=CheckStatus.OK, message="started successfully")
+                else:
+                    yield CheckResult(
+                        name=f"{name} ({ls_id.value})",
+                        status=CheckStatus.ERROR,
+                        message="the language server terminated after startup",
+                        hint=f"For details, run `serena project health-check {project.project_root}` and inspect the log.",
+                    )
+        finally:
+            ls_manager.stop_all()
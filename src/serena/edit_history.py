@@ -0,0 +1,209 @@
+"""
+Persistent log of the file mutations performed by Serena's editing tools, enabling edits to be undone without
+resorting to version control
+"""
+
+import json
+import logging
+import os
+import threading
+import time
+import uuid
+from collections.abc import Iterator
+from contextlib import contextmanager
+from dataclasses import asdict, dataclass, field
+
+from solidlsp.ls_utils import FileUtils
+from solidlsp.lsp_protocol_handler.lsp_types import FileChangeType
+
+log = logging.getLogger(__name__)
+
+
+@dataclass
+class FileMutation:
+    relative_path: str
+    before: str | None
+    """the contents of the file before the mutation; None if the file did not exist"""
+    after: str | None
+    """the contents of the file after the mutation; None if the file was deleted"""
+
+
+@dataclass
+class EditTransaction:
+    """
+    The file mutations performed by a single tool call
+    """
+
+    transaction_id: str
+    """identifier of the transaction, which increases with the time at which the transaction was started"""
+    tool_name: str
+    session_id: str
+    """identifier of the session (i.e. of the :class:`EditHistory` instance) in which the transaction was recorded"""
+    timestamp: float
+    mutations: list[FileMutation] = field(default_factory=list)
+
+    @classmethod
+    def from_dict(cls, data: dict) -> "EditTransaction":
+        mutations = [FileMutation(**mutation) for mutation in data.pop("mutations")]
+        return cls(**data, mutations=mutations)
+
+    def get_relative_paths(self) -> list[str]:
+        return [mutation.relative_path for mutation in self.mutations]
+
+    def to_string(self) -> str:
+        time_str = time.strftime("%Y-%m-%d %H:%M:%S", time.localtime(self.timestamp))
+        return f"edit by tool '{self.tool_name}' at {time_str} affecting {', '.join(self.get_relative_paths())}"
+
+
+class EditHistory:
+    """
+    Records the file mutations performed by editing tools in the project's Serena data folder (one file per transaction),
+    such that the most recent edits can be undone.
+    Mutations are grouped into transactions (one per tool call, see :meth:`transaction`); only the most recent
+    transactions (up to :attr:`MAX_NUM_TRANSACTIONS`) are retained.
+    """
+
+    MAX_NUM_TRANSACTIONS = 100
+
+    def __init__(self, history_dir: str, project_root: str, encoding: str, newline: str | None = None) -> None:
+        """
+        :param history_dir: the directory in which to store the transactions
+        :param project_root: the root directory of the project, relative to which the paths of mutated files are given
+        :param encoding: the encoding with which to write restored files
+        :param newline: the line ending with which to write restored files (see :meth:`FileUtils.write_file`)
+        """
+        self._history_dir = history_dir
+        self._project_root = project_root
+        self._encoding = encoding
+        self._newline = newline
+        self.session_id = uuid.uuid4().hex
+        self._lock = threading.Lock()
+        self._thread_local = threading.local()
+        """holds the transaction currently active in each thread (as attribute `transaction`)"""
+
+    @contextmanager
+    def transaction(self, tool_name: str) -> Iterator[EditTransaction]:
+        """
+        Context manager which groups all mutations recorded within it into a single transaction, which is saved
+        when the context is exited (even if an exception occurred, because the mutations performed up to that point
+        shall remain undoable). Transactions without mutations are not saved. Nested contexts are merged into the outermost.
+
+        :param tool_name: the name of the tool performing the mutations
+        :return: the transaction (the outermost one in the case of nested contexts)
+        """
+        current_transaction = self._get_current_transaction()
+        if current_transaction is not None:
+            yield current_transaction
+            return
+        transaction = EditTransaction(
+            transaction_id=f"{time.time_ns():020d}", tool_name=tool_name, session_id=self.session_id, timestamp=time.time()
+        )
+        self._thread_local.transaction = transaction
+        try:
+            yield transaction
+        finally:
+            self._thread_local.transaction = None
+            if transaction.mutations:
+                self._save_transaction(transaction)
+
+    def _get_current_transaction(self) -> EditTransaction | None:
+        return getattr(self._thread_local, "transaction", None)
+
+    def record_mutation(self, relative_path: str, before: str | None, after: str | None) -> None:
+        """
+        Records a mutation of a file as part of the current transaction (or as a transaction of its own
+        if no transaction is active). Several mutations of the same file within a transaction are combined.
+
+        :param relative_path: the relative path of the file
+        :param before: the contents of the file before the mutation; None if the file did not exist
+        :param after: the contents of the file after the mutation; None if the file was deleted
+        """
+        if before == after:
+            return
+        with self.transaction("<unknown>"):
+            transaction = self._get_current_transaction()
+            assert transaction is not None
+            relative_path = os.path.normpath(relative_path)
+            mutations = transaction.mutations
+            for i, mutation in enumerate(mutations):
+                if mutation.relative_path == relative_path:
+                    mutations[i] = FileMutation(relative_path, mutation.before, after)
+                    if mutation.before == after:
+                        del mutations[i]  # the mutations cancel each other out
+                    return
+            mutations.append(FileMutation(relative_path, before, after))
+
+    def _get_transaction_path(self, transaction_id: str) -> str:
+        return os.path.join(self._history_dir, f"{transaction_id}.json")
+
+    def _save_transaction(self, transaction: EditTransaction) -> None:
+        with self._lock:
+            try:
+                os.makedirs(self._history_dir, exist_ok=True)
+                with open(self._get_transaction_path(transaction.transaction_id), "w", encoding="utf-8") as f:
+                    json.dump(asdict(transaction), f, ensure_ascii=False)
+                for transaction_id in self._get_transaction_ids()[: -self.MAX_NUM_TRANSACTIONS]:
+                    os.remove(self._get_transaction_path(transaction_id))
+            except OSError as e:
+                log.error(f"Failed to save edit transaction {transaction.transaction_id}: {e}")
+
+    def _get_transaction_ids(self) -> list[str]:
+        if not os.path.isdir(self._history_dir):
+            return []
+        return sorted(filename.removesuffix(".json") for filename in os.listdir(self._history_dir) if filename.endswith(".json"))
+
+    def get_transactions(self, current_session_only: bool = False) -> list[EditTransaction]:
+        """
+        :param current_session_only: whether to return only the transactions recorded in the current session
+        :return: the recorded (and not yet undone) transactions in chronological order
+        """
+        transactions = []
+        for transaction_id in self._get_transaction_ids():
+            with open(self._get_transaction_path(transaction_id), encoding="utf-8") as f:
+                transaction = EditTransaction.from_dict(json.load(f))
+            if not current_session_only or transaction.session_id == self.session_id:
+                transactions.append(transaction)
+        return transactions
+
+    def _read_current_contents(self, relative_path: str) -> str | None:
+        abs_path = os.path.join(self._project_root, relative_path)
+        if not os.path.isfile(abs_path):
+            return None
+        return FileUtils.read_file(abs_path, self._encoding)
+
+    @staticmethod
+    def _normalize_line_endings(contents: str | None) -> str | None:
+        return None if contents is None else contents.replace("\r\n", "\n")
+
+    def undo(self, transaction: EditTransaction) -> list[tuple[str, FileChangeType]]:
+        """
+        Restores the state of the files prior to the given transaction and removes the transaction from the history.
+        The transaction is undone only if none of the affected files were changed since the transaction;
+        otherwise, no file is changed.
+
+        :param transaction: the transaction to undo
+        :return: pairs of relative paths of the restored files and the types of the changes made to them
+        """
+        with self._lock:
+            conflicting_paths = [
+                mutation.relative_path
+                for mutation in transaction.mutations
+                if self._read_current_contents(mutation.relative_path) != self._normalize_line_endings(mutation.after)
+            ]
+            if conflicting_paths:
+                raise ValueError(
+                    f"Cannot undo {transaction.to_string()}: the following files were changed since then: {', '.join(conflicting_paths)}"
+                )
+            changes: list[tuple[str, FileChangeType]] = []
+            for mutation in reversed(transaction.mutations):
+                abs_path = os.path.join(self._project_root, mutation.relative_path)
+                if mutation.before is None:
+                    os.remove(abs_path)
+                    changes.append((mutation.relative_path, FileChangeType.Deleted))
+                else:
+                    os.makedirs(os.path.dirname(abs_path), exist_ok=True)
+                    FileUtils.write_file(abs_path, mutation.before, self._encoding, newline=self._newline)
+                    change_type = FileChangeType.Created if mutation.after is None else FileChangeType.Changed
+                    changes.append((mutation.relative_path, change_type))
+            os.remove(self._get_transaction_path(transaction.transaction_id))
+            return changes
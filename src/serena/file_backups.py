@@ -0,0 +1,61 @@
+"""
+Backups of files which are taken prior to destructive edits
+"""
+
+import logging
+import os
+import shutil
+from datetime import datetime
+
+log = logging.getLogger(__name__)
+
+
+class FileBackups:
+    """
+    Stores snapshots of files prior to destructive edits (e.g. the replacement of a symbol's body) in the project's
+    Serena data folder, where each snapshot is stored in a folder named after the time at which it was taken
+    (e.g. `backups/20250101-120000-000000/modules/vpc/main.tf`). Only the most recent snapshots are retained.
+    """
+
+    def __init__(self, backups_dir: str, project_root: str, retention: int) -> None:
+        """
+        :param backups_dir: the directory in which to store the snapshots
+        :param project_root: the root directory of the project, relative to which the paths of files are given
+        :param retention: the number of snapshots to retain; 0 disables backups
+        """
+        self._backups_dir = backups_dir
+        self._project_root = project_root
+        self._retention = retention
+
+    def backup(self, relative_path: str) -> str | None:
+        """
+        Takes a snapshot of the given file (if it exists and backups are enabled) and removes the snapshots
+        exceeding the retention limit.
+
+        :param relative_path: the relative path of the file, which must be within the project root
+        :return: the path of the snapshot or None if no snapshot was taken
+        """
+        relative_path = os.path.normpath(relative_path)
+        if os.path.isabs(relative_path) or relative_path.split(os.sep)[0] == os.pardir:
+            raise ValueError(f"Cannot back up {relative_path}: the path is outside of the project root")
+        if self._retention <= 0:
+            return None
+        abs_path = os.path.join(self._project_root, relative_path)
+        if not os.path.isfile(abs_path):
+            return None
+        snapshot_dir = os.path.join(self._backups_dir, datetime.now().strftime("%Y%m%d-%H%M%S-%f"))
+        backup_path = os.path.join(snapshot_dir, relative_path)
+        os.makedirs(os.path.dirname(backup_path), exist_ok=True)
+        shutil.copy2(abs_path, backup_path)
+        log.debug(f"Backed up {relative_path} to {backup_path}")
+        for expired_snapshot_dir in self.get_snapshot_dirs()[: -self._retention]:
+            shutil.rmtree(expired_snapshot_dir, ignore_errors=True)
+        return backup_path
+
+    def get_snapshot_dirs(self) -> list[str]:
+        """
+        :return: the directories of the retained snapshots in chronological order
+        """
+        if not os.path.isdir(self._backups_dir):
+            return []
+        return [os.path.join(self._backups_dir, name) for name in sorted(os.listdir(self._backups_dir))]
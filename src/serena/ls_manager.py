@@ -1,6 +1,7 @@
 import logging
 import os.path
 import threading
+import time
 from collections.abc import Iterator
 from pathlib import Path
 from typing import TYPE_CHECKING
@@ -12,12 +13,17 @@ from solidlsp import SolidLanguageServer
 from solidlsp.ls_config import LanguageServerConfig, LanguageServerId
 from solidlsp.lsp_protocol_handler.lsp_types import DidChangeWatchedFilesParams, FileChangeType, FileEvent
 from solidlsp.settings import SolidLSPSettings
+from solidlsp.util.cache import SymbolCacheStats
 
 if TYPE_CHECKING:
     from .project import Project
 
 log = logging.getLogger(__name__)
 
+_RESTART_MAX_ATTEMPTS = 4
+_RESTART_INITIAL_BACKOFF = 1.0
+"""the delay (in seconds) before the second attempt to restart a language server, which is doubled for each further attempt"""
+
 
 class LanguageServerManagerInitialisationError(Exception):
     def __init__(self, message: str):
@@ -81,7 +87,8 @@ class LanguageServerManager:
     ) -> None:
         """
         :param language_servers: a mapping from language to language server; the servers are assumed to be already started.
-            The first server in the iteration order is used as the default server.
+            The first server in the iteration order which provides symbols is used as the default server.
+            Servers which do not provide symbols (e.g. linters) are used only for diagnostics.
             All servers are assumed to serve the same project root.
         :param language_server_factory: factory for language server creation; if None, dynamic (re)creation of language servers
             is not supported
@@ -94,6 +101,9 @@ class LanguageServerManager:
     def _default_language_server(self) -> SolidLanguageServer:
         if len(self._language_servers) == 0:
             raise ValueError("No language servers available in the manager")
+        for ls in self._language_servers.values():
+            if ls.provides_symbols():
+                return ls
         return next(iter(self._language_servers.values()))
 
     @staticmethod
@@ -162,10 +172,22 @@ class LanguageServerManager:
             ls = self.restart_language_server(ls.ls_id)
         return ls
 
+    def _get_suitable_language_servers(self, relative_path: str) -> list[SolidLanguageServer]:
+        """
+        :param relative_path: relative path to a file
+        :return: the language servers supporting the file, where servers providing symbols come first
+        """
+        candidates = [
+            candidate
+            for candidate in self._language_servers.values()
+            if not candidate.is_ignored_path(relative_path, ignore_unsupported_files=True)
+        ]
+        return sorted(candidates, key=lambda ls: 0 if ls.provides_symbols() else 1)
+
     def _get_suitable_language_server(self, relative_path: str) -> SolidLanguageServer | None:
         """:param relative_path: relative path to a file"""
-        for candidate in self._language_servers.values():
-            if not candidate.is_ignored_path(relative_path, ignore_unsupported_files=True):
+        for candidate in self._get_suitable_language_servers(relative_path):
+            if candidate.provides_symbols():
                 return candidate
         return None
 
@@ -180,6 +202,18 @@ class LanguageServerManager:
             ls = self._default_language_server
         return self._ensure_functional_ls(ls)
 
+    def get_language_servers(self, relative_path: str) -> list[SolidLanguageServer]:
+        """
+        Gets all language servers supporting the given file, e.g. in order to collect the diagnostics of a language server
+        providing symbols and of complementing linters.
+
+        :param relative_path: relative path to a file
+        :return: the language servers, where the server returned by `get_language_server` comes first
+        """
+        primary_ls = self.get_language_server(relative_path)
+        further_language_servers = self._get_suitable_language_servers(relative_path) if len(self._language_servers) > 1 else []
+        return [primary_ls, *(self._ensure_functional_ls(ls) for ls in further_language_servers if ls.ls_id != primary_ls.ls_id)]
+
     def _create_and_start_language_server(self, ls_id: LanguageServerId) -> SolidLanguageServer:
         if self._language_server_factory is None:
             raise ValueError(f"No language server factory available to create language server for {ls_id}")
@@ -190,15 +224,40 @@ class LanguageServerManager:
 
     def restart_language_server(self, language: LanguageServerId) -> SolidLanguageServer:
         """
-        Forces recreation and restart of the language server for the given language.
-        It is assumed that the language server for the given language is no longer running.
+        Forces recreation and restart of the language server for the given language, stopping the current
+        language server if it is still running (e.g. because it hangs).
+        If starting the new language server fails, further attempts are made with exponential backoff.
+        Files that were open in the previous language server are re-opened in the new one.
 
         :param language: the language
         :return: the newly created language server
         """
         if language not in self._language_servers:
             raise ValueError(f"No language server for language {language.value} present; cannot restart")
-        return self._create_and_start_language_server(language)
+        if self._language_server_factory is None:
+            raise ValueError(f"No language server factory available to restart language server for {language}")
+        previous_ls = self._language_servers[language]
+        try:
+            self._stop_language_server(previous_ls)
+        except Exception as e:
+            log.warning(f"Failed to stop language server for language {language.value} prior to restart: {e}")
+
+        backoff = _RESTART_INITIAL_BACKOFF
+        for attempt in range(1, _RESTART_MAX_ATTEMPTS + 1):
+            try:
+                language_server = self._create_and_start_language_server(language)
+                break
+            except Exception as e:
+                if attempt == _RESTART_MAX_ATTEMPTS:
+                    raise
+                log.warning(
+                    f"Failed to restart language server for language {language.value} (attempt {attempt}/{_RESTART_MAX_ATTEMPTS}): {e}; "
+                    f"retrying in {backoff} seconds"
+                )
+                time.sleep(backoff)
+                backoff *= 2
+        language_server.adopt_open_file_buffers(previous_ls)
+        return language_server
 
     def add_language_server(self, ls_id: LanguageServerId) -> SolidLanguageServer:
         """
@@ -238,8 +297,29 @@ class LanguageServerManager:
             log.info(f"Stopping language server for language {ls.ls_id} ...")
             ls.stop(shutdown_timeout=timeout)
 
-    def iter_language_servers(self) -> Iterator[SolidLanguageServer]:
+    def get_language_server_running_states(self) -> dict[LanguageServerId, bool]:
+        """
+        Determines the running states of the managed language servers (without restarting any language servers).
+
+        :return: a mapping from language server ID to whether the respective language server is running
+        """
+        return {ls_id: ls.is_running() for ls_id, ls in self._language_servers.items()}
+
+    def get_version_info(self) -> dict[LanguageServerId, dict[str, str]]:
+        """
+        :return: a mapping from language server ID to the versions of the respective language server and the tools it relies on
+            (see `SolidLanguageServer.get_version_info`)
+        """
+        return {ls_id: ls.get_version_info() for ls_id, ls in self._language_servers.items()}
+
+    def iter_language_servers(self, symbolic_only: bool = False) -> Iterator[SolidLanguageServer]:
+        """
+        :param symbolic_only: whether to restrict the iteration to language servers which provide symbols
+        :return: an iterator over the managed language servers
+        """
         for ls in self._language_servers.values():
+            if symbolic_only and not ls.provides_symbols():
+                continue
             yield self._ensure_functional_ls(ls)
 
     def stop_all(self, save_cache: bool = False, timeout: float = 2.0) -> None:
@@ -260,9 +340,30 @@ class LanguageServerManager:
             if ls.is_running():
                 ls.save_cache()
 
+    def get_symbol_cache_stats(self) -> dict[LanguageServerId, SymbolCacheStats]:
+        """
+        :return: a mapping from language server ID to the statistics of the respective language server's symbol caches
+        """
+        return {ls_id: ls.get_symbol_cache_stats() for ls_id, ls in self._language_servers.items()}
+
+    def clear_symbol_caches(self) -> None:
+        """
+        Clears the symbol caches of all managed language servers (in memory and on disk).
+        """
+        for ls in self._language_servers.values():
+            ls.clear_symbol_cache()
+
     def has_suitable_ls_for_file(self, relative_file_path: str) -> bool:
         return self._get_suitable_language_server(relative_file_path) is not None
 
+    def notify_file_changes(self, events: list[tuple[str, FileChangeType]]) -> None:
+        """
+        Notifies the language servers of the given changes to files, which were made by Serena itself.
+
+        :param events: pairs of relative file paths and the types of the changes
+        """
+        self._file_change_notifier.notify_file_changes(events)
+
     def sync_file_system_changes(self) -> int:
         """
         Polls the file system for changes to source files and notifies the language servers of any changes
@@ -285,7 +386,10 @@ class LanguageServerFileChangeNotifier:
     def __init__(self, project: "Project", language_server_manager: LanguageServerManager, initial_poll: bool = True) -> None:
         self._project = project
         self._language_server_manager = language_server_manager
-        self._freshness_last_seen_mtimes: dict[str, float] | None = None
+        self._freshness_last_seen_stats: dict[str, tuple[int, int]] | None = None
+        """
+        maps the relative paths of the source files seen in the last poll to their modification time (in ns) and size
+        """
         self._freshness_lock = threading.Lock()
 
         if initial_poll:
@@ -306,47 +410,93 @@ class LanguageServerFileChangeNotifier:
 
         The set of files considered is exactly the set Serena itself tracks (see
         :meth:`gather_source_files`), so no separate file-discovery logic has to be kept in sync.
+        A file is considered changed if its modification time or size differ from the last poll in any direction,
+        because files may be replaced with older versions whose modification time is preserved
+        (e.g. restored from an archive or copied with ``cp -p``).
         The dominant cost is the directory walk plus one ``os.stat`` per tracked file; this is
         intended to be called before symbolic tool invocations rather than on a timer.
 
         :return: the number of change events sent (0 if nothing changed, if no language server is
             running yet, or on the first call, which only establishes the baseline).
         """
-        current: dict[str, float] = {}
+        current: dict[str, tuple[int, int]] = {}
         for rel_path in self._project.gather_source_files():
             try:
-                current[rel_path] = os.stat(os.path.join(self._project.project_root, rel_path)).st_mtime
+                current[rel_path] = self._stat(rel_path)
             except OSError:
                 continue
 
         # Read-diff-swap under the lock only; the filesystem walk above and the LSP notifications
         # below stay outside it so concurrent callers do not serialize on I/O.
         with self._freshness_lock:
-            previous = self._freshness_last_seen_mtimes
-            self._freshness_last_seen_mtimes = current
+            previous = self._freshness_last_seen_stats
+            self._freshness_last_seen_stats = current
 
             if previous is None:
                 return 0
 
             # compute the set of individual events (created, changed, deleted)
             events: list[tuple[str, FileChangeType]] = []
-            for rel_path, mtime in current.items():
-                prev_mtime = previous.get(rel_path)
-                if prev_mtime is None:
+            for rel_path, stat in current.items():
+                prev_stat = previous.get(rel_path)
+                if prev_stat is None:
                     events.append((rel_path, FileChangeType.Created))
-                elif mtime > prev_mtime:
+                elif stat != prev_stat:
                     events.append((rel_path, FileChangeType.Changed))
             events.extend((rel_path, FileChangeType.Deleted) for rel_path in previous if rel_path not in current)
 
         if not events:
             return 0
 
+        self._notify_language_servers(events)
+        return len(events)
+
+    def _stat(self, rel_path: str) -> tuple[int, int]:
+        """
+        :param rel_path: the relative path of a file
+        :return: the modification time (in ns) and size of the file
+        """
+        stat_result = os.stat(os.path.join(self._project.project_root, rel_path))
+        return stat_result.st_mtime_ns, stat_result.st_size
+
+    def notify_file_changes(self, events: list[tuple[str, FileChangeType]]) -> None:
+        """
+        Notifies every language server of the given changes to files (e.g. files written by Serena's own editing tools),
+        such that subsequent requests (e.g. renames or reference searches) do not operate on a stale state.
+        The changed files are considered in the baseline of subsequent polls, such that the changes are not notified again.
+        Changes to files which are not source files (see :meth:`poll_and_notify`) are disregarded.
+
+        :param events: pairs of relative file paths and the types of the changes
+        """
+        events = [
+            (rel_path, change_type)
+            for rel_path, change_type in events
+            if not self._project.is_ignored_path(rel_path, ignore_non_source_files=True)
+        ]
+        if not events:
+            return
+
+        with self._freshness_lock:
+            if self._freshness_last_seen_stats is not None:
+                for rel_path, change_type in events:
+                    if change_type == FileChangeType.Deleted:
+                        self._freshness_last_seen_stats.pop(rel_path, None)
+                        continue
+                    try:
+                        self._freshness_last_seen_stats[rel_path] = self._stat(rel_path)
+                    except OSError:
+                        continue
+
+        self._notify_language_servers(events)
+
+    def _notify_language_servers(self, events: list[tuple[str, FileChangeType]]) -> None:
         # create the change didChangeWatchedFiles notification
         changes: list[FileEvent] = [
             {"uri": Path(self._project.project_root, rel_path).resolve().as_uri(), "type": change_type} for rel_path, change_type in events
         ]
         params: DidChangeWatchedFilesParams = {"changes": changes}
         created_paths = [rel_path for rel_path, change_type in events if change_type == FileChangeType.Created]
+        modified_paths = [rel_path for rel_path, change_type in events if change_type != FileChangeType.Created]
 
         for ls in self._language_server_manager.iter_language_servers():
             # send the didChangeWatchedFiles notification to the language server
@@ -354,6 +504,16 @@ class LanguageServerFileChangeNotifier:
                 ls.server.notify.did_change_watched_files(params)
             except Exception as e:
                 log.error("Failed to notify language server of watched file changes", exc_info=e)
+            # cached results of position-based requests may refer to the previous state of the changed files
+            ls.clear_position_request_cache()
+            # the cached symbols of the changed files are outdated (and those of moved or deleted files are never used again)
+            for rel_path, _ in events:
+                ls.invalidate_symbol_cache(rel_path)
+            # files kept open in the language server must be updated explicitly, as the server relies on their open state
+            try:
+                ls.sync_kept_open_files(modified_paths)
+            except Exception as e:
+                log.error("Failed to synchronise files kept open in language server", exc_info=e)
 
             # A didChangeWatchedFiles(Created) notification alone is not enough for every backend
             # (observed with pyright) to fold a brand-new file into its cross-file reference graph;
@@ -367,5 +527,3 @@ class LanguageServerFileChangeNotifier:
                         pass
                 except Exception as e:
                     log.error(f"Failed to refresh newly created file {rel_path!r} in language server", exc_info=e)
-
-        return len(events)
@@ -2,34 +2,66 @@
 The Serena Model Context Protocol (MCP) Server
 """
 
+import asyncio
+import concurrent.futures
+import functools
+import os
 import sys
-from collections.abc import AsyncIterator, Iterator
+import threading
+import weakref
+from collections.abc import AsyncIterator, Callable, Iterator
 from contextlib import asynccontextmanager
+from contextvars import ContextVar
 from copy import deepcopy
 from dataclasses import dataclass
-from typing import Any, Literal, cast
+from pathlib import Path
+from typing import Annotated, Any, Literal, cast
+from urllib.parse import quote, unquote
 
+import anyio
+import anyio.to_thread
 import docstring_parser
+from mcp.server.elicitation import AcceptedElicitation
 from mcp.server.fastmcp import server
 from mcp.server.fastmcp.exceptions import ToolError
+from mcp.server.fastmcp.prompts.base import Prompt
 from mcp.server.fastmcp.server import Context, FastMCP, Settings
 from mcp.server.fastmcp.tools.base import Tool as FastMCPTool
-from mcp.server.session import ServerSessionT
+from mcp.server.session import ServerSession, ServerSessionT
 from mcp.shared.context import LifespanContextT, RequestT
-from mcp.types import ToolAnnotations
+from mcp.shared.version import SUPPORTED_PROTOCOL_VERSIONS
+from mcp.types import (
+    LATEST_PROTOCOL_VERSION,
+    ClientCapabilities,
+    ElicitationCapability,
+    ListToolsRequest,
+    ListToolsResult,
+    LoggingLevel,
+    RootsCapability,
+    RootsListChangedNotification,
+    ServerResult,
+    ToolAnnotations,
+)
+from mcp.types import Tool as MCPToolInfo
+from pydantic import AnyUrl, BaseModel, Field
 from pydantic_settings import SettingsConfigDict
 from sensai.util import logging
+from starlette.applications import Starlette
 
 from serena.agent import (
     SerenaAgent,
     SerenaConfig,
 )
-from serena.config.context_mode import SerenaAgentContext
+from serena.approval import get_operation_description
+from serena.config.context_mode import SerenaAgentContext, SerenaAgentMode
 from serena.config.serena_config import LanguageBackend, ModeSelectionDefinition
 from serena.constants import DEFAULT_CONTEXT, SERENA_LOG_FORMAT
-from serena.tools import Tool, ToolCallError
+from serena.tools import ApprovePendingOperationTool, StructuredToolResult, Tool, ToolCallError
 from serena.util.exception import show_fatal_exception_safe
+from serena.util.http_compression import ResponseCompressionMiddleware
 from serena.util.logging import MemoryLogHandler
+from serena.util.thread import IdleTimeoutWatchdog
+from solidlsp.ls_utils import PathUtils
 
 log = logging.getLogger(__name__)
 
@@ -50,19 +82,355 @@ class SerenaMCPRequestContext:
     agent: SerenaAgent
 
 
+_call_approved_via_elicitation: ContextVar[bool] = ContextVar("call_approved_via_elicitation", default=False)
+"""
+whether the tool call currently being processed was explicitly approved by the user via MCP elicitation
+"""
+
+
+class ApprovalElicitationResponse(BaseModel):
+    """
+    The schema of the user's response when asked (via MCP elicitation) to approve an operation
+    """
+
+    approve: bool = Field(description="Whether to execute the operation")
+
+
+MEMORY_RESOURCE_URI_PREFIX = "memory://"
+
+
+def memory_resource_uri(memory_name: str) -> str:
+    """
+    :param memory_name: the name of a memory
+    :return: the URI of the MCP resource providing the memory, where slashes in the name (topics) are percent-encoded
+    """
+    return MEMORY_RESOURCE_URI_PREFIX + quote(memory_name, safe="")
+
+
+def memory_name_from_resource_uri(uri: str) -> str | None:
+    """
+    :param uri: a resource URI
+    :return: the name of the memory provided by the resource or None if the URI does not refer to a memory
+    """
+    if not uri.startswith(MEMORY_RESOURCE_URI_PREFIX):
+        return None
+    return unquote(uri[len(MEMORY_RESOURCE_URI_PREFIX) :].rstrip("/"))
+
+
+class MemoryResourceSubscriptions:
+    """
+    Manages the clients' subscriptions to memory resources, notifying subscribers whenever a memory changes.
+    Changes are detected by polling the modification times of the memory files, such that changes made outside of
+    Serena's tools (e.g. via the dashboard or an editor) are also picked up.
+    """
+
+    POLL_INTERVAL = 1.0
+
+    def __init__(self, get_memory_file_path: Callable[[str], Path | None]):
+        """
+        :param get_memory_file_path: function which maps a memory name to the path of the memory file
+            (None if the memory cannot currently be resolved, e.g. because no project is active)
+        """
+        self._get_memory_file_path = get_memory_file_path
+        self._subscribers: dict[str, list[ServerSession]] = {}
+        self._states: dict[str, tuple[Path | None, int | None]] = {}
+        self._poll_task: asyncio.Task | None = None
+
+    def _get_state(self, memory_name: str) -> tuple[Path | None, int | None]:
+        path = self._get_memory_file_path(memory_name)
+        mtime = None
+        if path is not None and path.exists():
+            mtime = path.stat().st_mtime_ns
+        return path, mtime
+
+    def get_subscribed_memory_names(self) -> list[str]:
+        return list(self._subscribers)
+
+    def subscribe(self, memory_name: str, session: ServerSession) -> None:
+        """
+        Subscribes the given session to changes of the given memory; must be called from within the server's event loop.
+
+        :param memory_name: the name of the memory
+        :param session: the session of the subscribing client
+        """
+        sessions = self._subscribers.setdefault(memory_name, [])
+        if session not in sessions:
+            sessions.append(session)
+        if memory_name not in self._states:
+            self._states[memory_name] = self._get_state(memory_name)
+        if self._poll_task is None or self._poll_task.done():
+            self._poll_task = asyncio.create_task(self._poll())
+
+    def unsubscribe(self, memory_name: str, session: ServerSession) -> None:
+        sessions = self._subscribers.get(memory_name, [])
+        if session in sessions:
+            sessions.remove(session)
+        if not sessions:
+            self._subscribers.pop(memory_name, None)
+            self._states.pop(memory_name, None)
+
+    async def notify_changes(self) -> None:
+        """
+        Notifies the subscribers of all memories which have changed since the last check.
+        Subscriptions of sessions which can no longer be notified (e.g. because the client disconnected) are removed.
+        """
+        for memory_name, sessions in list(self._subscribers.items()):
+            state = self._get_state(memory_name)
+            if state == self._states.get(memory_name):
+                continue
+            self._states[memory_name] = state
+            uri = AnyUrl(memory_resource_uri(memory_name))
+            log.info(f"Memory '{memory_name}' changed; notifying {len(sessions)} subscriber(s)")
+            for session in list(sessions):
+                try:
+                    await session.send_resource_updated(uri)
+                except Exception as e:
+                    log.info(f"Removing subscription to {uri} after failed notification: {e}")
+                    self.unsubscribe(memory_name, session)
+
+    async def _poll(self) -> None:
+        while self._subscribers:
+            await asyncio.sleep(self.POLL_INTERVAL)
+            try:
+                await self.notify_changes()
+            except Exception as e:
+                log.error(f"Error while checking subscribed memories for changes: {e}", exc_info=e)
+
+
+class MCPRootsProjectActivator:
+    """
+    Activates the project corresponding to the client's workspace root (MCP roots capability), such that clients which
+    provide the workspace at runtime can be used without specifying a project at startup.
+    The roots are requested from each client (session) upon its first tool call and again after a client has notified
+    the server about changed roots. A project which was activated by other means (e.g. via the `activate_project` tool
+    or from the roots of another session) is never replaced.
+    """
+
+    def __init__(self, agent: SerenaAgent):
+        self._agent = agent
+        self._sessions_with_known_roots: weakref.WeakSet[ServerSession] = weakref.WeakSet()
+        """the sessions whose roots were requested since the roots last changed"""
+        self._root_session: weakref.ReferenceType[ServerSession] | None = None
+        """the session from whose root the project was last activated"""
+        self._root_path: str | None = None
+        """the path of the root from which the project was last activated"""
+        self._lock = asyncio.Lock()
+
+    def mark_roots_changed(self) -> None:
+        """
+        Marks the roots of all sessions as changed (as notifications are not associated with the session of the
+        notifying client), such that each session's roots are requested again upon its next tool call.
+        """
+        self._sessions_with_known_roots.clear()
+
+    @staticmethod
+    def _supports_roots(session: ServerSession) -> bool:
+        try:
+            return session.check_client_capability(ClientCapabilities(roots=RootsCapability()))
+        except Exception as e:
+            log.debug(f"Could not determine client roots support: {e}")
+            return False
+
+    @staticmethod
+    def _is_same_path(path1: str, path2: str) -> bool:
+        return os.path.normcase(os.path.abspath(path1)) == os.path.normcase(os.path.abspath(path2))
+
+    def _is_activated_from_root_of(self, project_root: str, session: ServerSession) -> bool:
+        """
+        :param project_root: the root of the active project
+        :param session: the session
+        :return: whether the active project was activated from the given session's root
+        """
+        if self._root_session is None or self._root_session() is not session or self._root_path is None:
+            return False
+        return self._is_same_path(project_root, self._root_path)
+
+    async def update_project(self, session: ServerSession) -> None:
+        """
+        Requests the client's roots if they (may) have changed and, if applicable, issues the activation of the project
+        at the (first) root, which the agent's task executor will perform before executing any subsequently issued tool call.
+        Must be called from within the server's event loop.
+
+        :param session: the session of the client
+        """
+        async with self._lock:
+            if session in self._sessions_with_known_roots:
+                return
+            self._sessions_with_known_roots.add(session)
+            if not self._supports_roots(session):
+                return
+            try:
+                roots = (await session.list_roots()).roots
+            except Exception as e:
+                log.warning(f"Could not obtain the client's roots: {e}")
+                return
+            root_paths = [PathUtils.uri_to_path(str(root.uri)) for root in roots if str(root.uri).startswith("file://")]
+            if not root_paths:
+                log.info("The client did not provide any file system roots")
+                return
+            root_path = root_paths[0]
+            if len(root_paths) > 1:
+                log.info(f"The client provided {len(root_paths)} roots; using the first one ({root_path})")
+
+            active_project = self._agent.get_active_project()
+            if active_project is not None:
+                if not self._is_activated_from_root_of(active_project.project_root, session):
+                    log.info(f"Not activating the project at the client's root {root_path}, as project {active_project} is active")
+                    return
+                if self._is_same_path(active_project.project_root, root_path):
+                    return
+
+            log.info(f"Activating the project at the client's root {root_path}")
+            self._root_session = weakref.ref(session)
+            self._root_path = root_path
+            self._agent.issue_task(functools.partial(self._agent.activate_project_from_path_or_name, root_path), name="ActivateRootProject")
+
+
+PROTOCOL_VERSION_TOOL_ANNOTATIONS = "2025-03-26"
+"""the MCP protocol version which introduced tool annotations"""
+PROTOCOL_VERSION_STRUCTURED_TOOL_OUTPUT = "2025-06-18"
+"""the MCP protocol version which introduced tool output schemas (structured content) and tool titles"""
+
+
+def get_negotiated_protocol_version(session: ServerSession) -> str:
+    """
+    :param session: the session of a client
+    :return: the protocol version negotiated with the client during initialization, i.e. the version requested by
+        the client if it is supported and the latest version otherwise
+    """
+    client_params = session.client_params
+    if client_params is None:
+        return LATEST_PROTOCOL_VERSION
+    requested_version = str(client_params.protocolVersion)
+    return requested_version if requested_version in SUPPORTED_PROTOCOL_VERSIONS else LATEST_PROTOCOL_VERSION
+
+
+def adapt_tools_to_protocol_version(tools: list[MCPToolInfo], protocol_version: str) -> list[MCPToolInfo]:
+    """
+    Removes the tool metadata which is not defined in the given protocol version, such that clients which strictly
+    validate messages against the negotiated version accept the tool list.
+
+    :param tools: the tools (as defined by the latest protocol version); they are not modified
+    :param protocol_version: the negotiated protocol version (protocol versions are dates, so they can be compared as strings)
+    :return: the adapted tools (copies, if any adaptation was necessary)
+    """
+    update: dict[str, Any] = {}
+    if protocol_version < PROTOCOL_VERSION_STRUCTURED_TOOL_OUTPUT:
+        update.update(outputSchema=None, title=None)
+    if protocol_version < PROTOCOL_VERSION_TOOL_ANNOTATIONS:
+        update.update(annotations=None)
+    if not update:
+        return tools
+    return [tool.model_copy(update=update, deep=True) for tool in tools]
+
+
+MCP_LOGGING_LEVELS: dict[LoggingLevel, int] = {
+    "debug": logging.DEBUG,
+    "info": logging.INFO,
+    "notice": 25,
+    "warning": logging.WARNING,
+    "error": logging.ERROR,
+    "critical": logging.CRITICAL,
+    "alert": 60,
+    "emergency": 70,
+}
+"""mapping from the log levels defined by MCP (syslog severities) to Python log levels"""
+
+
+class MCPLogForwarder(logging.Handler):
+    """
+    Forwards log records (of Serena and the language servers) as MCP log message notifications to the clients
+    which requested them by setting a log level (via `logging/setLevel`).
+    Log records may be emitted in any thread; the notifications are sent in the server's event loop.
+    """
+
+    IGNORED_LOGGER_PREFIXES = ("mcp", "sse_starlette", "uvicorn", "httpx", "httpcore")
+    """
+    prefixes of the names of loggers whose records are not forwarded (in particular, the MCP library's own loggers,
+    which could otherwise cause feedback loops)
+    """
+
+    def __init__(self) -> None:
+        super().__init__()
+        self.setFormatter(logging.Formatter("%(message)s"))
+        self._session_levels: dict[ServerSession, int] = {}
+        self._loop: asyncio.AbstractEventLoop | None = None
+        self._lock = threading.Lock()
+
+    def set_level_for_session(self, session: ServerSession, level: LoggingLevel) -> None:
+        """
+        Sets the minimum level of the log messages to send to the given session;
+        must be called from within the server's event loop.
+
+        :param session: the session of the client
+        :param level: the MCP log level
+        """
+        with self._lock:
+            self._session_levels[session] = MCP_LOGGING_LEVELS[level]
+            self._loop = asyncio.get_running_loop()
+
+    @staticmethod
+    def _to_mcp_level(levelno: int) -> LoggingLevel:
+        mcp_level: LoggingLevel = "debug"
+        for name, value in MCP_LOGGING_LEVELS.items():
+            if levelno >= value:
+                mcp_level = name
+        return mcp_level
+
+    def emit(self, record: logging.LogRecord) -> None:
+        if record.name.startswith(self.IGNORED_LOGGER_PREFIXES):
+            return
+        with self._lock:
+            sessions = [session for session, level in self._session_levels.items() if record.levelno >= level]
+            loop = self._loop
+        if not sessions or loop is None or loop.is_closed():
+            return
+        try:
+            message = self.format(record)
+            for session in sessions:
+                future = asyncio.run_coroutine_threadsafe(
+                    session.send_log_message(level=self._to_mcp_level(record.levelno), data=message, logger=record.name), loop
+                )
+                future.add_done_callback(functools.partial(self._on_notification_sent, session))
+        except Exception:
+            self.handleError(record)
+
+    def _on_notification_sent(self, session: ServerSession, future: concurrent.futures.Future) -> None:
+        if future.cancelled() or future.exception() is not None:
+            # the client can no longer be notified (e.g. because it disconnected)
+            with self._lock:
+                self._session_levels.pop(session, None)
+
+
 class SerenaFastMCPTool(FastMCPTool):
-    def __init__(self, tool: Tool, openai_tool_compatible: bool, structured_output: bool | None):
+    def __init__(
+        self,
+        tool: Tool,
+        openai_tool_compatible: bool,
+        structured_output: bool | None,
+        idle_watchdog: IdleTimeoutWatchdog | None = None,
+        roots_project_activator: MCPRootsProjectActivator | None = None,
+    ):
         """
         :param tool: the Serena tool
         :param openai_tool_compatible: whether to process the tool schema to be compatible with OpenAI tools
             (doesn't accept integer, needs number instead, etc.). This allows using Serena MCP within Codex.
         :param structured_output: whether to use structured output for the tool (None = auto)
+        :param idle_watchdog: the watchdog with which to record tool calls as activity (if any)
+        :param roots_project_activator: the activator with which to activate the project at the client's root
+            before the tool is executed (if any)
         """
         func_name = tool.get_name()
         func_doc = tool.get_apply_docstring() or ""
         func_arg_metadata = tool.get_apply_fn_metadata(structured_output=structured_output)
-        is_async = False
+        if func_arg_metadata.output_schema is not None and tool.STRUCTURED_OUTPUT_SCHEMA is not None:
+            func_arg_metadata = func_arg_metadata.model_copy(
+                update={"output_schema": self._create_output_schema(func_arg_metadata.output_schema, tool.STRUCTURED_OUTPUT_SCHEMA)}
+            )
+        is_async = True
         parameters = func_arg_metadata.arg_model.model_json_schema()
+        # disallow unknown parameters (which would otherwise be silently dropped, causing the tool to run with defaults)
+        parameters["additionalProperties"] = False
         if openai_tool_compatible:
             parameters = SerenaMCPFactory._sanitize_for_openai_tools(parameters)
 
@@ -94,22 +462,35 @@ class SerenaFastMCPTool(FastMCPTool):
             if (param_doc := docstring_params.get(parameter)) and param_doc.description:
                 param_desc = f"{param_doc.description.strip().strip('.') + '.'}"
                 properties["description"] = param_desc[0].upper() + param_desc[1:]
+        # expose the defaults configured for the active project
+        for parameter, default in tool.get_parameter_defaults().items():
+            if parameter in parameters_properties:
+                parameters_properties[parameter]["default"] = default
+
+        async def execute_fn(**kwargs) -> str:
+            approved = _call_approved_via_elicitation.get()
 
-        def execute_fn(**kwargs) -> str:
+            def apply() -> str:
+                return tool.apply_ex(log_call=True, catch_exceptions=False, approved=approved, **kwargs)
+
+            # wait for the result in a worker thread, such that the event loop can handle other requests
+            # (e.g. tools/list or calls of tools which can run concurrently) while the tool is being executed
             try:
-                return tool.apply_ex(log_call=True, catch_exceptions=False, **kwargs)
+                return await anyio.to_thread.run_sync(apply)
             except ToolCallError as e:
                 raise ToolError(e.get_error_message()) from e
 
         # Generate human-readable title from snake_case tool name
         tool_title = " ".join(word.capitalize() for word in func_name.split("_"))
 
-        # Create annotations with appropriate hints based on tool capabilities
+        # Create annotations with appropriate hints based on tool capabilities, enabling clients to apply their own
+        # approval policies (e.g. auto-approving read-only tools but not tools executing shell commands)
         can_edit = tool.can_edit()
         annotations = ToolAnnotations(
             title=tool_title,
             readOnlyHint=not can_edit,
             destructiveHint=can_edit,
+            openWorldHint=tool.is_open_world(),
         )
 
         super().__init__(
@@ -127,19 +508,144 @@ class SerenaFastMCPTool(FastMCPTool):
         )
 
         self._param_aliases = tool.get_param_aliases()
+        self._tool = tool
+        self._agent = tool.agent
+        self._idle_watchdog = idle_watchdog
+        self._roots_project_activator = roots_project_activator
 
     async def run(
         self,
         arguments: dict[str, Any],
         context: Context[ServerSessionT, LifespanContextT, RequestT] | None = None,
         convert_result: bool = False,
+    ) -> Any:
+        if self._idle_watchdog is None:
+            return await self._run(arguments, context, convert_result)
+        with self._idle_watchdog.activity():
+            return await self._run(arguments, context, convert_result)
+
+    async def _run(
+        self,
+        arguments: dict[str, Any],
+        context: Context[ServerSessionT, LifespanContextT, RequestT] | None,
+        convert_result: bool,
     ) -> Any:
         # apply parameter aliases
         for param_alias, param_name in self._param_aliases.items():
             if param_alias in arguments and param_name not in arguments:
                 arguments[param_name] = arguments.pop(param_alias)
 
-        return await super().run(arguments, context, convert_result)
+        # reject unknown parameters
+        known_params = self.parameters.get("properties", {})
+        unknown_params = [p for p in arguments if p not in known_params]
+        if unknown_params:
+            raise ToolError(
+                f"Unrecognized parameter(s) for tool '{self.name}': {', '.join(unknown_params)}. Valid parameters: {', '.join(known_params)}"
+            )
+
+        if context is not None and self._roots_project_activator is not None:
+            await self._roots_project_activator.update_project(context.session)
+
+        # pass the configured defaults of parameters which are not given explicitly, as the MCP SDK
+        # fills in the defaults of all parameters not given, making them indistinguishable from given ones
+        for param_name, default in self._tool.get_parameter_defaults().items():
+            arguments.setdefault(param_name, default)
+
+        # if the call requires approval and the client supports elicitation, ask the user directly
+        # (rather than deferring the operation and having the LLM ask for approval)
+        approved = False
+        if context is not None and self._supports_elicitation(context):
+            approval_request = self._get_approval_request(arguments)
+            if approval_request is not None:
+                operation_description, approval_reason = approval_request
+                if not await self._elicit_approval(context, operation_description, approval_reason):
+                    raise ToolError(f"The user did not approve the operation {operation_description}; it was NOT executed.")
+                approved = True
+
+        token = _call_approved_via_elicitation.set(approved)
+        try:
+            result = await super().run(arguments, context, convert_result=False)
+        finally:
+            _call_approved_via_elicitation.reset(token)
+        if convert_result:
+            result = self._convert_result(result)
+
+        # notify the client if the tool call changed the set of exposed tools
+        if context is not None and self._agent.pop_exposed_tools_changed_flag():
+            await context.session.send_tool_list_changed()
+
+        return result
+
+    @staticmethod
+    def _create_output_schema(output_schema: dict[str, Any], data_schema: dict[str, Any]) -> dict[str, Any]:
+        """
+        :param output_schema: the output schema of the tool's apply function, whose property `result` holds the result text
+        :param data_schema: the schema of the structured data provided by the tool
+        :return: the output schema extended with the (optional) property `data`, which holds the structured data
+        """
+        output_schema = deepcopy(output_schema)
+        output_schema["properties"]["data"] = {
+            **data_schema,
+            "description": "the result data in structured form (omitted if not available, e.g. because the result was shortened)",
+        }
+        return output_schema
+
+    def _convert_result(self, result: Any) -> Any:
+        """
+        Converts the result of the tool's execution function to MCP content, adding the structured data of a
+        :class:`StructuredToolResult` to the structured content.
+        """
+        converted = self.fn_metadata.convert_result(result)
+        output_schema = self.fn_metadata.output_schema
+        if isinstance(result, StructuredToolResult) and output_schema is not None and "data" in output_schema["properties"]:
+            unstructured_content, structured_content = converted
+            structured_content["data"] = result.structured_data
+            return unstructured_content, structured_content
+        return converted
+
+    def _get_approval_request(self, arguments: dict[str, Any]) -> tuple[str, str] | None:
+        """
+        :param arguments: the arguments of the tool call
+        :return: a pair (operation description, reason) if the tool call requires the user's approval, None otherwise
+        """
+        if self.name == ApprovePendingOperationTool.get_name_from_cls():
+            # the approval of a pending operation executes the operation, so the user must approve the operation itself
+            # (an unknown operation id is reported by the tool)
+            pending_operation = self._agent.get_pending_operations().get(str(arguments.get("operation_id", "")))
+            if pending_operation is None:
+                return None
+            return pending_operation.get_description(), pending_operation.reason
+        approval_reason = self._agent.get_approval_policy().get_approval_reason(self.name, arguments)
+        if approval_reason is None:
+            return None
+        return get_operation_description(self.name, arguments), approval_reason
+
+    @staticmethod
+    def _supports_elicitation(context: Context) -> bool:
+        try:
+            return context.session.check_client_capability(ClientCapabilities(elicitation=ElicitationCapability()))
+        except Exception as e:
+            log.debug(f"Could not determine client elicitation support: {e}")
+            return False
+
+    @staticmethod
+    async def _elicit_approval(context: Context, operation_description: str, approval_reason: str) -> bool:
+        """
+        Asks the user to approve the given operation via MCP elicitation.
+
+        :param context: the MCP request context
+        :param operation_description: a description of the operation
+        :param approval_reason: the reason why the operation requires approval
+        :return: whether the user approved the operation
+        """
+        log.info(f"Requesting user approval via elicitation for {operation_description}")
+        result = await context.elicit(
+            message=f"Serena requests approval for the operation {operation_description} ({approval_reason}). Execute it?",
+            schema=ApprovalElicitationResponse,
+        )
+        approved = isinstance(result, AcceptedElicitation) and result.data.approve
+        log.info(f"User approval for {operation_description}: {approved} (action={result.action})")
+        return approved
 
 
 class SerenaMCPFactory:
@@ -149,13 +655,14 @@ class SerenaMCPFactory:
 
     def __init__(
         self,
-        transport: Literal["stdio", "sse", "streamable-http"],
+        transport: Literal["stdio", "sse", "streamable-http", "http"],
         context: str = DEFAULT_CONTEXT,
         project: str | None = None,
         memory_log_handler: MemoryLogHandler | None = None,
     ):
         """
-        :param transport: The transport to use for the MCP server.
+        :param transport: The transport to use for the MCP server, where "http" refers to the Streamable HTTP transport
+            with a fallback to the legacy SSE transport (served on the same port).
         :param context: The context name or path to context file
         :param project: Either an absolute path to the project directory or a name of an already registered project.
             If the project passed here hasn't been registered yet, it will be registered automatically and can be activated by its name
@@ -167,6 +674,10 @@ class SerenaMCPFactory:
         self.project = project
         self.agent: SerenaAgent | None = None
         self.memory_log_handler = memory_log_handler
+        self._idle_watchdog: IdleTimeoutWatchdog | None = None
+        self._roots_project_activator: MCPRootsProjectActivator | None = None
+        self._event_loop: asyncio.AbstractEventLoop | None = None
+        """the event loop in which the MCP server is run (set once the server has started)"""
 
     @staticmethod
     def _sanitize_for_openai_tools(schema: dict) -> dict:
@@ -276,7 +787,13 @@ class SerenaMCPFactory:
         return walk(s)
 
     @staticmethod
-    def make_mcp_tool(tool: Tool, openai_tool_compatible: bool = True, structured_output: bool | None = None) -> SerenaFastMCPTool:
+    def make_mcp_tool(
+        tool: Tool,
+        openai_tool_compatible: bool = True,
+        structured_output: bool | None = None,
+        idle_watchdog: IdleTimeoutWatchdog | None = None,
+        roots_project_activator: MCPRootsProjectActivator | None = None,
+    ) -> SerenaFastMCPTool:
         """
         Creates an MCP tool from a Serena Tool instance.
 
@@ -284,8 +801,16 @@ class SerenaMCPFactory:
         :param openai_tool_compatible: whether to process the tool schema to be compatible with OpenAI tools
             (doesn't accept integer, needs number instead, etc.). This allows using Serena MCP within codex.
         :param structured_output: whether to use structured output for the tool (None = auto)
+        :param idle_watchdog: the watchdog with which to record tool calls as activity (if any)
+        :param roots_project_activator: the activator with which to activate the project at the client's root (if any)
         """
-        return SerenaFastMCPTool(tool, openai_tool_compatible=openai_tool_compatible, structured_output=structured_output)
+        return SerenaFastMCPTool(
+            tool,
+            openai_tool_compatible=openai_tool_compatible,
+            structured_output=structured_output,
+            idle_watchdog=idle_watchdog,
+            roots_project_activator=roots_project_activator,
+        )
 
     def _iter_tools(self) -> Iterator[Tool]:
         assert self.agent is not None
@@ -301,12 +826,177 @@ class SerenaMCPFactory:
         :param structured_output: whether to use structured output for the tools (None = auto)
         """
         if mcp is not None:
-            mcp._tool_manager._tools = {}
+            mcp_tools = {}
             for tool in self._iter_tools():
-                mcp_tool = self.make_mcp_tool(tool, openai_tool_compatible=openai_tool_compatible, structured_output=structured_output)
-                mcp._tool_manager._tools[tool.get_name()] = mcp_tool
+                mcp_tools[tool.get_name()] = self.make_mcp_tool(
+                    tool,
+                    openai_tool_compatible=openai_tool_compatible,
+                    structured_output=structured_output,
+                    idle_watchdog=self._idle_watchdog,
+                    roots_project_activator=self._roots_project_activator,
+                )
+            mcp._tool_manager._tools = mcp_tools
             log.info(f"Starting MCP server with {len(mcp._tool_manager._tools)} tools: {list(mcp._tool_manager._tools.keys())}")
 
+    def _make_mcp_mode_prompt(self, mode: SerenaAgentMode) -> Prompt:
+        """
+        Creates an MCP prompt providing the prompt of the given mode
+
+        :param mode: the mode
+        :return: the MCP prompt
+        """
+        agent = self.agent
+        assert agent is not None
+
+        def get_mode_prompt(
+            task: Annotated[str, Field(description="an optional description of the task to be solved in the mode")] = "",
+        ) -> str:
+            return agent.create_mode_prompt(mode, task=task)
+
+        description = f"Guidance for working in the active mode '{mode.name}'"
+        if mode.description:
+            description += f": {mode.description}"
+        return Prompt.from_function(get_mode_prompt, name=f"mode_{mode.name}", description=description)
+
+    def _set_mcp_prompts(self, mcp: FastMCP) -> None:
+        """
+        Update the prompts in the MCP server, providing one prompt for each active mode that defines a prompt
+
+        :param mcp: The MCP server to update
+        """
+        assert self.agent is not None
+        prompts = {}
+        for mode in self.agent.get_active_modes().get_modes():
+            if mode.has_prompt():
+                prompt = self._make_mcp_mode_prompt(mode)
+                prompts[prompt.name] = prompt
+        if prompts.keys() != mcp._prompt_manager._prompts.keys():
+            log.info(f"Updating MCP server prompts: {list(prompts.keys())}")
+        mcp._prompt_manager._prompts = prompts
+
+    def _get_memory_file_path(self, memory_name: str) -> Path | None:
+        assert self.agent is not None
+        project = self.agent.get_active_project()
+        if project is None:
+            return None
+        try:
+            return project.memory_manager.get_memory_file_path(memory_name)
+        except ValueError:
+            return None
+
+    # noinspection PyProtectedMember
+    def _add_memory_resources(self, mcp: FastMCP) -> None:
+        """
+        Exposes the memories of the active project (and global memories) via the resource template `memory://{name}`,
+        supporting subscriptions, such that clients can pin a memory into their context and get notified when it changes.
+
+        :param mcp: the MCP server to add the resources to
+        """
+        agent = self.agent
+        assert agent is not None
+
+        @mcp.resource(
+            MEMORY_RESOURCE_URI_PREFIX + "{name}",
+            name="memory",
+            description="A Serena memory of the active project (Markdown); slashes in memory names (topics) must be percent-encoded, "
+            "e.g. memory://global%2Fconventions for the memory global/conventions. Available memories can be listed via list_memories.",
+            mime_type="text/markdown",
+        )
+        def read_memory(name: str) -> str:
+            return agent.get_active_project_or_raise().memory_manager.load_memory(unquote(name))
+
+        subscriptions = MemoryResourceSubscriptions(self._get_memory_file_path)
+        low_level_server = mcp._mcp_server
+
+        def get_memory_name(uri: AnyUrl) -> str:
+            memory_name = memory_name_from_resource_uri(str(uri))
+            if memory_name is None:
+                raise ValueError(f"Subscriptions are only supported for memory resources ({MEMORY_RESOURCE_URI_PREFIX}...), got {uri}")
+            return memory_name
+
+        @low_level_server.subscribe_resource()
+        async def subscribe(uri: AnyUrl) -> None:
+            memory_name = get_memory_name(uri)
+            log.info(f"Client subscribed to memory '{memory_name}'")
+            subscriptions.subscribe(memory_name, low_level_server.request_context.session)
+
+        @low_level_server.unsubscribe_resource()
+        async def unsubscribe(uri: AnyUrl) -> None:
+            memory_name = get_memory_name(uri)
+            log.info(f"Client unsubscribed from memory '{memory_name}'")
+            subscriptions.unsubscribe(memory_name, low_level_server.request_context.session)
+
+        # the low-level server does not advertise subscription support by itself, even if handlers are registered
+        get_capabilities = low_level_server.get_capabilities
+
+        def get_capabilities_with_subscriptions(*args: Any, **kwargs: Any) -> Any:
+            capabilities = get_capabilities(*args, **kwargs)
+            if capabilities.resources is not None:
+                capabilities.resources.subscribe = True
+            return capabilities
+
+        low_level_server.get_capabilities = get_capabilities_with_subscriptions  # type: ignore[method-assign]
+
+    # noinspection PyProtectedMember
+    def _add_roots_support(self, mcp: FastMCP) -> None:
+        """
+        Adds support for the client's roots (see :class:`MCPRootsProjectActivator`) if no project was specified at startup.
+
+        :param mcp: the MCP server
+        """
+        assert self.agent is not None
+        if self.project is not None:
+            return
+        roots_project_activator = MCPRootsProjectActivator(self.agent)
+
+        async def on_roots_list_changed(_notification: RootsListChangedNotification) -> None:
+            log.info("The client's roots have changed")
+            roots_project_activator.mark_roots_changed()
+
+        mcp._mcp_server.notification_handlers[RootsListChangedNotification] = on_roots_list_changed
+        self._roots_project_activator = roots_project_activator
+
+    # noinspection PyProtectedMember
+    @staticmethod
+    def _add_protocol_version_adaptation(mcp: FastMCP) -> None:
+        """
+        Adapts the tool list to the protocol version negotiated with the respective client (see `adapt_tools_to_protocol_version`).
+        The negotiation itself is handled by the MCP SDK's server session; the server's capabilities need not be adapted,
+        as all capabilities advertised by Serena (tools, prompts, resources and logging) are defined in all supported versions.
+
+        :param mcp: the MCP server
+        """
+        low_level_server = mcp._mcp_server
+
+        # replaces the handler registered by FastMCP; the handler is registered directly (rather than via the `list_tools`
+        # decorator), because the decorator stores the returned tools in the tool cache, which is shared by all sessions
+        # and used for validating tool calls, i.e. it must hold the unadapted tools rather than the ones of a particular session
+        async def handle_list_tools(_request: ListToolsRequest) -> ServerResult:
+            tools = await mcp.list_tools()
+            low_level_server._tool_cache = {tool.name: tool for tool in tools}
+            protocol_version = get_negotiated_protocol_version(low_level_server.request_context.session)
+            return ServerResult(ListToolsResult(tools=adapt_tools_to_protocol_version(tools, protocol_version)))
+
+        low_level_server.request_handlers[ListToolsRequest] = handle_list_tools
+
+    # noinspection PyProtectedMember
+    def _add_logging_capability(self, mcp: FastMCP) -> None:
+        """
+        Adds the MCP logging capability, forwarding Serena's log messages (including language server logs) to clients
+        which set a log level via `logging/setLevel`, e.g. for debugging language server startup failures from within the client.
+        The capability is advertised by the low-level server as soon as the handler is registered.
+
+        :param mcp: the MCP server
+        """
+        log_forwarder = MCPLogForwarder()
+        logging.getLogger().addHandler(log_forwarder)
+        low_level_server = mcp._mcp_server
+
+        @low_level_server.set_logging_level()
+        async def set_logging_level(level: LoggingLevel) -> None:
+            log.info(f"Client requested log messages with level '{level}' or above")
+            log_forwarder.set_level_for_session(low_level_server.request_context.session, level)
+
     def _create_serena_agent(self, serena_config: SerenaConfig, modes: ModeSelectionDefinition | None = None) -> SerenaAgent:
         return SerenaAgent(
             project=self.project, serena_config=serena_config, context=self.context, modes=modes, memory_log_handler=self.memory_log_handler
@@ -327,6 +1017,8 @@ class SerenaMCPFactory:
         log_level: Literal["DEBUG", "INFO", "WARNING", "ERROR", "CRITICAL"] | None = None,
         trace_lsp_communication: bool | None = None,
         tool_timeout: float | None = None,
+        idle_timeout: float | None = None,
+        serena_config: SerenaConfig | None = None,
     ) -> FastMCP:
         """
         Create an MCP server with process-isolated SerenaAgent to prevent asyncio contamination.
@@ -344,9 +1036,13 @@ class SerenaMCPFactory:
         :param trace_lsp_communication: Whether to trace the communication between Serena and the language servers.
             This is useful for debugging language server issues.
         :param tool_timeout: Timeout in seconds for tool execution. If not specified, will take the value from the serena configuration.
+        :param idle_timeout: if specified, the server shuts down after this number of seconds without tool calls,
+            which is useful for long-running (daemon) servers using an HTTP-based transport.
+        :param serena_config: the Serena configuration to use (which is updated with the provided parameters);
+            if not specified, the configuration is loaded from the configuration file.
         """
         try:
-            config = self._create_default_serena_config()
+            config = serena_config if serena_config is not None else self._create_default_serena_config()
 
             # update configuration with the provided parameters
             if enable_web_dashboard is not None:
@@ -385,8 +1081,104 @@ class SerenaMCPFactory:
             port=port,
             instructions=instructions,
         )
+
+        # update the MCP server's tools whenever the set of exposed tools changes (e.g. after reloading contexts and modes)
+        def update_mcp_tools() -> None:
+            assert self.agent is not None
+            structured_output = self.agent.get_context().structured_tool_output
+            self._set_mcp_tools(mcp, openai_tool_compatible=self._is_openai_tool_compatible(), structured_output=structured_output)
+
+        self.agent.register_exposed_tools_changed_callback(lambda: self._call_in_event_loop(update_mcp_tools))
+
+        # keep the MCP server's prompts in sync with the active modes (which can change, e.g. upon project activation)
+        self.agent.register_config_changed_callback(lambda: self._call_in_event_loop(lambda: self._set_mcp_prompts(mcp)))
+
+        self._add_memory_resources(mcp)
+        self._add_logging_capability(mcp)
+        self._add_protocol_version_adaptation(mcp)
+        self._add_roots_support(mcp)
+
+        if self.transport != "stdio" and self.agent.serena_config.http_compression:
+            self._add_response_compression(mcp, self.agent.serena_config.http_compression_min_size)
+
+        if idle_timeout is not None:
+            self._start_idle_watchdog(idle_timeout)
         return mcp
 
+    def run_mcp_server(self, mcp: FastMCP) -> None:
+        """
+        Runs the given MCP server (as created via `create_mcp_server`) using the factory's transport,
+        blocking until the server terminates.
+
+        :param mcp: the MCP server
+        """
+        if self.transport == "http":
+            anyio.run(self._serve_http_with_sse_fallback, mcp)
+        else:
+            mcp.run(transport=self.transport)
+
+    @staticmethod
+    def create_http_app_with_sse_fallback(mcp: FastMCP) -> Starlette:
+        """
+        Creates the Starlette app for the "http" transport, which serves the Streamable HTTP transport (at `/mcp`)
+        and, for clients which do not support it yet, the legacy SSE transport (at `/sse` and `/messages/`).
+
+        :param mcp: the MCP server
+        :return: the app
+        """
+        app = mcp.streamable_http_app()
+        # the SSE routes do not require any further setup, so they can simply be added to the Streamable HTTP app,
+        # whose lifespan manages the sessions of the Streamable HTTP transport
+        app.router.routes.extend(mcp.sse_app().routes)
+        return app
+
+    @classmethod
+    async def _serve_http_with_sse_fallback(cls, mcp: FastMCP) -> None:
+        import uvicorn
+
+        app = cls.create_http_app_with_sse_fallback(mcp)
+        config = uvicorn.Config(app, host=mcp.settings.host, port=mcp.settings.port, log_level=mcp.settings.log_level.lower())
+        await uvicorn.Server(config).serve()
+
+    @staticmethod
+    def _add_response_compression(mcp: FastMCP, minimum_size: int) -> None:
+        """
+        Adds response compression to the Starlette apps which serve the HTTP-based transports.
+
+        :param mcp: the MCP server
+        :param minimum_size: the minimum size (in bytes) of a non-streamed response for it to be compressed
+        """
+        create_sse_app = mcp.sse_app
+        create_streamable_http_app = mcp.streamable_http_app
+
+        def sse_app(mount_path: str | None = None) -> Starlette:
+            app = create_sse_app(mount_path)
+            app.add_middleware(ResponseCompressionMiddleware, minimum_size=minimum_size)
+            return app
+
+        def streamable_http_app() -> Starlette:
+            app = create_streamable_http_app()
+            app.add_middleware(ResponseCompressionMiddleware, minimum_size=minimum_size)
+            return app
+
+        mcp.sse_app = sse_app  # type: ignore[method-assign]
+        mcp.streamable_http_app = streamable_http_app  # type: ignore[method-assign]
+
+    def _start_idle_watchdog(self, idle_timeout: float) -> None:
+        assert self.agent is not None
+        agent = self.agent
+
+        def on_idle() -> None:
+            log.info(f"No tool calls for {idle_timeout} seconds; shutting down the MCP server")
+            agent.shutdown()
+
+        log.info(f"The MCP server will shut down after being idle for {idle_timeout} seconds")
+        self._idle_watchdog = IdleTimeoutWatchdog(idle_timeout, on_idle)
+        self._idle_watchdog.start()
+
+    def _is_openai_tool_compatible(self) -> bool:
+        return self.context.name in ["chatgpt", "codex", "oaicompat-agent"]
+
     @asynccontextmanager
     async def server_lifespan(self, mcp_server: FastMCP) -> AsyncIterator[None]:
         """
@@ -396,10 +1188,13 @@ class SerenaMCPFactory:
 
         :param mcp_server: the MCP server instance to configure
         """
-        openai_tool_compatible = self.context.name in ["chatgpt", "codex", "oaicompat-agent"]
         assert self.agent is not None
+        self._event_loop = asyncio.get_running_loop()
         context = self.agent.get_context()
-        self._set_mcp_tools(mcp_server, openai_tool_compatible=openai_tool_compatible, structured_output=context.structured_tool_output)
+        self._set_mcp_tools(
+            mcp_server, openai_tool_compatible=self._is_openai_tool_compatible(), structured_output=context.structured_tool_output
+        )
+        self._set_mcp_prompts(mcp_server)
         log.info("MCP server lifetime setup complete")
         try:
             yield
@@ -413,6 +1208,28 @@ class SerenaMCPFactory:
             else:
                 log.info("Client disconnected")
 
+    def _call_in_event_loop(self, fn: Callable[[], None]) -> None:
+        """
+        Calls the given function, which modifies the MCP server's state (e.g. its tools), in the server's event loop,
+        such that requests handled concurrently (e.g. `tools/list`) never observe a partially updated state.
+        The function is called directly if the server is not running or if it is called from within the event loop;
+        otherwise (e.g. if it is called in a tool's worker thread), it is scheduled to be called in the event loop.
+
+        :param fn: the function to call
+        """
+        loop = self._event_loop
+        if loop is None or loop.is_closed():
+            fn()
+            return
+        try:
+            running_loop: asyncio.AbstractEventLoop | None = asyncio.get_running_loop()
+        except RuntimeError:
+            running_loop = None
+        if running_loop is loop:
+            fn()
+        else:
+            loop.call_soon_threadsafe(fn)
+
     def _get_initial_instructions(self) -> str:
         assert self.agent is not None
         return self.agent.create_connection_prompt()
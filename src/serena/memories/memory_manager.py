@@ -2,7 +2,7 @@ import logging
 import os
 import re
 import shutil
-from collections.abc import Iterator, Sequence
+from collections.abc import Callable, Iterator, Sequence
 from pathlib import Path
 from typing import Literal
 
@@ -12,6 +12,7 @@ from serena.config.serena_config import (
 from serena.constants import SERENA_FILE_ENCODING
 from serena.util.text_utils import ContentReplacer
 
+from .memory_metadata import MemoryMetadata, MemoryType, split_front_matter, with_front_matter
 from .memory_reference_analysis import (
     MEMORY_REF_PREFIX,
     AutofixReport,
@@ -210,10 +211,27 @@ class MemoryManager:
         with open(memory_file_path, encoding=self._encoding) as f:
             return f.read()
 
-    def save_memory(self, name: str, content: str, is_tool_context: bool) -> str:
+    def get_memory_metadata(self, name: str) -> MemoryMetadata | None:
+        """
+        :param name: the memory name
+        :return: the metadata stored in the memory's front matter or None if the memory has no (valid) front matter
+        """
+        metadata, _ = split_front_matter(self.load_memory(name))
+        return metadata
+
+    def save_memory(self, name: str, content: str, is_tool_context: bool, metadata: MemoryMetadata | None = None) -> str:
+        """
+        :param name: the memory name
+        :param content: the memory content
+        :param is_tool_context: whether the call originates from a tool invocation (affects write-access checks)
+        :param metadata: if given, the metadata to store as front matter (replacing any front matter in `content`)
+        :return: a confirmation message
+        """
         name = self._sanitize_name(name)
         self._check_not_ignored(name)
         self._check_write_access(name, is_tool_context)
+        if metadata is not None:
+            content = with_front_matter(content, metadata)
         memory_file_path = self.get_memory_file_path(name)
         with open(memory_file_path, "w", encoding=self._encoding) as f:
             f.write(content)
@@ -248,6 +266,16 @@ class MemoryManager:
         def get_full_list(self) -> list[str]:
             return sorted(self.memories + self.read_only_memories)
 
+        def filter(self, predicate: Callable[[str], bool]) -> "MemoryManager.MemoriesList":
+            """
+            :param predicate: the predicate which memory names must satisfy
+            :return: a new list containing only the memories satisfying the predicate
+            """
+            result = MemoryManager.MemoriesList()
+            result.memories = [name for name in self.memories if predicate(name)]
+            result.read_only_memories = [name for name in self.read_only_memories if predicate(name)]
+            return result
+
     @staticmethod
     def _iter_memory_files(search_dir: Path) -> Iterator[Path]:
         """Yields every ``*.md`` file under ``search_dir``, descending into symlinked directories.
@@ -289,10 +317,14 @@ class MemoryManager:
             dir_path = dir_path / topic.replace("/", os.sep)
         return self._list_memories(dir_path, self._project_memory_dir)
 
-    def list_memories(self, topic: str = "") -> MemoriesList:
+    def list_memories(self, topic: str = "", memory_type: MemoryType | None = None, tags: Sequence[str] = ()) -> MemoriesList:
         """
         Lists all memories, optionally filtered by topic.
         If the topic is omitted, both global and project-specific memories are returned.
+
+        :param topic: the topic to restrict the listing to
+        :param memory_type: if given, only memories whose metadata (front matter) specifies this type are returned
+        :param tags: if given, only memories whose metadata (front matter) contains all of these tags are returned
         """
         memories: MemoryManager.MemoriesList
 
@@ -307,6 +339,14 @@ class MemoryManager:
             memories = self.list_project_memories()
             memories.extend(self.list_global_memories())
 
+        if memory_type is not None or tags:
+
+            def matches(name: str) -> bool:
+                metadata = self.get_memory_metadata(name)
+                return metadata is not None and metadata.matches(memory_type=memory_type, tags=list(tags))
+
+            memories = memories.filter(matches)
+
         return memories
 
     def delete_memory(self, name: str, is_tool_context: bool) -> str:
@@ -0,0 +1,110 @@
+"""
+Structured metadata of memories, which is stored as YAML front matter at the beginning of the memory content
+"""
+
+from dataclasses import dataclass, field
+from datetime import datetime
+from enum import StrEnum
+from typing import Any, Self
+
+import yaml
+
+FRONT_MATTER_DELIMITER = "---"
+
+
+class MemoryType(StrEnum):
+    """
+    The type of knowledge a memory contains, enabling agents to filter memories programmatically
+    """
+
+    ARCHITECTURE = "architecture"
+    """project structure, components and invariants"""
+    RUNBOOK = "runbook"
+    """commands and procedures (building, testing, deploying, etc.)"""
+    CONVENTIONS = "conventions"
+    """code style, naming and design conventions"""
+    REFERENCE = "reference"
+    """reference information such as the tech stack or external resources"""
+    STATS = "stats"
+    """statistics computed by a tool"""
+    OTHER = "other"
+
+    @classmethod
+    def from_name(cls, name: str) -> "MemoryType":
+        try:
+            return cls(name.strip().lower())
+        except ValueError:
+            raise ValueError(f"Invalid memory type '{name}'; valid types: {', '.join(t.value for t in cls)}") from None
+
+
+@dataclass
+class MemoryMetadata:
+    type: MemoryType
+    tags: list[str] = field(default_factory=list)
+    updated_at: str = field(default_factory=lambda: datetime.now().astimezone().isoformat(timespec="seconds"))
+    """the time at which the memory was last written (ISO 8601)"""
+    source_tool: str | None = None
+    """the name of the tool which wrote the memory"""
+
+    def to_dict(self) -> dict[str, Any]:
+        result: dict[str, Any] = {"type": self.type.value, "tags": list(self.tags), "updated_at": self.updated_at}
+        if self.source_tool is not None:
+            result["source_tool"] = self.source_tool
+        return result
+
+    @classmethod
+    def from_dict(cls, data: dict[str, Any]) -> Self:
+        tags = data.get("tags") or []
+        if isinstance(tags, str):
+            tags = [tags]
+        return cls(
+            type=MemoryType.from_name(str(data.get("type", MemoryType.OTHER))),
+            tags=[str(tag) for tag in tags],
+            updated_at=str(data.get("updated_at", "")),
+            source_tool=data.get("source_tool"),
+        )
+
+    def matches(self, memory_type: MemoryType | None = None, tags: list[str] | None = None) -> bool:
+        """
+        :param memory_type: the required type; None to accept any type
+        :param tags: tags which must all be present; None or empty to accept any tags
+        :return: whether the metadata satisfies the given criteria
+        """
+        if memory_type is not None and self.type != memory_type:
+            return False
+        return all(tag in self.tags for tag in tags or [])
+
+
+def split_front_matter(content: str) -> tuple[MemoryMetadata | None, str]:
+    """
+    Splits the given memory content into the metadata (parsed from the front matter) and the actual content.
+    If the content has no front matter or the front matter cannot be parsed, the content is returned unchanged.
+
+    :param content: the memory content
+    :return: a tuple (metadata, content without front matter)
+    """
+    lines = content.split("\n")
+    if not lines or lines[0].strip() != FRONT_MATTER_DELIMITER:
+        return None, content
+    for i in range(1, len(lines)):
+        if lines[i].strip() == FRONT_MATTER_DELIMITER:
+            try:
+                data = yaml.safe_load("\n".join(lines[1:i]))
+                if not isinstance(data, dict):
+                    return None, content
+                metadata = MemoryMetadata.from_dict(data)
+            except (yaml.YAMLError, ValueError):
+                return None, content
+            return metadata, "\n".join(lines[i + 1 :]).lstrip("\n")
+    return None, content
+
+
+def with_front_matter(content: str, metadata: MemoryMetadata) -> str:
+    """
+    :param content: the memory content, which may already contain front matter (which is replaced)
+    :param metadata: the metadata to store in the front matter
+    :return: the content with the front matter prepended
+    """
+    _, content = split_front_matter(content)
+    front_matter = yaml.safe_dump(metadata.to_dict(), sort_keys=False, default_flow_style=None, allow_unicode=True)
+    return f"{FRONT_MATTER_DELIMITER}\n{front_matter}{FRONT_MATTER_DELIMITER}\n\n{content}"
@@ -0,0 +1,77 @@
+"""
+Records information on the current session, which is persisted as a memory for subsequent sessions to resume from
+"""
+
+import threading
+from datetime import datetime
+
+LAST_SESSION_MEMORY_NAME = "last_session"
+
+
+class SessionRecord:
+    """
+    Collects the files edited during a session along with a summary of the tasks worked on and pending follow-ups
+    (as provided by the LLM).
+    """
+
+    def __init__(self) -> None:
+        self._edited_files: dict[str, None] = {}  # ordered set
+        self._tasks = ""
+        self._follow_ups = ""
+        self._is_modified = False
+        self._lock = threading.Lock()
+
+    def add_edited_file(self, relative_path: str) -> None:
+        with self._lock:
+            if relative_path not in self._edited_files:
+                self._edited_files[relative_path] = None
+                self._is_modified = True
+
+    def set_summary(self, tasks: str, follow_ups: str) -> None:
+        """
+        :param tasks: a description of the tasks worked on in the session
+        :param follow_ups: a description of the pending follow-ups
+        """
+        with self._lock:
+            self._tasks = tasks.strip()
+            self._follow_ups = follow_ups.strip()
+            self._is_modified = True
+
+    def get_edited_files(self) -> list[str]:
+        with self._lock:
+            return list(self._edited_files)
+
+    def create_change_summary(self) -> str:
+        """
+        :return: a summary of the changes made in the session (Markdown), e.g. for use as a pull request description
+        """
+        with self._lock:
+            content = "## Summary\n\n" + (self._tasks or "(not summarised)") + "\n"
+            if self._edited_files:
+                content += "\n## Files Edited\n\n" + "".join(f"- {path}\n" for path in self._edited_files)
+            return content
+
+    def is_modified(self) -> bool:
+        """
+        :return: whether the record has changed since the memory content was last created
+        """
+        with self._lock:
+            return self._is_modified
+
+    def create_memory_content(self) -> str:
+        """
+        Creates the content of the memory which persists this record, marking the record as unmodified.
+
+        :return: the memory content (Markdown)
+        """
+        with self._lock:
+            self._is_modified = False
+            content = f"# Last Session\n\nRecorded at {datetime.now().strftime('%Y-%m-%d %H:%M')}.\n"
+            content += "\n## Tasks Worked On\n\n" + (self._tasks or "(not summarised)") + "\n"
+            content += "\n## Files Edited\n\n"
+            if self._edited_files:
+                content += "".join(f"- {path}\n" for path in self._edited_files)
+            else:
+                content += "(none)\n"
+            content += "\n## Pending Follow-Ups\n\n" + (self._follow_ups or "(none recorded)") + "\n"
+            return content
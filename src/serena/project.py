@@ -14,6 +14,8 @@ from serena.config.serena_config import (
     ProjectConfigAutoGenerationMode,
     SerenaConfig,
 )
+from serena.edit_history import EditHistory
+from serena.file_backups import FileBackups
 from serena.ls_manager import LanguageServerFactory, LanguageServerManager
 from serena.memories.memory_manager import MemoryManager
 from serena.util.file_proxy import FileCollection, FileProxy
@@ -21,6 +23,7 @@ from serena.util.file_system import GitignoreParser, match_path, scan_directory
 from serena.util.text_utils import MatchedConsecutiveLines, search_files
 from solidlsp import SolidLanguageServer
 from solidlsp.ls_config import LanguageServerId
+from solidlsp.lsp_protocol_handler.lsp_types import FileChangeType
 
 if TYPE_CHECKING:
     from serena.agent import SerenaAgent
@@ -29,6 +32,9 @@ log = logging.getLogger(__name__)
 
 
 class Project(ToStringMixin):
+    EDIT_HISTORY_FOLDER_NAME = "history"
+    BACKUPS_FOLDER_NAME = "backups"
+
     def __init__(
         self,
         *,
@@ -55,19 +61,22 @@ class Project(ToStringMixin):
         # resolve line ending (project -> global)
         self.line_ending = project_config.line_ending or serena_config.line_ending
 
+        self.edit_history = EditHistory(
+            os.path.join(self._serena_data_folder, self.EDIT_HISTORY_FOLDER_NAME),
+            self.project_root,
+            project_config.encoding,
+            newline=self.line_ending.newline_str,
+        )
+        self.file_backups = FileBackups(
+            os.path.join(self._serena_data_folder, self.BACKUPS_FOLDER_NAME), self.project_root, project_config.backup_retention
+        )
+
         self.language_server_manager: LanguageServerManager | None = None
         self._language_server_manager_init_error: Exception | None = None
         self.is_newly_created = is_newly_created
         self._agent: Optional["SerenaAgent"] = None
 
-        # create .gitignore file in the project's Serena data folder if not yet present
-        serena_data_gitignore_path = os.path.join(self._serena_data_folder, ".gitignore")
-        if not os.path.exists(serena_data_gitignore_path):
-            os.makedirs(os.path.dirname(serena_data_gitignore_path), exist_ok=True)
-            log.info(f"Creating .gitignore file in {serena_data_gitignore_path}")
-            with open(serena_data_gitignore_path, "w", encoding="utf-8") as f:
-                f.write(f"/{SolidLanguageServer.CACHE_FOLDER_NAME}\n")
-                f.write(f"/{ProjectConfig.SERENA_LOCAL_PROJECT_FILE}\n")
+        self._update_serena_data_gitignore()
 
         # prepare ignore spec asynchronously, ensuring immediate project activation.
         self.__ignored_patterns: list[str] | None = None
@@ -75,6 +84,38 @@ class Project(ToStringMixin):
         self._ignore_spec_available = threading.Event()
         threading.Thread(name=f"gather-ignorespec[{self.project_config.project_name}]", target=self._gather_ignorespec, daemon=True).start()
 
+    def _update_serena_data_gitignore(self) -> None:
+        """
+        Creates the .gitignore file in the project's Serena data folder or, if it already exists (e.g. because it was
+        created by an earlier version of Serena), adds the entries which are missing.
+        """
+        entries = [
+            f"/{SolidLanguageServer.CACHE_FOLDER_NAME}",
+            f"/{ProjectConfig.SERENA_LOCAL_PROJECT_FILE}",
+            f"/{self.EDIT_HISTORY_FOLDER_NAME}",
+            f"/{self.BACKUPS_FOLDER_NAME}",
+        ]
+        gitignore_path = os.path.join(self._serena_data_folder, ".gitignore")
+        if not os.path.exists(gitignore_path):
+            os.makedirs(os.path.dirname(gitignore_path), exist_ok=True)
+            log.info(f"Creating .gitignore file in {gitignore_path}")
+            with open(gitignore_path, "w", encoding="utf-8") as f:
+                f.write("".join(f"{entry}\n" for entry in entries))
+            return
+
+        with open(gitignore_path, encoding="utf-8") as f:
+            content = f.read()
+        # entries are considered present regardless of the leading and trailing slashes (e.g. "history/" is equivalent to "/history")
+        present_entries = {line.strip().strip("/") for line in content.splitlines()}
+        missing_entries = [entry for entry in entries if entry.strip("/") not in present_entries]
+        if not missing_entries:
+            return
+        log.info(f"Adding missing entries to {gitignore_path}: {missing_entries}")
+        with open(gitignore_path, "a", encoding="utf-8") as f:
+            if content and not content.endswith("\n"):
+                f.write("\n")
+            f.write("".join(f"{entry}\n" for entry in missing_entries))
+
     def _gather_ignorespec(self) -> None:
         with LogTime(f"Gathering ignore spec for project {self.project_config.project_name}", logger=log):
             try:
@@ -277,6 +318,34 @@ class Project(ToStringMixin):
 
         return self._is_ignored_relative_path(str(relative_path), ignore_non_source_files=ignore_non_source_files)
 
+    def is_ignored_directory_path(self, relative_path: str) -> bool:
+        """
+        Checks whether the directory with the given relative path, which need not exist (unlike in :meth:`is_ignored_path`),
+        or any of its parent directories is ignored according to the project's ignore patterns
+
+        :param relative_path: the relative path of the directory
+        """
+        parts = Path(os.path.normpath(relative_path)).parts
+        if ".git" in parts:
+            return True
+        for i in range(1, len(parts) + 1):
+            if match_path("/".join(parts[:i]) + "/", self._ignore_spec, root_path=self.project_root):
+                return True
+        return False
+
+    def is_ignored_file_path(self, relative_path: str) -> bool:
+        """
+        Checks whether the file with the given relative path, which need not exist (unlike in :meth:`is_ignored_path`),
+        or any of its parent directories is ignored according to the project's ignore patterns
+
+        :param relative_path: the relative path of the file
+        """
+        normalized_path = os.path.normpath(relative_path)
+        parent_dir = os.path.dirname(normalized_path)
+        if parent_dir and self.is_ignored_directory_path(parent_dir):
+            return True
+        return os.path.basename(normalized_path) == ".git" or match_path(normalized_path, self._ignore_spec, root_path=self.project_root)
+
     def is_path_in_project(self, path: str | Path) -> bool:
         """
         Checks if the given (absolute or relative) path is inside the project directory.
@@ -570,6 +639,22 @@ class Project(ToStringMixin):
             return self.language_server_manager.sync_file_system_changes()
         return 0
 
+    def notify_file_changes(self, events: list[tuple[str, FileChangeType]]) -> None:
+        """
+        Handles changes to files which were made by Serena itself (e.g. by its editing tools), refreshing all state derived
+        from the files, such that subsequent requests do not operate on a stale state:
+        the ignore spec is re-gathered if an ignore file changed, and the project's associated language server(s), if any,
+        are notified (see :meth:`LanguageServerManager.notify_file_changes`), which invalidates the cached symbols of the files
+        and updates the files' contents in the language servers.
+
+        :param events: pairs of relative file paths and the types of the changes
+        """
+        if any(os.path.basename(rel_path) == ".gitignore" for rel_path, _ in events):
+            log.info("An ignore file was changed; re-gathering the ignore spec")
+            self._gather_ignorespec()
+        if self.language_server_manager:
+            self.language_server_manager.notify_file_changes(events)
+
     def shutdown(self, timeout: float = 2.0) -> None:
         if self.language_server_manager is not None:
             self.language_server_manager.stop_all(save_cache=True, timeout=timeout)
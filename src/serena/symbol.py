@@ -2,13 +2,17 @@ import copy
 import json
 import logging
 import os
+import re
 from abc import ABC, abstractmethod
-from collections import OrderedDict
+from collections import Counter, OrderedDict, defaultdict
 from collections.abc import Callable, Iterable, Iterator, Sequence
+from concurrent.futures import Future, ThreadPoolExecutor
 from contextlib import contextmanager
 from dataclasses import asdict, dataclass
+from enum import Enum
+from fnmatch import fnmatchcase
 from time import perf_counter
-from typing import Any, Generic, Literal, NotRequired, Self, TypedDict, TypeVar
+from typing import Any, Generic, Literal, NotRequired, Optional, Self, TypedDict, TypeVar
 
 from sensai.util.string import ToStringMixin
 
@@ -17,7 +21,7 @@ from solidlsp import SolidLanguageServer, ls_types
 from solidlsp.ls import LSPFileBuffer
 from solidlsp.ls import ReferenceInSymbol as LSPReferenceInSymbol
 from solidlsp.ls_types import Position, SymbolKind, UnifiedSymbolInformation
-from solidlsp.ls_utils import TextUtils
+from solidlsp.ls_utils import FileUtils, TextUtils
 
 from .ls_manager import LanguageServerManager
 from .project import Project
@@ -154,6 +158,13 @@ class NamePathMatcher(ToStringMixin):
      * a relative path like "class/method", which will match any symbol with that name path suffix
      * an absolute name path "/class/method" (absolute name path), which requires an exact match of the full name path within the source file.
     Append an index `[i]` to match a specific overload only, e.g. "MyClass/my_method[1]".
+    Each component of the pattern may contain the glob-style wildcards `*` (any sequence of characters) and `?` (any single
+    character), e.g. "get_*" or "module/*/vpc"; wildcards never match across name path separators.
+    With regex matching, each component is instead a regular expression which must match the entire name
+    (or, with substring matching, any part of it), e.g. "module/.*prod.*". Since `/` separates the components,
+    a regular expression matching `/` must escape it as `\\/`. As in other patterns, a trailing `[i]` (where i consists
+    of digits only) denotes an overload index rather than a character class; to match a character class at the end of
+    a name, use a group, e.g. "item(?:[0])", or a class not consisting of digits only, e.g. "item[0-9]".
     """
 
     class PatternComponent(NamePathComponent):
@@ -168,8 +179,26 @@ class NamePathMatcher(ToStringMixin):
                     overload_idx = int(index_part)
             return cls(name=component_str, overload_idx=overload_idx)
 
-        def matches(self, name_path_component: NamePathComponent, substring_matching: bool) -> bool:
-            if substring_matching:
+        def is_wildcard_pattern(self) -> bool:
+            return "*" in self.name or "?" in self.name
+
+        def matches(
+            self, name_path_component: NamePathComponent, substring_matching: bool, regex: re.Pattern[str] | None = None
+        ) -> bool:
+            """
+            :param name_path_component: the name path component of the symbol
+            :param substring_matching: whether the pattern may match any part of the name
+            :param regex: the compiled regular expression to use (for regex matching); if None, use exact/glob matching
+            """
+            if regex is not None:
+                match = regex.search(name_path_component.name) if substring_matching else regex.fullmatch(name_path_component.name)
+                if match is None:
+                    return False
+            elif self.is_wildcard_pattern():
+                glob_pattern = f"*{self.name}*" if substring_matching else self.name
+                if not fnmatchcase(name_path_component.name, glob_pattern):
+                    return False
+            elif substring_matching:
                 if self.name not in name_path_component.name:
                     return False
             else:
@@ -179,18 +208,39 @@ class NamePathMatcher(ToStringMixin):
                 return False
             return True
 
-    def __init__(self, name_path_pattern: str, substring_matching: bool) -> None:
+    def __init__(self, name_path_pattern: str, substring_matching: bool, regex_matching: bool = False) -> None:
         """
         :param name_path_pattern: the name path expression to match against
         :param substring_matching: whether to use substring matching for the last segment
+        :param regex_matching: whether the components of the pattern are regular expressions (instead of names/glob patterns)
         """
         assert name_path_pattern, "name_path must not be empty"
         self._expr = name_path_pattern
         self._substring_matching = substring_matching
+        self._regex_matching = regex_matching
         self._is_absolute_pattern = name_path_pattern.startswith(NAME_PATH_SEP)
-        self._components = [
-            self.PatternComponent.from_string(x) for x in name_path_pattern.lstrip(NAME_PATH_SEP).rstrip(NAME_PATH_SEP).split(NAME_PATH_SEP)
-        ]
+        self._components = [self.PatternComponent.from_string(x) for x in self._split_components(name_path_pattern, regex_matching)]
+        self._component_regexes: list[re.Pattern[str] | None] = [None] * len(self._components)
+        if regex_matching:
+            try:
+                self._component_regexes = [re.compile(c.name) for c in self._components]
+            except re.error as e:
+                raise ValueError(f"Invalid regular expression in name path pattern '{name_path_pattern}': {e}") from e
+
+    @staticmethod
+    def _split_components(name_path_pattern: str, regex_matching: bool) -> list[str]:
+        """
+        :param name_path_pattern: the name path pattern
+        :param regex_matching: whether the components are regular expressions, in which separators escaped with a backslash
+            are part of the respective component
+        :return: the components of the pattern
+        """
+        if not regex_matching:
+            return name_path_pattern.lstrip(NAME_PATH_SEP).rstrip(NAME_PATH_SEP).split(NAME_PATH_SEP)
+        components = re.split(rf"(?<!\\){NAME_PATH_SEP}", name_path_pattern.lstrip(NAME_PATH_SEP))
+        while len(components) > 1 and components[-1] == "":
+            components.pop()
+        return components
 
     def _tostring_includes(self) -> list[str]:
         return ["_expr"]
@@ -199,13 +249,13 @@ class NamePathMatcher(ToStringMixin):
         return self.matches_reversed_components(symbol.iter_name_path_components_reversed())
 
     def matches_reversed_components(self, components_reversed: Iterator[NamePathComponent]) -> bool:
-        for i, pattern_component in enumerate(reversed(self._components)):
+        for i, (pattern_component, regex) in enumerate(zip(reversed(self._components), reversed(self._component_regexes), strict=True)):
             try:
                 symbol_component = next(components_reversed)
             except StopIteration:
                 return False
             use_substring_matching = self._substring_matching and (i == 0)
-            if not pattern_component.matches(symbol_component, use_substring_matching):
+            if not pattern_component.matches(symbol_component, use_substring_matching, regex=regex):
                 return False
         if self._is_absolute_pattern:
             # ensure that there are no more components in the symbol
@@ -216,6 +266,31 @@ class NamePathMatcher(ToStringMixin):
                 pass
         return True
 
+    def get_top_level_name_query(self, is_top_level_name: Callable[[str], bool]) -> str | None:
+        """
+        Determines a query for searching the names of top-level symbols (e.g. via a `workspace/symbol` request), i.e. a string
+        which is contained in the name of every top-level symbol which matches or contains a matching symbol.
+
+        :param is_top_level_name: a function which determines whether a name pattern can only match the names of top-level
+            symbols (e.g. because of a prefix which is specific to top-level symbols)
+        :return: the query, or None if no such query can be determined (e.g. because matching symbols can be nested in
+            arbitrary top-level symbols)
+        """
+        if self._regex_matching:
+            # a literal part contained in all matching names cannot be reliably determined for a regular expression
+            return None
+        first_component = self._components[0]
+        if not self._is_absolute_pattern:
+            # for a relative pattern, the first component must be known to refer to a top-level symbol;
+            # this does not apply if the first component is subject to substring matching
+            if (self._substring_matching and len(self._components) == 1) or not is_top_level_name(first_component.name):
+                return None
+        if first_component.is_wildcard_pattern():
+            # use the longest literal part of the pattern
+            query = max(re.split(r"[*?]", first_component.name), key=len)
+            return query if query else None
+        return first_component.name
+
 
 class LanguageServerSymbol(Symbol, ToStringMixin):
     def __init__(self, symbol_root_from_ls: UnifiedSymbolInformation) -> None:
@@ -338,11 +413,19 @@ class LanguageServerSymbol(Symbol, ToStringMixin):
 
     @property
     def body(self) -> str | None:
+        return self.get_body()
+
+    def get_body(self, include_leading_comments: bool = False) -> str | None:
+        """
+        :param include_leading_comments: whether to include the block of comment lines (e.g. a doc comment) immediately
+            preceding the symbol
+        :return: the body of the symbol or None if the body was not retrieved
+        """
         body = self.symbol_root.get("body")
         if body is None:
             return None
         else:
-            return body.get_text()
+            return body.get_text(include_leading_comments=include_leading_comments)
 
     def get_name_path(self) -> str:
         """
@@ -387,6 +470,7 @@ class LanguageServerSymbol(Symbol, ToStringMixin):
         substring_matching: bool = False,
         include_kinds: Sequence[SymbolKind] | None = None,
         exclude_kinds: Sequence[SymbolKind] | None = None,
+        regex_matching: bool = False,
     ) -> list[Self]:
         """
         Find all symbols within the symbol's subtree that match the given name path pattern.
@@ -397,9 +481,10 @@ class LanguageServerSymbol(Symbol, ToStringMixin):
         :param include_kinds: an optional sequence of ints representing the LSP symbol kind.
             If provided, only symbols of the given kinds will be included in the result.
         :param exclude_kinds: If provided, symbols of the given kinds will be excluded from the result.
+        :param regex_matching: whether the components of the pattern are regular expressions
         """
         result = []
-        name_path_matcher = NamePathMatcher(name_path_pattern, substring_matching)
+        name_path_matcher = NamePathMatcher(name_path_pattern, substring_matching, regex_matching=regex_matching)
 
         def should_include(s: "LanguageServerSymbol") -> bool:
             if include_kinds is not None and s.symbol_kind not in include_kinds:
@@ -428,6 +513,10 @@ class LanguageServerSymbol(Symbol, ToStringMixin):
         """
         string representation of the symbol kind (name attribute of the `SymbolKind` enum item)
         """
+        tf_kind: NotRequired[str]
+        """
+        the Terraform-specific kind of the symbol (e.g. "resource"), if any; see `UnifiedSymbolInformation`
+        """
         children: NotRequired[list["LanguageServerSymbol.OutputDict"]]
         content_around_reference: NotRequired[str]
         """set by :class:`FindReferencingSymbolsTool` when including surrounding code lines"""
@@ -442,6 +531,7 @@ class LanguageServerSymbol(Symbol, ToStringMixin):
         "body_location",
         "body",
         "kind",
+        "tf_kind",
         "children",
         "content_around_reference",
         "reference_line",
@@ -462,6 +552,7 @@ class LanguageServerSymbol(Symbol, ToStringMixin):
         children_name: bool | None = None,
         relative_path: bool = False,
         child_inclusion_predicate: Callable[[Self], bool] | None = None,
+        leading_comments: bool = False,
     ) -> OutputDict:
         """
         Converts the symbol to a dictionary.
@@ -484,6 +575,8 @@ class LanguageServerSymbol(Symbol, ToStringMixin):
             Relative paths of the symbol's children are always excluded.
         :param child_inclusion_predicate: an optional predicate that decides whether a child symbol
             should be included.
+        :param leading_comments: whether the body (and the body location) shall include the block of comment lines
+            (e.g. a doc comment) immediately preceding the symbol; only applies if the body was retrieved
         :return: a dictionary representation of the symbol
         """
         result: LanguageServerSymbol.OutputDict = {}
@@ -500,6 +593,8 @@ class LanguageServerSymbol(Symbol, ToStringMixin):
 
         if kind:
             result["kind"] = self.symbol_kind_name
+            if "tf_kind" in self.symbol_root:
+                result["tf_kind"] = self.symbol_root["tf_kind"]
 
         if location:
             result["location"] = self.location.to_dict(include_relative_path=relative_path)
@@ -508,10 +603,13 @@ class LanguageServerSymbol(Symbol, ToStringMixin):
 
         if body_location:
             body_start_line, body_end_line = self.get_body_line_numbers()
+            symbol_body = self.symbol_root.get("body")
+            if leading_comments and symbol_body is not None:
+                body_start_line = symbol_body.get_leading_comment_start_line()
             result["body_location"] = {"start_line": body_start_line, "end_line": body_end_line}
 
         if body:
-            result["body"] = self.body
+            result["body"] = self.get_body(include_leading_comments=leading_comments)
 
         if child_inclusion_predicate is None:
             child_inclusion_predicate = lambda s: True
@@ -534,6 +632,7 @@ class LanguageServerSymbol(Symbol, ToStringMixin):
                         children_body=children_body,
                         # all children have the same relative path as the parent
                         relative_path=False,
+                        leading_comments=leading_comments,
                     )
                 )
             return children
@@ -576,7 +675,377 @@ class ReferenceInLanguageServerSymbol(ToStringMixin):
         return self.symbol.location.relative_path
 
 
+class TerraformBlockType(Enum):
+    """
+    Terraform-specific symbol kinds, which are determined by the Terraform language server (see `tf_kind` in
+    `UnifiedSymbolInformation`), since terraform-ls reports all blocks as classes (e.g. `resource "aws_instance" "web"`).
+    """
+
+    RESOURCE = "resource"
+    DATA = "data"
+    EPHEMERAL = "ephemeral"
+    """
+    an ephemeral resource, whose values are not persisted in the state
+    """
+    MODULE = "module"
+    VARIABLE = "variable"
+    OUTPUT = "output"
+    PROVIDER = "provider"
+    TERRAFORM = "terraform"
+    """
+    the `terraform` settings block
+    """
+    LOCALS = "locals"
+    """
+    a `locals` block (containing local values)
+    """
+    LOCAL = "local"
+    """
+    an individual local value (defined within a `locals` block)
+    """
+
+    @classmethod
+    def from_name(cls, name: str) -> Optional["TerraformBlockType"]:
+        """
+        :param name: the name of a Terraform block type (case-insensitive)
+        :return: the block type or None if the name does not correspond to a block type
+        """
+        try:
+            return cls(name.strip().lower())
+        except ValueError:
+            return None
+
+    @classmethod
+    def of_symbol(cls, symbol: LanguageServerSymbol) -> Optional["TerraformBlockType"]:
+        """
+        :param symbol: the symbol
+        :return: the Terraform block type of the symbol or None if the symbol is not a Terraform block (or local value)
+        """
+        tf_kind = symbol.symbol_root.get("tf_kind")
+        if tf_kind is not None:
+            return cls.from_name(tf_kind)
+
+        # symbols without a Terraform-specific kind (e.g. symbols not provided by the Terraform language server)
+        if symbol.symbol_kind != SymbolKind.Class:
+            parent = symbol.get_parent()
+            if parent is not None and parent.symbol_kind == SymbolKind.Class and parent.name == "locals":
+                return cls.LOCAL
+            return None
+        keyword, _, labels = symbol.name.partition(" ")
+        if keyword in (cls.LOCAL.value, cls.TERRAFORM.value) or not labels.startswith('"'):
+            return None
+        return cls.from_name(keyword)
+
+    @staticmethod
+    def get_block_labels(symbol: LanguageServerSymbol) -> list[str]:
+        """
+        :param symbol: a symbol representing a Terraform block
+        :return: the block's labels, e.g. ["aws_instance", "web"] for the block `resource "aws_instance" "web"`
+        """
+        return re.findall(r'"([^"]*)"', symbol.name)
+
+    @classmethod
+    def get_address(cls, symbol: LanguageServerSymbol) -> str | None:
+        """
+        :param symbol: the symbol
+        :return: the Terraform address of the symbol within its module (e.g. `aws_instance.web`, `data.aws_ami.ubuntu`,
+            `var.region`, `local.tags` or `module.vpc`) or None if the symbol is not an addressable Terraform object
+        """
+        block_type = cls.of_symbol(symbol)
+        if block_type == cls.LOCAL:
+            return f"local.{symbol.name}"
+        labels = cls.get_block_labels(symbol)
+        match block_type, len(labels):
+            case cls.RESOURCE, 2:
+                return f"{labels[0]}.{labels[1]}"
+            case cls.DATA, 2:
+                return f"data.{labels[0]}.{labels[1]}"
+            case cls.EPHEMERAL, 2:
+                return f"ephemeral.{labels[0]}.{labels[1]}"
+            case cls.MODULE, 1:
+                return f"module.{labels[0]}"
+            case cls.VARIABLE, 1:
+                return f"var.{labels[0]}"
+            case cls.OUTPUT, 1:
+                return f"output.{labels[0]}"
+        return None
+
+
+class SymbolKindFilter:
+    """
+    Filters symbols by kind, where kinds can be specified as LSP symbol kinds (integers or names such as "Function",
+    case-insensitive) or as Terraform block types (see :class:`TerraformBlockType`).
+    Names denoting both an LSP symbol kind and a block type (e.g. "variable" or "module") refer to the block type
+    for symbols which are Terraform blocks and to the LSP symbol kind for all other symbols.
+    """
+
+    Kind = SymbolKind | TerraformBlockType | tuple[SymbolKind, TerraformBlockType]
+    """a parsed kind, where a tuple represents a name denoting both an LSP symbol kind and a Terraform block type"""
+
+    def __init__(self, include_kinds: Sequence[int | str] = (), exclude_kinds: Sequence[int | str] = ()):
+        """
+        :param include_kinds: the kinds of symbols to include; if empty, all kinds are included
+        :param exclude_kinds: the kinds of symbols to exclude (takes precedence over `include_kinds`)
+        :raises ValueError: if a kind is not recognized
+        """
+        self._include_kinds = [self._parse_kind(k) for k in include_kinds]
+        self._exclude_kinds = [self._parse_kind(k) for k in exclude_kinds]
+
+    @staticmethod
+    def _parse_kind(kind: int | str) -> "SymbolKindFilter.Kind":
+        if isinstance(kind, int):
+            return SymbolKind(kind)
+        kind_str = kind.strip()
+        if kind_str.isdigit():
+            return SymbolKind(int(kind_str))
+        normalised_kind_str = kind_str.replace("_", "").lower()
+        lsp_kind = next((k for k in SymbolKind if k.name.lower() == normalised_kind_str), None)
+        terraform_block_type = TerraformBlockType.from_name(kind_str)
+        if lsp_kind is not None and terraform_block_type is not None:
+            return lsp_kind, terraform_block_type
+        if lsp_kind is not None:
+            return lsp_kind
+        if terraform_block_type is not None:
+            return terraform_block_type
+        raise ValueError(
+            f"Unknown symbol kind '{kind}'; valid kinds are LSP symbol kinds {[k.name for k in SymbolKind]} (or their integer values) "
+            f"and Terraform block types {[t.value for t in TerraformBlockType]}"
+        )
+
+    @staticmethod
+    def _lsp_kinds(kinds: list["SymbolKindFilter.Kind"]) -> list[SymbolKind]:
+        # ambiguous kinds are not included, as they cannot be applied during retrieval
+        return [k for k in kinds if isinstance(k, SymbolKind)]
+
+    def requires_post_filtering(self) -> bool:
+        """
+        :return: whether the filter cannot be fully applied during retrieval (via :meth:`get_lsp_include_kinds`
+            and :meth:`get_lsp_exclude_kinds`), i.e. whether the retrieved symbols must additionally be filtered
+            via :meth:`apply`/:meth:`is_included`
+        """
+        return len(self._lsp_kinds(self._include_kinds)) < len(self._include_kinds) or len(self._lsp_kinds(self._exclude_kinds)) < len(
+            self._exclude_kinds
+        )
+
+    def get_lsp_include_kinds(self) -> list[SymbolKind] | None:
+        """
+        :return: the LSP symbol kinds to which retrieval can be restricted (None if retrieval cannot be restricted)
+        """
+        lsp_include_kinds = self._lsp_kinds(self._include_kinds)
+        if not self._include_kinds or len(lsp_include_kinds) < len(self._include_kinds):
+            return None
+        return lsp_include_kinds
+
+    def get_lsp_exclude_kinds(self) -> list[SymbolKind] | None:
+        """
+        :return: the LSP symbol kinds to exclude during retrieval (None if there are none)
+        """
+        return self._lsp_kinds(self._exclude_kinds) or None
+
+    @staticmethod
+    def _matches(symbol: LanguageServerSymbol, kind: "SymbolKindFilter.Kind") -> bool:
+        if isinstance(kind, SymbolKind):
+            return symbol.symbol_kind == kind
+        if isinstance(kind, TerraformBlockType):
+            return TerraformBlockType.of_symbol(symbol) == kind
+        lsp_kind, terraform_block_type = kind
+        symbol_block_type = TerraformBlockType.of_symbol(symbol)
+        if symbol_block_type is not None:
+            return symbol_block_type == terraform_block_type
+        return symbol.symbol_kind == lsp_kind
+
+    def is_included(self, symbol: LanguageServerSymbol) -> bool:
+        """
+        :param symbol: the symbol to check
+        :return: whether the symbol passes the filter
+        """
+        if self._include_kinds and not any(self._matches(symbol, k) for k in self._include_kinds):
+            return False
+        return not any(self._matches(symbol, k) for k in self._exclude_kinds)
+
+    def apply(self, symbols: list[LanguageServerSymbol]) -> list[LanguageServerSymbol]:
+        """
+        Applies the parts of the filter that could not be applied during retrieval.
+
+        :param symbols: the symbols to filter
+        :return: the symbols passing the filter
+        """
+        if not self.requires_post_filtering():
+            return symbols
+        return [s for s in symbols if self.is_included(s)]
+
+
+class TerraformResourceFilter:
+    """
+    Filters Terraform blocks by resource type and/or provider.
+    Only resource and data blocks have a resource type; the provider is derived from the resource type's prefix
+    (e.g. "aws" for "aws_s3_bucket") or, for provider blocks, from the block's label.
+    """
+
+    def __init__(self, resource_type: str = "", provider: str = ""):
+        """
+        :param resource_type: the resource type (may contain glob-style wildcards, e.g. "aws_s3_*"); if empty, do not filter
+            by resource type
+        :param provider: the provider name (e.g. "aws"); if empty, do not filter by provider
+        """
+        self._resource_type = resource_type.strip()
+        self._provider = provider.strip()
+
+    def is_active(self) -> bool:
+        return bool(self._resource_type or self._provider)
+
+    @staticmethod
+    def get_resource_type(symbol: LanguageServerSymbol) -> str | None:
+        """
+        :param symbol: the symbol
+        :return: the resource type if the symbol is a Terraform resource, data or ephemeral block, None otherwise
+        """
+        if TerraformBlockType.of_symbol(symbol) not in (TerraformBlockType.RESOURCE, TerraformBlockType.DATA, TerraformBlockType.EPHEMERAL):
+            return None
+        labels = TerraformBlockType.get_block_labels(symbol)
+        return labels[0] if labels else None
+
+    @classmethod
+    def get_provider(cls, symbol: LanguageServerSymbol) -> str | None:
+        """
+        :param symbol: the symbol
+        :return: the provider of the Terraform resource, data or provider block, None if the symbol is not such a block
+        """
+        if TerraformBlockType.of_symbol(symbol) == TerraformBlockType.PROVIDER:
+            labels = TerraformBlockType.get_block_labels(symbol)
+            return labels[0] if labels else None
+        resource_type = cls.get_resource_type(symbol)
+        if resource_type is None:
+            return None
+        return resource_type.split("_", 1)[0]
+
+    def is_included(self, symbol: LanguageServerSymbol) -> bool:
+        if self._resource_type:
+            resource_type = self.get_resource_type(symbol)
+            if resource_type is None or not fnmatchcase(resource_type, self._resource_type):
+                return False
+        if self._provider:
+            if self.get_provider(symbol) != self._provider:
+                return False
+        return True
+
+    def apply(self, symbols: list[LanguageServerSymbol]) -> list[LanguageServerSymbol]:
+        """
+        :param symbols: the symbols to filter
+        :return: the symbols passing the filter
+        """
+        if not self.is_active():
+            return symbols
+        return [s for s in symbols if self.is_included(s)]
+
+
+class SymbolStatistics:
+    """
+    Aggregates statistics over the symbols of a set of files: the number of symbols per kind per directory,
+    the number of symbols per file and the number of Terraform blocks per resource type.
+    """
+
+    ROOT_DIRECTORY = "."
+
+    def __init__(self) -> None:
+        self.num_symbols_by_kind_by_dir: dict[str, Counter[str]] = defaultdict(Counter)
+        self.num_symbols_by_file: Counter[str] = Counter()
+        self.num_blocks_by_resource_type: Counter[str] = Counter()
+
+    @staticmethod
+    def get_kind_name(symbol: LanguageServerSymbol) -> str:
+        """
+        :param symbol: the symbol
+        :return: the name of the symbol's kind, where Terraform blocks are identified by their block type (e.g. "resource")
+        """
+        block_type = TerraformBlockType.of_symbol(symbol)
+        if block_type is not None:
+            return block_type.value
+        return symbol.symbol_kind_name
+
+    def add_file(self, relative_path: str, root_symbols: Iterable[LanguageServerSymbol]) -> None:
+        """
+        Adds the symbols of the given file (including all nested symbols) to the statistics.
+
+        :param relative_path: the relative path of the file
+        :param root_symbols: the top-level symbols of the file
+        """
+        directory = os.path.dirname(relative_path).replace(os.path.sep, "/") or self.ROOT_DIRECTORY
+        kind_counter = self.num_symbols_by_kind_by_dir[directory]
+        num_symbols = 0
+
+        def traverse(symbol: LanguageServerSymbol) -> None:
+            nonlocal num_symbols
+            num_symbols += 1
+            kind_counter[self.get_kind_name(symbol)] += 1
+            resource_type = TerraformResourceFilter.get_resource_type(symbol)
+            if resource_type is not None:
+                self.num_blocks_by_resource_type[resource_type] += 1
+            for child in symbol.iter_children():
+                traverse(child)
+
+        for root_symbol in root_symbols:
+            traverse(root_symbol)
+        self.num_symbols_by_file[relative_path] = num_symbols
+
+    def to_dict(self, max_files: int = 10, max_resource_types: int = 10) -> dict[str, Any]:
+        """
+        :param max_files: the maximum number of files to include in the list of files with the most symbols
+        :param max_resource_types: the maximum number of resource types to include
+        :return: a dictionary representation of the statistics
+        """
+        result: dict[str, Any] = {
+            "num_files": len(self.num_symbols_by_file),
+            "num_symbols": sum(self.num_symbols_by_file.values()),
+            "symbols_by_kind_by_dir": {
+                directory: dict(counter.most_common()) for directory, counter in sorted(self.num_symbols_by_kind_by_dir.items())
+            },
+            "largest_files": [
+                {"relative_path": path, "num_symbols": count} for path, count in self.num_symbols_by_file.most_common(max_files)
+            ],
+        }
+        if self.num_blocks_by_resource_type:
+            result["top_resource_types"] = dict(self.num_blocks_by_resource_type.most_common(max_resource_types))
+        return result
+
+
+class SymbolSearchFilePrioritizer:
+    """
+    Orders the files to be searched for symbols matching a name path pattern, such that files whose names hint at
+    matches are searched first (e.g. `variables.tf` for the pattern `variable "region"` or `user_service.py` for `UserService`).
+    """
+
+    def __init__(self, name_path_pattern: str):
+        self._pattern_words = self._get_words(name_path_pattern)
+        self._pattern_compact = re.sub(r"[^a-z0-9]", "", name_path_pattern.lower())
+
+    @staticmethod
+    def _normalize_word(word: str) -> str:
+        # remove a plural suffix, such that e.g. "variables" matches "variable"
+        return word[:-1] if len(word) > 3 and word.endswith("s") else word
+
+    @classmethod
+    def _get_words(cls, s: str) -> set[str]:
+        return {cls._normalize_word(w) for w in re.findall(r"[a-z0-9]+", s.lower())}
+
+    def is_hinted(self, relative_path: str) -> bool:
+        """
+        :param relative_path: the relative path of a file
+        :return: whether the file's name hints at containing symbols matching the pattern
+        """
+        stem = os.path.splitext(os.path.basename(relative_path))[0].lower()
+        if self._get_words(stem) & self._pattern_words:
+            return True
+        stem_compact = self._normalize_word(re.sub(r"[^a-z0-9]", "", stem))
+        return len(stem_compact) > 2 and stem_compact in self._pattern_compact
+
+
 class LanguageServerSymbolRetriever:
+    MAX_PARALLEL_SYMBOL_RETRIEVALS = 8
+    """
+    the maximum number of files for which symbols are retrieved in parallel when searching with a limit on the number of results
+    """
+
     def __init__(self, project: Project) -> None:
         """
         :param project: the project instance
@@ -618,9 +1087,12 @@ class LanguageServerSymbolRetriever:
         return None
 
     def request_info_for_symbol(self, symbol: LanguageServerSymbol) -> str | None:
-        if None in [symbol.relative_path, symbol.line, symbol.column]:
+        if symbol.relative_path is None or symbol.line is None or symbol.column is None:
             return None
-        return self._request_info(relative_file_path=symbol.relative_path, line=symbol.line, column=symbol.column)  # type: ignore[arg-type]
+        ls = self.get_language_server(symbol.relative_path)
+        with ls.open_file(symbol.relative_path) as file_buffer:
+            line, column = ls.get_symbol_info_position(file_buffer.contents, symbol.line, symbol.column)
+            return self._request_info(symbol.relative_path, line, column, file_buffer=file_buffer)
 
     def _get_symbol_info_budget(self) -> float:
         symbol_info_budget = self.project.serena_config.symbol_info_budget
@@ -693,9 +1165,8 @@ class LanguageServerSymbolRetriever:
                         if skipped_due_to_budget == 1:
                             log.debug("Skipping further hover operations due to budget exceeded")
                     else:
-                        line = sym.line
-                        column = sym.column
-                        assert line is not None and column is not None  # for mypy, we filtered invalid symbols above
+                        assert sym.line is not None and sym.column is not None  # for mypy, we filtered invalid symbols above
+                        line, column = ls.get_symbol_info_position(file_buffer.contents, sym.line, sym.column)
                         t0_hover = perf_counter()
                         info = self._request_info(file_path, line, column, file_buffer=file_buffer)
                         hover_spent_seconds += perf_counter() - t0_hover
@@ -739,11 +1210,39 @@ class LanguageServerSymbolRetriever:
         exclude_kinds: Sequence[SymbolKind] | None = None,
         substring_matching: bool = False,
         within_relative_path: str | None = None,
+        max_results: int | None = None,
+        predicate: Callable[[LanguageServerSymbol], bool] | None = None,
+        regex_matching: bool = False,
     ) -> list[LanguageServerSymbol]:
         """
         Finds all symbols that match the given name path pattern (see class :class:`NamePathMatcher` for details),
         optionally limited to a specific file and filtered by kind.
-        """
+
+        :param name_path_pattern: the name path pattern
+        :param include_kinds: the symbol kinds to include (if None, include all kinds)
+        :param exclude_kinds: the symbol kinds to exclude
+        :param substring_matching: whether to use substring matching for the last component of the pattern
+        :param within_relative_path: the relative path of the file or directory to which to restrict the search
+        :param max_results: if given, the search terminates once this number of matching symbols has been found.
+            Files are then searched in parallel, starting with files whose names hint at matches.
+        :param predicate: an additional condition which symbols must satisfy in order to be included
+        :param regex_matching: whether the components of the pattern are regular expressions
+        :return: the matching symbols
+        """
+        # create the matcher upfront in order to fail early for invalid patterns
+        NamePathMatcher(name_path_pattern, substring_matching, regex_matching=regex_matching)
+        if max_results is not None:
+            return self._find_with_limit(
+                name_path_pattern,
+                max_results,
+                include_kinds=include_kinds,
+                exclude_kinds=exclude_kinds,
+                substring_matching=substring_matching,
+                within_relative_path=within_relative_path,
+                predicate=predicate,
+                regex_matching=regex_matching,
+            )
+
         symbols: list[LanguageServerSymbol] = []
         if within_relative_path and os.path.isfile(os.path.join(self.project.project_root, within_relative_path)):
             """
@@ -753,17 +1252,153 @@ class LanguageServerSymbolRetriever:
             """
             lang_servers: Iterable[SolidLanguageServer] = [self._ls_manager.get_language_server(within_relative_path)]
         else:
-            lang_servers = self._ls_manager.iter_language_servers()
+            lang_servers = self._ls_manager.iter_language_servers(symbolic_only=True)
         for lang_server in lang_servers:
-            symbol_roots = lang_server.request_full_symbol_tree(within_relative_path=within_relative_path)
+            ls_name_path_pattern, ls_within_relative_path = self._resolve_symbol_address(
+                lang_server, name_path_pattern, within_relative_path, regex_matching
+            )
+            candidate_files = self._find_candidate_files_via_workspace_symbols(
+                lang_server, ls_name_path_pattern, substring_matching, ls_within_relative_path, regex_matching=regex_matching
+            )
+            if candidate_files is None:
+                symbol_roots = lang_server.request_full_symbol_tree(within_relative_path=ls_within_relative_path)
+            else:
+                symbol_roots = [root for path in candidate_files for root in lang_server.request_document_symbols(path).root_symbols]
             for root in symbol_roots:
                 symbols.extend(
                     LanguageServerSymbol(root).find(
-                        name_path_pattern, include_kinds=include_kinds, exclude_kinds=exclude_kinds, substring_matching=substring_matching
+                        ls_name_path_pattern,
+                        include_kinds=include_kinds,
+                        exclude_kinds=exclude_kinds,
+                        substring_matching=substring_matching,
+                        regex_matching=regex_matching,
                     )
                 )
+        if predicate is not None:
+            symbols = [s for s in symbols if predicate(s)]
         return symbols
 
+    @staticmethod
+    def _resolve_symbol_address(
+        lang_server: SolidLanguageServer, name_path_pattern: str, within_relative_path: str | None, regex_matching: bool
+    ) -> tuple[str, str | None]:
+        """
+        Resolves the given pattern if it is a language-specific symbol address (e.g. a Terraform address such as `var.region`).
+
+        :return: the name path pattern to use and the relative path to which to restrict the search
+        """
+        if regex_matching:
+            return name_path_pattern, within_relative_path
+        resolved_address = lang_server.resolve_symbol_address(name_path_pattern)
+        if resolved_address is None:
+            return name_path_pattern, within_relative_path
+        resolved_name_path_pattern, address_relative_path = resolved_address
+        log.debug(
+            "Resolved address '%s' to name path pattern '%s' in '%s'", name_path_pattern, resolved_name_path_pattern, address_relative_path
+        )
+        # an explicitly given path takes precedence (e.g. the file containing the symbol to edit)
+        return resolved_name_path_pattern, within_relative_path or address_relative_path
+
+    @staticmethod
+    def _find_candidate_files_via_workspace_symbols(
+        lang_server: SolidLanguageServer,
+        name_path_pattern: str,
+        substring_matching: bool,
+        within_relative_path: str | None,
+        regex_matching: bool = False,
+    ) -> list[str] | None:
+        """
+        Determines the files which may contain symbols matching the given pattern via a `workspace/symbol` request
+        (instead of retrieving the symbols of all files), provided that the language server supports this.
+
+        :return: the relative paths of the candidate files or None if they could not be determined, in which case all files
+            must be searched
+        """
+        if within_relative_path and os.path.isfile(os.path.join(lang_server.repository_root_path, within_relative_path)):
+            return None
+        if not lang_server.supports_workspace_symbol_search():
+            return None
+        query = NamePathMatcher(name_path_pattern, substring_matching, regex_matching=regex_matching).get_top_level_name_query(
+            lang_server.is_top_level_symbol_name
+        )
+        if query is None:
+            return None
+        files_with_symbols = lang_server.request_files_with_top_level_symbols(query)
+        if not files_with_symbols:
+            # note: an empty result is not trusted, as the language server may not have finished indexing the workspace
+            return None
+        candidate_files = [
+            path
+            for path in lang_server.iter_source_files(within_relative_path=within_relative_path)
+            if path in files_with_symbols or not lang_server.is_covered_by_workspace_symbols(path)
+        ]
+        log.debug("Workspace symbol search for '%s' yielded %d candidate files", query, len(candidate_files))
+        return candidate_files
+
+    def _find_with_limit(
+        self,
+        name_path_pattern: str,
+        max_results: int,
+        include_kinds: Sequence[SymbolKind] | None,
+        exclude_kinds: Sequence[SymbolKind] | None,
+        substring_matching: bool,
+        within_relative_path: str | None,
+        predicate: Callable[[LanguageServerSymbol], bool] | None,
+        regex_matching: bool,
+    ) -> list[LanguageServerSymbol]:
+        if max_results <= 0:
+            raise ValueError(f"max_results must be positive, got {max_results}")
+
+        # determine the files to search, prioritising files whose names hint at matches
+        if within_relative_path and os.path.isfile(os.path.join(self.project.project_root, within_relative_path)):
+            lang_servers: Iterable[SolidLanguageServer] = [self._ls_manager.get_language_server(within_relative_path)]
+        else:
+            lang_servers = self._ls_manager.iter_language_servers(symbolic_only=True)
+        files: list[tuple[SolidLanguageServer, str, str]] = []
+        for lang_server in lang_servers:
+            ls_name_path_pattern, ls_within_relative_path = self._resolve_symbol_address(
+                lang_server, name_path_pattern, within_relative_path, regex_matching
+            )
+            candidate_files = self._find_candidate_files_via_workspace_symbols(
+                lang_server, ls_name_path_pattern, substring_matching, ls_within_relative_path, regex_matching=regex_matching
+            )
+            if candidate_files is None:
+                candidate_files = list(lang_server.iter_source_files(within_relative_path=ls_within_relative_path))
+            files.extend((lang_server, path, ls_name_path_pattern) for path in candidate_files)
+        prioritizer = SymbolSearchFilePrioritizer(name_path_pattern)
+        files.sort(key=lambda f: 0 if prioritizer.is_hinted(f[1]) else 1)
+
+        def find_in_file(lang_server: SolidLanguageServer, relative_path: str, ls_name_path_pattern: str) -> list[LanguageServerSymbol]:
+            result = []
+            for root in lang_server.request_document_symbols(relative_path).root_symbols:
+                for symbol in LanguageServerSymbol(root).find(
+                    ls_name_path_pattern,
+                    include_kinds=include_kinds,
+                    exclude_kinds=exclude_kinds,
+                    substring_matching=substring_matching,
+                    regex_matching=regex_matching,
+                ):
+                    if predicate is None or predicate(symbol):
+                        result.append(symbol)
+            return result
+
+        # retrieve the symbols in parallel, terminating early once sufficiently many matches have been found.
+        # The results are collected in the order of the files (rather than in the order of completion), such that
+        # the matches which are returned do not depend on the timing of the retrievals.
+        symbols: list[LanguageServerSymbol] = []
+        executor = ThreadPoolExecutor(max_workers=self.MAX_PARALLEL_SYMBOL_RETRIEVALS, thread_name_prefix="SymbolRetrieval")
+        try:
+            futures: list[Future] = [executor.submit(find_in_file, *file) for file in files]
+            for future in futures:
+                symbols.extend(future.result())
+                if len(symbols) >= max_results:
+                    break
+        finally:
+            executor.shutdown(wait=True, cancel_futures=True)
+        log.debug("Found %d matches for '%s' in %d files (with limit %d)", len(symbols), name_path_pattern, len(files), max_results)
+
+        return symbols[:max_results]
+
     def find_unique(
         self,
         name_path_pattern: str,
@@ -771,7 +1406,20 @@ class LanguageServerSymbolRetriever:
         exclude_kinds: Sequence[SymbolKind] | None = None,
         substring_matching: bool = False,
         within_relative_path: str | None = None,
+        occurrence_index: int | None = None,
     ) -> LanguageServerSymbol:
+        """
+        Finds the unique symbol matching the given name path pattern.
+
+        :param name_path_pattern: the name path pattern
+        :param include_kinds: the symbol kinds to include (if None, include all kinds)
+        :param exclude_kinds: the symbol kinds to exclude
+        :param substring_matching: whether to use substring matching for the last component of the pattern
+        :param within_relative_path: the relative path of the file or directory to which to restrict the search
+        :param occurrence_index: the index of the symbol to select among multiple matching symbols
+            (as listed in the error message that is raised if the match is ambiguous); if None, the match must be unique
+        :return: the symbol
+        """
         symbol_candidates = self.find(
             name_path_pattern,
             include_kinds=include_kinds,
@@ -779,6 +1427,12 @@ class LanguageServerSymbolRetriever:
             substring_matching=substring_matching,
             within_relative_path=within_relative_path,
         )
+        if occurrence_index is not None and len(symbol_candidates) > 0:
+            if not 0 <= occurrence_index < len(symbol_candidates):
+                raise ValueError(
+                    f"Invalid occurrence_index {occurrence_index}: found {len(symbol_candidates)} symbols matching '{name_path_pattern}'"
+                )
+            return symbol_candidates[occurrence_index]
         if len(symbol_candidates) == 1:
             return symbol_candidates[0]
         elif len(symbol_candidates) == 0:
@@ -789,11 +1443,14 @@ class LanguageServerSymbolRetriever:
             exact_matches = [s for s in symbol_candidates if s.get_name_path() == name_path_pattern]
             if len(exact_matches) == 1:
                 return exact_matches[0]
-            # otherwise, raise an error
-            include_rel_path = within_relative_path is not None
+            # otherwise, raise an error listing the candidates, such that the caller can select one via its occurrence index
+            candidates = [
+                {"occurrence_index": i, **s.to_dict(kind=True, location=True, relative_path=True)} for i, s in enumerate(symbol_candidates)
+            ]
             raise ValueError(
                 f"Found multiple {len(symbol_candidates)} symbols matching '{name_path_pattern}'. "
-                "They are: \n" + json.dumps([s.to_dict(kind=True, relative_path=include_rel_path) for s in symbol_candidates], indent=2)
+                "Pass the occurrence_index of the intended symbol (or use a more specific name path) to resolve the ambiguity. "
+                "They are: \n" + json.dumps(candidates, indent=2)
             )
 
     def find_by_location(self, location: LanguageServerSymbolLocation) -> LanguageServerSymbol | None:
@@ -984,15 +1641,59 @@ class LanguageServerSymbolRetriever:
         :param start_line: the first 0-based line to include.
         :param end_line: the last 0-based line to include. `-1` means until end of file.
         :param min_severity: minimum LSP severity to include, where 1=Error, 2=Warning, 3=Information, 4=Hint.
-        :return: the diagnostics matching the requested constraints.
+        :return: the diagnostics matching the requested constraints (of all language servers supporting the file,
+            e.g. including the diagnostics of complementing linters).
+        """
+        diagnostics: list[ls_types.Diagnostic] = []
+        for lang_server in self._ls_manager.get_language_servers(relative_file_path):
+            diagnostics.extend(
+                lang_server.request_text_document_diagnostics(
+                    relative_file_path=relative_file_path,
+                    start_line=start_line,
+                    end_line=end_line,
+                    min_severity=min_severity,
+                )
+            )
+        return diagnostics
+
+    def get_diagnostics(
+        self,
+        within_relative_path: str | None = None,
+        min_severity: int = 4,
+        max_files: int = -1,
+    ) -> tuple[dict[str, list[ls_types.Diagnostic]], int, list[str]]:
         """
-        lang_server = self.get_language_server(relative_file_path)
-        return lang_server.request_text_document_diagnostics(
-            relative_file_path=relative_file_path,
-            start_line=start_line,
-            end_line=end_line,
-            min_severity=min_severity,
-        )
+        Get the diagnostics of a file or of all source files within a directory (or the entire project).
+        For directories, the diagnostics published by the language servers for the source files are collected
+        (see `SolidLanguageServer.request_published_diagnostics_for_files`).
+
+        :param within_relative_path: the relative path of the file or directory; if None, the entire project is considered.
+        :param min_severity: minimum LSP severity to include, where 1=Error, 2=Warning, 3=Information, 4=Hint.
+        :param max_files: the maximum number of files (per language server) to check in the case of a directory; -1 for no limit
+        :return: a triple `(diagnostics_by_file, num_skipped_files, timed_out_files)`, where `diagnostics_by_file` maps relative
+            file paths to the files' diagnostics (omitting files without diagnostics), `num_skipped_files` is the number of files
+            which were not checked due to the limit and `timed_out_files` are the relative paths of the files for which
+            the language servers did not publish diagnostics in time
+        """
+        if within_relative_path and os.path.isfile(os.path.join(self.project.project_root, within_relative_path)):
+            diagnostics = self.get_file_diagnostics(within_relative_path, min_severity=min_severity)
+            return ({within_relative_path: diagnostics} if diagnostics else {}), 0, []
+
+        diagnostics_by_file: dict[str, list[ls_types.Diagnostic]] = defaultdict(list)
+        num_skipped_files = 0
+        timed_out_files: list[str] = []
+        for lang_server in self._ls_manager.iter_language_servers():
+            relative_paths = list(lang_server.iter_source_files(within_relative_path=within_relative_path))
+            if 0 <= max_files < len(relative_paths):
+                num_skipped_files += len(relative_paths) - max_files
+                relative_paths = relative_paths[:max_files]
+            ls_diagnostics_by_file, ls_timed_out_files = lang_server.request_published_diagnostics_for_files(
+                relative_paths, min_severity=min_severity
+            )
+            for relative_path, diagnostics in ls_diagnostics_by_file.items():
+                diagnostics_by_file[relative_path].extend(diagnostics)
+            timed_out_files.extend(ls_timed_out_files)
+        return dict(diagnostics_by_file), num_skipped_files, timed_out_files
 
     @staticmethod
     def _symbol_identity(symbol: LanguageServerSymbol) -> tuple[str | None, int | None, int | None, str]:
@@ -1135,6 +1836,25 @@ class LanguageServerSymbolRetriever:
                 result[current_symbol] = diagnostics
         return result
 
+    def get_symbol_statistics(self, within_relative_path: str | None = None) -> SymbolStatistics:
+        """
+        Computes statistics over all symbols in the project (or within the given path), based on the (cached)
+        document symbols of all source files.
+
+        :param within_relative_path: the relative path of the file or directory to which to restrict the statistics
+        :return: the statistics
+        """
+        statistics = SymbolStatistics()
+        if within_relative_path and os.path.isfile(os.path.join(self.project.project_root, within_relative_path)):
+            lang_servers: Iterable[SolidLanguageServer] = [self._ls_manager.get_language_server(within_relative_path)]
+        else:
+            lang_servers = self._ls_manager.iter_language_servers(symbolic_only=True)
+        for lang_server in lang_servers:
+            for relative_path in lang_server.iter_source_files(within_relative_path=within_relative_path):
+                root_symbols = lang_server.request_document_symbols(relative_path).root_symbols
+                statistics.add_file(relative_path, [LanguageServerSymbol(root) for root in root_symbols])
+        return statistics
+
     def get_symbol_overview(self, relative_path: str) -> dict[str, list[LanguageServerSymbol]]:
         """
         :param relative_path: the path of the file for which to get the symbol overview
@@ -1174,7 +1894,7 @@ class JetBrainsSymbol(Symbol):
         if self._cached_file_content is None:
             path = os.path.join(self._project.project_root, self.get_relative_path())
             with open(path, encoding=self._project.project_config.encoding) as f:
-                self._cached_file_content = f.read()
+                self._cached_file_content = f.read().removeprefix(FileUtils.UTF8_BOM)
         return self._cached_file_content
 
     def is_position_in_file_available(self) -> bool:
@@ -1,4 +1,5 @@
 import concurrent.futures
+import functools
 import threading
 import time
 from collections.abc import Callable
@@ -28,29 +29,37 @@ class TaskExecutor:
         self._task_executor_thread.start()
         self._task_executor_task_index = 1
         self._task_executor_current_task: TaskExecutor.Task | None = None
+        self._task_executor_concurrent_tasks: list[TaskExecutor.Task] = []
+        """the concurrent tasks which have been started and may still be running"""
         self._task_executor_last_executed_task_info: TaskExecutor.TaskInfo | None = None
         self._task_completion_callback = task_completion_callback
 
     class Task(ToStringMixin, Generic[T]):
-        def __init__(self, function: Callable[[], T], name: str, logged: bool = True, timeout: float | None = None):
+        def __init__(
+            self, function: Callable[[], T], name: str, logged: bool = True, timeout: float | None = None, concurrent: bool = False
+        ):
             """
             :param function: the function representing the task to execute
             :param name: the name of the task
             :param logged: whether to log management of the task; if False, only errors will be logged
             :param timeout: the maximum time to wait for task completion in seconds, or None to wait indefinitely
+            :param concurrent: whether the task may run concurrently with other concurrent tasks
             """
             self.name = name
-            self.future: concurrent.futures.Future = concurrent.futures.Future()
+            self.future: Future = Future()
             self.logged = logged
             self.timeout = timeout
+            self.concurrent = concurrent
             self._function = function
 
         def _tostring_includes(self) -> list[str]:
             return ["name"]
 
-        def start(self) -> None:
+        def start(self, on_finished: Callable[[], None] | None = None) -> None:
             """
             Executes the task in a separate thread, setting the result or exception on the future.
+
+            :param on_finished: a function to call in the task's thread after the task function has returned or raised
             """
 
             def run_task() -> None:
@@ -67,6 +76,9 @@ class TaskExecutor:
                     if not self.future.done():
                         log.error(f"Error during execution of {self.name}: {e}", exc_info=e)
                         self.future.set_exception(e)
+                finally:
+                    if on_finished is not None:
+                        on_finished()
 
             thread = Thread(target=run_task, name=self.name)
             thread.start()
@@ -123,17 +135,32 @@ class TaskExecutor:
 
     def _process_task_queue(self) -> None:
         while True:
-            # obtain task from the queue
+            # obtain the next task (leaving it in the queue until it can be started)
             task: TaskExecutor.Task | None = None
             with self._task_executor_lock:
                 if len(self._task_executor_queue) > 0:
-                    task = self._task_executor_queue.pop(0)
+                    task = self._task_executor_queue[0]
             if task is None:
                 time.sleep(0.1)
                 continue
 
+            # concurrent tasks are started without waiting for their completion, such that subsequent concurrent tasks
+            # can run alongside them
+            if task.concurrent:
+                with self._task_executor_lock:
+                    self._task_executor_queue.pop(0)
+                    self._task_executor_concurrent_tasks.append(task)
+                if task.logged:
+                    log.info("Starting concurrent execution of %s", task.name)
+                task.start(on_finished=functools.partial(self._on_concurrent_task_finished, task))
+                continue
+
+            # a non-concurrent task must not run alongside any other task
+            self._wait_for_concurrent_tasks()
+
             # start task execution asynchronously
             with self._task_executor_lock:
+                self._task_executor_queue.pop(0)
                 self._task_executor_current_task = task
             if task.logged:
                 log.info("Starting execution of %s", task.name)
@@ -145,15 +172,40 @@ class TaskExecutor:
                 log.warning("Task %s did not complete within the timeout of %s seconds; continuing ...", task.name, task.timeout)
             with self._task_executor_lock:
                 self._task_executor_current_task = None
-                if task.logged:
-                    self._task_executor_last_executed_task_info = self.TaskInfo.from_task(task, is_running=False)
+            self._on_task_completed(task)
 
-            # call the task completion callback if provided
-            if self._task_completion_callback is not None:
-                try:
-                    self._task_completion_callback()
-                except Exception as e:
-                    log.error(f"Error in task completion callback after executing {task.name}: {e}", exc_info=e)
+    def _wait_for_concurrent_tasks(self) -> None:
+        with self._task_executor_lock:
+            running_tasks = list(self._task_executor_concurrent_tasks)
+        for task in running_tasks:
+            if not task.wait_until_done():
+                log.warning("Concurrent task %s did not complete within the timeout of %s seconds; continuing ...", task.name, task.timeout)
+            with self._task_executor_lock:
+                if task not in self._task_executor_concurrent_tasks:
+                    # completion was already handled in the task's thread
+                    continue
+                self._task_executor_concurrent_tasks.remove(task)
+            self._on_task_completed(task)
+
+    def _on_concurrent_task_finished(self, task: "TaskExecutor.Task") -> None:
+        with self._task_executor_lock:
+            if task not in self._task_executor_concurrent_tasks:
+                # completion was already handled while waiting for the task (e.g. after a timeout)
+                return
+            self._task_executor_concurrent_tasks.remove(task)
+        self._on_task_completed(task)
+
+    def _on_task_completed(self, task: "TaskExecutor.Task") -> None:
+        with self._task_executor_lock:
+            if task.logged:
+                self._task_executor_last_executed_task_info = self.TaskInfo.from_task(task, is_running=False)
+
+        # call the task completion callback if provided
+        if self._task_completion_callback is not None:
+            try:
+                self._task_completion_callback()
+            except Exception as e:
+                log.error(f"Error in task completion callback after executing {task.name}: {e}", exc_info=e)
 
     @dataclass
     class TaskInfo:
@@ -184,10 +236,13 @@ class TaskExecutor:
         Gets the list of tasks currently running or queued for execution.
         The function returns a list of thread-safe TaskInfo objects (specifically created for the caller).
 
-        :return: the list of tasks in the execution order (running task first)
+        :return: the list of tasks in the execution order (running tasks first)
         """
         tasks = []
         with self._task_executor_lock:
+            for task in self._task_executor_concurrent_tasks:
+                if not task.is_done():
+                    tasks.append(self.TaskInfo.from_task(task, True))
             if self._task_executor_current_task is not None:
                 tasks.append(self.TaskInfo.from_task(self._task_executor_current_task, True))
             for task in self._task_executor_queue:
@@ -195,15 +250,21 @@ class TaskExecutor:
                     tasks.append(self.TaskInfo.from_task(task, False))
         return tasks
 
-    def issue_task(self, task: Callable[[], T], name: str | None = None, logged: bool = True, timeout: float | None = None) -> Task[T]:
+    def issue_task(
+        self, task: Callable[[], T], name: str | None = None, logged: bool = True, timeout: float | None = None, concurrent: bool = False
+    ) -> Task[T]:
         """
         Issue a task to the executor for asynchronous execution.
-        It is ensured that tasks are executed in the order they are issued, one after another.
+        It is ensured that tasks are started in the order they are issued and that a non-concurrent task runs only
+        after all previously issued tasks have completed (and before any subsequently issued task is started).
+        Consecutive concurrent tasks, however, may run concurrently.
 
         :param task: the task to execute
         :param name: the name of the task for logging purposes; if None, use the task function's name
         :param logged: whether to log management of the task; if False, only errors will be logged
         :param timeout: the maximum time to wait for task completion in seconds, or None to wait indefinitely
+        :param concurrent: whether the task may run concurrently with other concurrent tasks (i.e. whether it neither
+            modifies nor depends on state that is modified by other tasks)
         :return: the task object, through which the task's future result can be accessed
         """
         with self._task_executor_lock:
@@ -215,7 +276,7 @@ class TaskExecutor:
             task_name = f"{task_prefix_name}:{name or getattr(task, '__name__', 'task')}"
             if logged:
                 log.info(f"Scheduling {task_name}")
-            task_obj = self.Task(function=task, name=task_name, logged=logged, timeout=timeout)
+            task_obj = self.Task(function=task, name=task_name, logged=logged, timeout=timeout, concurrent=concurrent)
             self._task_executor_queue.append(task_obj)
             return task_obj
 
@@ -4,15 +4,37 @@ Tools supporting the execution of (external) commands
 
 import os.path
 
-from serena.tools import Tool, ToolMarkerCanEdit
-from serena.util.shell import execute_shell_command
+from serena.config.serena_config import SerenaConfig
+from serena.tools import Tool, ToolMarkerCanEdit, ToolMarkerOpenWorld, ToolMarkerOptional
+from serena.util.file_system import is_path_within_directory
+from serena.util.git import get_changed_files, get_current_branch, get_default_branch, get_remote_url
+from serena.util.policy_check import DEFAULT_POLICY_DIR, parse_conftest_output
+from serena.util.pre_commit import PRE_COMMIT_CONFIG_FILENAME, PreCommitHookResult, parse_pre_commit_output
+from serena.util.shell import ShellEnvironmentFilter, execute_command, execute_shell_command
 
 
-class ExecuteShellCommandTool(Tool, ToolMarkerCanEdit):
+def _create_command_env(serena_config: SerenaConfig) -> dict[str, str]:
+    """
+    :param serena_config: the Serena configuration
+    :return: the environment for external commands, withholding variables that shall not be exposed to the command
+    """
+    env_filter = ShellEnvironmentFilter(serena_config.shell_env_allowed_patterns, serena_config.shell_env_denied_patterns)
+    return env_filter.apply(os.environ)
+
+
+class ExecuteShellCommandTool(Tool, ToolMarkerCanEdit, ToolMarkerOpenWorld):
     """
     Executes a shell command.
     """
 
+    def _is_allowed_cwd(self, path: str) -> bool:
+        """
+        :param path: the absolute path of the working directory
+        :return: whether the path lies within the project root or one of the configured additional directories
+        """
+        allowed_dirs = [self.get_project_root(), *self.agent.serena_config.shell_command_allowed_cwd_paths]
+        return any(is_path_within_directory(path, d) for d in allowed_dirs)
+
     def apply(
         self,
         command: str,
@@ -28,13 +50,15 @@ class ExecuteShellCommandTool(Tool, ToolMarkerCanEdit):
           * processes that require user interaction.
 
         :param command: the shell command to execute
-        :param cwd: the working directory to execute the command in. If None, the project root will be used.
+        :param cwd: the working directory to execute the command in (absolute or relative to the project root); it must be within
+            the project. If None, the project root will be used.
         :param capture_stderr: whether to capture and return stderr output
         :param max_answer_chars: if the output is longer than this number of characters,
             no content will be returned. -1 means using the default value, don't adjust unless there is no other way to get the content
             required for the task.
         :return: a JSON object containing the command's stdout and optionally stderr output
         """
+        # determine the working directory, which must lie within the project or an explicitly allowed directory
         if cwd is None:
             _cwd = self.get_project_root()
         else:
@@ -42,11 +66,285 @@ class ExecuteShellCommandTool(Tool, ToolMarkerCanEdit):
                 _cwd = cwd
             else:
                 _cwd = os.path.join(self.get_project_root(), cwd)
-                if not os.path.isdir(_cwd):
-                    raise FileNotFoundError(
-                        f"Specified a relative working directory ({cwd}), but the resulting path is not a directory: {_cwd}"
-                    )
+            if not self._is_allowed_cwd(_cwd):
+                raise ValueError(
+                    f"The working directory {cwd} is outside of the project root and not among the allowed directories "
+                    f"{self.agent.serena_config.shell_command_allowed_cwd_paths}."
+                )
+            if not os.path.isdir(_cwd):
+                raise FileNotFoundError(f"Specified a working directory ({cwd}), but the resulting path is not a directory: {_cwd}")
 
-        result = execute_shell_command(command, cwd=_cwd, capture_stderr=capture_stderr)
+        result = execute_shell_command(command, cwd=_cwd, capture_stderr=capture_stderr, env=_create_command_env(self.agent.serena_config))
         result = result.model_dump_json()
         return self._limit_length(result, max_answer_chars)
+
+
+class RunPreCommitTool(Tool, ToolMarkerCanEdit, ToolMarkerOpenWorld, ToolMarkerOptional):
+    """
+    Runs the project's pre-commit hooks (e.g. formatters and linters) on changed files.
+    """
+
+    # noinspection PyDefaultArgument
+    def apply(
+        self,
+        hook_ids: list[str] = [],  # noqa: B006
+        relative_paths: list[str] = [],  # noqa: B006
+        all_files: bool = False,
+        max_answer_chars: int = -1,
+    ) -> str:
+        """
+        Runs the hooks configured in the project's .pre-commit-config.yaml, such that your edits pass the same checks
+        as edits made by humans. By default, all hooks are run on the files which were changed (compared to HEAD) or added.
+        Note that some hooks (e.g. formatters) modify files; such hooks are reported as failed, and the files must be re-read.
+
+        :param hook_ids: the ids of the hooks to run; if empty, all configured hooks are run
+        :param relative_paths: the relative paths of the files to check; if empty, the changed and untracked files are checked
+        :param all_files: whether to check all files in the repository instead (ignoring `relative_paths`)
+        :param max_answer_chars: max result length; -1 for default
+        :return: a JSON object indicating overall success along with the results of the individual hooks (including the output
+            of failed hooks)
+        """
+        project_root = self.get_project_root()
+        if not os.path.exists(os.path.join(project_root, PRE_COMMIT_CONFIG_FILENAME)):
+            raise FileNotFoundError(f"The project does not contain a pre-commit configuration ({PRE_COMMIT_CONFIG_FILENAME})")
+
+        for relative_path in relative_paths:
+            if relative_path.startswith("-"):
+                raise ValueError(f"Invalid relative path '{relative_path}': paths must not start with '-'")
+            self.project.validate_relative_path(relative_path)
+
+        file_args: list[str]
+        if all_files:
+            file_args = ["--all-files"]
+        else:
+            files = relative_paths or get_changed_files(project_root)
+            if not files:
+                return "No changed files to check."
+            file_args = ["--files", *files]
+
+        env = _create_command_env(self.agent.serena_config)
+        hook_results: list[PreCommitHookResult] = []
+        for hook_id in hook_ids or [None]:
+            command = ["pre-commit", "run", "--color", "never"]
+            if hook_id is not None:
+                command.append(hook_id)
+            result = execute_command(command + file_args, cwd=project_root, capture_stderr=True, env=env)
+            parsed_results = parse_pre_commit_output(result.stdout)
+            if result.return_code != 0 and not parsed_results:
+                raise RuntimeError(
+                    f"pre-commit failed with return code {result.return_code} (is pre-commit installed?):\n{result.stdout}\n{result.stderr}"
+                )
+            hook_results.extend(parsed_results)
+
+        # hooks may have modified files
+        if any(r.files_modified for r in hook_results):
+            self.project.ls_sync_file_system_changes()
+
+        result_dict = {
+            "success": not any(r.is_failed() for r in hook_results),
+            "hooks": [r.to_dict() for r in hook_results],
+        }
+        return self._limit_length(self._to_json(result_dict), max_answer_chars)
+
+
+class CheckPoliciesTool(Tool, ToolMarkerOpenWorld, ToolMarkerOptional):
+    """
+    Checks files (e.g. Terraform configurations or plan JSON) against policy-as-code rules using conftest (Rego/OPA policies).
+    """
+
+    # noinspection PyDefaultArgument
+    def apply(
+        self,
+        relative_paths: list[str],
+        policy_dir: str = "",
+        namespaces: list[str] = [],  # noqa: B006
+        parser: str = "",
+        max_answer_chars: int = -1,
+    ) -> str:
+        """
+        Checks the given files against the organisation's policies (guardrails) with conftest, returning the violations
+        with the violated rules and messages. Use it to check your changes before proposing them, e.g. on the .tf files
+        you edited or on a plan in JSON format (`terraform show -json <planfile>`).
+
+        :param relative_paths: the relative paths of the files or directories to check
+        :param policy_dir: the relative path of the directory containing the Rego policies; if empty, conftest's default
+            directory `policy` is used
+        :param namespaces: the policy namespaces (Rego packages) to apply; if empty, the policies of all namespaces are applied
+        :param parser: the parser to use for the input files (e.g. "hcl2" or "json"); if empty, it is determined by conftest
+            based on the file extension
+        :param max_answer_chars: max result length; -1 for default
+        :return: a JSON object indicating whether the check passed (no failures) along with the violations (failures and warnings)
+        """
+        if not relative_paths:
+            raise ValueError("At least one file or directory to check must be provided")
+        project_root = self.get_project_root()
+        policy_dir = policy_dir or DEFAULT_POLICY_DIR
+        for path in [policy_dir, *relative_paths]:
+            if not is_path_within_directory(os.path.join(project_root, path), project_root):
+                raise ValueError(f"The path {path} is outside of the project root")
+        if not os.path.isdir(os.path.join(project_root, policy_dir)):
+            raise FileNotFoundError(f"Policy directory not found: {policy_dir}")
+
+        command = ["conftest", "test", "--output", "json", "--policy", policy_dir]
+        if namespaces:
+            for namespace in namespaces:
+                command.extend(["--namespace", namespace])
+        else:
+            command.append("--all-namespaces")
+        if parser:
+            command.extend(["--parser", parser])
+        # the paths are separated from the options, such that they cannot be interpreted as options
+        command.extend(["--", *relative_paths])
+
+        # note: conftest returns a non-zero return code if there are policy failures
+        result = execute_command(command, cwd=project_root, capture_stderr=True, env=_create_command_env(self.agent.serena_config))
+        try:
+            check_result = parse_conftest_output(result.stdout)
+        except ValueError:
+            raise RuntimeError(
+                f"conftest failed with return code {result.return_code} (is conftest installed?):\n{result.stdout}\n{result.stderr}"
+            ) from None
+        return self._limit_length(self._to_json(check_result.to_dict()), max_answer_chars)
+
+
+class RunTerragruntTool(Tool, ToolMarkerCanEdit, ToolMarkerOpenWorld, ToolMarkerOptional):
+    """
+    Runs a Terragrunt command which does not modify infrastructure, e.g. in order to validate or plan a Terragrunt unit or stack.
+    Note that commands may nevertheless write files (e.g. `init` writes the `.terraform` directory and the dependency lock file)
+    and that Terragrunt hooks may run arbitrary commands.
+    """
+
+    ALLOWED_COMMANDS = (
+        "graph-dependencies",
+        "init",
+        "output",
+        "plan",
+        "providers",
+        "render-json",
+        "show",
+        "validate",
+        "validate-inputs",
+    )
+    """
+    the Terragrunt/Terraform commands which may be run; commands which modify infrastructure (e.g. apply, destroy)
+    are deliberately not supported
+    """
+
+    REJECTED_ARG_PREFIXES = ("-out", "--out", "--terragrunt-")
+    """
+    the prefixes of arguments which are rejected, as they write output files (possibly outside of the project, e.g. `-out=<path>`
+    or `--out=<path>`) or change Terragrunt's own behaviour (`--terragrunt-*`)
+    """
+
+    # noinspection PyDefaultArgument
+    def apply(
+        self,
+        command: str,
+        relative_path: str = ".",
+        args: list[str] = [],  # noqa: B006
+        all_units: bool = False,
+        max_answer_chars: int = -1,
+    ) -> str:
+        """
+        Runs a Terragrunt command in the given directory (a unit containing a terragrunt.hcl file or, with `all_units`,
+        a stack containing several units), returning its output. Commands run non-interactively.
+        Only commands which do not modify infrastructure are supported; never try to apply or destroy infrastructure by other means.
+        Note that "init" writes files (the .terraform directory and the dependency lock file .terraform.lock.hcl).
+
+        :param command: the command to run; one of "init", "validate", "plan", "output", "show", "providers", "render-json",
+            "validate-inputs" and "graph-dependencies"
+        :param relative_path: the relative path of the directory in which to run the command
+        :param args: further arguments to pass to the command (e.g. ["-var-file=prod.tfvars"]); arguments specifying output
+            files (e.g. "-out=...") and Terragrunt flags ("--terragrunt-...") are not supported
+        :param all_units: whether to run the command for all units in the directory and its subdirectories (`run-all`)
+        :param max_answer_chars: max result length; -1 for default
+        :return: a JSON object containing the command's return code, stdout and stderr output
+        """
+        if command not in self.ALLOWED_COMMANDS:
+            raise ValueError(f"Unsupported command '{command}'; supported commands: {', '.join(self.ALLOWED_COMMANDS)}")
+        rejected_args = [arg for arg in args if arg.startswith(self.REJECTED_ARG_PREFIXES)]
+        if rejected_args:
+            raise ValueError(
+                f"Unsupported arguments {rejected_args}: arguments specifying output files and Terragrunt flags are not supported"
+            )
+        project_root = self.get_project_root()
+        cwd = os.path.join(project_root, relative_path)
+        if not is_path_within_directory(cwd, project_root):
+            raise ValueError(f"The directory {relative_path} is outside of the project root")
+        if not os.path.isdir(cwd):
+            raise FileNotFoundError(f"Directory not found: {relative_path}")
+
+        terragrunt_command = ["terragrunt", *(["run-all"] if all_units else []), command, *args]
+        env = _create_command_env(self.agent.serena_config)
+        env["TG_NON_INTERACTIVE"] = "true"
+        env["TERRAGRUNT_NON_INTERACTIVE"] = "true"
+        result = execute_command(terragrunt_command, cwd=cwd, capture_stderr=True, env=env)
+        return self._limit_length(result.model_dump_json(), max_answer_chars)
+
+
+class CreatePullRequestTool(Tool, ToolMarkerCanEdit, ToolMarkerOpenWorld, ToolMarkerOptional):
+    """
+    Pushes the current branch and opens a pull request (GitHub) or merge request (GitLab) for it.
+    """
+
+    REMOTE = "origin"
+
+    def apply(
+        self,
+        title: str,
+        body: str = "",
+        base_branch: str = "",
+        draft: bool = False,
+        provider: str = "",
+    ) -> str:
+        """
+        Pushes the current branch to the remote and opens a pull request for it, using the GitHub CLI (gh) or,
+        for GitLab, the GitLab CLI (glab), which must be installed and authenticated.
+        Only committed changes are included, so commit your changes first.
+        Call this only if the user asked you to open a pull request.
+
+        :param title: the title of the pull request (a concise summary of the changes)
+        :param body: the description of the pull request (Markdown); if empty, a description is generated from the session summary
+            (see save_session_summary) and the list of files edited in this session
+        :param base_branch: the branch into which the changes shall be merged; if empty, the repository's default branch is used
+        :param draft: whether to open the pull request as a draft
+        :param provider: "github" or "gitlab"; if empty, the provider is determined from the URL of the remote
+        :return: the output of the CLI (containing the URL of the pull request)
+        """
+        project_root = self.get_project_root()
+        branch = get_current_branch(project_root)
+        if branch is None:
+            raise ValueError("HEAD is detached; check out a branch containing the changes first")
+        if branch in (base_branch, get_default_branch(project_root, self.REMOTE)):
+            raise ValueError(
+                f"The current branch '{branch}' is the base or default branch; create a separate branch for the changes first"
+            )
+        if not provider:
+            provider = "gitlab" if "gitlab" in get_remote_url(project_root, self.REMOTE) else "github"
+        if not body:
+            body = self.agent.get_session_record().create_change_summary()
+
+        command: list[str]
+        match provider.strip().lower():
+            case "github":
+                command = ["gh", "pr", "create", "--title", title, "--body", body, "--head", branch]
+                if base_branch:
+                    command.extend(["--base", base_branch])
+            case "gitlab":
+                command = ["glab", "mr", "create", "--title", title, "--description", body, "--source-branch", branch, "--yes"]
+                if base_branch:
+                    command.extend(["--target-branch", base_branch])
+            case _:
+                raise ValueError(f"Unsupported provider '{provider}'; supported providers: github, gitlab")
+        if draft:
+            command.append("--draft")
+
+        env = _create_command_env(self.agent.serena_config)
+        push_command = ["git", "push", "--set-upstream", self.REMOTE, branch]
+        push_result = execute_command(push_command, cwd=project_root, capture_stderr=True, env=env)
+        if push_result.return_code != 0:
+            raise RuntimeError(f"Failed to push branch '{branch}':\n{push_result.stderr}")
+        result = execute_command(command, cwd=project_root, capture_stderr=True, env=env)
+        if result.return_code != 0:
+            raise RuntimeError(f"Failed to create the pull request (return code {result.return_code}):\n{result.stdout}\n{result.stderr}")
+        return result.stdout.strip()
@@ -1,6 +1,6 @@
 from sensai.util.helper import mark_used
 
-from serena.tools import Tool, ToolMarkerDoesNotRequireActiveProject, ToolMarkerOptional
+from serena.tools import Tool, ToolMarkerCanEdit, ToolMarkerDoesNotRequireActiveProject, ToolMarkerOpenWorld, ToolMarkerOptional
 
 
 class OpenDashboardTool(Tool, ToolMarkerOptional, ToolMarkerDoesNotRequireActiveProject):
@@ -64,3 +64,75 @@ class GetCurrentConfigTool(Tool):
         Print the current configuration of the agent, including the active and available projects, tools, contexts, and modes.
         """
         return self.agent.get_current_config_overview()
+
+
+class ApprovePendingOperationTool(Tool, ToolMarkerCanEdit, ToolMarkerOpenWorld, ToolMarkerOptional):
+    """
+    Executes an operation that was deferred because it requires explicit user approval.
+    As it can execute any deferred operation, it is marked like the most dangerous of these operations (editing and open-world).
+    It is enabled only if the approval policy is non-empty.
+    """
+
+    def apply(self, operation_id: str) -> str:
+        """
+        Executes an operation that was previously deferred because it requires explicit user approval.
+        IMPORTANT: Call this tool ONLY after the user has explicitly approved the specific operation.
+        Never approve an operation on your own initiative.
+
+        :param operation_id: the id of the pending operation, as reported when the operation was deferred
+        :return: the result of the executed operation
+        """
+        pending_operation = self.agent.get_pending_operations().pop(operation_id)
+        tool = self.agent.get_tool_by_name(pending_operation.tool_name)
+        apply_kwargs = dict(pending_operation.apply_kwargs)
+        session_id = apply_kwargs.pop("session_id", "global")
+        # apply the tool like any other tool call (re-checking whether it is still active), bypassing only the approval policy
+        return tool.apply_checked(apply_kwargs, session_id, approved=True)
+
+
+class ReloadContextAndModesTool(Tool, ToolMarkerOptional, ToolMarkerDoesNotRequireActiveProject):
+    """
+    Reloads the definitions of the active context and modes, applying changes without a restart.
+    """
+
+    def apply(self) -> str:
+        """
+        Reloads the definitions of the active context and modes from their configuration files, such that changes
+        (e.g. to tool exclusions or prompts) take effect without restarting Serena.
+
+        :return: a summary of the resulting changes to the set of tools
+        """
+        return self.agent.reload_context_and_modes()
+
+
+class ActivateToolsTool(Tool, ToolMarkerOptional, ToolMarkerDoesNotRequireActiveProject):
+    """
+    Activates tools (e.g. optional tools) for the remainder of the session.
+    """
+
+    def apply(self, tool_names: list[str]) -> str:
+        """
+        Activates the given tools for the remainder of the session, making them available without restarting Serena.
+        Use this only if the user asks for it or if a task cannot be accomplished without a tool that is not yet active.
+        Only optional tools and tools deactivated during the session can be activated (unless they are excluded by the configuration).
+
+        :param tool_names: the names of the tools to activate
+        :return: a summary of the resulting changes to the set of tools
+        """
+        return self.agent.set_tools_activated(tool_names, activated=True)
+
+
+class DeactivateToolsTool(Tool, ToolMarkerOptional, ToolMarkerDoesNotRequireActiveProject):
+    """
+    Deactivates tools for the remainder of the session.
+    """
+
+    def apply(self, tool_names: list[str]) -> str:
+        """
+        Deactivates the given tools for the remainder of the session, such that they are no longer available.
+        They can be re-activated via the `activate_tools` tool.
+
+        :param tool_names: the names of the tools to deactivate
+        :return: a summary of the resulting changes to the set of tools
+        """
+        return self.agent.set_tools_activated(tool_names, activated=False)
@@ -7,12 +7,23 @@ File and file system-related tools, specifically for
 """
 
 import os
+import re
 from collections import defaultdict
 from fnmatch import fnmatch
 from pathlib import Path
-from typing import Literal
-
-from serena.tools import SUCCESS_RESULT, EditedFileContext, EditingToolWithDiagnostics, Tool, ToolMarkerOptional
+from typing import TYPE_CHECKING, Any, Literal
+
+from serena.tools import (
+    SUCCESS_RESULT,
+    EditedFileContext,
+    EditingToolWithDiagnostics,
+    StructuredToolResult,
+    Tool,
+    ToolMarkerCanEdit,
+    ToolMarkerConcurrent,
+    ToolMarkerOptional,
+)
+from serena.util.file_proxy import FileProxy
 from serena.util.file_system import scan_directory
 from serena.util.text_utils import (
     ContentReplacer,
@@ -20,21 +31,38 @@ from serena.util.text_utils import (
     MultiFileContentReplacer,
     ReplacementOccurrence,
 )
-from solidlsp.ls_utils import TextUtils
+from solidlsp import ls_types
+from solidlsp.ls_utils import FileUtils, TextUtils
+from solidlsp.lsp_protocol_handler.lsp_types import FileChangeType
+from solidlsp.util.terraform_address import find_local_module_sources
+
+if TYPE_CHECKING:
+    from serena.code_editor import CodeEditor
 
 
-class ReadFileTool(Tool):
+class ReadFileTool(Tool, ToolMarkerConcurrent):
     """
     Reads a file within the project directory.
     """
 
-    def apply(self, relative_path: str, start_line: int = 0, end_line: int | None = None, max_answer_chars: int = -1) -> str:
+    def apply(
+        self,
+        relative_path: str,
+        start_line: int = 0,
+        end_line: int | None = None,
+        include_metadata: bool = False,
+        max_answer_chars: int = -1,
+    ) -> str:
         """
         Reads the given file or a chunk of it.
 
         :param relative_path: the relative path to the file to read
         :param start_line: the 0-based index of the first line to be retrieved, negative values count from the end of the file.
         :param end_line: the 0-based index of the last line to be retrieved (inclusive). If None, read until the end of the file.
+        :param include_metadata: whether to return a JSON object containing, in addition to the `content`, the file's `metadata`:
+            the encoding with which the file was decoded, whether it starts with a UTF-8 byte order mark (which is never part
+            of the content and is retained when editing the file) and the total number of lines.
+            Use this if the content appears garbled.
         :param max_answer_chars: if the file (chunk) is longer than this number of characters,
             no content will be returned. Don't adjust unless there is really no other way to get the content
             required for the task.
@@ -43,14 +71,26 @@ class ReadFileTool(Tool):
         self.project.validate_relative_path(relative_path, require_not_ignored=True)
 
         # read lines, using the same (LSP-compliant) notion of line breaks as the line-based editing tools
-        result = self.project.read_file(relative_path)
+        metadata: dict[str, Any] | None = None
+        if include_metadata:
+            if FileProxy.is_external_path(relative_path):
+                raise ValueError(f"Metadata is not available for external file {relative_path}")
+            abs_path = os.path.join(self.get_project_root(), relative_path)
+            result, encoding = FileUtils.read_file_with_encoding(abs_path, self.project.project_config.encoding)
+            metadata = {"encoding": encoding, "utf8_bom": FileUtils.has_utf8_bom(abs_path)}
+        else:
+            result = self.project.read_file(relative_path)
         result_lines = TextUtils.split_lines(result)
+        if metadata is not None:
+            metadata["num_lines"] = len(result_lines)
 
         if end_line is None:
             result_lines = result_lines[start_line:]
         else:
             result_lines = result_lines[start_line : end_line + 1]
         result = "\n".join(result_lines)
+        if metadata is not None:
+            result = self._to_json({"metadata": metadata, "content": result})
 
         return self._limit_length(result, max_answer_chars)
 
@@ -60,14 +100,18 @@ class CreateTextFileTool(EditingToolWithDiagnostics):
     Creates/overwrites a file in the project directory.
     """
 
-    def apply(self, relative_path: str, content: str) -> str:
+    def apply(self, relative_path: str, content: str, preview: bool = False) -> str:
         """
         Write a new file or overwrite an existing file with the given content.
 
         :param relative_path: the relative path to the file to create
         :param content: the (appropriately encoded) content to write to the file
+        :param preview: if True, do not write the file but return a unified diff of the changes writing it would make
+            (relative to the existing file or, if the file does not exist, to an empty file)
         :return: a message indicating success or failure
         """
+        if preview:
+            return self._preview_file_creation(relative_path, content)
         with self.DiagnosticsContext(self, relative_path) as diagnostics_context:
             # validating the destination path
             project_root = self.get_project_root()
@@ -81,32 +125,196 @@ class CreateTextFileTool(EditingToolWithDiagnostics):
                     f"Cannot create file outside of the project directory, got {relative_path=}"
                 )
 
-            # writing the file
+            # writing the file (backing up the existing file, if any)
+            if will_overwrite_existing:
+                self._backup_file(relative_path)
+            previous_content = FileUtils.read_file(str(abs_path), self.project.project_config.encoding) if will_overwrite_existing else None
             abs_path.parent.mkdir(parents=True, exist_ok=True)
-            abs_path.write_text(content, encoding=self.project.project_config.encoding, newline=self.project.line_ending.newline_str)
+            FileUtils.write_file(
+                str(abs_path), content, self.project.project_config.encoding, newline=self.project.line_ending.newline_str
+            )
+            self.project.edit_history.record_mutation(relative_path, previous_content, content)
+            change_type = FileChangeType.Changed if will_overwrite_existing else FileChangeType.Created
+            self.project.notify_file_changes([(relative_path, change_type)])
             answer = f"File created: {relative_path}."
             if will_overwrite_existing:
                 answer += " Overwrote existing file."
 
             return diagnostics_context.format_result(answer)
 
+    def _preview_file_creation(self, relative_path: str, content: str) -> str:
+        from serena.code_editor import CodeEditor
+
+        abs_path = (Path(self.get_project_root()) / relative_path).resolve()
+        if abs_path.exists():
+            self.project.validate_relative_path(relative_path, require_not_ignored=True)
+            existing_content = FileUtils.read_file(str(abs_path), self.project.project_config.encoding)
+        elif abs_path.is_relative_to(self.get_project_root()):
+            existing_content = ""
+        else:
+            raise ValueError(f"Cannot create file outside of the project directory, got {relative_path=}")
+        if existing_content == content:
+            return self._format_edit_previews([])
+        return self._format_edit_previews([CodeEditor.EditPreview.from_contents(relative_path, existing_content, content)])
+
+
+class MoveFileTool(Tool, ToolMarkerCanEdit):
+    """
+    Moves or renames a file in the project directory, reporting module calls whose local sources are affected.
+    """
+
+    def apply(self, relative_path: str, new_relative_path: str) -> str:
+        """
+        Moves (or renames) a file within the project directory, creating the destination directory if necessary.
+        Since a Terraform module comprises all .tf files in a directory, moving a file to another directory changes
+        the contents of both modules. Module calls elsewhere in the project whose local `source` referred to the file's
+        previous directory (or to the file itself) as well as the local sources of the module calls in the moved file
+        (which are relative to the file's directory) are reported but NOT updated; adjust them as required.
+
+        :param relative_path: the relative path of the file to move
+        :param new_relative_path: the relative path to move the file to; the file must not exist yet, and the path must not be ignored
+        :return: a summary of the move, including the affected module sources
+        """
+        project_root = self.get_project_root()
+        self.project.validate_relative_path(relative_path, require_not_ignored=True)
+        abs_path = Path(project_root) / relative_path
+        if not abs_path.is_file():
+            raise FileNotFoundError(f"File {relative_path} does not exist")
+        self.project.validate_relative_path(new_relative_path)
+        if self.project.is_ignored_file_path(new_relative_path):
+            raise ValueError(f"Path {new_relative_path} is ignored; cannot move file there for safety reasons")
+        new_abs_path = Path(project_root) / new_relative_path
+        if new_abs_path.exists():
+            raise ValueError(f"Cannot move file to {new_relative_path}: the path already exists")
+
+        # moving the file (recording the move in the edit history only for text files, as the history holds text)
+        contents = self._read_text_contents(abs_path)
+        new_abs_path.parent.mkdir(parents=True, exist_ok=True)
+        os.rename(abs_path, new_abs_path)
+        if contents is not None:
+            edit_history = self.project.edit_history
+            with edit_history.transaction(self.get_name()):
+                edit_history.record_mutation(relative_path, contents, None)
+                edit_history.record_mutation(new_relative_path, None, contents)
+        self.project.notify_file_changes([(relative_path, FileChangeType.Deleted), (new_relative_path, FileChangeType.Created)])
+        result = f"Moved {relative_path} to {new_relative_path}."
+        if contents is None:
+            return result + " As it is not a text file, the move cannot be reverted via undo_last_edit."
+        if not relative_path.endswith(".tf"):
+            return result
+
+        # reporting the affected module sources
+        old_dir = os.path.normpath(os.path.dirname(relative_path) or ".")
+        new_dir = os.path.normpath(os.path.dirname(new_relative_path) or ".")
+        referenced_paths = {os.path.normpath(relative_path)}
+        if old_dir != new_dir:
+            referenced_paths.add(old_dir)
+        if references := self._find_module_calls_referring_to(referenced_paths, excluded_relative_path=new_relative_path):
+            result += f"\nModule calls referring to {' or '.join(sorted(referenced_paths))} (NOT updated):\n" + "\n".join(references)
+        if old_dir != new_dir and (outdated_sources := self._find_outdated_module_sources(contents, old_dir, new_dir)):
+            result += f"\nLocal module sources in {new_relative_path}, which are relative to its directory (NOT updated):\n"
+            result += "\n".join(outdated_sources)
+        return result
+
+    def _find_module_calls_referring_to(self, referenced_paths: set[str], excluded_relative_path: str) -> list[str]:
+        references = []
+        for path in self.project.gather_source_files():
+            if not path.endswith(".tf") or os.path.normpath(path) == os.path.normpath(excluded_relative_path):
+                continue
+            contents = FileUtils.read_file(os.path.join(self.get_project_root(), path), self.project.project_config.encoding)
+            for module_source in find_local_module_sources(contents):
+                if module_source.resolve(os.path.dirname(path)) in referenced_paths:
+                    references.append(
+                        f'  {path}, line {module_source.line}: module "{module_source.module_name}" (source = "{module_source.source}")'
+                    )
+        return references
+
+    @staticmethod
+    def _find_outdated_module_sources(contents: str, old_dir: str, new_dir: str) -> list[str]:
+        outdated_sources = []
+        for module_source in find_local_module_sources(contents):
+            adjusted_source = os.path.relpath(module_source.resolve(old_dir), new_dir).replace(os.sep, "/")
+            if not adjusted_source.startswith(".."):
+                adjusted_source = "./" + adjusted_source
+            outdated_sources.append(
+                f'  line {module_source.line}: module "{module_source.module_name}" (source = "{module_source.source}", '
+                f'should become "{adjusted_source}")'
+            )
+        return outdated_sources
+
+
+class DeleteFileTool(Tool, ToolMarkerCanEdit):
+    """
+    Deletes a file in the project directory.
+    """
+
+    def apply(self, relative_path: str) -> str:
+        """
+        Deletes a file in the project directory. The deletion of a text file can be reverted via undo_last_edit.
+
+        :param relative_path: the relative path of the file to delete
+        :return: a message indicating success or failure
+        """
+        self.project.validate_relative_path(relative_path, require_not_ignored=True)
+        abs_path = Path(self.get_project_root()) / relative_path
+        if not abs_path.is_file():
+            raise FileNotFoundError(f"File {relative_path} does not exist")
+        contents = self._read_text_contents(abs_path)
+        self._backup_file(relative_path)
+        abs_path.unlink()
+        if contents is not None:
+            self.project.edit_history.record_mutation(relative_path, contents, None)
+        self.project.notify_file_changes([(relative_path, FileChangeType.Deleted)])
+        if contents is None:
+            return f"File deleted: {relative_path}. As it is not a text file, the deletion cannot be reverted via undo_last_edit."
+        return f"File deleted: {relative_path}."
+
+
+class CreateDirectoryTool(Tool, ToolMarkerCanEdit):
+    """
+    Creates a directory in the project directory.
+    """
 
-class ListDirTool(Tool):
+    def apply(self, relative_path: str) -> str:
+        """
+        Creates a directory (including any missing parent directories) in the project directory.
+        Use this to create empty directories only; create_text_file creates the directories of the files it creates.
+
+        :param relative_path: the relative path of the directory to create
+        :return: a message indicating success or failure
+        """
+        self.project.validate_relative_path(relative_path)
+        if self.project.is_ignored_directory_path(relative_path):
+            raise ValueError(f"Path {relative_path} is ignored; cannot create directory for safety reasons")
+        abs_path = Path(self.get_project_root()) / relative_path
+        if abs_path.is_dir():
+            return f"Directory already exists: {relative_path}."
+        if abs_path.exists():
+            raise ValueError(f"Cannot create directory {relative_path}: a file with this path exists")
+        abs_path.mkdir(parents=True)
+        return f"Directory created: {relative_path}."
+
+
+class ListDirTool(Tool, ToolMarkerConcurrent):
     """
     Lists files and directories in the given directory (optionally with recursion).
     """
 
-    def apply(self, relative_path: str, recursive: bool, skip_ignored_files: bool = False, max_answer_chars: int = -1) -> str:
+    def apply(
+        self, relative_path: str, recursive: bool, skip_ignored_files: bool = False, output_format: str = "", max_answer_chars: int = -1
+    ) -> str:
         """
         Lists files and directories in the given directory (optionally with recursion).
 
         :param relative_path: the relative path to the directory to list; pass "." to scan the project root
         :param recursive: whether to scan subdirectories recursively
         :param skip_ignored_files: whether to skip files and directories that are ignored
+        :param output_format: the format of the result: "json", "markdown" (nested lists) or "plain" (indented text);
+            if empty, the default format of the current context is used
         :param max_answer_chars: if the output is longer than this number of characters,
             no content will be returned. -1 means the default value from the config will be used.
             Don't adjust unless there is really no other way to get the content required for the task.
-        :return: a JSON object with the names of directories and files within the given directory
+        :return: the names of directories and files within the given directory
         """
         # Check if the directory exists before validation
         if not self.project.relative_path_exists(relative_path):
@@ -127,11 +335,11 @@ class ListDirTool(Tool):
             is_ignored_file=self.project.is_ignored_path if skip_ignored_files else None,
         )
 
-        result = self._to_json({"dirs": dirs, "files": files})
+        result = self._to_output({"dirs": dirs, "files": files}, output_format)
         return self._limit_length(result, max_answer_chars)
 
 
-class FindFileTool(Tool):
+class FindFileTool(Tool, ToolMarkerConcurrent):
     """
     Finds files in the given relative paths
     """
@@ -177,8 +385,10 @@ class ReplaceContentTool(EditingToolWithDiagnostics):
         relative_path: str,
         needle: str,
         repl: str,
-        mode: Literal["literal", "regex"],
+        mode: Literal["literal", "fuzzy", "regex"],
         allow_multiple_occurrences: bool = False,
+        occurrence_index: int | None = None,
+        preview: bool = False,
     ) -> str:
         r"""
         Replaces one or more occurrences of a given pattern in a file with new content.
@@ -189,21 +399,37 @@ class ReplaceContentTool(EditingToolWithDiagnostics):
         mistakes, because an ambiguous match returns an error you can refine, so wildcards are safe.
         Prefer regex mode with suitable wildcards for long multi-line replacements; use the
         symbol-level editors when replacing a whole method/class.
+        For text containing many special characters (e.g. `${...}` interpolations or brackets), which are easy to
+        get wrong when escaped, use "literal" mode instead, or "fuzzy" mode if you are unsure about the exact whitespace.
 
         :param relative_path: the relative path to the file
         :param needle: the string or regex pattern to search for.
             If `mode` is "literal", this string will be matched exactly.
+            If `mode` is "fuzzy", this string will be matched literally, except that differences in whitespace are tolerated:
+            a run of spaces/tabs matches any run of spaces/tabs, and line breaks match line breaks regardless of the
+            indentation and trailing whitespace surrounding them.
             If `mode` is "regex", this string will be treated as a regular expression (syntax of Python's `re` module,
             with flags DOTALL and MULTILINE enabled).
         :param repl: the replacement string (verbatim).
             If mode is "regex", the string can contain backreferences to matched groups in the needle regex,
             specified using the syntax $!1, $!2, etc. for groups 1, 2, etc.
-        :param mode: either "literal" or "regex", specifying how the `needle` parameter is to be interpreted.
+        :param mode: "literal", "fuzzy" or "regex", specifying how the `needle` parameter is to be interpreted.
         :param allow_multiple_occurrences: whether to allow matching and replacing multiple occurrences.
             If false and multiple occurrences are found, an error will be returned
+        :param occurrence_index: if given, only the occurrence with this index is replaced (0 for the first, 1 for the second, ...;
+            negative indices count from the last, e.g. -1 for the last occurrence); use this to pick one of several
+            occurrences instead of making the needle longer
+        :param preview: if True, do not modify the file but return a unified diff of the changes the replacement would make
         """
         return self.replace_content(
-            relative_path, needle, repl, mode=mode, allow_multiple_occurrences=allow_multiple_occurrences, require_not_ignored=True
+            relative_path,
+            needle,
+            repl,
+            mode=mode,
+            allow_multiple_occurrences=allow_multiple_occurrences,
+            require_not_ignored=True,
+            occurrence_index=occurrence_index,
+            preview=preview,
         )
 
     def replace_content(
@@ -211,21 +437,32 @@ class ReplaceContentTool(EditingToolWithDiagnostics):
         relative_path: str,
         needle: str,
         repl: str,
-        mode: Literal["literal", "regex"],
+        mode: Literal["literal", "fuzzy", "regex"],
         allow_multiple_occurrences: bool = False,
         require_not_ignored: bool = True,
+        occurrence_index: int | None = None,
+        preview: bool = False,
     ) -> str:
         """
         Performs the replacement, with additional options not exposed in the tool.
         This function can be used internally by other tools.
         """
-        with self.DiagnosticsContext(self, relative_path) as diagnostics_context:
-            self.project.validate_relative_path(relative_path, require_not_ignored=require_not_ignored)
-            with EditedFileContext(relative_path, self.create_code_editor()) as context:
+        self.project.validate_relative_path(relative_path, require_not_ignored=require_not_ignored)
+
+        def replace(code_editor: "CodeEditor") -> None:
+            with EditedFileContext(relative_path, code_editor) as context:
                 original_content = context.get_original_content()
-                replacer = ContentReplacer(mode=mode, allow_multiple_occurrences=allow_multiple_occurrences)
+                replacer = ContentReplacer(
+                    mode=mode, allow_multiple_occurrences=allow_multiple_occurrences, occurrence_index=occurrence_index
+                )
                 updated_content = replacer.replace(original_content, needle, repl)
                 context.set_updated_content(updated_content)
+
+        if preview:
+            return self._preview_edit(replace)
+        with self.DiagnosticsContext(self, relative_path) as diagnostics_context:
+            self._backup_file(relative_path)
+            replace(self.create_code_editor())
             return diagnostics_context.format_result(SUCCESS_RESULT)
 
 
@@ -462,6 +699,123 @@ class ReplaceInFilesTool(EditingToolWithDiagnostics):
             return diagnostics_context.format_result(summary)
 
 
+class ApplyTextEditsTool(EditingToolWithDiagnostics):
+    """
+    Applies a batch of text edits (possibly spanning several files) atomically.
+    """
+
+    def apply(self, edits: list[dict[str, Any]], force: bool = False) -> str:
+        """
+        Applies several text edits in ONE call, atomically: all edits are validated first, and if any of them
+        is invalid (e.g. a range lies outside of the file or overlaps with another edit of the same file),
+        NO file is changed and all problems are reported.
+        Use this for coordinated changes at multiple locations, which must not end up half-applied.
+
+        Each edit is a dictionary with the keys
+          * "relative_path": the relative path of the file to edit,
+          * "range": the range to replace, given as {"start": {"line": l, "character": c}, "end": {"line": l, "character": c}}
+            with 0-based lines and columns (the end being exclusive; start == end for pure insertions),
+          * "new_text": the text to replace the range with ("" for deletions).
+        All ranges refer to the files' contents BEFORE any of the edits are applied, i.e. you do not need to
+        account for edits shifting the positions of subsequent edits.
+
+        :param edits: the list of edits
+        :param force: whether to apply the edits even if any of the files already contains syntax errors
+            (by default, such edits are refused and the syntax errors are returned)
+        :return: a summary of the applied edits
+        """
+        if not edits:
+            raise ValueError("No edits were given")
+        text_edits_by_file: dict[str, list[ls_types.TextEdit]] = {}
+        for i, edit in enumerate(edits):
+            try:
+                relative_path, edit_range, new_text = edit["relative_path"], edit["range"], edit["new_text"]
+                start, end = edit_range["start"], edit_range["end"]
+                text_edit = ls_types.TextEdit(
+                    range={
+                        "start": {"line": int(start["line"]), "character": int(start["character"])},
+                        "end": {"line": int(end["line"]), "character": int(end["character"])},
+                    },
+                    newText=new_text,
+                )
+            except (KeyError, TypeError, ValueError) as e:
+                raise ValueError(
+                    f"Edit {i} is malformed ({e.__class__.__name__}: {e}) - NO changes were applied. "
+                    "Each edit must have the keys 'relative_path', 'range' and 'new_text'."
+                ) from e
+            self.project.validate_relative_path(relative_path, require_not_ignored=True)
+            text_edits_by_file.setdefault(relative_path, []).append(text_edit)
+
+        for relative_path in text_edits_by_file:
+            self._refuse_edit_of_unparsable_file(relative_path, force)
+        with self.DiagnosticsContext(self, *text_edits_by_file.keys()) as diagnostics_context:
+            code_editor = self.create_code_editor()
+            code_editor.apply_text_edits(text_edits_by_file)
+            per_file = "\n".join(f"  {path}: {len(text_edits)}" for path, text_edits in text_edits_by_file.items())
+            summary = f"Applied {len(edits)} edit(s) in {len(text_edits_by_file)} file(s):\n{per_file}"
+            return diagnostics_context.format_result(summary)
+
+
+class UndoLastEditTool(Tool, ToolMarkerCanEdit):
+    """
+    Undoes the most recent edit performed by an editing tool.
+    """
+
+    def apply(self) -> str:
+        """
+        Undoes the most recent edit (i.e. all changes made to files by the most recent call of an editing tool),
+        restoring the affected files to their prior state. Use this to revert an edit that went wrong, e.g. a symbolic edit
+        which affected more than intended. The edit is undone only if the affected files were not changed since.
+        Calling the tool repeatedly undoes further edits (in reverse chronological order).
+
+        :return: a description of the undone edit
+        """
+        edit_history = self.project.edit_history
+        transactions = edit_history.get_transactions()
+        if not transactions:
+            return "There are no recorded edits which could be undone."
+        transaction = transactions[-1]
+        self.project.notify_file_changes(edit_history.undo(transaction))
+        return f"Undid {transaction.to_string()}."
+
+
+class UndoAllEditsInSessionTool(Tool, ToolMarkerCanEdit):
+    """
+    Undoes all edits performed by editing tools in the current session.
+    """
+
+    def apply(self) -> str:
+        """
+        Undoes all edits performed by editing tools in the current session (in reverse chronological order),
+        restoring the affected files to their state prior to the session's first edit.
+        If an edit cannot be undone (because an affected file was changed by other means since), the process stops
+        and the edits undone up to that point are reported.
+
+        :return: a description of the undone edits
+        """
+        edit_history = self.project.edit_history
+        transactions = edit_history.get_transactions(current_session_only=True)
+        if not transactions:
+            return "There are no recorded edits in the current session which could be undone."
+        undone_transactions = []
+        error_message = None
+        for transaction in reversed(transactions):
+            try:
+                self.project.notify_file_changes(edit_history.undo(transaction))
+            except ValueError as e:
+                error_message = str(e)
+                break
+            undone_transactions.append(transaction)
+        result = f"Undid {len(undone_transactions)} of {len(transactions)} edit(s) in the current session"
+        if undone_transactions:
+            result += ":\n" + "\n".join(f"  {transaction.to_string()}" for transaction in undone_transactions)
+        else:
+            result += "."
+        if error_message is not None:
+            result += f"\nStopped: {error_message}"
+        return result
+
+
 class DeleteLinesTool(EditingToolWithDiagnostics, ToolMarkerOptional):
     """
     Deletes a range of lines within a file.
@@ -472,19 +826,40 @@ class DeleteLinesTool(EditingToolWithDiagnostics, ToolMarkerOptional):
         relative_path: str,
         start_line: int,
         end_line: int,
+        expected_first_line: str | None = None,
+        expected_last_line: str | None = None,
+        force: bool = False,
+        preview: bool = False,
     ) -> str:
         """
         Deletes the given lines in the file.
         Requires that the same range of lines was previously read using the `read_file` tool to verify correctness
         of the operation.
+        Pass the expected content of the first and last lines to ensure that stale line indices cannot cause
+        the wrong lines to be deleted.
 
         :param relative_path: the relative path to the file
-        :param start_line: the 0-based index of the first line to be deleted
-        :param end_line: the 0-based index of the last line to be deleted
+        :param start_line: the 0-based index of the first line to be deleted; negative indices count from the end of the file
+            (-1 being the last line)
+        :param end_line: the 0-based index of the last line to be deleted; negative indices count from the end of the file
+        :param expected_first_line: (optional) the expected content of the line at `start_line` (leading/trailing whitespace is ignored);
+            if the actual content differs, nothing is deleted
+        :param expected_last_line: (optional) the expected content of the line at `end_line`, analogous to `expected_first_line`
+        :param force: whether to apply the edit even if the file already contains syntax errors
+            (by default, such edits are refused and the syntax errors are returned)
+        :param preview: if True, do not modify the file but return a unified diff of the changes the edit would make
         """
+
+        def delete_lines(code_editor: "CodeEditor") -> None:
+            code_editor.delete_lines(
+                relative_path, start_line, end_line, expected_first_line=expected_first_line, expected_last_line=expected_last_line
+            )
+
+        if preview:
+            return self._preview_edit(delete_lines)
+        self._refuse_edit_of_unparsable_file(relative_path, force)
         with self.DiagnosticsContext(self, relative_path) as diagnostics_context:
-            code_editor = self.create_code_editor()
-            code_editor.delete_lines(relative_path, start_line, end_line)
+            delete_lines(self.create_code_editor())
             return diagnostics_context.format_result(SUCCESS_RESULT)
 
 
@@ -499,6 +874,8 @@ class ReplaceLinesTool(EditingToolWithDiagnostics, ToolMarkerOptional):
         start_line: int,
         end_line: int,
         content: str,
+        force: bool = False,
+        preview: bool = False,
     ) -> str:
         """
         Replaces the given range of lines in the given file.
@@ -506,59 +883,117 @@ class ReplaceLinesTool(EditingToolWithDiagnostics, ToolMarkerOptional):
         of the operation.
 
         :param relative_path: the relative path to the file
-        :param start_line: the 0-based index of the first line to be deleted
-        :param end_line: the 0-based index of the last line to be deleted
+        :param start_line: the 0-based index of the first line to be deleted; negative indices count from the end of the file
+            (-1 being the last line)
+        :param end_line: the 0-based index of the last line to be deleted; negative indices count from the end of the file
         :param content: the content to insert
+        :param force: whether to apply the edit even if the file already contains syntax errors
+            (by default, such edits are refused and the syntax errors are returned)
+        :param preview: if True, do not modify the file but return a unified diff of the changes the edit would make
         """
         # normalizing the replacement content
         if not content.endswith("\n"):
             content += "\n"
 
-        with self.DiagnosticsContext(self, relative_path) as diagnostics_context:
-            code_editor = self.create_code_editor()
-            code_editor.delete_lines(relative_path, start_line, end_line)
-            code_editor.insert_at_line(relative_path, start_line, content)
+        def replace_lines(code_editor: "CodeEditor") -> None:
+            code_editor.replace_lines(relative_path, start_line, end_line, content)
 
+        if preview:
+            return self._preview_edit(replace_lines)
+        self._refuse_edit_of_unparsable_file(relative_path, force)
+        self._backup_file(relative_path)
+        with self.DiagnosticsContext(self, relative_path) as diagnostics_context:
+            replace_lines(self.create_code_editor())
             return diagnostics_context.format_result(SUCCESS_RESULT)
 
 
 class InsertAtLineTool(EditingToolWithDiagnostics, ToolMarkerOptional):
     """
-    Inserts content at a given line in a file.
+    Inserts content at a given line in a file or before/after a line matching a pattern.
     """
 
     def apply(
         self,
         relative_path: str,
-        line: int,
+        line: int | None,
         content: str,
+        anchor_pattern: str = "",
+        anchor_occurrence_index: int = 0,
+        insert_before_anchor: bool = False,
+        force: bool = False,
+        preview: bool = False,
     ) -> str:
         """
         Inserts the given content at the given line in the file, pushing existing content of the line down.
+        Alternatively, the position can be specified relative to a line matching a pattern (`anchor_pattern`),
+        which is robust to line numbers having changed since the file was read.
         In general, symbolic insert operations like insert_after_symbol or insert_before_symbol should be preferred if you know which
         symbol you are looking for.
         However, this can also be useful for small targeted edits of the body of a longer symbol (without replacing the entire body).
 
         :param relative_path: the relative path to the file
-        :param line: the 0-based index of the line to insert content at
+        :param line: the 0-based index of the line to insert content at; negative indices count from the end of the file,
+            where -1 refers to the end of the file, i.e. the content is appended (no need to determine the number of lines first).
+            Must be null if `anchor_pattern` is used.
         :param content: the content to be inserted
+        :param anchor_pattern: a regular expression (Python syntax) identifying the line relative to which to insert
+            (searched within each line); by default, the content is inserted after the first matching line
+        :param anchor_occurrence_index: the index of the matching line to use as the anchor if several lines match
+            (0 for the first, 1 for the second, ...; negative indices count from the last, e.g. -1 for the last)
+        :param insert_before_anchor: whether to insert before the anchor line instead of after it
+        :param force: whether to apply the edit even if the file already contains syntax errors
+            (by default, such edits are refused and the syntax errors are returned)
+        :param preview: if True, do not modify the file but return a unified diff of the changes the edit would make
         """
+        if (line is None) == (not anchor_pattern):
+            raise ValueError("Exactly one of line and anchor_pattern must be provided")
+
         # normalizing the inserted content
         if not content.endswith("\n"):
             content += "\n"
 
-        with self.DiagnosticsContext(self, relative_path) as diagnostics_context:
-            code_editor = self.create_code_editor()
-            code_editor.insert_at_line(relative_path, line, content)
+        def insert(code_editor: "CodeEditor") -> None:
+            if line is not None:
+                code_editor.insert_at_line(relative_path, line, content)
+            else:
+                code_editor.insert_relative_to_pattern(
+                    relative_path, anchor_pattern, content, occurrence_index=anchor_occurrence_index, before=insert_before_anchor
+                )
 
+        if preview:
+            return self._preview_edit(insert)
+        self._refuse_edit_of_unparsable_file(relative_path, force)
+        with self.DiagnosticsContext(self, relative_path) as diagnostics_context:
+            insert(self.create_code_editor())
             return diagnostics_context.format_result(SUCCESS_RESULT)
 
 
-class SearchForPatternTool(Tool):
+class SearchForPatternTool(Tool, ToolMarkerConcurrent):
     """
     Performs a search for a pattern in the project.
     """
 
+    STRUCTURED_OUTPUT_SCHEMA = {
+        "type": "array",
+        "items": {
+            "type": "object",
+            "properties": {
+                "relative_path": {"type": "string"},
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "type": "object",
+                        "properties": {
+                            "line": {"type": "integer"},
+                            "text": {"type": "string"},
+                            "type": {"enum": ["match", "prefix", "postfix"]},
+                        },
+                    },
+                },
+            },
+        },
+    }
+
     def apply(
         self,
         substring_pattern: str,
@@ -569,6 +1004,7 @@ class SearchForPatternTool(Tool):
         relative_path: str = "",
         restrict_search_to_code_files: bool = False,
         multiline: bool = True,
+        output_format: str = "",
         max_answer_chars: int = -1,
     ) -> str:
         """
@@ -584,6 +1020,8 @@ class SearchForPatternTool(Tool):
         :param restrict_search_to_code_files: whether to search only files containing analyzable code symbols
             (useful when looking for class/method definitions); otherwise also search non-code files.
         :param multiline: whether to apply multi-line matching (default: True), enabling the flags re.DOTALL and re.MULTILINE
+        :param output_format: the format of the result: "json", "markdown" (nested lists) or "plain" (indented text);
+            if empty, the default format of the current context is used
         :param max_answer_chars: if the output exceeds this many characters, a progressively shortened summary is returned instead.
             ``-1`` uses the configured default.
         :return: A mapping from file paths to matched consecutive lines (0-based line numbers).
@@ -660,7 +1098,7 @@ class SearchForPatternTool(Tool):
         def make_summary() -> str:
             return f"Found {len(matches)} matches in {len(match_lines_by_file)} files."
 
-        result = self._to_json(file_to_matches)
+        result = StructuredToolResult(self._to_output(file_to_matches, output_format), [match.to_dict() for match in matches])
         return self._limit_length(
             result,
             max_answer_chars,
@@ -672,3 +1110,87 @@ class SearchForPatternTool(Tool):
                 make_summary,
             ],
         )
+
+
+class FindReferencingCodeSnippetsTool(Tool, ToolMarkerOptional):
+    """
+    Finds the raw locations (file, line and code snippet) of usages of an identifier in the project.
+    """
+
+    def apply(
+        self,
+        identifier: str,
+        relative_path: str = "",
+        paths_include_glob: str = "",
+        paths_exclude_glob: str = "",
+        context_lines: int = 0,
+        output_format: str = "",
+        max_answer_chars: int = -1,
+    ) -> str:
+        """
+        Finds all usages of the given identifier, matched as a whole word, returning their locations along with code snippets.
+        In contrast to find_referencing_symbols, this works for arbitrary identifiers which are not well-formed symbols
+        (e.g. a key inside a locals map), but as the search is purely textual, matches in comments or strings
+        as well as usages of unrelated entities with the same name are included.
+
+        :param identifier: the identifier to search for (taken literally, e.g. "instance_type" or "local.common_tags")
+        :param relative_path: restricts the search to this file or subdirectory of the project root
+        :param paths_include_glob: optional glob (relative to project root) restricting which files are searched
+        :param paths_exclude_glob: optional glob to exclude files; takes precedence over `paths_include_glob`
+        :param context_lines: the number of lines to include before and after each usage in the snippet
+        :param output_format: the format of the result: "json", "markdown" (nested lists) or "plain" (indented text);
+            if empty, the default format of the current context is used
+        :param max_answer_chars: max result length; -1 for default
+        :return: a mapping from file paths to the usages within the file, each with the (0-based) line number and the code snippet
+        """
+        identifier = identifier.strip()
+        if not identifier:
+            raise ValueError("identifier must not be empty")
+        if context_lines < 0:
+            raise ValueError(f"context_lines must be non-negative, got {context_lines}")
+        relative_path = relative_path.strip()
+        if relative_path:
+            self.project.validate_relative_path(relative_path, require_not_ignored=True)
+
+        # match whole words only, where characters which may be part of identifiers (including dashes, as in Terraform) delimit words
+        pattern = rf"(?<![\w-]){re.escape(identifier)}(?![\w-])"
+        matches = self.project.search_project_files_for_pattern(
+            pattern=pattern,
+            relative_path=relative_path,
+            context_lines_before=context_lines,
+            context_lines_after=context_lines,
+            paths_include_glob=paths_include_glob.strip(),
+            paths_exclude_glob=paths_exclude_glob.strip(),
+            multiline=False,
+            code_files_only=False,
+        )
+
+        # group usages by file, reporting each line only once (even if it contains several usages)
+        file_to_usages: dict[str, list[dict[str, int | str]]] = defaultdict(list)
+        seen_locations: set[tuple[str, int]] = set()
+        for match in matches:
+            assert match.source_file_path is not None
+            line = match.matched_lines[0]
+            location = (match.source_file_path, line.line_number)
+            if location in seen_locations:
+                continue
+            seen_locations.add(location)
+            snippet = match.to_display_string() if context_lines > 0 else line.line_content.strip()
+            file_to_usages[match.source_file_path].append({"line": line.line_number, "snippet": snippet})
+
+        # shortened result closures, from least to most aggressive shortening
+        def make_line_numbers_only() -> str:
+            numbers = {path: [u["line"] for u in usages] for path, usages in file_to_usages.items()}
+            return f"Lines with usages per file:\n{self._to_json(numbers)}"
+
+        def make_per_file_counts() -> str:
+            counts = {path: len(usages) for path, usages in file_to_usages.items()}
+            return f"Usage counts per file:\n{self._to_json(counts)}"
+
+        def make_summary() -> str:
+            return f"Found {len(seen_locations)} lines with usages in {len(file_to_usages)} files."
+
+        result = self._to_output(file_to_usages, output_format)
+        return self._limit_length(
+            result, max_answer_chars, shortened_result_factories=[make_line_numbers_only, make_per_file_counts, make_summary]
+        )
@@ -276,6 +276,7 @@ class JetBrainsFindReferencingSymbolsTool(Tool, ToolMarkerSymbolicRead, ToolMark
         self,
         name_path: str,
         relative_path: str,
+        context_lines: int = 1,
         max_answer_chars: int = -1,
     ) -> str:
         """
@@ -287,6 +288,7 @@ class JetBrainsFindReferencingSymbolsTool(Tool, ToolMarkerSymbolicRead, ToolMark
         :param relative_path: the relative path to the file containing the symbol (must be a file, not a directory)
             Note: for external dependencies, this must be an identifier starting with `<ext` that you have received
             earlier (don't try to guess!).
+        :param context_lines: the number of lines to include before and after each reference line
         :param max_answer_chars: max characters for the result (-1 for default). If exceeded, no content/a shortened result is returned.
         """
         relative_path = self._sanitize_input_param(relative_path)
@@ -305,7 +307,7 @@ class JetBrainsFindReferencingSymbolsTool(Tool, ToolMarkerSymbolicRead, ToolMark
                 ref_relative_path = symbol_dict["relative_path"]
                 if not SymbolDTOUtil.is_external_symbol(symbol_dict) and ref_line is not None and ref_line >= 0:
                     content_around_ref = self.project.retrieve_content_around_line(
-                        relative_file_path=ref_relative_path, line=ref_line, context_lines_before=1, context_lines_after=1
+                        relative_file_path=ref_relative_path, line=ref_line, context_lines_before=context_lines, context_lines_after=context_lines
                     )
                     symbol_dict["context"] = content_around_ref.to_display_string()
                     del symbol_dict["reference_line_no"]
@@ -1,7 +1,8 @@
 import logging
-from typing import Literal
+from typing import Any, Literal
 
-from serena.tools import Tool, ToolMarkerCanEdit
+from serena.memories.memory_metadata import MemoryMetadata, MemoryType
+from serena.tools import Tool, ToolMarkerCanEdit, ToolMarkerConcurrent
 
 log = logging.getLogger(__name__)
 
@@ -12,7 +13,15 @@ class WriteMemoryTool(Tool, ToolMarkerCanEdit):
     The memory name should be meaningful.
     """
 
-    def apply(self, memory_name: str, content: str, max_chars: int = -1) -> str:
+    # noinspection PyDefaultArgument
+    def apply(
+        self,
+        memory_name: str,
+        content: str,
+        memory_type: str = "",
+        tags: list[str] = [],  # noqa: B006
+        max_chars: int = -1,
+    ) -> str:
         """
         Write information about this project that can be useful for future tasks in md format.
         The name should be meaningful and can include "/" to organize into topics.
@@ -22,6 +31,10 @@ class WriteMemoryTool(Tool, ToolMarkerCanEdit):
 
         :param memory_name: memory name
         :param content: memory content, utf8-encoded
+        :param memory_type: (optional) the type of knowledge contained in the memory, which is stored as front matter
+            (along with the tags and the time of writing), enabling memories to be filtered by type;
+            one of "architecture", "runbook", "conventions", "reference", "stats", "other"
+        :param tags: (optional) tags to store in the front matter; requires `memory_type`
         :param max_chars: see other tools
         """
         # NOTE: utf-8 encoding is configured in the MemoriesManager
@@ -32,10 +45,15 @@ class WriteMemoryTool(Tool, ToolMarkerCanEdit):
                 f"Content for {memory_name} is too long. Max length is {max_chars} characters. " + "Please make the content shorter."
             )
 
-        return self.memory_manager.save_memory(memory_name, content, is_tool_context=True)
+        metadata = None
+        if memory_type:
+            metadata = MemoryMetadata(type=MemoryType.from_name(memory_type), tags=tags, source_tool=self.get_name())
+        elif tags:
+            raise ValueError("Tags can only be stored along with a memory type")
+        return self.memory_manager.save_memory(memory_name, content, is_tool_context=True, metadata=metadata)
 
 
-class ReadMemoryTool(Tool):
+class ReadMemoryTool(Tool, ToolMarkerConcurrent):
     """
     Reads the content of a memory file.
     """
@@ -47,16 +65,41 @@ class ReadMemoryTool(Tool):
         return self.memory_manager.load_memory(memory_name)
 
 
-class ListMemoriesTool(Tool):
+class ListMemoriesTool(Tool, ToolMarkerConcurrent):
     """
     Lists available memories.
     """
 
-    def apply(self, topic: str = "") -> str:
+    # noinspection PyDefaultArgument
+    def apply(
+        self,
+        topic: str = "",
+        memory_type: str = "",
+        tags: list[str] = [],  # noqa: B006
+        include_metadata: bool = False,
+    ) -> str:
         """
         Lists available memories, optionally filtered by topic.
+        Memories with front matter can furthermore be filtered by type and tags.
+
+        :param topic: (optional) the topic to restrict the listing to
+        :param memory_type: (optional) only list memories of this type, e.g. "architecture" or "runbook"
+        :param tags: (optional) only list memories having all of these tags
+        :param include_metadata: whether to include the metadata of each memory (type, tags, time of the last update, source tool),
+            mapping memory names to their metadata (null for memories without front matter)
         """
-        return self._to_json(self.memory_manager.list_memories(topic).to_dict())
+        memories = self.memory_manager.list_memories(
+            topic, memory_type=MemoryType.from_name(memory_type) if memory_type else None, tags=tags
+        )
+        if not include_metadata:
+            return self._to_json(memories.to_dict())
+
+        def metadata_dict(name: str) -> dict[str, Any] | None:
+            metadata = self.memory_manager.get_memory_metadata(name)
+            return metadata.to_dict() if metadata is not None else None
+
+        result = {key: {name: metadata_dict(name) for name in names} for key, names in memories.to_dict().items()}
+        return self._to_json(result)
 
 
 class DeleteMemoryTool(Tool, ToolMarkerCanEdit):
@@ -3,23 +3,90 @@ Language server-related tools
 """
 
 import copy
+import logging
 import os
+import re
 from collections import Counter, defaultdict
-from collections.abc import Sequence
-from typing import Any
-
-from serena.symbol import LanguageServerSymbol, LanguageServerSymbolDictGrouper
+from pathlib import Path
+from typing import TYPE_CHECKING, Any
+
+from serena.symbol import (
+    LanguageServerSymbol,
+    LanguageServerSymbolDictGrouper,
+    LanguageServerSymbolRetriever,
+    SymbolKindFilter,
+    TerraformBlockType,
+    TerraformResourceFilter,
+)
 from serena.tools import (
     SUCCESS_RESULT,
     EditingToolWithDiagnostics,
+    StructuredToolResult,
     Tool,
     ToolMarkerSymbolicEdit,
     ToolMarkerSymbolicRead,
 )
 from serena.tools.tools_base import ToolMarkerOptional
 from serena.util.ls_diagnostics import GroupedDiagnostics
-from serena.util.text_utils import find_text_coordinates
-from solidlsp.ls_types import SymbolKind
+from serena.util.text_utils import TextCoords, find_text_coordinates
+from solidlsp.ls_types import CompletionItemKind, Diagnostic
+from solidlsp.ls_utils import FileUtils
+from solidlsp.lsp_protocol_handler.lsp_types import FileChangeType
+from solidlsp.util.terraform_address import find_local_module_call, format_moved_block
+
+if TYPE_CHECKING:
+    from serena.code_editor import CodeEditor
+
+log = logging.getLogger(__name__)
+
+_SYMBOL_PROPERTIES_SCHEMA: dict[str, Any] = {
+    "name_path": {"type": "string"},
+    "name": {"type": "string"},
+    "kind": {"type": "string"},
+    "tf_kind": {"type": "string"},
+    "relative_path": {"type": ["string", "null"]},
+    "location": {"type": "object"},
+    "body_location": {"type": "object"},
+    "body": {"type": ["string", "null"]},
+    "children": {"type": "array", "items": {"type": "object"}},
+    "address": {"type": "string"},
+}
+"""JSON schema properties of symbol dictionaries (see `LanguageServerSymbol.OutputDict`)"""
+
+SYMBOL_LIST_SCHEMA: dict[str, Any] = {"type": "array", "items": {"type": "object", "properties": _SYMBOL_PROPERTIES_SCHEMA}}
+"""JSON schema of the structured data of tools returning lists of (ungrouped) symbol dictionaries"""
+
+
+def _find_regex_group_coordinates(
+    tool: Tool,
+    symbol_retriever: LanguageServerSymbolRetriever,
+    relative_path: str,
+    regex: str,
+    containing_symbol_name_path: str | None,
+) -> TextCoords:
+    """
+    Finds the location of the (unique) match of a regex with one group in a file or in the body of a symbol.
+
+    :param tool: the tool requiring the location
+    :param symbol_retriever: the symbol retriever with which to find the containing symbol
+    :param relative_path: the relative path of the file
+    :param regex: the regex, whose group's start is the location of interest
+    :param containing_symbol_name_path: optional name path of a containing symbol whose body shall be searched instead of the full file
+    :return: the coordinates of the start of the group
+    """
+    editor = tool.create_code_editor()
+    if not containing_symbol_name_path:
+        content = editor.read_file(relative_path)
+        coords = find_text_coordinates(content, regex, require_unique=True)
+        assert coords is not None
+    else:
+        symbol = symbol_retriever.find_unique(name_path_pattern=containing_symbol_name_path, within_relative_path=relative_path)
+        body_line_numers = symbol.get_body_line_numbers_or_raise()
+        content = editor.read_file(relative_path, lines=body_line_numers)
+        coords = find_text_coordinates(content, regex, require_unique=True)
+        assert coords is not None
+        coords.line += body_line_numers[0]
+    return coords
 
 
 class RestartLanguageServerTool(Tool, ToolMarkerOptional):
@@ -29,18 +96,52 @@ class RestartLanguageServerTool(Tool, ToolMarkerOptional):
         """Use this tool only on explicit user request or after confirmation.
         It may be necessary to restart the language server if it hangs.
         """
-        self.agent.reset_language_server_manager()
+        ls_manager = self.agent.get_language_server_manager()
+        if ls_manager is None:
+            # no language servers were started successfully, so start them from scratch
+            self.agent.reset_language_server_manager()
+        else:
+            for ls_id in ls_manager.get_active_language_server_ids():
+                ls_manager.restart_language_server(ls_id)
         return SUCCESS_RESULT
 
 
+class ClearSymbolCacheTool(Tool, ToolMarkerOptional):
+    """Clears the symbol caches of the language server(s)."""
+
+    def apply(self) -> str:
+        """Use this tool only on explicit user request or after confirmation.
+        Clearing the symbol caches may be necessary if symbol information is outdated or wrong;
+        symbols are subsequently re-requested from the language server(s), which may be slow for large projects.
+
+        :return: the statistics of the cleared caches
+        """
+        ls_manager = self.agent.get_language_server_manager_or_raise()
+        cache_stats = ls_manager.get_symbol_cache_stats()
+        ls_manager.clear_symbol_caches()
+        lines = ["Cleared symbol caches:"]
+        for ls_id, stats in cache_stats.items():
+            lines.append(f"  {ls_id.value}: {stats.to_string()}")
+        return "\n".join(lines)
+
+
 class GetSymbolsOverviewTool(Tool, ToolMarkerSymbolicRead):
     """
     Gets an overview of the top-level symbols defined in a given file.
     """
 
     symbol_dict_grouper = LanguageServerSymbolDictGrouper(["kind"], ["kind"], collapse_singleton=True)
+    STRUCTURED_OUTPUT_SCHEMA = SYMBOL_LIST_SCHEMA
 
-    def apply(self, relative_path: str, depth: int = -1, max_answer_chars: int = -1) -> str:
+    # noinspection PyDefaultArgument
+    def apply(
+        self,
+        relative_path: str,
+        depth: int = -1,
+        include_kinds: list[int | str] = [],  # noqa: B006
+        exclude_kinds: list[int | str] = [],  # noqa: B006
+        max_answer_chars: int = -1,
+    ) -> str:
         """
         Use this tool to get a high-level understanding of the code symbols in a file.
         This should be the first tool to call when you want to understand a new file, unless you already know
@@ -49,6 +150,10 @@ class GetSymbolsOverviewTool(Tool, ToolMarkerSymbolicRead):
         :param relative_path: the relative path to the file to get the overview of
         :param depth: depth up to which descendants shall be retrieved.
             Default (-1) results in a language specific choice: 1 for java and kotlin and 0 for other languages
+        :param include_kinds: (optional) limits the top-level symbols to the given kinds, which can be given as LSP symbol kinds
+            (integers or names, e.g. 12 or "Function") or, for Terraform, as block types
+            ("resource", "data", "module", "variable", "output", "provider", "local")
+        :param exclude_kinds: (optional) list of top-level symbol kinds to exclude (same format as include_kinds).
         :param max_answer_chars: if the overview is longer than this number of characters,
             no content will be returned. -1 means the default value from the config will be used.
             Don't adjust unless there is really no other way to get the content required for the task.
@@ -62,9 +167,10 @@ class GetSymbolsOverviewTool(Tool, ToolMarkerSymbolicRead):
             else:
                 depth = 0
 
-        result = self.get_symbol_overview(relative_path, depth=depth)
+        result = self.get_symbol_overview(relative_path, depth=depth, kind_filter=SymbolKindFilter(include_kinds, exclude_kinds))
 
-        # capture kind names and depth-0 snapshots before grouping, which mutates the dicts
+        # capture kind names, depth-0 snapshots and structured data before grouping, which mutates the dicts
+        structured_data = copy.deepcopy(result)
         kind_names = [d.get("kind", "unknown") for d in result]
         if depth > 0:
             depth_0_result = [d.copy() for d in result]
@@ -72,7 +178,7 @@ class GetSymbolsOverviewTool(Tool, ToolMarkerSymbolicRead):
                 d.pop("children", None)
 
         compact_result = self.symbol_dict_grouper.group(result)
-        result_json_str = self._to_json(compact_result)
+        result_json_str = StructuredToolResult(self._to_json(compact_result), structured_data)
 
         # shortened result closures
         def make_kind_counts() -> str:
@@ -90,10 +196,13 @@ class GetSymbolsOverviewTool(Tool, ToolMarkerSymbolicRead):
 
         return self._limit_length(result_json_str, max_answer_chars, shortened_result_factories=shortened_results)
 
-    def get_symbol_overview(self, relative_path: str, depth: int = 0) -> list[LanguageServerSymbol.OutputDict]:
+    def get_symbol_overview(
+        self, relative_path: str, depth: int = 0, kind_filter: SymbolKindFilter | None = None
+    ) -> list[LanguageServerSymbol.OutputDict]:
         """
         :param relative_path: relative path to a source file
         :param depth: the depth up to which descendants shall be retrieved
+        :param kind_filter: an optional filter to apply to the top-level symbols
         :return: a list of symbol dictionaries representing the symbol overview of the file
         """
         symbol_retriever = self.create_language_server_symbol_retriever()
@@ -111,6 +220,8 @@ class GetSymbolsOverviewTool(Tool, ToolMarkerSymbolicRead):
             )
 
         symbols = symbol_retriever.get_symbol_overview(relative_path)[relative_path]
+        if kind_filter is not None:
+            symbols = [s for s in symbols if kind_filter.is_included(s)]
 
         def child_inclusion_predicate(s: LanguageServerSymbol) -> bool:
             return not s.is_low_level()
@@ -131,6 +242,48 @@ class GetSymbolsOverviewTool(Tool, ToolMarkerSymbolicRead):
         return symbol_dicts
 
 
+class GetSymbolStatsTool(Tool, ToolMarkerSymbolicRead):
+    """
+    Gets statistics on the symbols in the project (or a part of it), e.g. to decide where to focus in an unfamiliar codebase.
+    """
+
+    def apply(
+        self,
+        relative_path: str = "",
+        max_files: int = 10,
+        max_resource_types: int = 10,
+        output_format: str = "",
+        max_answer_chars: int = -1,
+    ) -> str:
+        """
+        Computes aggregate statistics over the symbols of all source files in the project or in the given directory:
+        the number of symbols per kind per directory, the files with the most symbols and (for Terraform projects)
+        the most frequent resource types.
+        Use it to get a quick overview of an unfamiliar codebase before exploring individual files.
+
+        :param relative_path: the relative path of a directory or file to restrict the statistics to; empty for the whole project
+        :param max_files: the maximum number of files to list among the files with the most symbols
+        :param max_resource_types: the maximum number of resource types to list
+        :param output_format: the format of the result: "json", "markdown" (nested lists) or "plain" (indented text);
+            if empty, the default format of the current context is used
+        :param max_answer_chars: max result length; -1 for default
+        :return: the symbol statistics
+        """
+        self.project.ls_sync_file_system_changes()
+
+        symbol_retriever = self.create_language_server_symbol_retriever()
+        statistics = symbol_retriever.get_symbol_statistics(within_relative_path=relative_path or None)
+        result = self._to_output(statistics.to_dict(max_files=max_files, max_resource_types=max_resource_types), output_format)
+
+        def make_totals_by_dir() -> str:
+            totals_by_dir = {
+                directory: sum(counter.values()) for directory, counter in sorted(statistics.num_symbols_by_kind_by_dir.items())
+            }
+            return "Number of symbols by directory:\n" + self._to_output(totals_by_dir, output_format)
+
+        return self._limit_length(result, max_answer_chars, shortened_result_factories=[make_totals_by_dir])
+
+
 class FindSymbolTool(Tool, ToolMarkerSymbolicRead):
     """
     Performs a global (or local) search using the language server backend.
@@ -139,6 +292,7 @@ class FindSymbolTool(Tool, ToolMarkerSymbolicRead):
     # group children by kind, keeping just the name (the parent's name_path makes it unambiguous);
     # we don't group the top-level result list because many tests rely on it being a flat list of symbol dicts
     symbol_dict_grouper = LanguageServerSymbolDictGrouper([], ["kind"], collapse_singleton=True)
+    STRUCTURED_OUTPUT_SCHEMA = SYMBOL_LIST_SCHEMA
 
     # noinspection PyDefaultArgument
     def apply(
@@ -147,11 +301,16 @@ class FindSymbolTool(Tool, ToolMarkerSymbolicRead):
         depth: int = 0,
         relative_path: str = "",
         include_body: bool = False,
+        include_leading_comments: bool = False,
         include_info: bool = False,
-        include_kinds: list[int] = [],  # noqa: B006
-        exclude_kinds: list[int] = [],  # noqa: B006
+        include_kinds: list[int | str] = [],  # noqa: B006
+        exclude_kinds: list[int | str] = [],  # noqa: B006
         substring_matching: bool = False,
+        regex_matching: bool = False,
+        resource_type: str = "",
+        provider: str = "",
         max_matches: int = -1,
+        output_format: str = "",
         max_answer_chars: int = -1,
     ) -> str:
         """
@@ -170,6 +329,10 @@ class FindSymbolTool(Tool, ToolMarkerSymbolicRead):
          * a relative path like "class/method", which will match any symbol with that name path suffix
          * an absolute name path "/class/method" (absolute name path), which requires an exact match of the full name path within the source file.
         Append an index `[i]` to match a specific overload only, e.g. "MyClass/my_method[1]".
+        Pattern components may contain the wildcards `*` and `?`, e.g. "MyClass/get_*" or "module/*/vpc" (`*` does not match `/`).
+        With `regex_matching=True`, pattern components are regular expressions instead, e.g. "module/.*prod.*".
+        For Terraform, canonical addresses can be used as well, e.g. "aws_instance.web", "var.region", "local.tags" or
+        "module.vpc.aws_subnet.private" (resolving the local module called `vpc`); matching blocks include their `address`.
 
         :param name_path_pattern: the name path matching pattern (see above)
         :param depth: depth up to which descendants shall be retrieved (e.g. use 1 to also retrieve immediate children;
@@ -179,15 +342,27 @@ class FindSymbolTool(Tool, ToolMarkerSymbolicRead):
             If a directory is passed, the search will be restricted to the files in that directory.
             If a file is passed, the search will be restricted to that file.
         :param include_body: whether to include the symbol's source code. Use judiciously.
+        :param include_leading_comments: whether the included source code (and body location) shall also comprise the comment lines
+            immediately preceding the symbol (e.g. a descriptive comment above a resource). Only relevant if include_body is True.
         :param include_info: whether to include additional info (hover-like, typically including docstring and signature),
             about the symbol (ignored if include_body is True). Info is never included for child symbols.
             Note: Depending on the language, this can be slow (e.g., C/C++).
-        :param include_kinds: (optional) limits results to the given LSP symbol kinds (integers)
-        :param exclude_kinds: (optional) list of LSP symbol kinds (integers) to exclude.
+        :param include_kinds: (optional) limits results to the given symbol kinds, which can be given as LSP symbol kinds
+            (integers or names, e.g. 12 or "Function") or, for Terraform, as block types
+            ("resource", "data", "module", "variable", "output", "provider", "local")
+        :param exclude_kinds: (optional) list of symbol kinds to exclude (same format as include_kinds).
         :param substring_matching: If True, use substring matching for the last element of the pattern, such that
             "Foo/get" would match "Foo/getValue" and "Foo/getData".
+        :param regex_matching: If True, each component of the pattern is a regular expression which must match the entire
+            name (or, with substring matching, any part of the last component's name), e.g. ".*_prod" or "module/vpc_.*".
+            A `/` within a regular expression must be escaped as `\\/`; a trailing `[i]` (digits only) is an overload index.
+        :param resource_type: (Terraform only, optional) limits results to resource and data blocks of the given type,
+            e.g. "aws_s3_bucket" (wildcards allowed, e.g. "aws_s3_*")
+        :param provider: (Terraform only, optional) limits results to resource, data and provider blocks of the given provider, e.g. "aws"
         :param max_matches: maximum number of permitted matches. If exceeded, a shortened result is returned
              which allows refining the search. -1 (default) means no limit. Set to 1 if you search for a single symbol.
+        :param output_format: the format of the result: "json", "markdown" (nested lists) or "plain" (indented text);
+            if empty, the default format of the current context is used
         :param max_answer_chars: max result length; -1 for default
         :return: symbols (with locations) matching the name.
         """
@@ -196,15 +371,19 @@ class FindSymbolTool(Tool, ToolMarkerSymbolicRead):
         if include_body:
             depth = 0  # ignore user-specified depth if include_body is True
         assert max_matches != 0, "max_matches must be > 0 or equal to -1."
-        parsed_include_kinds: Sequence[SymbolKind] | None = [SymbolKind(k) for k in include_kinds] if include_kinds else None
-        parsed_exclude_kinds: Sequence[SymbolKind] | None = [SymbolKind(k) for k in exclude_kinds] if exclude_kinds else None
+        kind_filter = SymbolKindFilter(include_kinds, exclude_kinds)
+        terraform_resource_filter = TerraformResourceFilter(resource_type=resource_type, provider=provider)
         symbol_retriever = self.create_language_server_symbol_retriever()
+        # if the number of matches is limited, the search can terminate as soon as the limit is exceeded
         symbols = symbol_retriever.find(
             name_path_pattern,
-            include_kinds=parsed_include_kinds,
-            exclude_kinds=parsed_exclude_kinds,
+            include_kinds=kind_filter.get_lsp_include_kinds(),
+            exclude_kinds=kind_filter.get_lsp_exclude_kinds(),
             substring_matching=substring_matching,
             within_relative_path=relative_path,
+            max_results=max_matches + 1 if max_matches > 0 else None,
+            predicate=lambda s: kind_filter.is_included(s) and terraform_resource_filter.is_included(s),
+            regex_matching=regex_matching,
         )
         n_matches = len(symbols)
 
@@ -215,7 +394,7 @@ class FindSymbolTool(Tool, ToolMarkerSymbolicRead):
             return f"Shortened result:\n{self._to_json(relative_path_to_name_paths)}"
 
         if 0 < max_matches < n_matches:
-            return f"Matched {n_matches}>{max_matches=} symbols.\n" + create_short_result_relative_path_to_name_paths()
+            return f"Matched more than {max_matches=} symbols.\n" + create_short_result_relative_path_to_name_paths()
 
         symbol_dicts = [
             s.to_dict(
@@ -228,9 +407,13 @@ class FindSymbolTool(Tool, ToolMarkerSymbolicRead):
                 body=include_body,
                 children_name=True,
                 children_name_path=False,
+                leading_comments=include_leading_comments,
             )
             for s in symbols
         ]
+        for s, s_dict in zip(symbols, symbol_dicts, strict=True):
+            if address := TerraformBlockType.get_address(s):
+                s_dict["address"] = address  # type: ignore[typeddict-unknown-key]
         if not include_body and include_info:
             info_by_symbol = symbol_retriever.request_info_for_symbol_batch(symbols)
             for s, s_dict in zip(symbols, symbol_dicts, strict=True):
@@ -240,8 +423,9 @@ class FindSymbolTool(Tool, ToolMarkerSymbolicRead):
                     # If we ever upgrade to 3.15, we can remove the type: ignore[typeddict-unknown-key]
                     s_dict["info"] = symbol_info
 
+        structured_data = copy.deepcopy(symbol_dicts)
         grouped_symbol_dicts = self.symbol_dict_grouper.group(symbol_dicts)
-        result = self._to_json(grouped_symbol_dicts)
+        result = StructuredToolResult(self._to_output(grouped_symbol_dicts, output_format), structured_data)
         return self._limit_length(result, max_answer_chars, shortened_result_factories=[create_short_result_relative_path_to_name_paths])
 
     @classmethod
@@ -255,43 +439,62 @@ class FindReferencingSymbolsTool(Tool, ToolMarkerSymbolicRead):
     """
 
     symbol_dict_grouper = LanguageServerSymbolDictGrouper(["relative_path", "kind"], ["kind"], collapse_singleton=True)
+    STRUCTURED_OUTPUT_SCHEMA = {
+        "type": "array",
+        "items": {
+            "type": "object",
+            "properties": {
+                **_SYMBOL_PROPERTIES_SCHEMA,
+                "reference_line": {"type": "integer"},
+                "content_around_reference": {"type": "string"},
+            },
+        },
+    }
 
     # noinspection PyDefaultArgument
     def apply(
         self,
         name_path: str,
         relative_path: str,
-        include_kinds: list[int] = [],  # noqa: B006
-        exclude_kinds: list[int] = [],  # noqa: B006
+        include_kinds: list[int | str] = [],  # noqa: B006
+        exclude_kinds: list[int | str] = [],  # noqa: B006
+        context_lines: int = 1,
         max_answer_chars: int = -1,
     ) -> str:
         """
         Finds references to the symbol at the given `name_path`. The result will contain metadata about the referencing symbols
-        as well as a short code snippet around the reference.
+        (the symbols containing the references) as well as a short code snippet around the reference.
 
         :param name_path: name path of the symbol
         :param relative_path: the relative path to the file containing the symbol for which to find references.
-        :param include_kinds: (optional) limits results to the given LSP symbol kinds (integers)
-        :param exclude_kinds: optional list of LSP symbol kinds (integers) to exclude.
+        :param include_kinds: (optional) limits results to the given symbol kinds, which can be given as LSP symbol kinds
+            (integers or names, e.g. 12 or "Function") or, for Terraform, as block types
+            ("resource", "data", "module", "variable", "output", "provider", "local")
+        :param exclude_kinds: (optional) list of symbol kinds to exclude (same format as include_kinds).
+        :param context_lines: the number of lines to include before and after each reference in the code snippet
         :param max_answer_chars: max result length; -1 for default
         :return: a list of JSON objects with the symbols referencing the requested symbol
         """
+        if context_lines < 0:
+            raise ValueError(f"context_lines must be non-negative, got {context_lines}")
+
         # file system sync needed for case where symbol finder does not perform a global search, updating everything
         if relative_path:
             self.project.ls_sync_file_system_changes()
 
         include_body = False  # It is probably never a good idea to include the body of the referencing symbols
-        parsed_include_kinds: Sequence[SymbolKind] | None = [SymbolKind(k) for k in include_kinds] if include_kinds else None
-        parsed_exclude_kinds: Sequence[SymbolKind] | None = [SymbolKind(k) for k in exclude_kinds] if exclude_kinds else None
+        kind_filter = SymbolKindFilter(include_kinds, exclude_kinds)
 
         symbol_retriever = self.create_language_server_symbol_retriever()
         references_in_symbols = symbol_retriever.find_referencing_symbols(
             name_path,
             relative_file_path=relative_path,
             include_body=include_body,
-            include_kinds=parsed_include_kinds,
-            exclude_kinds=parsed_exclude_kinds,
+            include_kinds=kind_filter.get_lsp_include_kinds(),
+            exclude_kinds=kind_filter.get_lsp_exclude_kinds(),
         )
+        if kind_filter.requires_post_filtering():
+            references_in_symbols = [ref for ref in references_in_symbols if kind_filter.is_included(ref.symbol)]
 
         reference_dicts = []
         for ref in references_in_symbols:
@@ -301,7 +504,7 @@ class FindReferencingSymbolsTool(Tool, ToolMarkerSymbolicRead):
                 ref_relative_path = ref.symbol.location.relative_path
                 assert ref_relative_path is not None, f"Referencing symbol {ref.symbol.name} has no relative path, this is likely a bug."
                 content_around_ref = self.project.retrieve_content_around_line(
-                    relative_file_path=ref_relative_path, line=ref.line, context_lines_before=1, context_lines_after=1
+                    relative_file_path=ref_relative_path, line=ref.line, context_lines_before=context_lines, context_lines_after=context_lines
                 )
                 ref_dict["content_around_reference"] = content_around_ref.to_display_string()
             reference_dicts.append(ref_dict)
@@ -318,6 +521,7 @@ class FindReferencingSymbolsTool(Tool, ToolMarkerSymbolicRead):
                 }
             )
 
+        structured_data = copy.deepcopy(reference_dicts)
         result = self.symbol_dict_grouper.group(reference_dicts)
 
         # shortened result closures, from least to most aggressive shortening
@@ -335,7 +539,7 @@ class FindReferencingSymbolsTool(Tool, ToolMarkerSymbolicRead):
 
         shortened_results = [make_refs_without_context, make_per_file_counts, make_summary]
 
-        result_json = self._to_json(result)
+        result_json = StructuredToolResult(self._to_json(result), structured_data)
         return self._limit_length(result_json, max_answer_chars, shortened_result_factories=shortened_results)
 
 
@@ -350,8 +554,8 @@ class FindImplementationsTool(Tool, ToolMarkerSymbolicRead):
         name_path: str,
         relative_path: str,
         include_info: bool = False,
-        include_kinds: list[int] = [],  # noqa: B006
-        exclude_kinds: list[int] = [],  # noqa: B006
+        include_kinds: list[int | str] = [],  # noqa: B006
+        exclude_kinds: list[int | str] = [],  # noqa: B006
         max_answer_chars: int = -1,
     ) -> str:
         """
@@ -362,25 +566,27 @@ class FindImplementationsTool(Tool, ToolMarkerSymbolicRead):
             Note that here you can't pass a directory but must pass a file.
         :param include_info: whether to include additional info (hover-like, typically including docstring and signature),
             about the implementing symbols.
-        :param include_kinds: (optional) limits results to the given LSP symbol kinds (integers)
-        :param exclude_kinds: (optional) list of LSP symbol kinds (integers) to exclude.
+        :param include_kinds: (optional) limits results to the given symbol kinds, which can be given as LSP symbol kinds
+            (integers or names, e.g. 12 or "Function") or, for Terraform, as block types
+            ("resource", "data", "module", "variable", "output", "provider", "local")
+        :param exclude_kinds: (optional) list of symbol kinds to exclude (same format as include_kinds).
         :param max_answer_chars: max result length; -1 for default
         :return: a list of JSON objects with the symbols implementing the requested symbol
         """
         self.project.ls_sync_file_system_changes()
 
         include_body = False
-        parsed_include_kinds: Sequence[SymbolKind] | None = [SymbolKind(k) for k in include_kinds] if include_kinds else None
-        parsed_exclude_kinds: Sequence[SymbolKind] | None = [SymbolKind(k) for k in exclude_kinds] if exclude_kinds else None
+        kind_filter = SymbolKindFilter(include_kinds, exclude_kinds)
         symbol_retriever = self.create_language_server_symbol_retriever()
 
         implementing_symbols = symbol_retriever.find_implementing_symbols(
             name_path,
             relative_file_path=relative_path,
             include_body=include_body,
-            include_kinds=parsed_include_kinds,
-            exclude_kinds=parsed_exclude_kinds,
+            include_kinds=kind_filter.get_lsp_include_kinds(),
+            exclude_kinds=kind_filter.get_lsp_exclude_kinds(),
         )
+        implementing_symbols = kind_filter.apply(implementing_symbols)
 
         symbol_dicts = [
             dict(s.to_dict(kind=True, relative_path=True, depth=0, body=include_body, body_location=True)) for s in implementing_symbols
@@ -429,18 +635,7 @@ class FindDeclarationTool(Tool, ToolMarkerSymbolicRead):
         regex = self._sanitize_input_param(regex)
 
         # find relevant location for lookup
-        editor = self.create_code_editor()
-        if not containing_symbol_name_path:
-            content = editor.read_file(relative_path)
-            coords = find_text_coordinates(content, regex, require_unique=True)
-            assert coords is not None
-        else:
-            symbol = symbol_retriever.find_unique(name_path_pattern=containing_symbol_name_path, within_relative_path=relative_path)
-            body_line_numers = symbol.get_body_line_numbers_or_raise()
-            content = editor.read_file(relative_path, lines=body_line_numers)
-            coords = find_text_coordinates(content, regex, require_unique=True)
-            assert coords is not None
-            coords.line += body_line_numers[0]
+        coords = _find_regex_group_coordinates(self, symbol_retriever, relative_path, regex, containing_symbol_name_path)
 
         # retrieve declaration
         defining_symbol = symbol_retriever.find_declaration(
@@ -479,12 +674,248 @@ class FindDeclarationTool(Tool, ToolMarkerSymbolicRead):
         return symbol_dict
 
 
+class GetSymbolInfoTool(Tool, ToolMarkerSymbolicRead):
+    """
+    Gets the documentation and type information provided by the language server for a symbol and its children
+    """
+
+    def apply(self, name_path_pattern: str, relative_path: str, include_children: bool = True, max_answer_chars: int = -1) -> str:
+        """
+        Gets the documentation and type information (as shown on hover in an IDE) for a symbol and, optionally, its direct children.
+        For a Terraform resource or data source, for instance, this is the provider's documentation of the resource type
+        and the types and descriptions of the attributes and nested blocks used in it.
+        Use it to check the valid arguments or the meaning of a symbol without consulting external documentation.
+
+        :param name_path_pattern: the name path pattern of the symbol (see `find_symbol`), which must match a unique symbol in the file
+        :param relative_path: the relative path of the file containing the symbol
+        :param include_children: whether to include the information for the symbol's direct children (e.g. attributes)
+        :param max_answer_chars: max result length; -1 for default
+        :return: the symbol (name path, kind, location) with its information (`info`, omitted if the language server provides none)
+            and, if requested, its children along with their information
+        """
+        self.project.ls_sync_file_system_changes()
+
+        symbol_retriever = self.create_language_server_symbol_retriever()
+        symbol = symbol_retriever.find_unique(name_path_pattern, within_relative_path=relative_path)
+        children = list(symbol.iter_children()) if include_children else []
+        info_by_symbol = symbol_retriever.request_info_for_symbol_batch([symbol, *children])
+
+        symbol_dict: dict[str, Any] = dict(symbol.to_dict(kind=True, relative_path=True, body_location=True))
+        if symbol_info := info_by_symbol.get(symbol):
+            symbol_dict["info"] = symbol_info
+        if children:
+            child_dicts = []
+            for child in children:
+                child_dict: dict[str, Any] = dict(child.to_dict(name_path=False, name=True, kind=True))
+                if child_info := info_by_symbol.get(child):
+                    child_dict["info"] = child_info
+                child_dicts.append(child_dict)
+            symbol_dict["children"] = child_dicts
+        return self._limit_length(self._to_json(symbol_dict), max_answer_chars)
+
+    @classmethod
+    def get_param_aliases(cls) -> dict[str, str]:
+        return {"name_path": "name_path_pattern"}
+
+
+class GetCompletionsTool(Tool, ToolMarkerSymbolicRead):
+    """
+    Gets the completions proposed by the language server at a location in a file (e.g. the valid attributes of a block)
+    """
+
+    def apply(
+        self,
+        relative_path: str,
+        regex: str,
+        containing_symbol_name_path: str | None = None,
+        max_answer_chars: int = -1,
+    ) -> str:
+        r"""
+        Gets the completions the language server proposes at a location in a file, i.e. the names and values which are valid there.
+        For Terraform, the completions are determined by the provider schemas: requested at the start of a line within a block,
+        they are the valid attributes and nested blocks (e.g. of a resource); requested after `=`, they are the valid values.
+
+        :param relative_path: the relative path of the file
+        :param regex: a regular expression with one group, where the start of the group is the location at which completions
+            are requested. For example, to get the attributes which are valid in the block `resource "aws_s3_bucket" "logs"`,
+            pass an expression like `resource "aws_s3_bucket" "logs" \{\n()`.
+            Uses Python syntax with MULTILINE and DOTALL flags enabled.
+        :param containing_symbol_name_path: optional name path of a containing symbol whose body shall be searched instead of the full file.
+        :param max_answer_chars: max result length; -1 for default
+        :return: the completions, each with the completion text, the kind and (if available) details such as type information
+        """
+        self.project.ls_sync_file_system_changes()
+
+        symbol_retriever = self.create_language_server_symbol_retriever()
+        relative_path = self._sanitize_input_param(relative_path)
+        regex = self._sanitize_input_param(regex)
+        coords = _find_regex_group_coordinates(self, symbol_retriever, relative_path, regex, containing_symbol_name_path)
+
+        lang_server = symbol_retriever.get_language_server(relative_path)
+        completions = lang_server.request_completions(relative_path, coords.line, coords.col, allow_incomplete=True)
+        completion_dicts = []
+        for completion in sorted(completions, key=lambda c: c["completionText"]):
+            completion_dict = {"text": completion["completionText"], "kind": CompletionItemKind(completion["kind"]).name}
+            if "detail" in completion:
+                completion_dict["detail"] = completion["detail"]
+            completion_dicts.append(completion_dict)
+        return self._limit_length(self._to_json(completion_dicts), max_answer_chars)
+
+
+class GetCodeActionsTool(Tool, ToolMarkerSymbolicRead):
+    """
+    Lists the code actions (e.g. quick fixes) which the language server offers for a file or a range of lines
+    """
+
+    def apply(self, relative_path: str, start_line: int = 0, end_line: int = -1, max_answer_chars: int = -1) -> str:
+        """
+        Lists the code actions which the language server offers for a range of lines, e.g. quick fixes for diagnostics
+        (such as adding missing required attributes) or formatting fixes. A code action can be applied via `apply_code_action`.
+
+        :param relative_path: the relative path of the file
+        :param start_line: the first 0-based line of the range. Defaults to 0.
+        :param end_line: the last 0-based line of the range. Defaults to -1, which means until the end of the file.
+        :param max_answer_chars: max result length; -1 for default
+        :return: the code actions with their titles, kinds (e.g. `quickfix`), the messages of the diagnostics they fix
+            and whether they are the preferred fix
+        """
+        self.project.ls_sync_file_system_changes()
+
+        lang_server = self.create_language_server_symbol_retriever().get_language_server(relative_path)
+        code_action_dicts = []
+        for code_action in lang_server.request_code_actions(relative_path, start_line=start_line, end_line=end_line):
+            code_action_dict: dict[str, Any] = {"title": code_action["title"]}
+            if "kind" in code_action:
+                code_action_dict["kind"] = code_action["kind"]
+            if diagnostics := code_action.get("diagnostics"):
+                code_action_dict["fixes"] = [diagnostic["message"] for diagnostic in diagnostics]
+            if code_action.get("isPreferred"):
+                code_action_dict["preferred"] = True
+            code_action_dicts.append(code_action_dict)
+        return self._limit_length(self._to_json(code_action_dicts), max_answer_chars)
+
+
+class ApplyCodeActionTool(EditingToolWithDiagnostics):
+    """
+    Applies a code action (e.g. a quick fix) offered by the language server
+    """
+
+    def apply(self, relative_path: str, title: str, start_line: int = 0, end_line: int = -1) -> str:
+        """
+        Applies a code action which the language server offers for a range of lines (as listed by `get_code_actions`).
+
+        :param relative_path: the relative path of the file
+        :param title: the title of the code action to apply
+        :param start_line: the first 0-based line of the range for which the code action is offered (as passed to `get_code_actions`)
+        :param end_line: the last 0-based line of the range; -1 for the end of the file
+        :return: a status message
+        """
+        self.project.ls_sync_file_system_changes()
+        with self.DiagnosticsContext(self, relative_path) as diagnostics_context:
+            code_editor = self.create_ls_code_editor()
+            status_message = code_editor.apply_code_action(relative_path, title, start_line=start_line, end_line=end_line)
+            return diagnostics_context.format_result(status_message)
+
+
+class FormatFileTool(Tool, ToolMarkerSymbolicEdit):
+    """
+    Formats a file using the language server (e.g. canonical HCL formatting for Terraform files)
+    """
+
+    def apply(self, relative_path: str) -> str:
+        """
+        Formats a file according to the language's conventions using the language server's formatting
+        (for Terraform, the canonical formatting of `terraform fmt`). Use it after editing a file.
+
+        :param relative_path: the relative path of the file
+        :return: a message indicating whether the file was changed
+        """
+        self.project.ls_sync_file_system_changes()
+        code_editor = self.create_ls_code_editor()
+        if code_editor.format_file(relative_path):
+            return f"Formatted {relative_path}."
+        return f"{relative_path} is formatted already; no changes were made."
+
+
+class FormatProjectTool(Tool, ToolMarkerSymbolicEdit):
+    """
+    Formats all source files in the project or a directory using the language servers
+    """
+
+    def apply(self, relative_path: str = "", max_answer_chars: int = -1) -> str:
+        """
+        Formats all source files in the project (or in the given directory) according to the languages' conventions
+        using the language servers' formatting (for Terraform, the canonical formatting of `terraform fmt`).
+
+        :param relative_path: the relative path of the directory whose files to format; if empty, the entire project is formatted
+        :param max_answer_chars: max result length; -1 for default
+        :return: the relative paths of the files which were changed and, if any, the files which could not be formatted
+            (along with the reasons)
+        """
+        self.project.ls_sync_file_system_changes()
+        code_editor = self.create_ls_code_editor()
+        changed_files = []
+        failed_files = {}
+        for file_path in self.project.gather_source_files(relative_path):
+            try:
+                if code_editor.format_file(file_path):
+                    changed_files.append(file_path)
+            except Exception as e:
+                failed_files[file_path] = str(e)
+
+        result: dict[str, Any] = {"changed_files": changed_files}
+        if failed_files:
+            result["failed_files"] = failed_files
+        return self._limit_length(self._to_json(result), max_answer_chars)
+
+
+def _group_diagnostics_by_owner_symbol(
+    symbol_retriever: LanguageServerSymbolRetriever, diagnostics_by_file: dict[str, list[Diagnostic]]
+) -> GroupedDiagnostics:
+    """
+    Groups diagnostics by file, severity and the symbol containing them.
+
+    :param symbol_retriever: the symbol retriever with which to find the symbols containing the diagnostics
+    :param diagnostics_by_file: a mapping from relative file paths to the diagnostics of the respective file
+    :return: the grouped diagnostics, where diagnostics which cannot be mapped to a symbol are grouped under the name path
+        `GetDiagnosticsForFileTool.FILE_LEVEL_DIAGNOSTIC_BUCKET`
+    """
+    grouped_diagnostics = GroupedDiagnostics()
+    for relative_path, diagnostics in sorted(diagnostics_by_file.items()):
+        for diagnostic in diagnostics:
+            diag_start = diagnostic["range"]["start"]
+            owner_symbol = symbol_retriever.find_diagnostic_owner_symbol(
+                relative_file_path=relative_path, line=diag_start["line"], column=diag_start["character"]
+            )
+            name_path = GetDiagnosticsForFileTool.FILE_LEVEL_DIAGNOSTIC_BUCKET
+            if owner_symbol is not None:
+                name_path = owner_symbol.get_name_path()
+            grouped_diagnostics.add(relative_path, name_path, diagnostic)
+    return grouped_diagnostics
+
+
 class GetDiagnosticsForFileTool(Tool, ToolMarkerSymbolicRead):
     """
     Gets diagnostics for a file, optionally restricted to a line range, grouped by file, severity, and containing symbol.
     """
 
     FILE_LEVEL_DIAGNOSTIC_BUCKET = "<file>"
+    STRUCTURED_OUTPUT_SCHEMA = {
+        "type": "array",
+        "items": {
+            "type": "object",
+            "properties": {
+                "relative_path": {"type": "string"},
+                "severity": {"type": "string"},
+                "name_path": {"type": "string"},
+                "message": {"type": "string"},
+                "range": {"type": "object"},
+                "code": {"type": ["string", "integer"]},
+                "source": {"type": "string"},
+            },
+            "required": ["relative_path", "severity", "name_path", "message", "range"],
+        },
+    }
 
     def apply(
         self,
@@ -516,21 +947,59 @@ class GetDiagnosticsForFileTool(Tool, ToolMarkerSymbolicRead):
             min_severity=min_severity,
         )
 
-        grouped_diagnostics = GroupedDiagnostics()
-        for diagnostic in diagnostics:
-            diag_range = diagnostic["range"]["start"]
-            name_path = self.FILE_LEVEL_DIAGNOSTIC_BUCKET
-            owner_symbol = symbol_retriever.find_diagnostic_owner_symbol(
-                relative_file_path=relative_path,
-                line=diag_range["line"],
-                column=diag_range["character"],
-            )
-            if owner_symbol is not None:
-                name_path = owner_symbol.get_name_path()
-            grouped_diagnostics.add(relative_path, name_path, diagnostic)
+        grouped_diagnostics = _group_diagnostics_by_owner_symbol(symbol_retriever, {relative_path: diagnostics})
+        result = StructuredToolResult(self._to_json(grouped_diagnostics.get_dict()), grouped_diagnostics.get_list())
+        return self._limit_length(result, max_answer_chars)
+
+
+class GetDiagnosticsTool(Tool, ToolMarkerSymbolicRead):
+    """
+    Gets the errors and warnings (diagnostics) of a file, a directory or the entire project.
+    """
+
+    DEFAULT_MAX_FILES = 200
+    STRUCTURED_OUTPUT_SCHEMA = GetDiagnosticsForFileTool.STRUCTURED_OUTPUT_SCHEMA
+
+    def apply(
+        self,
+        relative_path: str = "",
+        min_severity: int = 2,
+        max_files: int = DEFAULT_MAX_FILES,
+        max_answer_chars: int = -1,
+    ) -> str:
+        """
+        Gets the diagnostics reported by the language servers for a file, for all files in a directory or for the entire project.
+        Use it to verify your edits (e.g. to check that no errors were introduced) without running the project's build or
+        validation commands. Diagnostics are grouped as `relative_path -> severity -> name_path -> diagnostics_results`,
+        where diagnostics that cannot be mapped to a symbol are grouped under the special name path `<file>`.
+
+        :param relative_path: the relative path of the file or directory to check; if empty, the entire project is checked
+        :param min_severity: minimum LSP severity to include, where 1=Error, 2=Warning, 3=Information, 4=Hint.
+            By default, errors and warnings are included.
+        :param max_files: the maximum number of files to check (per language); -1 for no limit
+        :param max_answer_chars: max result length; -1 for default
+        :return: the grouped diagnostics of the files with diagnostics (files without diagnostics are omitted),
+            followed by notes on files which were not checked or whose diagnostics were not received in time
+        """
+        self.project.ls_sync_file_system_changes()
+
+        symbol_retriever = self.create_language_server_symbol_retriever()
+        diagnostics_by_file, num_skipped_files, timed_out_files = symbol_retriever.get_diagnostics(
+            within_relative_path=relative_path or None,
+            min_severity=min_severity,
+            max_files=max_files,
+        )
 
+        grouped_diagnostics = _group_diagnostics_by_owner_symbol(symbol_retriever, diagnostics_by_file)
         result = self._to_json(grouped_diagnostics.get_dict())
-        return self._limit_length(result, max_answer_chars)
+        if num_skipped_files > 0:
+            result += f"\n{num_skipped_files} files were not checked (limit: {max_files} files); restrict the path or increase `max_files`."
+        if timed_out_files:
+            result += (
+                f"\nThe diagnostics of {len(timed_out_files)} files were not received in time, so these files may contain "
+                f"unreported problems: {', '.join(sorted(timed_out_files))}"
+            )
+        return self._limit_length(StructuredToolResult(result, grouped_diagnostics.get_list()), max_answer_chars)
 
 
 class GetDiagnosticsForSymbolTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
@@ -592,6 +1061,10 @@ class ReplaceSymbolBodyTool(EditingToolWithDiagnostics):
         name_path: str,
         relative_path: str,
         body: str,
+        occurrence_index: int | None = None,
+        include_leading_comments: bool = False,
+        force: bool = False,
+        preview: bool = False,
     ) -> str:
         r"""
         Replaces the body of the given symbol.
@@ -604,14 +1077,31 @@ class ReplaceSymbolBodyTool(EditingToolWithDiagnostics):
         :param body: the new symbol body. The symbol body is the definition of a symbol
             in the programming language, including e.g. the signature line for functions.
             Depending on the language, it may or may not include a preceding docstring or other preceding annotations.
+        :param occurrence_index: if multiple symbols match the name path, the index of the intended symbol
+            (as listed in the error message returned for the ambiguous call); leave unset if the name path is unique
+        :param include_leading_comments: whether the comment lines immediately preceding the symbol are part of the body to be replaced
+            (as when retrieving the body via find_symbol with include_leading_comments=True); if so, the new body should contain
+            the (possibly updated) comments, as they are otherwise removed
+        :param force: whether to apply the edit even if the file already contains syntax errors
+            (by default, such edits are refused and the syntax errors are returned)
+        :param preview: if True, do not modify the file but return a unified diff of the changes the edit would make
         """
-        with self.DiagnosticsContext(self, relative_path) as diagnostics_context:
-            code_editor = self.create_code_editor()
+
+        def replace_body(code_editor: "CodeEditor") -> None:
             code_editor.replace_body(
                 name_path,
                 relative_file_path=relative_path,
                 body=body,
+                occurrence_index=occurrence_index,
+                include_leading_comments=include_leading_comments,
             )
+
+        if preview:
+            return self._preview_edit(replace_body)
+        self._refuse_edit_of_unparsable_file(relative_path, force)
+        self._backup_file(relative_path)
+        with self.DiagnosticsContext(self, relative_path) as diagnostics_context:
+            replace_body(self.create_code_editor())
             return diagnostics_context.format_result(SUCCESS_RESULT)
 
 
@@ -625,6 +1115,8 @@ class InsertAfterSymbolTool(EditingToolWithDiagnostics):
         name_path: str,
         relative_path: str,
         body: str,
+        occurrence_index: int | None = None,
+        force: bool = False,
     ) -> str:
         """
         Use this to insert code after a class/method/function definition.
@@ -633,11 +1125,16 @@ class InsertAfterSymbolTool(EditingToolWithDiagnostics):
         :param name_path: name path of the symbol after which to insert content
         :param relative_path: the relative path to the file containing the symbol
         :param body: the body/content to be inserted. The inserted code shall begin with the next line after
-            the symbol.
+            the symbol. It is re-indented to match the indentation of the symbol.
+        :param occurrence_index: if multiple symbols match the name path, the index of the intended symbol
+            (as listed in the error message returned for the ambiguous call); leave unset if the name path is unique
+        :param force: whether to apply the edit even if the file already contains syntax errors
+            (by default, such edits are refused and the syntax errors are returned)
         """
+        self._refuse_edit_of_unparsable_file(relative_path, force)
         with self.DiagnosticsContext(self, relative_path) as diagnostics_context:
             code_editor = self.create_code_editor()
-            code_editor.insert_after_symbol(name_path, relative_file_path=relative_path, body=body)
+            code_editor.insert_after_symbol(name_path, relative_file_path=relative_path, body=body, occurrence_index=occurrence_index)
             return diagnostics_context.format_result(SUCCESS_RESULT)
 
 
@@ -651,6 +1148,8 @@ class InsertBeforeSymbolTool(EditingToolWithDiagnostics):
         name_path: str,
         relative_path: str,
         body: str,
+        occurrence_index: int | None = None,
+        force: bool = False,
     ) -> str:
         """
         Inserts the given content before the beginning of the definition of the given symbol (via the symbol's location).
@@ -659,11 +1158,79 @@ class InsertBeforeSymbolTool(EditingToolWithDiagnostics):
 
         :param name_path: name path of the symbol before which to insert content
         :param relative_path: the relative path to the file containing the symbol
-        :param body: the body/content to be inserted before the line in which the referenced symbol is defined
+        :param body: the body/content to be inserted before the line in which the referenced symbol is defined;
+            it is re-indented to match the indentation of the symbol
+        :param occurrence_index: if multiple symbols match the name path, the index of the intended symbol
+            (as listed in the error message returned for the ambiguous call); leave unset if the name path is unique
+        :param force: whether to apply the edit even if the file already contains syntax errors
+            (by default, such edits are refused and the syntax errors are returned)
+        """
+        self._refuse_edit_of_unparsable_file(relative_path, force)
+        with self.DiagnosticsContext(self, relative_path) as diagnostics_context:
+            code_editor = self.create_code_editor()
+            code_editor.insert_before_symbol(name_path, relative_file_path=relative_path, body=body, occurrence_index=occurrence_index)
+            return diagnostics_context.format_result(SUCCESS_RESULT)
+
+
+class GetBlockAttributeTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Reads the value of a single attribute of an HCL block (e.g. a Terraform resource).
+    Enabled automatically for projects using Terraform (see `TERRAFORM_TOOLS`).
+    """
+
+    def apply(self, name_path: str, relative_path: str, attribute: str, occurrence_index: int | None = None) -> str:
+        """
+        Reads the value of a single attribute (e.g. `instance_type`) of a block (e.g. `resource "aws_instance" "web"`),
+        which is cheaper than retrieving the block's full body.
+
+        :param name_path: name path of the block
+        :param relative_path: the relative path to the file containing the block
+        :param attribute: the name of the attribute, which must be directly contained in the block
+            (for attributes of nested blocks, use the nested block's name path)
+        :param occurrence_index: if multiple symbols match the name path, the index of the intended symbol
+            (as listed in the error message returned for the ambiguous call); leave unset if the name path is unique
+        :return: the attribute's value expression as written in the file (e.g. `"t3.micro"` or `var.instance_type`)
+        """
+        code_editor = self.create_code_editor()
+        return code_editor.get_block_attribute(name_path, relative_path, attribute, occurrence_index=occurrence_index)
+
+
+class SetBlockAttributeTool(EditingToolWithDiagnostics, ToolMarkerOptional):
+    """
+    Sets the value of a single attribute of an HCL block (e.g. a Terraform resource).
+    Enabled automatically for projects using Terraform (see `TERRAFORM_TOOLS`).
+    """
+
+    def apply(
+        self,
+        name_path: str,
+        relative_path: str,
+        attribute: str,
+        value: str,
+        occurrence_index: int | None = None,
+        force: bool = False,
+    ) -> str:
         """
+        Sets the value of a single attribute (e.g. `instance_type`) of a block (e.g. `resource "aws_instance" "web"`),
+        preserving the remainder of the block, including comments and formatting.
+        If the block does not contain the attribute, it is added after the block's last attribute.
+        Prefer this tool over replace_symbol_body for changing individual attributes.
+
+        :param name_path: name path of the block
+        :param relative_path: the relative path to the file containing the block
+        :param attribute: the name of the attribute, which must be directly contained in the block
+            (for attributes of nested blocks, use the nested block's name path)
+        :param value: the new value expression in HCL syntax, e.g. `"t3.micro"` (strings must be quoted),
+            `var.instance_type`, `3` or `["a", "b"]`
+        :param occurrence_index: if multiple symbols match the name path, the index of the intended symbol
+            (as listed in the error message returned for the ambiguous call); leave unset if the name path is unique
+        :param force: whether to apply the edit even if the file already contains syntax errors
+            (by default, such edits are refused and the syntax errors are returned)
+        """
+        self._refuse_edit_of_unparsable_file(relative_path, force)
         with self.DiagnosticsContext(self, relative_path) as diagnostics_context:
             code_editor = self.create_code_editor()
-            code_editor.insert_before_symbol(name_path, relative_file_path=relative_path, body=body)
+            code_editor.set_block_attribute(name_path, relative_path, attribute, value, occurrence_index=occurrence_index)
             return diagnostics_context.format_result(SUCCESS_RESULT)
 
 
@@ -678,21 +1245,195 @@ class RenameSymbolTool(Tool, ToolMarkerSymbolicEdit):
         name_path: str,
         relative_path: str,
         new_name: str,
+        occurrence_index: int | None = None,
+        dry_run: bool = False,
+        max_answer_chars: int = -1,
     ) -> str:
         """
         Renames the symbol with the given `name_path` to `new_name` throughout the entire codebase.
         Note: for languages with method overloading, like Java, name_path may have to include a method's
         signature to uniquely identify a method.
+        For large cross-file renames, consider reviewing the changes with dry_run=True first.
 
-        :param name_path: name path of the symbol to rename
+        :param name_path: name path of the symbol to rename (for Terraform, the symbol's address can be used instead,
+            e.g. "var.region")
         :param relative_path: the relative path to the file containing the symbol to rename
         :param new_name: the new name for the symbol
-        :return: result summary indicating success or failure
+        :param occurrence_index: if multiple symbols match the name path, the index of the intended symbol
+            (as listed in the error message returned for the ambiguous call); leave unset if the name path is unique
+        :param dry_run: if True, do not modify anything; return the prospective changes (per-file diffs and the number of
+            affected locations) instead
+        :param max_answer_chars: the maximum length of the dry run result; -1 means the default value from the config will be used
+        :return: result summary indicating success or failure (or the prospective changes in case of a dry run)
         """
         self.project.ls_sync_file_system_changes()
         code_editor = self.create_ls_code_editor()
-        status_message = code_editor.rename_symbol(name_path, relative_path=relative_path, new_name=new_name)
-        return status_message
+        if not dry_run:
+            return code_editor.rename_symbol(name_path, relative_path=relative_path, new_name=new_name, occurrence_index=occurrence_index)
+
+        previews = code_editor.preview_rename_symbol(
+            name_path, relative_path=relative_path, new_name=new_name, occurrence_index=occurrence_index
+        )
+        num_locations = sum(preview.num_locations for preview in previews)
+        header = (
+            f"DRY RUN - no changes were applied. Renaming '{name_path}' to '{new_name}' would change {num_locations} location(s) "
+            f"in {len(previews)} file(s). Re-issue with dry_run=False to apply the rename."
+        )
+        result = "\n\n".join([header] + [preview.to_string() for preview in previews])
+
+        def make_per_file_counts() -> str:
+            counts = {preview.relative_path: preview.num_locations for preview in previews}
+            return f"{header}\nLocations per file:\n{self._to_json(counts)}"
+
+        return self._limit_length(result, max_answer_chars, [make_per_file_counts])
+
+
+class MoveSymbolTool(Tool, ToolMarkerSymbolicEdit, ToolMarkerOptional):
+    """
+    Moves a Terraform resource or module block to another file and/or renames it, adding a `moved` block for the changed address.
+    Enabled automatically for projects using Terraform (see `TERRAFORM_TOOLS`).
+    """
+
+    def apply(
+        self,
+        name_path: str,
+        relative_path: str,
+        target_relative_path: str | None = None,
+        new_name: str | None = None,
+        add_moved_block: bool = True,
+    ) -> str:
+        """
+        Moves a resource or module block (including its leading comments) to another file (which is created if necessary)
+        and/or renames it (i.e. changes its name label). If the block's address changes (because it is renamed or moved
+        into a module called by the block's module or vice versa), a `moved` block is added, such that Terraform moves
+        the existing object instead of destroying and re-creating it. Moving a block between files in the same directory
+        does not change its address. References to a renamed block are not updated (use rename_symbol for plain renames).
+
+        :param name_path: name path of the block (or its address, e.g. "aws_instance.web")
+        :param relative_path: the relative path to the file containing the block
+        :param target_relative_path: the relative path of the file to move the block to; if unset, the block stays in its file
+        :param new_name: the new name of the block (e.g. "app" to rename `aws_instance.web` to `aws_instance.app`);
+            if unset, the name is kept
+        :param add_moved_block: whether to add a `moved` block if the block's address changes (to the file containing the
+            block after the move or, when moving the block into a called module, to the calling module's file)
+        :return: a summary of the changes
+        """
+        target_relative_path = target_relative_path or relative_path
+        if target_relative_path == relative_path and new_name is None:
+            raise ValueError("Nothing to do: specify a target file and/or a new name")
+        self.project.validate_relative_path(relative_path, require_not_ignored=True)
+        self.project.validate_relative_path(target_relative_path, require_not_ignored=True)
+        if new_name is not None and re.fullmatch(r"[A-Za-z_][\w-]*", new_name) is None:
+            raise ValueError(f"Invalid block name '{new_name}'")
+        self.project.ls_sync_file_system_changes()
+
+        symbol_retriever = self.create_language_server_symbol_retriever()
+        symbol = symbol_retriever.find_unique(name_path, within_relative_path=relative_path)
+        block_type = TerraformBlockType.of_symbol(symbol)
+        if block_type not in (TerraformBlockType.RESOURCE, TerraformBlockType.MODULE):
+            raise ValueError(f"Only resource and module blocks can be moved, but {symbol.get_name_path()} is not one")
+        old_address = TerraformBlockType.get_address(symbol)
+        assert old_address is not None
+        body = symbol.get_body()
+        body_with_comments = symbol.get_body(include_leading_comments=True)
+        assert body is not None and body_with_comments is not None
+        if new_name is not None:
+            # replace the last label of the block header
+            header_end = body.index("{")
+            header = re.sub(r'"[^"]*"(\s*)$', rf'"{new_name}"\1', body[:header_end], count=1)
+            body_with_comments = body_with_comments[: len(body_with_comments) - len(body)] + header + body[header_end:]
+            new_address = old_address.rsplit(".", 1)[0] + "." + new_name
+        else:
+            new_address = old_address
+
+        # determine the addresses relative to the calling module if the block is moved into or out of a called module
+        moved_block_relative_path = target_relative_path
+        source_dir, target_dir = os.path.dirname(relative_path), os.path.dirname(target_relative_path)
+        if source_dir != target_dir:
+            if (module_name := find_local_module_call(self._read_module_dir_contents(source_dir), source_dir, target_dir)) is not None:
+                new_address = f"module.{module_name}.{new_address}"
+                moved_block_relative_path = relative_path
+            elif (module_name := find_local_module_call(self._read_module_dir_contents(target_dir), target_dir, source_dir)) is not None:
+                old_address = f"module.{module_name}.{old_address}"
+            elif add_moved_block:
+                raise ValueError(
+                    f"Cannot determine the new address of {old_address}, because neither of the directories '{source_dir or '.'}' "
+                    f"and '{target_dir or '.'}' calls the other as a module; set add_moved_block=False to move the block anyway"
+                )
+
+        # back up the affected files and perform all writes in a single transaction, restoring the files if a write fails
+        affected_relative_paths = sorted({relative_path, target_relative_path, moved_block_relative_path})
+        for affected_relative_path in affected_relative_paths:
+            self._backup_file(affected_relative_path)
+        with self.project.edit_history.transaction(self.get_name()):
+            original_contents = {path: self._read_file_if_exists(path) for path in affected_relative_paths}
+            try:
+                code_editor = self.create_ls_code_editor()
+                symbol_name_path = symbol.get_name_path()
+                if target_relative_path == relative_path:
+                    code_editor.replace_body(symbol_name_path, relative_path, body_with_comments, include_leading_comments=True)
+                else:
+                    self._ensure_file_exists(target_relative_path)
+                    code_editor.delete_symbol(symbol_name_path, relative_path, include_leading_comments=True)
+                    code_editor.append_to_file(target_relative_path, body_with_comments)
+                result = f"Moved {old_address} to {new_address} in {target_relative_path}"
+                if add_moved_block and old_address != new_address:
+                    code_editor.append_to_file(moved_block_relative_path, format_moved_block(old_address, new_address))
+                    result += f"; added a moved block to {moved_block_relative_path}"
+            except Exception:
+                log.error(f"Failed to move {old_address}; restoring the affected files {affected_relative_paths}")
+                self._restore_files(original_contents)
+                raise
+        return result
+
+    def _read_file_if_exists(self, relative_path: str) -> str | None:
+        abs_path = os.path.join(self.get_project_root(), relative_path)
+        if not os.path.isfile(abs_path):
+            return None
+        return FileUtils.read_file(abs_path, self.project.project_config.encoding)
+
+    def _restore_files(self, contents_by_relative_path: dict[str, str | None]) -> None:
+        """
+        Restores the given files, recording the changes in the edit history.
+
+        :param contents_by_relative_path: the contents to restore (None if the file did not exist) by relative path
+        """
+        changes: list[tuple[str, FileChangeType]] = []
+        for relative_path, contents in contents_by_relative_path.items():
+            current_contents = self._read_file_if_exists(relative_path)
+            if current_contents == contents:
+                continue
+            abs_path = os.path.join(self.get_project_root(), relative_path)
+            if contents is None:
+                os.remove(abs_path)
+                changes.append((relative_path, FileChangeType.Deleted))
+            else:
+                FileUtils.write_file(abs_path, contents, self.project.project_config.encoding, newline=self.project.line_ending.newline_str)
+                changes.append((relative_path, FileChangeType.Created if current_contents is None else FileChangeType.Changed))
+            self.project.edit_history.record_mutation(relative_path, current_contents, contents)
+        self.project.notify_file_changes(changes)
+
+    def _read_module_dir_contents(self, module_dir: str) -> list[str]:
+        abs_module_dir = os.path.join(self.get_project_root(), module_dir)
+        if not os.path.isdir(abs_module_dir):
+            return []
+        return [
+            FileUtils.read_file(os.path.join(abs_module_dir, filename), self.project.project_config.encoding)
+            for filename in sorted(os.listdir(abs_module_dir))
+            if filename.endswith(".tf")
+        ]
+
+    def _ensure_file_exists(self, relative_path: str) -> None:
+        """
+        Creates the given file (which must have been validated to lie within the project) if it does not exist yet.
+        """
+        abs_path = Path(self.get_project_root()) / relative_path
+        if abs_path.exists():
+            return
+        abs_path.parent.mkdir(parents=True, exist_ok=True)
+        FileUtils.write_file(str(abs_path), "", self.project.project_config.encoding, newline=self.project.line_ending.newline_str)
+        self.project.edit_history.record_mutation(relative_path, None, "")
+        self.project.notify_file_changes([(relative_path, FileChangeType.Created)])
 
 
 class SafeDeleteSymbol(Tool, ToolMarkerSymbolicEdit):
@@ -736,3 +1477,9 @@ class SafeDeleteSymbol(Tool, ToolMarkerSymbolicEdit):
         code_editor = self.create_ls_code_editor()
         code_editor.delete_symbol(symbol_name_path, relative_file_path=symbol_rel_path)
         return SUCCESS_RESULT
+
+
+TERRAFORM_TOOLS: tuple[type[Tool], ...] = (GetBlockAttributeTool, SetBlockAttributeTool, MoveSymbolTool)
+"""
+the optional tools which support only Terraform/HCL files; they are included in the exposed tools if the project uses Terraform
+"""
@@ -2,8 +2,10 @@ import inspect
 import json
 from abc import ABC
 from collections.abc import Callable, Iterable
+from contextlib import AbstractContextManager, nullcontext
 from dataclasses import dataclass
 from functools import cached_property
+from pathlib import Path
 from types import TracebackType
 from typing import TYPE_CHECKING, Any, Optional, Protocol, Self, TypeVar, cast
 
@@ -14,13 +16,22 @@ from sensai.util import logging
 from sensai.util.string import dict_string
 
 from serena.config.serena_config import LanguageBackend
+from serena.edit_history import EditTransaction
 from serena.memories.memory_manager import MemoryManager
 from serena.project import Project
 from serena.prompt_factory import PromptFactory
 from serena.util.class_decorators import singleton
 from serena.util.inspection import iter_subclasses
-from serena.util.ls_diagnostics import DiagnosticsDiff, EditedFilePath, PublishedDiagnosticsSnapshot
+from serena.util.ls_diagnostics import (
+    DiagnosticsDiff,
+    EditedFilePath,
+    GroupedDiagnostics,
+    PublishedDiagnosticsSnapshot,
+    is_syntax_error_diagnostic,
+)
+from serena.util.output_format import OutputFormat
 from solidlsp.ls_exceptions import SolidLSPException
+from solidlsp.ls_utils import FileUtils
 
 if TYPE_CHECKING:
     from serena.agent import SerenaAgent
@@ -32,6 +43,26 @@ T = TypeVar("T")
 SUCCESS_RESULT = "OK"
 
 
+class StructuredToolResult(str):
+    """
+    A tool result (i.e. the string returned to the LLM), which additionally provides the result data in structured form
+    (as described by the tool's `STRUCTURED_OUTPUT_SCHEMA`) for clients supporting structured tool output.
+    Since string operations yield plain strings, results derived from it (e.g. shortened results) do not retain
+    the structured data.
+    """
+
+    structured_data: Any
+
+    def __new__(cls, text: str, structured_data: Any) -> Self:
+        """
+        :param text: the result text
+        :param structured_data: the JSON-serializable result data
+        """
+        result = super().__new__(cls, text)
+        result.structured_data = structured_data
+        return result
+
+
 class Component(ABC):
     def __init__(self, agent: "SerenaAgent"):
         self.agent = agent
@@ -113,6 +144,21 @@ class ToolMarkerSymbolicEdit(ToolMarkerCanEdit):
     """
 
 
+class ToolMarkerOpenWorld(ToolMarker):
+    """
+    Marker class for tools which execute external commands or interact with external services, such that their
+    effects are not limited to the project's files and Serena's own state.
+    """
+
+
+class ToolMarkerConcurrent(ToolMarker):
+    """
+    Marker class for tools which can be executed concurrently with other such tools, because they neither use
+    the language server nor change the state of the agent or any files (e.g. reading files).
+    Tools which can edit are never executed concurrently, even if they are marked as concurrent.
+    """
+
+
 class ToolMarkerBeta(ToolMarker):
     """
     Marker for tools that are considered beta features (may not be fully robust)
@@ -157,6 +203,12 @@ class Tool(Component):
     when the tool is called, allowing tools to be session-aware if needed.
     """
 
+    STRUCTURED_OUTPUT_SCHEMA: dict[str, Any] | None = None
+    """
+    the JSON schema of the structured data which the tool provides along with its result (see :class:`StructuredToolResult`);
+    None if the tool does not provide structured data
+    """
+
     _last_tool_call_client_str: str | None = None
     """We can only get the client info from within a tool call. Each tool call will update this variable."""
 
@@ -216,6 +268,33 @@ class Tool(Component):
         """
         return issubclass(cls, ToolMarkerCanEdit)
 
+    @classmethod
+    def is_open_world(cls) -> bool:
+        """
+        :return: whether this tool executes external commands or interacts with external services
+        """
+        return issubclass(cls, ToolMarkerOpenWorld)
+
+    @classmethod
+    def can_run_concurrently(cls) -> bool:
+        """
+        :return: whether this tool can be executed concurrently with other tools supporting concurrent execution
+        """
+        return issubclass(cls, ToolMarkerConcurrent) and not cls.can_edit()
+
+    @classmethod
+    def get_marker_names(cls) -> list[str]:
+        """
+        :return: the names of the markers of this tool in snake case (e.g. `symbolic_read` for `ToolMarkerSymbolicRead`),
+            sorted alphabetically
+        """
+        marker_names = []
+        for base_cls in cls.__mro__:
+            if issubclass(base_cls, ToolMarker) and not issubclass(base_cls, Tool) and base_cls is not ToolMarker:
+                name = base_cls.__name__.removeprefix("ToolMarker")
+                marker_names.append("".join(["_" + c.lower() if c.isupper() else c for c in name]).lstrip("_"))
+        return sorted(marker_names)
+
     @classmethod
     def get_tool_description(cls) -> str:
         docstring = cls.__doc__
@@ -327,7 +406,43 @@ class Tool(Component):
         """
         return {}
 
-    def apply_ex(self, log_call: bool = True, catch_exceptions: bool = True, mcp_ctx: Context | None = None, **kwargs) -> str:
+    def get_parameter_defaults(self) -> dict[str, Any]:
+        """
+        :return: the active project's overrides of the default values of the tool's parameters (`tool_parameter_defaults`),
+            omitting overrides for parameters which do not exist or are not optional
+        """
+        project = self.agent.get_active_project()
+        if project is None:
+            return {}
+        overrides = project.project_config.tool_parameter_defaults.get(self.get_name(), {})
+        if not overrides:
+            return {}
+        parameters = inspect.signature(self.get_apply_fn()).parameters
+        parameter_defaults = {}
+        for param_name, value in overrides.items():
+            param = parameters.get(param_name)
+            if param is None or param.default is inspect.Parameter.empty:
+                log.warning(f"Ignoring default override for '{param_name}' of tool '{self.get_name()}': no such optional parameter")
+                continue
+            parameter_defaults[param_name] = value
+        return parameter_defaults
+
+    def _apply_parameter_defaults(self, kwargs: dict[str, Any]) -> dict[str, Any]:
+        """
+        Applies the active project's overrides of the tool's parameter defaults (see `get_parameter_defaults`)
+        to the parameters which are not given.
+
+        :param kwargs: the parameters of the tool call
+        :return: the parameters to use
+        """
+        apply_kwargs = dict(kwargs)
+        for param_name, value in self.get_parameter_defaults().items():
+            apply_kwargs.setdefault(param_name, value)
+        return apply_kwargs
+
+    def apply_ex(
+        self, log_call: bool = True, catch_exceptions: bool = True, mcp_ctx: Context | None = None, approved: bool = False, **kwargs
+    ) -> str:
         """
         Applies the tool with logging and exception handling, using the given keyword arguments.
         This method either returns a string result or raises a ToolCallError in case of an error during tool application
@@ -335,6 +450,8 @@ class Tool(Component):
 
         :param log_call: whether to log the tool call and its result
         :param catch_exceptions: whether to catch exceptions and return their messages as strings, instead of raising a ToolCallError
+        :param mcp_ctx: the MCP request context (if the tool is called via MCP)
+        :param approved: whether the user has already explicitly approved the call, such that the approval policy need not be applied
         """
         # obtain session ID and client info
         session_id = "global"
@@ -352,31 +469,74 @@ class Tool(Component):
                 log.info(f"Failed to get client info: {e}.")
 
         def task() -> str:
-            apply_fn = self.get_apply_fn()
+            return self.apply_checked(kwargs, session_id, log_call=log_call, approved=approved)
 
-            try:
-                if not self.is_active():
+        # execute the tool in the agent's task executor, with timeout
+        # (task timeout bounds task execution in the dispatcher once it runs, result timeout limits the time we wait)
+        tool_call_error: ToolCallError
+        timeout = self.agent.serena_config.tool_timeout
+        try:
+            task_exec = self.agent.issue_task(task, name=self.__class__.__name__, timeout=timeout, concurrent=self.can_run_concurrently())
+            return task_exec.result(timeout=timeout)
+        except ToolCallError as e:
+            tool_call_error = e
+        except TimeoutError:
+            msg = f"Tool execution timed out after {timeout} seconds. "
+            log.error(msg)
+            tool_call_error = ToolCallError(msg)
+        except Exception as e:  # unexpected errors (exceptions in the task itself are caught and forwarded as ToolCallError)
+            msg = f"{e.__class__.__name__}: {e}"
+            log.error(msg)
+            tool_call_error = ToolCallError(msg)
+        if catch_exceptions:
+            return tool_call_error.get_error_message()
+        else:
+            raise tool_call_error
+
+    def apply_checked(self, kwargs: dict[str, Any], session_id: str, log_call: bool = True, approved: bool = False) -> str:
+        """
+        Applies the tool with the given keyword arguments, checking whether the tool is active (and has the required active project),
+        applying the approval policy, recording the file mutations in the edit history and retrying once after a
+        language server crash. This method must be called within the agent's task executor (see `apply_ex`).
+
+        :param kwargs: the parameters of the tool call
+        :param session_id: the id of the session in which the tool is called
+        :param log_call: whether to log the tool call and its result
+        :param approved: whether the user has already explicitly approved the call, such that the approval policy need not be applied
+        :return: the result of the tool
+        """
+        apply_fn = self.get_apply_fn()
+
+        try:
+            if not self.is_active():
+                raise ToolCallError(
+                    f"Tool '{self.get_name_from_cls()}' is not active. Active tools: {self.agent.get_active_tool_names()}"
+                )
+
+            if log_call:
+                self._log_tool_application(inspect.currentframe(), session_id)
+
+            # check whether the tool requires an active project and language server
+            if not isinstance(self, ToolMarkerDoesNotRequireActiveProject):
+                if self.agent.get_active_project() is None:
                     raise ToolCallError(
-                        f"Tool '{self.get_name_from_cls()}' is not active. Active tools: {self.agent.get_active_tool_names()}"
+                        "No active project. Ask the user to provide the project path or to select a project from this list of known projects: "
+                        + f"{self.agent.serena_config.project_names}"
                     )
 
-                if log_call:
-                    self._log_tool_application(inspect.currentframe(), session_id)
-
-                # check whether the tool requires an active project and language server
-                if not isinstance(self, ToolMarkerDoesNotRequireActiveProject):
-                    if self.agent.get_active_project() is None:
-                        raise ToolCallError(
-                            "No active project. Ask the user to provide the project path or to select a project from this list of known projects: "
-                            + f"{self.agent.serena_config.project_names}"
-                        )
+            # construct apply kwargs, adding session_id if the tool is session-aware
+            apply_kwargs = self._apply_parameter_defaults(kwargs)
+            if self._is_session_aware:
+                apply_kwargs["session_id"] = session_id
 
-                # construct apply kwargs, adding session_id if the tool is session-aware
-                apply_kwargs = dict(kwargs)
-                if self._is_session_aware:
-                    apply_kwargs["session_id"] = session_id
+            # defer the operation if the approval policy requires explicit user approval for it
+            approval_reason = None if approved else self.agent.get_approval_policy().get_approval_reason(self.get_name(), apply_kwargs)
+            if approval_reason is not None:
+                pending_operation = self.agent.get_pending_operations().add(self.get_name(), apply_kwargs, approval_reason)
+                return pending_operation.get_approval_request_message()
 
-                # apply the actual tool
+            # apply the actual tool (recording the file mutations it performs in the edit history)
+            with self._edit_history_transaction() as edit_transaction:
                 try:
                     result = apply_fn(**apply_kwargs)
                 except SolidLSPException as e:
@@ -396,54 +556,86 @@ class Tool(Component):
                     else:
                         raise
 
-                # record tool usage
-                self.agent.record_tool_usage(apply_kwargs, result, self)
+            # record tool usage
+            edited_files = edit_transaction.get_relative_paths() if edit_transaction is not None else []
+            self.agent.record_tool_usage(apply_kwargs, result, self, edited_files)
 
-            except ToolCallError:
-                raise
-            except Exception as e:
-                msg = f"{e.__class__.__name__}: {e}"
-                log.error(msg, exc_info=e)
-                raise ToolCallError(msg)
+        except ToolCallError:
+            raise
+        except Exception as e:
+            msg = f"{e.__class__.__name__}: {e}"
+            log.error(msg, exc_info=e)
+            raise ToolCallError(msg)
 
-            if log_call:
-                log.info(f"Result: {result}")
+        if log_call:
+            log.info(f"Result: {result}")
 
-            try:
-                ls_manager = self.agent.get_language_server_manager()
-                if ls_manager is not None:
-                    ls_manager.save_all_caches()
-            except Exception as e:
-                log.error(f"Error saving language server cache: {e}")
+        try:
+            ls_manager = self.agent.get_language_server_manager()
+            if ls_manager is not None:
+                ls_manager.save_all_caches()
+        except Exception as e:
+            log.error(f"Error saving language server cache: {e}")
 
-            return result
+        return result
 
-        # execute the tool in the agent's task executor, with timeout
-        # (task timeout bounds task execution in the dispatcher once it runs, result timeout limits the time we wait)
-        tool_call_error: ToolCallError
-        timeout = self.agent.serena_config.tool_timeout
+    def _edit_history_transaction(self) -> AbstractContextManager[EditTransaction | None]:
+        """
+        :return: a context manager which groups the file mutations performed by this tool into a single transaction of the
+            active project's edit history, yielding the transaction (a null context yielding None if the tool cannot edit
+            or if no project is active)
+        """
+        project = self.agent.get_active_project()
+        if project is None or not isinstance(self, ToolMarkerCanEdit):
+            return nullcontext()
+        return project.edit_history.transaction(self.get_name())
+
+    def _backup_file(self, relative_path: str) -> None:
+        """
+        Takes a backup of the given file prior to a destructive edit (if enabled, see :class:`FileBackups`).
+        A failure to take the backup is logged but does not prevent the edit.
+        Raises a ValueError if the path is outside of the project root.
+
+        :param relative_path: the relative path of the file to be edited
+        """
+        self.project.validate_relative_path(relative_path)
         try:
-            task_exec = self.agent.issue_task(task, name=self.__class__.__name__, timeout=timeout)
-            return task_exec.result(timeout=timeout)
-        except ToolCallError as e:
-            tool_call_error = e
-        except TimeoutError:
-            msg = f"Tool execution timed out after {timeout} seconds. "
-            log.error(msg)
-            tool_call_error = ToolCallError(msg)
-        except Exception as e:  # unexpected errors (exceptions in the task itself are caught and forwarded as ToolCallError)
-            msg = f"{e.__class__.__name__}: {e}"
-            log.error(msg)
-            tool_call_error = ToolCallError(msg)
-        if catch_exceptions:
-            return tool_call_error.get_error_message()
-        else:
-            raise tool_call_error
+            self.project.file_backups.backup(relative_path)
+        except OSError as e:
+            log.error(f"Failed to back up {relative_path}: {e}")
+
+    def _read_text_contents(self, abs_path: Path) -> str | None:
+        """
+        :param abs_path: the absolute path of the file
+        :return: the file's contents if it is a text file in the project's encoding (such that the edit history, which holds text,
+            can restore it), None otherwise
+        """
+        encoding = self.project.project_config.encoding
+        raw_contents = abs_path.read_bytes()
+        if b"\0" in raw_contents:
+            return None
+        try:
+            raw_contents.decode(encoding)
+        except UnicodeDecodeError:
+            return None
+        return FileUtils.read_file(str(abs_path), encoding)
 
     @staticmethod
     def _to_json(x: Any) -> str:
         return json.dumps(x, ensure_ascii=False)
 
+    def _to_output(self, x: Any, output_format: str) -> str:
+        """
+        Converts the given (JSON-serializable) data to a string in the given output format.
+
+        :param x: the data to convert
+        :param output_format: the name of the output format (see :class:`OutputFormat`); if empty, use the default of the active context
+        :return: the string representation
+        """
+        if not output_format:
+            output_format = self.agent.get_context().default_output_format
+        return OutputFormat.from_name(output_format).format(x)
+
     def _wrapped_tool_response(self, response: Any, message: str) -> str:
         """
         Wraps an existing tool response in an object with a message and the original response, i.e. returns
@@ -478,6 +670,56 @@ class EditingToolWithDiagnostics(Tool, ToolMarkerCanEdit):
 
     DIAGNOSTICS_KEY = "diagnostics[warning-or-higher]"
 
+    def _preview_edit(self, edit: Callable[["CodeEditor"], None]) -> str:
+        """
+        Performs the given edit in preview mode, i.e. without changing any files (see :meth:`CodeEditor.preview_mode`).
+
+        :param edit: the function performing the edit using the given code editor
+        :return: the tool result containing unified diffs of the changes the edit would make
+        """
+        code_editor = self.create_code_editor()
+        with code_editor.preview_mode() as previews:
+            edit(code_editor)
+        return self._format_edit_previews(previews)
+
+    @staticmethod
+    def _format_edit_previews(previews: list["CodeEditor.EditPreview"]) -> str:
+        if not previews:
+            return "PREVIEW - the edit would not change any files."
+        return "PREVIEW - no changes were applied:\n" + "\n".join(preview.to_string() for preview in previews)
+
+    def _refuse_edit_of_unparsable_file(self, relative_path: str, force: bool) -> None:
+        """
+        Raises an error (containing the diagnostics) if the given file already has syntax errors according to the language server,
+        because symbol ranges and line-based anchors are unreliable for such files, and edits are likely to compound the damage.
+        The check is skipped if `force` is set, if no language server is used or if diagnostics cannot be obtained.
+
+        :param relative_path: the relative path of the file to be edited
+        :param force: whether to proceed regardless of syntax errors
+        """
+        if force or not self.agent.is_using_language_server():
+            return
+        symbol_retriever = self.create_language_server_symbol_retriever()
+        try:
+            language_server = symbol_retriever.get_language_server(relative_path)
+            diagnostics = language_server.get_cached_published_text_document_diagnostics(relative_path, min_severity=1)
+            if diagnostics is None:
+                diagnostics = language_server.request_text_document_diagnostics(relative_path, min_severity=1)
+        except Exception as e:
+            log.debug(f"Could not obtain diagnostics for {relative_path}, skipping syntax error check: {e}")
+            return
+        syntax_errors = [diagnostic for diagnostic in diagnostics if is_syntax_error_diagnostic(diagnostic)]
+        if not syntax_errors:
+            return
+        grouped_diagnostics = GroupedDiagnostics()
+        for diagnostic in syntax_errors:
+            grouped_diagnostics.add(relative_path, "<file>", diagnostic)
+        raise ValueError(
+            f"The file {relative_path} already contains syntax errors, so the edit was NOT applied (symbol ranges and line positions "
+            "may be unreliable). Fix the syntax errors first (e.g. via replace_content) or, if you are certain that the edit is "
+            f"correct, repeat the call with force=True. Diagnostics: {self._to_json(grouped_diagnostics.get_dict())}"
+        )
+
     class DiagnosticsContext:
         def __init__(self, tool: "EditingToolWithDiagnostics", *edited_relative_paths: str) -> None:
             self._tool = tool
@@ -596,12 +838,23 @@ class ToolRegistry:
         for cls in iter_subclasses(Tool, inclusion_predicate=inclusion_predicate):
             if not any(cls.__module__.startswith(pkg) for pkg in tool_packages):
                 continue
-            is_optional = issubclass(cls, ToolMarkerOptional)
-            is_beta = issubclass(cls, ToolMarkerBeta)
-            name = cls.get_name_from_cls()
-            if name in self._tool_dict:
-                raise ValueError(f"Duplicate tool name found: {name}. Tool classes must have unique names.")
-            self._tool_dict[name] = RegisteredTool(tool_class=cls, is_optional=is_optional, tool_name=name, is_beta=is_beta)
+            self.register_tool_class(cls)
+
+    def register_tool_class(self, tool_class: type[Tool]) -> None:
+        """
+        Registers a tool class, which allows custom tools (defined outside of Serena's tool packages) to be added.
+        Tools must be registered before the agent is created, as the agent instantiates all registered tools upon creation.
+
+        :param tool_class: the (concrete) tool class, whose name is derived from the class name (see `Tool.get_name_from_cls`)
+        """
+        if "apply" not in tool_class.__dict__:
+            raise ValueError(f"Tool class {tool_class.__name__} must implement the `apply` method")
+        name = tool_class.get_name_from_cls()
+        if name in self._tool_dict:
+            raise ValueError(f"Duplicate tool name found: {name}. Tool classes must have unique names.")
+        is_optional = issubclass(tool_class, ToolMarkerOptional)
+        is_beta = issubclass(tool_class, ToolMarkerBeta)
+        self._tool_dict[name] = RegisteredTool(tool_class=tool_class, is_optional=is_optional, tool_name=name, is_beta=is_beta)
 
     def get_registered_tools_by_module(self) -> dict[str, list[RegisteredTool]]:
         """
@@ -4,7 +4,7 @@ Tools supporting the general workflow of the agent
 
 import platform
 
-from serena.tools import Tool, ToolMarkerDoesNotRequireActiveProject, ToolMarkerOptional, WriteMemoryTool
+from serena.tools import Tool, ToolMarkerCanEdit, ToolMarkerDoesNotRequireActiveProject, ToolMarkerOptional, WriteMemoryTool
 
 
 class OnboardingTool(Tool):
@@ -29,6 +29,29 @@ class OnboardingTool(Tool):
         return self.prompt_factory.create_onboarding_prompt(system=system, memory_maintenance_name=memory_maintenance_name)
 
 
+class SaveSessionSummaryTool(Tool, ToolMarkerCanEdit, ToolMarkerOptional):
+    """
+    Saves a summary of the current session to the `last_session` memory, such that a new session can resume from it.
+    """
+
+    def apply(self, tasks: str, follow_ups: str = "") -> str:
+        """
+        Saves a summary of the current session (tasks worked on and pending follow-ups) to the `last_session` memory,
+        which additionally lists the files edited in the session. Call this tool when the user asks you to prepare
+        for a new conversation or when the session's work has been completed.
+
+        :param tasks: a concise description of the tasks worked on in this session, including their status
+        :param follow_ups: a concise description of pending follow-ups (open issues, next steps), if any
+        :return: a confirmation message
+        """
+        session_record = self.agent.get_session_record()
+        session_record.set_summary(tasks, follow_ups)
+        memory_name = self.agent.save_session_record(force=True)
+        if memory_name is None:
+            return "Session summary recorded, but it could not be saved as a memory (session recording or memories are disabled)."
+        return f"Session summary saved to memory '{memory_name}'."
+
+
 class InitialInstructionsTool(Tool, ToolMarkerDoesNotRequireActiveProject):
     """
     Provides instructions Serena usage (i.e. the 'Serena Instructions Manual')
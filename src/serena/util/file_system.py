@@ -372,3 +372,20 @@ def match_path(relative_path: str, path_spec: PathSpec, root_path: str = "") ->
     if os.path.isdir(abs_path) and not normalized_path.endswith("/"):
         normalized_path = normalized_path + "/"
     return path_spec.match_file(normalized_path)
+
+
+def is_path_within_directory(path: str, directory: str) -> bool:
+    """
+    Checks whether the given path is (or is inside) the given directory, resolving symbolic links and ".." segments.
+
+    :param path: the absolute path to check
+    :param directory: the absolute path of the directory
+    :return: True if the resolved path lies within the resolved directory, False otherwise
+    """
+    resolved_path = os.path.realpath(path)
+    resolved_directory = os.path.realpath(directory)
+    try:
+        return os.path.commonpath([resolved_directory, resolved_path]) == resolved_directory
+    except ValueError:
+        # occurs, in particular, if paths are on different drives on Windows
+        return False
@@ -1,4 +1,5 @@
 import logging
+import subprocess
 
 from sensai.util.git import GitStatus
 
@@ -20,3 +21,45 @@ def get_git_status() -> GitStatus | None:
         )
     except:
         return None
+
+
+def get_changed_files(cwd: str) -> list[str]:
+    """
+    :param cwd: a directory within a git repository
+    :return: the paths (relative to `cwd`) of the existing files within `cwd` which differ from HEAD (staged or unstaged)
+        or are untracked (and not ignored)
+    """
+    changed = subprocess_check_output(["git", "diff", "HEAD", "--name-only", "--relative", "--diff-filter=d"], cwd=cwd).splitlines()
+    untracked = subprocess_check_output(["git", "ls-files", "--others", "--exclude-standard"], cwd=cwd).splitlines()
+    return sorted({path for path in changed + untracked if path})
+
+
+def get_current_branch(cwd: str) -> str | None:
+    """
+    :param cwd: a directory within a git repository
+    :return: the name of the checked out branch or None if HEAD is detached
+    """
+    branch = subprocess_check_output(["git", "rev-parse", "--abbrev-ref", "HEAD"], cwd=cwd)
+    return None if branch == "HEAD" else branch
+
+
+def get_default_branch(cwd: str, remote: str = "origin") -> str | None:
+    """
+    :param cwd: a directory within a git repository
+    :param remote: the name of the remote
+    :return: the name of the remote's default branch (as recorded in the remote's HEAD reference) or None if it is unknown
+    """
+    try:
+        ref = subprocess_check_output(["git", "symbolic-ref", "--short", f"refs/remotes/{remote}/HEAD"], cwd=cwd)
+    except subprocess.CalledProcessError:
+        return None
+    return ref.removeprefix(f"{remote}/")
+
+
+def get_remote_url(cwd: str, remote: str = "origin") -> str:
+    """
+    :param cwd: a directory within a git repository
+    :param remote: the name of the remote
+    :return: the (fetch) URL of the remote
+    """
+    return subprocess_check_output(["git", "remote", "get-url", remote], cwd=cwd)
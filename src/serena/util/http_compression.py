@@ -0,0 +1,205 @@
+"""
+ASGI middleware for the compression of HTTP responses (negotiated via the Accept-Encoding header)
+"""
+
+import importlib.util
+import zlib
+from abc import ABC, abstractmethod
+from collections.abc import Awaitable, Callable, MutableMapping
+from typing import Any
+
+Message = MutableMapping[str, Any]
+ASGIApp = Callable[[MutableMapping[str, Any], Callable[[], Awaitable[Message]], Callable[[Message], Awaitable[None]]], Awaitable[None]]
+
+COMPRESSIBLE_CONTENT_TYPES = ("text/", "application/json", "application/javascript", "application/xml")
+EXCLUDED_CONTENT_TYPES = ("text/event-stream",)
+"""
+content types which are never compressed: event streams (as used by the MCP transports) are not reliably decoded
+incrementally by clients and may be buffered by intermediaries when compressed, delaying the delivery of events
+"""
+
+
+class StreamCompressor(ABC):
+    """
+    Incrementally compresses a response body, where every chunk is flushed, such that streamed responses
+    (e.g. server-sent events) can be decoded by the client without waiting for the end of the stream.
+    """
+
+    @abstractmethod
+    def compress(self, data: bytes) -> bytes:
+        """
+        :param data: the next chunk of data
+        :return: the compressed data (flushed, i.e. decodable without subsequent data)
+        """
+
+    @abstractmethod
+    def finish(self) -> bytes:
+        """
+        :return: the remaining compressed data which terminates the stream
+        """
+
+
+class GzipStreamCompressor(StreamCompressor):
+    def __init__(self, level: int = 6):
+        self._compressor = zlib.compressobj(level, zlib.DEFLATED, 16 + zlib.MAX_WBITS)
+
+    def compress(self, data: bytes) -> bytes:
+        return self._compressor.compress(data) + self._compressor.flush(zlib.Z_SYNC_FLUSH)
+
+    def finish(self) -> bytes:
+        return self._compressor.flush(zlib.Z_FINISH)
+
+
+class ZstdStreamCompressor(StreamCompressor):
+    def __init__(self, level: int = 3):
+        import zstandard
+
+        self._compressor = zstandard.ZstdCompressor(level=level).compressobj()
+        self._flush_block_mode = zstandard.COMPRESSOBJ_FLUSH_BLOCK
+
+    def compress(self, data: bytes) -> bytes:
+        return self._compressor.compress(data) + self._compressor.flush(self._flush_block_mode)
+
+    def finish(self) -> bytes:
+        return self._compressor.flush()
+
+
+def get_supported_encodings() -> list[str]:
+    """
+    :return: the supported content encodings in order of preference (zstd is only supported if the `zstandard` package is installed)
+    """
+    is_zstd_supported = importlib.util.find_spec("zstandard") is not None
+    return (["zstd"] if is_zstd_supported else []) + ["gzip"]
+
+
+def negotiate_encoding(accept_encoding: str, supported_encodings: list[str]) -> str | None:
+    """
+    :param accept_encoding: the value of the request's Accept-Encoding header
+    :param supported_encodings: the supported encodings in order of preference
+    :return: the encoding to use, or None if the response shall not be compressed
+    """
+    quality_by_encoding: dict[str, float] = {}
+    for item in accept_encoding.split(","):
+        name, _, params = item.strip().partition(";")
+        quality = 1.0
+        params = params.strip()
+        if params.startswith("q="):
+            try:
+                quality = float(params[2:])
+            except ValueError:
+                quality = 0.0
+        quality_by_encoding[name.strip().lower()] = quality
+    wildcard_quality = quality_by_encoding.get("*", 0.0)
+    candidates = [e for e in supported_encodings if quality_by_encoding.get(e, wildcard_quality) > 0]
+    if not candidates:
+        return None
+    # prefer the highest quality; among equal qualities, use our order of preference
+    return max(candidates, key=lambda e: (quality_by_encoding.get(e, wildcard_quality), -supported_encodings.index(e)))
+
+
+def create_stream_compressor(encoding: str) -> StreamCompressor:
+    match encoding:
+        case "gzip":
+            return GzipStreamCompressor()
+        case "zstd":
+            return ZstdStreamCompressor()
+        case _:
+            raise ValueError(f"Unsupported encoding: {encoding}")
+
+
+class ResponseCompressionMiddleware:
+    """
+    Compresses HTTP responses if the client accepts a supported encoding (zstd or gzip).
+    Complete responses are compressed only if they reach a minimum size; streamed responses (whose total size is unknown)
+    are always compressed, flushing every chunk. Server-sent events are never compressed (see `EXCLUDED_CONTENT_TYPES`).
+    """
+
+    def __init__(self, app: ASGIApp, minimum_size: int = 1024, encodings: list[str] | None = None) -> None:
+        """
+        :param app: the ASGI application whose responses to compress
+        :param minimum_size: the minimum size (in bytes) of a complete response body for it to be compressed
+        :param encodings: the encodings to offer in order of preference; if None, use all supported encodings
+        """
+        self.app = app
+        self.minimum_size = minimum_size
+        self.encodings = encodings if encodings is not None else get_supported_encodings()
+
+    async def __call__(
+        self, scope: MutableMapping[str, Any], receive: Callable[[], Awaitable[Message]], send: Callable[[Message], Awaitable[None]]
+    ) -> None:
+        if scope["type"] != "http":
+            await self.app(scope, receive, send)
+            return
+        accept_encoding = ""
+        for key, value in scope.get("headers", []):
+            if key.lower() == b"accept-encoding":
+                accept_encoding = value.decode("latin-1")
+        encoding = negotiate_encoding(accept_encoding, self.encodings)
+        if encoding is None:
+            await self.app(scope, receive, send)
+            return
+        await self.app(scope, receive, _CompressingSender(send, encoding, self.minimum_size))
+
+
+class _CompressingSender:
+    def __init__(self, send: Callable[[Message], Awaitable[None]], encoding: str, minimum_size: int) -> None:
+        self._send = send
+        self._encoding = encoding
+        self._minimum_size = minimum_size
+        self._start_message: Message | None = None
+        self._compressor: StreamCompressor | None = None
+        self._is_passthrough = False
+
+    async def __call__(self, message: Message) -> None:
+        message_type = message["type"]
+        if message_type == "http.response.start":
+            # defer sending the headers until we know whether the response is compressed
+            self._start_message = message
+            return
+        if message_type != "http.response.body" or self._is_passthrough:
+            await self._send(message)
+            return
+
+        body: bytes = message.get("body", b"")
+        more_body: bool = message.get("more_body", False)
+
+        if self._start_message is not None:
+            # first body message: decide whether to compress
+            start_message, self._start_message = self._start_message, None
+            headers = list(start_message.get("headers", []))
+            is_complete_and_small = not more_body and len(body) < self._minimum_size
+            if is_complete_and_small or not self._is_compressible(headers):
+                self._is_passthrough = True
+                await self._send(start_message)
+                await self._send(message)
+                return
+            headers = [(k, v) for k, v in headers if k.lower() != b"content-length"]
+            headers.append((b"content-encoding", self._encoding.encode("latin-1")))
+            headers.append((b"vary", b"Accept-Encoding"))
+            self._compressor = create_stream_compressor(self._encoding)
+            if not more_body:
+                compressed_body = self._compressor.compress(body) + self._compressor.finish()
+                headers.append((b"content-length", str(len(compressed_body)).encode("latin-1")))
+                await self._send({**start_message, "headers": headers})
+                await self._send({"type": "http.response.body", "body": compressed_body, "more_body": False})
+                return
+            await self._send({**start_message, "headers": headers})
+
+        assert self._compressor is not None
+        compressed_body = self._compressor.compress(body) if body else b""
+        if not more_body:
+            compressed_body += self._compressor.finish()
+        await self._send({"type": "http.response.body", "body": compressed_body, "more_body": more_body})
+
+    @staticmethod
+    def _is_compressible(headers: list[tuple[bytes, bytes]]) -> bool:
+        content_type = ""
+        for key, value in headers:
+            key = key.lower()
+            if key == b"content-encoding":
+                return False  # already encoded
+            if key == b"content-type":
+                content_type = value.decode("latin-1").lower()
+        if content_type.startswith(EXCLUDED_CONTENT_TYPES):
+            return False
+        return content_type.startswith(COMPRESSIBLE_CONTENT_TYPES) or "+json" in content_type
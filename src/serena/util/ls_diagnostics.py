@@ -1,4 +1,5 @@
 import json
+import re
 from collections.abc import Iterable
 from dataclasses import dataclass
 from typing import TYPE_CHECKING, Any
@@ -88,6 +89,28 @@ class PublishedDiagnosticsSnapshot:
         self.warning_identities_by_before_path = warning_identities_by_before_path
 
 
+SYNTAX_ERROR_MESSAGE_PATTERN = re.compile(
+    r"\b(syntax|parse|parsing|unterminated|unclosed|unbalanced|unmatched|unindent|unexpected (token|character|indent\w*|end|eof)|"
+    r"invalid (character|token|expression)|expected expression|argument or block definition required)\b",
+    re.IGNORECASE,
+)
+"""
+heuristic pattern for diagnostic messages (or codes) indicating that a file cannot be parsed; language servers do not
+mark syntax errors consistently, so the message is the most reliable indicator
+"""
+
+
+def is_syntax_error_diagnostic(diagnostic: ls_types.Diagnostic) -> bool:
+    """
+    :param diagnostic: the diagnostic to check
+    :return: whether the diagnostic is an error which indicates that the file cannot be parsed
+    """
+    if diagnostic.get("severity", DiagnosticSeverity.Error) != DiagnosticSeverity.Error:
+        return False
+    code = diagnostic.get("code")
+    return any(SYNTAX_ERROR_MESSAGE_PATTERN.search(text) for text in (diagnostic["message"], str(code) if code is not None else ""))
+
+
 class GroupedDiagnostics:
     def __init__(self) -> None:
         self._grouped_diagnostics: dict[str, dict[str, dict[str, list[dict[str, Any]]]]] = {}
@@ -122,6 +145,19 @@ class GroupedDiagnostics:
         """
         return self._grouped_diagnostics
 
+    def get_list(self) -> list[dict[str, Any]]:
+        """
+        :return: the diagnostics as a flat list of diagnostic dictionaries (see `get_dict`), each of which is extended
+            with the keys relative_path, severity and name_path
+        """
+        return [
+            {"relative_path": relative_path, "severity": severity_name, "name_path": name_path, **diagnostic}
+            for relative_path, by_severity in self._grouped_diagnostics.items()
+            for severity_name, by_name_path in by_severity.items()
+            for name_path, diagnostics in by_name_path.items()
+            for diagnostic in diagnostics
+        ]
+
     @staticmethod
     def _diagnostic_severity_name(severity: int | None) -> str:
         if severity is None:
@@ -0,0 +1,119 @@
+"""
+Rendering of (JSON-serializable) tool results in different output formats
+"""
+
+import json
+from enum import StrEnum
+from typing import Any
+
+
+class OutputFormat(StrEnum):
+    """
+    The format in which structured tool results are returned to the client.
+    """
+
+    JSON = "json"
+    """compact JSON"""
+    MARKDOWN = "markdown"
+    """nested Markdown lists, with multi-line texts (e.g. symbol bodies) as code blocks"""
+    PLAIN = "plain"
+    """indented plain text"""
+
+    @classmethod
+    def from_name(cls, name: str) -> "OutputFormat":
+        """
+        :param name: the name of the output format (case-insensitive)
+        :return: the output format
+        """
+        try:
+            return cls(name.strip().lower())
+        except ValueError:
+            raise ValueError(f"Invalid output format '{name}'; valid formats: {', '.join(f.value for f in cls)}") from None
+
+    def format(self, data: Any) -> str:
+        """
+        :param data: the data to format, which must be JSON-serializable
+        :return: the string representation of the data in this format
+        """
+        if self == OutputFormat.JSON:
+            return json.dumps(data, ensure_ascii=False)
+        return "\n".join(_TreeRenderer(markdown=self == OutputFormat.MARKDOWN).render(data))
+
+
+class _TreeRenderer:
+    """
+    Renders nested dictionaries and lists as an indented tree (in Markdown, as nested lists).
+    """
+
+    INDENT = "  "
+
+    def __init__(self, markdown: bool):
+        self._markdown = markdown
+
+    @staticmethod
+    def _is_scalar(value: Any) -> bool:
+        return not isinstance(value, dict | list) and not (isinstance(value, str) and "\n" in value)
+
+    def _prefix(self, indent: int) -> str:
+        return self.INDENT * indent + ("- " if self._markdown else "")
+
+    def _key(self, key: Any) -> str:
+        return f"**{key}**" if self._markdown else str(key)
+
+    def _render_text_block(self, text: str, indent: int) -> list[str]:
+        block_indent = self.INDENT * indent
+        lines = text.rstrip("\n").split("\n")
+        if self._markdown:
+            return [block_indent + "```"] + [block_indent + line for line in lines] + [block_indent + "```"]
+        return [block_indent + line for line in lines]
+
+    def render(self, data: Any, indent: int = 0) -> list[str]:
+        """
+        :param data: the data to render
+        :param indent: the indentation level
+        :return: the rendered lines
+        """
+        if isinstance(data, dict):
+            return self._render_dict(data, indent)
+        if isinstance(data, list):
+            return self._render_list(data, indent)
+        if isinstance(data, str) and "\n" in data:
+            return self._render_text_block(data, indent)
+        return [self.INDENT * indent + str(data)]
+
+    def _render_value_below(self, value: Any, indent: int) -> list[str]:
+        if isinstance(value, str):
+            return self._render_text_block(value, indent)
+        return self.render(value, indent)
+
+    def _render_dict(self, data: dict, indent: int) -> list[str]:
+        lines = []
+        for key, value in data.items():
+            if self._is_scalar(value):
+                lines.append(f"{self._prefix(indent)}{self._key(key)}: {value}")
+            else:
+                lines.append(f"{self._prefix(indent)}{self._key(key)}:")
+                lines.extend(self._render_value_below(value, indent + 1))
+        return lines
+
+    def _render_list(self, data: list, indent: int) -> list[str]:
+        lines = []
+        for item in data:
+            if isinstance(item, dict):
+                # scalar entries are rendered inline, nested entries below
+                scalar_items = [(k, v) for k, v in item.items() if self._is_scalar(v)]
+                nested_items = [(k, v) for k, v in item.items() if not self._is_scalar(v)]
+                if scalar_items:
+                    lines.append(self._prefix(indent) + ", ".join(f"{k}: {v}" for k, v in scalar_items))
+                for i, (key, value) in enumerate(nested_items):
+                    # without scalar entries, the first nested entry starts the list item (avoiding an empty item line)
+                    key_indent = indent if i == 0 and not scalar_items else indent + 1
+                    lines.append(f"{self._prefix(key_indent)}{self._key(key)}:")
+                    lines.extend(self._render_value_below(value, indent + 2))
+            elif isinstance(item, list):
+                lines.extend(self._render_list(item, indent + 1))
+            elif isinstance(item, str) and "\n" in item:
+                lines.extend(self._render_text_block(item, indent))
+            else:
+                lines.append(f"{self._prefix(indent)}{item}")
+        return lines
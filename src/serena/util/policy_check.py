@@ -0,0 +1,76 @@
+"""
+Support for checking files (e.g. Terraform configurations or plans) against policy-as-code rules via conftest (https://www.conftest.dev)
+"""
+
+import json
+from dataclasses import asdict, dataclass
+from typing import Any, Literal
+
+DEFAULT_POLICY_DIR = "policy"
+"""the directory containing the Rego policies, which conftest uses by default"""
+
+_SEVERITY_BY_RESULT_KEY: dict[str, Literal["failure", "warning"]] = {"failures": "failure", "warnings": "warning"}
+
+
+@dataclass
+class PolicyViolation:
+    relative_path: str
+    severity: Literal["failure", "warning"]
+    message: str
+    namespace: str | None = None
+    policy: str | None = None
+    """the rule that was violated (e.g. `data.terraform.deny`), if reported by conftest"""
+
+    def to_dict(self) -> dict[str, Any]:
+        return {k: v for k, v in asdict(self).items() if v is not None}
+
+
+@dataclass
+class PolicyCheckResult:
+    violations: list[PolicyViolation]
+    num_successes: int
+    exceptions: list[str]
+    """messages of (explicitly excepted) rules, which are not considered violations"""
+
+    def is_passed(self) -> bool:
+        return not any(v.severity == "failure" for v in self.violations)
+
+    def to_dict(self) -> dict[str, Any]:
+        result: dict[str, Any] = {
+            "passed": self.is_passed(),
+            "num_successes": self.num_successes,
+            "violations": [v.to_dict() for v in self.violations],
+        }
+        if self.exceptions:
+            result["exceptions"] = self.exceptions
+        return result
+
+
+def parse_conftest_output(output: str) -> PolicyCheckResult:
+    """
+    Parses the output of `conftest test --output json`.
+
+    :param output: the JSON output of conftest (a list of per-file and per-namespace results)
+    :return: the parsed result
+    """
+    violations: list[PolicyViolation] = []
+    num_successes = 0
+    exceptions: list[str] = []
+    for file_result in json.loads(output) or []:
+        relative_path = file_result.get("filename", "")
+        namespace = file_result.get("namespace")
+        num_successes += file_result.get("successes", 0)
+        for key, severity in _SEVERITY_BY_RESULT_KEY.items():
+            for entry in file_result.get(key) or []:
+                metadata = entry.get("metadata") or {}
+                violations.append(
+                    PolicyViolation(
+                        relative_path=relative_path,
+                        severity=severity,
+                        message=entry.get("msg", ""),
+                        namespace=namespace,
+                        policy=metadata.get("query"),
+                    )
+                )
+        exceptions.extend(entry.get("msg", "") for entry in file_result.get("exceptions") or [])
+    return PolicyCheckResult(violations=violations, num_successes=num_successes, exceptions=exceptions)
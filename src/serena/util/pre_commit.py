@@ -0,0 +1,73 @@
+"""
+Support for running pre-commit (https://pre-commit.com) hooks and parsing their results
+"""
+
+import re
+from dataclasses import asdict, dataclass, field
+from typing import Any
+
+PRE_COMMIT_CONFIG_FILENAME = ".pre-commit-config.yaml"
+
+_HOOK_STATUS_LINE_PATTERN = re.compile(r"^(?P<name>.+?)\.{2,}(\((?P<reason>[^)]*)\))?(?P<status>Passed|Failed|Skipped)$")
+_HOOK_DETAIL_LINE_PATTERN = re.compile(r"^- (?P<key>hook id|exit code|duration): (?P<value>.*)$")
+_FILES_MODIFIED_LINE = "- files were modified by this hook"
+
+
+@dataclass
+class PreCommitHookResult:
+    name: str
+    status: str
+    """the status reported by pre-commit ("Passed", "Failed" or "Skipped")"""
+    hook_id: str | None = None
+    """the id of the hook (only reported by pre-commit for failed hooks)"""
+    exit_code: int | None = None
+    files_modified: bool = False
+    """whether the hook modified files (e.g. a formatter), which causes the hook to fail"""
+    skip_reason: str | None = None
+    output: list[str] = field(default_factory=list)
+    """the lines output by the hook"""
+
+    def is_failed(self) -> bool:
+        return self.status == "Failed"
+
+    def to_dict(self) -> dict[str, Any]:
+        """
+        :return: a dictionary representation, omitting empty/unset values
+        """
+        result = asdict(self)
+        result["output"] = "\n".join(self.output).strip()
+        return {k: v for k, v in result.items() if v not in (None, False, "")}
+
+
+def parse_pre_commit_output(output: str) -> list[PreCommitHookResult]:
+    """
+    Parses the (uncoloured) output of `pre-commit run` into per-hook results.
+
+    :param output: the output of `pre-commit run --color never`
+    :return: the results of the hooks in the order in which they were run
+    """
+    results: list[PreCommitHookResult] = []
+    current: PreCommitHookResult | None = None
+    for line in output.splitlines():
+        line = line.rstrip()
+        status_match = _HOOK_STATUS_LINE_PATTERN.match(line)
+        if status_match:
+            current = PreCommitHookResult(
+                name=status_match.group("name").strip(), status=status_match.group("status"), skip_reason=status_match.group("reason")
+            )
+            results.append(current)
+            continue
+        if current is None:
+            continue
+        detail_match = _HOOK_DETAIL_LINE_PATTERN.match(line)
+        if detail_match:
+            key, value = detail_match.group("key"), detail_match.group("value")
+            if key == "hook id":
+                current.hook_id = value
+            elif key == "exit code" and value.lstrip("-").isdigit():
+                current.exit_code = int(value)
+        elif line == _FILES_MODIFIED_LINE:
+            current.files_modified = True
+        elif line or current.output:
+            current.output.append(line)
+    return results
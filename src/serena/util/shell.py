@@ -1,5 +1,7 @@
 import os
 import subprocess
+from collections.abc import Mapping, Sequence
+from fnmatch import fnmatchcase
 
 from pydantic import BaseModel
 
@@ -13,13 +15,54 @@ class ShellCommandResult(BaseModel):
     stderr: str | None = None
 
 
-def execute_shell_command(command: str, cwd: str | None = None, capture_stderr: bool = False) -> ShellCommandResult:
+class ShellEnvironmentFilter:
+    """
+    Determines which environment variables are propagated to shell commands.
+    A variable is propagated unless its name matches one of the denied patterns; explicitly allowed patterns take precedence
+    over denied patterns.
+    Patterns are glob patterns (e.g. `AWS_*`), which are matched case-insensitively.
+    """
+
+    def __init__(self, allowed_patterns: Sequence[str] = (), denied_patterns: Sequence[str] = ()):
+        """
+        :param allowed_patterns: patterns of variable names which are always propagated
+        :param denied_patterns: patterns of variable names which are not propagated (unless explicitly allowed)
+        """
+        self._allowed_patterns = [p.upper() for p in allowed_patterns]
+        self._denied_patterns = [p.upper() for p in denied_patterns]
+
+    @staticmethod
+    def _matches_any(name: str, patterns: list[str]) -> bool:
+        return any(fnmatchcase(name, p) for p in patterns)
+
+    def is_propagated(self, name: str) -> bool:
+        """
+        :param name: the name of the environment variable
+        :return: whether the variable is to be propagated
+        """
+        name = name.upper()
+        if self._matches_any(name, self._allowed_patterns):
+            return True
+        return not self._matches_any(name, self._denied_patterns)
+
+    def apply(self, environ: Mapping[str, str]) -> dict[str, str]:
+        """
+        :param environ: the environment to filter
+        :return: a new environment containing only the variables to be propagated
+        """
+        return {k: v for k, v in environ.items() if self.is_propagated(k)}
+
+
+def execute_shell_command(
+    command: str, cwd: str | None = None, capture_stderr: bool = False, env: dict[str, str] | None = None
+) -> ShellCommandResult:
     """
     Execute a shell command and return the output.
 
     :param command: The command to execute.
     :param cwd: The working directory to execute the command in. If None, the current working directory will be used.
     :param capture_stderr: Whether to capture the stderr output.
+    :param env: The environment variables for the command. If None, the environment of the current process is inherited.
     :return: The output of the command.
     """
     if cwd is None:
@@ -35,6 +78,7 @@ def execute_shell_command(command: str, cwd: str | None = None, capture_stderr:
         encoding="utf-8",
         errors="replace",
         cwd=cwd,
+        env=env,
         **subprocess_kwargs(),
     )
 
@@ -42,6 +86,39 @@ def execute_shell_command(command: str, cwd: str | None = None, capture_stderr:
     return ShellCommandResult(stdout=stdout, stderr=stderr, return_code=process.returncode, cwd=cwd)
 
 
+def execute_command(
+    args: Sequence[str], cwd: str | None = None, capture_stderr: bool = False, env: dict[str, str] | None = None
+) -> ShellCommandResult:
+    """
+    Execute a command without a shell, i.e. the arguments are passed to the program as they are, without being
+    subject to the shell's interpretation (expansions, redirections, command separators, etc.).
+
+    :param args: the program to execute followed by its arguments
+    :param cwd: the working directory to execute the command in. If None, the current working directory will be used.
+    :param capture_stderr: whether to capture the stderr output
+    :param env: the environment variables for the command. If None, the environment of the current process is inherited.
+    :return: the output of the command
+    """
+    if cwd is None:
+        cwd = os.getcwd()
+
+    process = subprocess.run(
+        list(args),
+        shell=False,
+        stdin=subprocess.DEVNULL,
+        stdout=subprocess.PIPE,
+        stderr=subprocess.PIPE if capture_stderr else None,
+        text=True,
+        encoding="utf-8",
+        errors="replace",
+        cwd=cwd,
+        env=env,
+        check=False,
+        **subprocess_kwargs(),
+    )
+    return ShellCommandResult(stdout=process.stdout, stderr=process.stderr, return_code=process.returncode, cwd=cwd)
+
+
 def subprocess_check_output(
     args: list[str], encoding: str = "utf-8", strip: bool = True, timeout: float | None = None, cwd: str | None = None
 ) -> str:
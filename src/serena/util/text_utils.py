@@ -96,6 +96,16 @@ class MatchedConsecutiveLines:
     def to_display_string(self, include_line_numbers: bool = True) -> str:
         return "\n".join([line.format_line(include_line_numbers) for line in self.lines])
 
+    def to_dict(self) -> dict[str, Any]:
+        """
+        :return: a JSON-serializable representation, containing the source file path and the lines
+            (each with the line number, the content and the match type)
+        """
+        return {
+            "relative_path": self.source_file_path,
+            "lines": [{"line": line.line_number, "text": line.line_content, "type": line.match_type.value} for line in self.lines],
+        }
+
     @classmethod
     def from_file_contents(
         cls, file_contents: str, line: int, context_lines_before: int = 0, context_lines_after: int = 0, source_file_path: str | None = None
@@ -385,18 +395,53 @@ class ContentReplacer:
     provides dual modes for maximum flexibility.
     """
 
-    def __init__(self, mode: Literal["literal", "regex"], allow_multiple_occurrences: bool, regex_multiline: bool = True):
+    def __init__(
+        self,
+        mode: Literal["literal", "fuzzy", "regex"],
+        allow_multiple_occurrences: bool,
+        regex_multiline: bool = True,
+        occurrence_index: int | None = None,
+    ):
         """
 
         :param mode: the mode indicating whether to the needle in replacements corresponds to a regular expression
-            (mode "regex") or to a literal string (mode "literal")
+            (mode "regex"), to a literal string (mode "literal") or to a literal string in which whitespace is matched
+            fuzzily (mode "fuzzy", see :meth:`fuzzy_literal_to_regex`)
         :param allow_multiple_occurrences: whether it is allowed that the search expression matches multiple occurrences.
             If False, an error will be raised if more than one match is found.
         :param regex_multiline: whether to apply multi-line regex matching, enabling the flags re.DOTALL and re.MULTILINE
+        :param occurrence_index: if given, only the match with this index is replaced (0 for the first, 1 for the second, ...;
+            negative indices count from the last match), regardless of `allow_multiple_occurrences`
         """
         self.mode = mode
         self.allow_multiple_occurrences = allow_multiple_occurrences
         self.regex_multiline = regex_multiline
+        self.occurrence_index = occurrence_index
+
+    @staticmethod
+    def fuzzy_literal_to_regex(needle: str) -> str:
+        """
+        Converts a literal string into a regular expression which matches the string regardless of differences in the amount
+        of whitespace: a run of spaces/tabs matches any (non-empty) run of spaces/tabs, and a run of whitespace containing
+        line breaks matches any whitespace containing the same number of line breaks (i.e. indentation and trailing whitespace
+        are ignored, but the line structure must agree).
+
+        :param needle: the literal string
+        :return: the regular expression
+        """
+        regex_parts = []
+        for part in re.split(r"(\s+)", needle):
+            if not part:
+                continue
+            if part.isspace():
+                num_line_breaks = part.count("\n")
+                if num_line_breaks == 0:
+                    regex_parts.append(r"[ \t]+")
+                else:
+                    regex_parts.append(r"[ \t]*" + r"\r?\n[ \t]*" * num_line_breaks)
+            else:
+                regex_parts.append(re.escape(part))
+        return "".join(regex_parts)
 
     @staticmethod
     def _create_replacement_function(regex_pattern: str, repl_template: str, regex_flags: int) -> Callable[[re.Match], str]:
@@ -457,16 +502,29 @@ class ContentReplacer:
         """
         if self.mode == "literal":
             regex = re.escape(needle)
+        elif self.mode == "fuzzy":
+            regex = self.fuzzy_literal_to_regex(needle)
         elif self.mode == "regex":
             regex = needle
         else:
-            raise ValueError(f"Invalid mode: '{self.mode}', expected 'literal' or 'regex'.")
+            raise ValueError(f"Invalid mode: '{self.mode}', expected 'literal', 'fuzzy' or 'regex'.")
 
         regex_flags = (re.MULTILINE | re.DOTALL) if self.regex_multiline else 0
 
         # create replacement function with validation and backreference handling
         repl_fn = self._create_replacement_function(regex, repl, regex_flags=regex_flags)
 
+        if self.occurrence_index is not None:
+            matches = list(re.finditer(regex, content, flags=regex_flags))
+            if not matches:
+                raise ValueError("Error: No matches of search expression found.")
+            if not -len(matches) <= self.occurrence_index < len(matches):
+                raise ValueError(
+                    f"Occurrence index {self.occurrence_index} is out of range: the expression matches {len(matches)} occurrences."
+                )
+            match = matches[self.occurrence_index]
+            return content[: match.start()] + repl_fn(match) + content[match.end() :]
+
         # perform replacement
         updated_content, n = re.subn(regex, repl_fn, content, flags=regex_flags)
 
@@ -475,7 +533,8 @@ class ContentReplacer:
         if not self.allow_multiple_occurrences and n > 1:
             raise ValueError(
                 f"Expression matches {n} occurrences. "
-                "Please revise the expression to be more specific or enable allow_multiple_occurrences if this is expected."
+                "Please revise the expression to be more specific, pass the occurrence_index of the occurrence to be replaced "
+                "or enable allow_multiple_occurrences if all occurrences are to be replaced."
             )
         return updated_content
 
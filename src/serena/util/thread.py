@@ -1,5 +1,7 @@
 import threading
-from collections.abc import Callable
+import time
+from collections.abc import Callable, Iterator
+from contextlib import contextmanager
 from enum import Enum
 from typing import Generic, TypeVar
 
@@ -66,3 +68,68 @@ def execute_with_timeout(func: Callable[[], T], timeout: float, function_name: s
         execution_result.set_timed_out(timeout_exception)
 
     return execution_result
+
+
+class IdleTimeoutWatchdog:
+    """
+    Calls a handler once no activity has been recorded for a given period of time.
+    Periods during which an activity is in progress do not count as idle time.
+    """
+
+    def __init__(self, timeout: float, on_idle: Callable[[], None], check_interval: float | None = None) -> None:
+        """
+        :param timeout: the idle period, in seconds, after which to call the handler
+        :param on_idle: the handler to call (once) when the idle period has elapsed
+        :param check_interval: the interval, in seconds, at which to check for idleness; if None, derive from the timeout
+        """
+        if timeout <= 0:
+            raise ValueError(f"Idle timeout must be positive, got {timeout}")
+        self._timeout = timeout
+        self._on_idle = on_idle
+        self._check_interval = check_interval if check_interval is not None else min(max(timeout / 10, 0.1), 10.0)
+        self._lock = threading.Lock()
+        self._last_activity_time = time.monotonic()
+        self._num_activities_in_progress = 0
+        self._stop_event = threading.Event()
+        self._thread: threading.Thread | None = None
+
+    def start(self) -> None:
+        """
+        Starts monitoring in a background (daemon) thread.
+        """
+        with self._lock:
+            self._last_activity_time = time.monotonic()
+        self._thread = threading.Thread(target=self._run, name="IdleTimeoutWatchdog", daemon=True)
+        self._thread.start()
+
+    def stop(self) -> None:
+        self._stop_event.set()
+
+    @contextmanager
+    def activity(self) -> Iterator[None]:
+        """
+        Context manager marking an activity, which resets the idle period and during which the watchdog does not fire.
+        """
+        with self._lock:
+            self._num_activities_in_progress += 1
+        try:
+            yield
+        finally:
+            with self._lock:
+                self._num_activities_in_progress -= 1
+                self._last_activity_time = time.monotonic()
+
+    def get_idle_time(self) -> float:
+        """
+        :return: the time, in seconds, since the last activity ended (0 if an activity is in progress)
+        """
+        with self._lock:
+            if self._num_activities_in_progress > 0:
+                return 0.0
+            return time.monotonic() - self._last_activity_time
+
+    def _run(self) -> None:
+        while not self._stop_event.wait(self._check_interval):
+            if self.get_idle_time() >= self._timeout:
+                self._on_idle()
+                return
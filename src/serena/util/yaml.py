@@ -80,6 +80,10 @@ def load_yaml(path: str, comment_normalisation: YamlCommentNormalisation = YamlC
                     "This often happens in `ignored_paths` when using gitignore-style globs like "
                     '`"**/bin/**"` or `"**/obj/**"`.'
                 ) from e
+            mark = getattr(e, "problem_mark", None)
+            if mark is not None:
+                problem = getattr(e, "problem", None) or msg
+                raise ValueError(f"Invalid YAML in {path} (line {mark.line + 1}, column {mark.column + 1}): {problem}") from e
             raise
     if commented_map is None:  # ruamel returns None for empty documents, but we want an empty CommentedMap
         commented_map = CommentedMap()
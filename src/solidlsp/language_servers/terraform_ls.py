@@ -1,14 +1,33 @@
+import json
 import logging
 import os
+import re
 import shutil
+import subprocess
+import tempfile
+import urllib.request
+from collections.abc import Callable, Hashable
 
 from overrides import override
 
-from solidlsp.ls import SolidLanguageServer
+from solidlsp import ls_types
+from solidlsp.ls import (
+    DocumentSymbols,
+    LanguageServerDependencyProvider,
+    LanguageServerDependencyProviderSinglePath,
+    LSPFileBuffer,
+    SolidLanguageServer,
+)
 from solidlsp.ls_config import LanguageServerConfig, LanguageServerId
-from solidlsp.ls_utils import PlatformUtils
-from solidlsp.lsp_protocol_handler.server import ProcessLaunchInfo
+from solidlsp.ls_exceptions import SolidLSPException
+from solidlsp.ls_process import LanguageServerInterface, UnavailableLanguageServer
+from solidlsp.ls_utils import PlatformUtils, TextUtils
+from solidlsp.lsp_protocol_handler.lsp_types import DocumentSymbol, SymbolInformation, SymbolKind
+from solidlsp.lsp_protocol_handler.server import StringDict
 from solidlsp.settings import SolidLSPSettings
+from solidlsp.util.hcl import parse_hcl_document_symbols
+from solidlsp.util.subprocess_util import subprocess_kwargs
+from solidlsp.util.terraform_address import TerraformAddress, find_local_module_source
 
 from .common import RuntimeDependency, RuntimeDependencyCollection
 
@@ -37,6 +56,67 @@ DEFAULT_TERRAFORM_LS_SHA256_BY_PLATFORM = {
 }
 
 
+IGNORED_TERRAFORM_DIRNAMES = (".terraform", "terraform.tfstate.d", ".terragrunt-cache")
+"""
+the names of directories containing (downloaded or generated) data of Terraform and Terragrunt, which are always ignored
+"""
+TERRAFORM_LS_FILE_SUFFIXES = (".tf", ".tfvars", ".tfstate")
+"""
+the file name suffixes of the files which are natively supported by terraform-ls
+"""
+DEFAULT_ADDITIONAL_TERRAFORM_FILE_SUFFIXES = (".hcl",)
+"""
+the default file name suffixes of (HCL) files which are treated as project files in addition to the files supported by terraform-ls,
+e.g. Terragrunt (`terragrunt.hcl`), Packer (`.pkr.hcl`), Nomad (`.nomad.hcl`) and Waypoint (`waypoint.hcl`) files;
+the dependency lock file (`.terraform.lock.hcl`) is never treated as a project file
+"""
+TOP_LEVEL_BLOCK_NAME_PATTERN = re.compile(r'(resource|ephemeral|module|variable|output|provider)\s+"')
+"""
+matches the names of blocks which are necessarily top-level blocks
+(in contrast to e.g. `data` blocks, which may be nested in `check` blocks)
+"""
+LABELLED_TF_KINDS = ("resource", "data", "ephemeral", "module", "variable", "output", "provider")
+"""
+the Terraform symbol kinds (see `UnifiedSymbolInformation.tf_kind`) of blocks with labels, which correspond to the block types
+"""
+UNLABELLED_TF_KINDS = ("terraform", "locals")
+"""
+the Terraform symbol kinds of blocks without labels, which correspond to the block types
+"""
+DOCUMENTED_BLOCK_TYPE_PATTERN = re.compile(r'(resource|data|ephemeral|provider)\s+"')
+"""
+matches the types of blocks whose first label (e.g. `"aws_instance"`) is documented by the provider schema
+"""
+TERRAFORM_LS_PATH_ENV_VAR = "SERENA_TERRAFORM_LS_PATH"
+"""
+the environment variable with which the path of a custom terraform-ls executable can be specified (alternatively to `ls_path`)
+"""
+MIN_SYSTEM_TERRAFORM_LS_VERSION = "0.34.0"
+"""
+the minimum version of a system-wide terraform-ls installation (found on the PATH) for it to be used instead of a downloaded one
+"""
+TERRAFORM_LS_RELEASES_URL = "https://releases.hashicorp.com/terraform-ls"
+TERRAFORM_LS_NETWORK_TIMEOUT = 30
+TERRAFORM_LS_ARCHIVE_SUFFIX_BY_PLATFORM = {
+    "osx-arm64": "darwin_arm64",
+    "osx-x64": "darwin_amd64",
+    "linux-arm64": "linux_arm64",
+    "linux-x64": "linux_amd64",
+    "win-x64": "windows_amd64",
+}
+
+
+def _parse_version(version: str) -> tuple[int, ...] | None:
+    """
+    :param version: a version string, possibly with a prefix and suffix (e.g. "v0.36.5" or "0.36.5-beta1")
+    :return: the numeric components of the version or None if the string does not contain a version
+    """
+    match = re.search(r"(\d+)\.(\d+)\.(\d+)", version)
+    if match is None:
+        return None
+    return tuple(int(part) for part in match.groups())
+
+
 def _terraform_ls_sha(version: str, platform_key: str) -> str | None:
     if version == INITIAL_TERRAFORM_LS_VERSION:
         return INITIAL_TERRAFORM_LS_SHA256_BY_PLATFORM[platform_key]
@@ -52,11 +132,35 @@ class TerraformLS(SolidLanguageServer):
     You can pass the following entries in ``ls_specific_settings["terraform"]``:
         - terraform_ls_version: Override the pinned terraform-ls version downloaded
           by Serena (default: the bundled Serena version).
+        - additional_file_suffixes: File name suffixes of further (HCL) files to be treated as project files
+          (indexed, searchable and editable with symbolic tools); default: ``[".hcl"]``.
+          The symbols of files which terraform-ls does not support (e.g. Packer or Nomad files) are determined
+          by a native HCL parser.
+        - ignored_dirnames: Names of further directories to be ignored (in addition to ``.terraform``,
+          ``terraform.tfstate.d`` and ``.terragrunt-cache``).
+        - use_system_terraform_ls: Whether to prefer a terraform-ls executable found on the PATH (if its version
+          is at least ``MIN_SYSTEM_TERRAFORM_LS_VERSION``) over a download, unless terraform_ls_version is set (default: true).
+        - ls_path: Path to an existing terraform-ls executable (e.g. an approved build),
+          bypassing the download. Alternatively, the environment variable ``SERENA_TERRAFORM_LS_PATH`` can be set.
+        - use_workspace_symbols: Whether to determine the files to be searched by project-wide symbol searches
+          (for names of top-level blocks, e.g. ``resource "aws_instance" "web"``) via ``workspace/symbol`` requests (default: true).
+        - keep_open_files: The number of recently used files kept open in terraform-ls, such that they need not be
+          re-parsed for subsequent requests (default: ``KEEP_OPEN_FILES``).
+        - native_hcl_fallback: Whether to fall back to a degraded mode if the Terraform CLI or terraform-ls is unavailable
+          (e.g. in air-gapped environments), in which the symbols of all files are determined by the native HCL parser,
+          such that overviews, symbol searches and block-level editing remain usable, while requests requiring
+          terraform-ls (e.g. references and renaming) fail (default: true).
     """
 
+    KEEP_OPEN_FILES = 50
+
     @override
     def is_ignored_dirname(self, dirname: str) -> bool:
-        return super().is_ignored_dirname(dirname) or dirname in [".terraform", "terraform.tfstate.d"]
+        return (
+            super().is_ignored_dirname(dirname)
+            or dirname in IGNORED_TERRAFORM_DIRNAMES
+            or dirname in self._custom_settings.get("ignored_dirnames", [])
+        )
 
     @staticmethod
     def _determine_log_level(line: str) -> int:
@@ -118,16 +222,34 @@ class TerraformLS(SolidLanguageServer):
         )
 
     @classmethod
-    def _setup_runtime_dependencies(cls, solidlsp_settings: SolidLSPSettings) -> str:
+    def get_configured_version(cls, solidlsp_settings: SolidLSPSettings) -> str:
+        """
+        :param solidlsp_settings: the settings
+        :return: the terraform-ls version to use (as configured via `terraform_ls_version` or the version pinned by Serena)
+        """
+        terraform_settings = solidlsp_settings.get_ls_specific_settings(LanguageServerId.TERRAFORM)
+        return terraform_settings.get("terraform_ls_version", DEFAULT_TERRAFORM_LS_VERSION)
+
+    @classmethod
+    def get_custom_executable_path(cls, solidlsp_settings: SolidLSPSettings) -> str | None:
         """
-        Setup runtime dependencies for terraform-ls.
-        Downloads and installs terraform-ls if not already present.
+        :param solidlsp_settings: the settings
+        :return: the path of the custom terraform-ls executable to use instead of a downloaded one (if any),
+            as configured via `ls_path` or the environment variable `SERENA_TERRAFORM_LS_PATH`
         """
-        cls._ensure_tf_command_available()
         terraform_settings = solidlsp_settings.get_ls_specific_settings(LanguageServerId.TERRAFORM)
-        terraform_ls_version = terraform_settings.get("terraform_ls_version", DEFAULT_TERRAFORM_LS_VERSION)
-        platform_id = PlatformUtils.get_platform_id()
-        deps = RuntimeDependencyCollection(
+        return terraform_settings.get("ls_path") or os.environ.get(TERRAFORM_LS_PATH_ENV_VAR) or None
+
+    @classmethod
+    def _create_runtime_dependencies(cls, terraform_ls_version: str) -> RuntimeDependencyCollection:
+        sha256_by_platform: dict[str, str] = {}
+        if terraform_ls_version not in (INITIAL_TERRAFORM_LS_VERSION, DEFAULT_TERRAFORM_LS_VERSION):
+            sha256_by_platform = cls._fetch_sha256_by_platform(terraform_ls_version)
+
+        def sha256(platform_key: str) -> str | None:
+            return _terraform_ls_sha(terraform_ls_version, platform_key) or sha256_by_platform.get(platform_key)
+
+        return RuntimeDependencyCollection(
             [
                 RuntimeDependency(
                     id="TerraformLS",
@@ -136,7 +258,7 @@ class TerraformLS(SolidLanguageServer):
                     platform_id="osx-arm64",
                     archive_type="zip",
                     binary_name="terraform-ls",
-                    sha256=_terraform_ls_sha(terraform_ls_version, "osx-arm64"),
+                    sha256=sha256("osx-arm64"),
                     allowed_hosts=TERRAFORM_LS_ALLOWED_HOSTS,
                 ),
                 RuntimeDependency(
@@ -146,7 +268,7 @@ class TerraformLS(SolidLanguageServer):
                     platform_id="osx-x64",
                     archive_type="zip",
                     binary_name="terraform-ls",
-                    sha256=_terraform_ls_sha(terraform_ls_version, "osx-x64"),
+                    sha256=sha256("osx-x64"),
                     allowed_hosts=TERRAFORM_LS_ALLOWED_HOSTS,
                 ),
                 RuntimeDependency(
@@ -156,7 +278,7 @@ class TerraformLS(SolidLanguageServer):
                     platform_id="linux-arm64",
                     archive_type="zip",
                     binary_name="terraform-ls",
-                    sha256=_terraform_ls_sha(terraform_ls_version, "linux-arm64"),
+                    sha256=sha256("linux-arm64"),
                     allowed_hosts=TERRAFORM_LS_ALLOWED_HOSTS,
                 ),
                 RuntimeDependency(
@@ -166,7 +288,7 @@ class TerraformLS(SolidLanguageServer):
                     platform_id="linux-x64",
                     archive_type="zip",
                     binary_name="terraform-ls",
-                    sha256=_terraform_ls_sha(terraform_ls_version, "linux-x64"),
+                    sha256=sha256("linux-x64"),
                     allowed_hosts=TERRAFORM_LS_ALLOWED_HOSTS,
                 ),
                 RuntimeDependency(
@@ -176,28 +298,222 @@ class TerraformLS(SolidLanguageServer):
                     platform_id="win-x64",
                     archive_type="zip",
                     binary_name="terraform-ls.exe",
-                    sha256=_terraform_ls_sha(terraform_ls_version, "win-x64"),
+                    sha256=sha256("win-x64"),
                     allowed_hosts=TERRAFORM_LS_ALLOWED_HOSTS,
                 ),
             ]
         )
-        dependency = deps.get_single_dep_for_current_platform()
 
+    @classmethod
+    def _fetch_sha256_by_platform(cls, terraform_ls_version: str) -> dict[str, str]:
+        """
+        Fetches the checksums of the release archives of a terraform-ls version which is not pinned by Serena.
+
+        :param terraform_ls_version: the terraform-ls version
+        :return: a mapping from platform key to the SHA256 checksum of the respective archive
+        :raises RuntimeError: if the checksums could not be retrieved (since the download could not be verified)
+        """
+        checksums_url = f"{TERRAFORM_LS_RELEASES_URL}/{terraform_ls_version}/terraform-ls_{terraform_ls_version}_SHA256SUMS"
+        try:
+            req = urllib.request.Request(checksums_url, headers={"User-Agent": "Serena-LSP"})
+            with urllib.request.urlopen(req, timeout=TERRAFORM_LS_NETWORK_TIMEOUT) as response:
+                content = response.read().decode("utf-8")
+        except Exception as e:
+            raise RuntimeError(
+                f"Failed to get the terraform-ls checksums from {checksums_url} ({e}); refusing to install an unverified download"
+            ) from e
+        sha256_by_filename = {}
+        for line in content.strip().splitlines():
+            parts = line.split()
+            if len(parts) == 2:
+                sha256_by_filename[parts[1]] = parts[0]
+        result = {}
+        for platform_key, archive_suffix in TERRAFORM_LS_ARCHIVE_SUFFIX_BY_PLATFORM.items():
+            filename = f"terraform-ls_{terraform_ls_version}_{archive_suffix}.zip"
+            if filename in sha256_by_filename:
+                result[platform_key] = sha256_by_filename[filename]
+        return result
+
+    @classmethod
+    def get_latest_version(cls) -> str:
+        """
+        Determines the latest (stable) terraform-ls release.
+
+        :return: the version
+        """
+        req = urllib.request.Request(f"{TERRAFORM_LS_RELEASES_URL}/index.json", headers={"User-Agent": "Serena-LSP"})
+        with urllib.request.urlopen(req, timeout=TERRAFORM_LS_NETWORK_TIMEOUT) as response:
+            data = json.loads(response.read().decode())
+        stable_versions = [v for v in data["versions"] if re.fullmatch(r"\d+\.\d+\.\d+", v)]
+        if not stable_versions:
+            raise RuntimeError("No terraform-ls releases found")
+        return max(stable_versions, key=lambda v: _parse_version(v) or ())
+
+    @classmethod
+    def find_system_executable(cls) -> tuple[str, str] | None:
+        """
+        Searches the PATH for a system-wide terraform-ls installation (e.g. installed via a package manager)
+        whose version is at least `MIN_SYSTEM_TERRAFORM_LS_VERSION`.
+
+        :return: a pair (executable path, version) or None if no suitable installation was found
+        """
+        executable_path = shutil.which("terraform-ls")
+        if executable_path is None:
+            return None
+        version = cls.determine_executable_version(executable_path)
+        if version is None:
+            log.warning(f"Could not determine the version of the terraform-ls executable {executable_path}; ignoring it")
+            return None
+        version_tuple = _parse_version(version)
+        assert version_tuple is not None
+        min_version_tuple = _parse_version(MIN_SYSTEM_TERRAFORM_LS_VERSION)
+        assert min_version_tuple is not None
+        if version_tuple < min_version_tuple:
+            log.info(f"Ignoring terraform-ls {version} at {executable_path} (older than {MIN_SYSTEM_TERRAFORM_LS_VERSION})")
+            return None
+        return executable_path, version
+
+    @staticmethod
+    def determine_executable_version(executable_path: str) -> str | None:
+        """
+        :param executable_path: the path of a terraform-ls executable
+        :return: the version reported by the executable (e.g. "0.36.5" or "v0.36.5") or None if it could not be determined
+        """
+        try:
+            completed_process = subprocess.run(
+                [executable_path, "version"], capture_output=True, text=True, timeout=10, **subprocess_kwargs()
+            )
+        except Exception as e:
+            log.warning(f"Failed to determine the version of the terraform-ls executable {executable_path}: {e}")
+            return None
+        version_lines = completed_process.stdout.strip().splitlines()
+        version = version_lines[0].strip() if version_lines else ""
+        if completed_process.returncode != 0 or _parse_version(version) is None:
+            return None
+        return version
+
+    @staticmethod
+    def determine_terraform_cli_version() -> str | None:
+        """
+        :return: the version reported by the Terraform CLI found on the PATH (e.g. "v1.9.5") or None if it could not be determined
+        """
+        terraform_cmd = shutil.which("terraform")
+        if terraform_cmd is None:
+            return None
+        try:
+            completed_process = subprocess.run(
+                [terraform_cmd, "version"], capture_output=True, text=True, timeout=10, **subprocess_kwargs()
+            )
+        except Exception as e:
+            log.warning(f"Failed to determine the version of the Terraform CLI {terraform_cmd}: {e}")
+            return None
+        # the first line is of the form "Terraform v1.9.5"
+        version_lines = completed_process.stdout.strip().splitlines()
+        if completed_process.returncode != 0 or not version_lines:
+            return None
+        return version_lines[0].removeprefix("Terraform").strip()
+
+    @staticmethod
+    def determine_workspace(working_dir: str) -> str:
+        """
+        Determines the Terraform workspace selected for the given working directory, i.e. the workspace given by the
+        environment variable `TF_WORKSPACE` or, if it is not set, the workspace selected via `terraform workspace select`
+        (which is stored in `.terraform/environment`).
+
+        :param working_dir: the working directory (root module)
+        :return: the name of the workspace ("default" if no other workspace is selected)
+        """
+        workspace = os.environ.get("TF_WORKSPACE")
+        if workspace:
+            return workspace
+        try:
+            with open(os.path.join(working_dir, ".terraform", "environment"), encoding="utf-8") as f:
+                return f.read().strip() or "default"
+        except OSError:
+            return "default"
+
+    @classmethod
+    def determine_used_version(cls, custom_settings: SolidLSPSettings.CustomLSSettings) -> str:
+        """
+        Determines the version of the terraform-ls executable which is used given the settings (see :class:`DependencyProvider`).
+
+        :param custom_settings: the terraform-specific settings
+        :return: the version or "unknown" if the version of a custom executable could not be determined
+        """
+        custom_path = custom_settings.get("ls_path", None) or os.environ.get(TERRAFORM_LS_PATH_ENV_VAR)
+        if custom_path:
+            return cls.determine_executable_version(custom_path) or "unknown"
+        terraform_ls_version = custom_settings.get("terraform_ls_version", None)
+        if terraform_ls_version is None and custom_settings.get("use_system_terraform_ls", True):
+            system_executable = cls.find_system_executable()
+            if system_executable is not None:
+                return system_executable[1]
+        return terraform_ls_version or DEFAULT_TERRAFORM_LS_VERSION
+
+    @staticmethod
+    def _get_install_dir(ls_resources_dir: str, terraform_ls_version: str) -> str:
         # legacy unversioned dir reserved for INITIAL; every other version goes into a versioned subdir
-        install_dir = (
-            cls.ls_resources_dir(solidlsp_settings)
-            if terraform_ls_version == INITIAL_TERRAFORM_LS_VERSION
-            else os.path.join(cls.ls_resources_dir(solidlsp_settings), f"terraform-ls-{terraform_ls_version}")
-        )
-        terraform_ls_executable_path = deps.binary_path(install_dir)
-        if not os.path.exists(terraform_ls_executable_path):
-            log.info(f"Downloading terraform-ls from {dependency.url}")
-            deps.install(install_dir)
+        if terraform_ls_version == INITIAL_TERRAFORM_LS_VERSION:
+            return ls_resources_dir
+        return os.path.join(ls_resources_dir, f"terraform-ls-{terraform_ls_version}")
 
-        assert os.path.exists(terraform_ls_executable_path), f"terraform-ls executable not found at {terraform_ls_executable_path}"
+    @staticmethod
+    def _get_binary_name() -> str:
+        return "terraform-ls.exe" if PlatformUtils.get_platform_id().value == "win-x64" else "terraform-ls"
+
+    @classmethod
+    def get_installed_versions(cls, solidlsp_settings: SolidLSPSettings) -> list[str]:
+        """
+        :param solidlsp_settings: the settings
+        :return: the terraform-ls versions which are installed (i.e. downloaded by Serena)
+        """
+        binary_name = cls._get_binary_name()
+        resources_dir = cls.ls_resources_dir(solidlsp_settings)
+        versions = []
+        if os.path.exists(os.path.join(resources_dir, binary_name)):
+            versions.append(INITIAL_TERRAFORM_LS_VERSION)
+        for dirname in os.listdir(resources_dir):
+            if dirname.startswith("terraform-ls-") and os.path.exists(os.path.join(resources_dir, dirname, binary_name)):
+                versions.append(dirname.removeprefix("terraform-ls-"))
+        return sorted(set(versions), key=lambda v: _parse_version(v) or ())
+
+    @classmethod
+    def install(cls, solidlsp_settings: SolidLSPSettings, terraform_ls_version: str, force: bool = False) -> str:
+        """
+        Installs the given terraform-ls version (if it is not installed yet).
+
+        :param solidlsp_settings: the settings
+        :param terraform_ls_version: the version to install
+        :param force: whether to reinstall the version if it is already installed
+        :return: the path of the terraform-ls executable
+        """
+        return cls._install(cls.ls_resources_dir(solidlsp_settings), terraform_ls_version, force=force)
+
+    @classmethod
+    def _install(cls, ls_resources_dir: str, terraform_ls_version: str, force: bool = False) -> str:
+        install_dir = cls._get_install_dir(ls_resources_dir, terraform_ls_version)
+        binary_name = cls._get_binary_name()
+        terraform_ls_executable_path = os.path.join(install_dir, binary_name)
+        if force or not os.path.exists(terraform_ls_executable_path):
+            deps = cls._create_runtime_dependencies(terraform_ls_version)
+            dependency = deps.get_single_dep_for_current_platform()
+            if dependency.sha256 is None:
+                raise RuntimeError(f"No checksum available for {dependency.url}; refusing to install an unverified download")
+            log.info(f"Downloading terraform-ls from {dependency.url}")
+            # download to a temporary directory, such that an existing installation is replaced only if the download succeeds
+            os.makedirs(ls_resources_dir, exist_ok=True)
+            download_dir = tempfile.mkdtemp(prefix=".terraform-ls-download-", dir=ls_resources_dir)
+            try:
+                deps.install(download_dir)
+                downloaded_executable_path = os.path.join(download_dir, binary_name)
+                assert os.path.exists(downloaded_executable_path), f"terraform-ls executable not found at {downloaded_executable_path}"
+                os.makedirs(install_dir, exist_ok=True)
+                os.replace(downloaded_executable_path, terraform_ls_executable_path)
+            finally:
+                shutil.rmtree(download_dir, ignore_errors=True)
 
         # Make the executable file executable on Unix-like systems
-        if platform_id.value != "win-x64":
+        if PlatformUtils.get_platform_id().value != "win-x64":
             os.chmod(terraform_ls_executable_path, 0o755)
 
         return terraform_ls_executable_path
@@ -206,16 +522,262 @@ class TerraformLS(SolidLanguageServer):
         """
         Creates a TerraformLS instance. This class is not meant to be instantiated directly. Use LanguageServer.create() instead.
         """
-        terraform_ls_executable_path = self._setup_runtime_dependencies(solidlsp_settings)
-
+        self._unavailability_reason: str | None = None
+        """
+        the reason why terraform-ls is unavailable, in which case the native HCL parser is used in a degraded mode
+        """
+        self._used_terraform_ls_version: str | None = None
+        """
+        the version of the terraform-ls executable which is used (determined lazily, see :meth:`_get_used_terraform_ls_version`)
+        """
+        self._terraform_cli_version: str | None = None
+        """
+        the version of the Terraform CLI (determined lazily, see :meth:`get_version_info`)
+        """
+        self._native_hcl_fallback = solidlsp_settings.get_ls_specific_settings(LanguageServerId.TERRAFORM).get("native_hcl_fallback", True)
+        try:
+            self._ensure_tf_command_available()
+        except RuntimeError as e:
+            if not self._native_hcl_fallback:
+                raise
+            self._unavailability_reason = str(e)
         super().__init__(
             config,
             repository_root_path,
-            ProcessLaunchInfo(cmd=f"{terraform_ls_executable_path} serve", cwd=repository_root_path),
+            None,
             "terraform",
             solidlsp_settings,
         )
         self.request_id = 0
+        self._workspace_symbol_provider = False
+        # add the configured file name suffixes (e.g. of Terragrunt files) to the source file matcher, such that
+        # the files are indexed and can be used with symbolic tools
+        additional_file_suffixes = self._custom_settings.get("additional_file_suffixes", list(DEFAULT_ADDITIONAL_TERRAFORM_FILE_SUFFIXES))
+        LanguageServerId.TERRAFORM.get_source_fn_matcher().add_extensions(*additional_file_suffixes)
+
+    @override
+    def _raw_document_symbols_cache_fingerprint(self) -> Hashable | None:
+        # the symbols reported by terraform-ls may differ between versions, so symbols cached for another version are discarded
+        return self._get_used_terraform_ls_version()
+
+    def _get_used_terraform_ls_version(self) -> str:
+        if self._used_terraform_ls_version is None:
+            self._used_terraform_ls_version = self.determine_used_version(self._custom_settings)
+        return self._used_terraform_ls_version
+
+    @override
+    def get_version_info(self) -> dict[str, str]:
+        if self._terraform_cli_version is None:
+            self._terraform_cli_version = self.determine_terraform_cli_version() or "not found"
+        return {"terraform-ls": self._get_used_terraform_ls_version(), "Terraform CLI": self._terraform_cli_version}
+
+    def is_degraded(self) -> bool:
+        """
+        :return: whether terraform-ls is unavailable, such that only the functionality provided by the native HCL parser
+            (document symbols) is supported
+        """
+        return self._unavailability_reason is not None
+
+    @override
+    def _validate_text_document_diagnostics_request(
+        self, relative_file_path: str, start_line: int, end_line: int, min_severity: int
+    ) -> str:
+        # in degraded mode, no diagnostics are ever published, which must not be mistaken for the absence of problems
+        if self._unavailability_reason is not None:
+            raise SolidLSPException(f"Diagnostics are not supported, since terraform-ls is unavailable ({self._unavailability_reason})")
+        return super()._validate_text_document_diagnostics_request(relative_file_path, start_line, end_line, min_severity)
+
+    @override
+    def _create_language_server_interface(self, logging_fn: Callable[[str, str, StringDict | str], None] | None) -> LanguageServerInterface:
+        if self._unavailability_reason is None:
+            try:
+                return super()._create_language_server_interface(logging_fn)
+            except Exception as e:
+                if not self._native_hcl_fallback:
+                    raise
+                self._unavailability_reason = f"terraform-ls could not be provided: {e}"
+        log.warning(
+            f"terraform-ls is unavailable ({self._unavailability_reason}); falling back to the native HCL parser. "
+            "Symbol overviews, searches and block-level editing are supported, but references, renaming and diagnostics are not."
+        )
+        return UnavailableLanguageServer(self.ls_id, self._unavailability_reason)
+
+    @override
+    def supports_workspace_symbol_search(self) -> bool:
+        # terraform-ls reports the top-level blocks of all indexed Terraform files whose names contain the query
+        return self._workspace_symbol_provider and self._custom_settings.get("use_workspace_symbols", True)
+
+    @override
+    def is_top_level_symbol_name(self, name_pattern: str) -> bool:
+        return TOP_LEVEL_BLOCK_NAME_PATTERN.match(name_pattern) is not None
+
+    @override
+    def is_covered_by_workspace_symbols(self, relative_file_path: str) -> bool:
+        return relative_file_path.endswith(".tf")
+
+    @override
+    def resolve_symbol_address(self, name_path_pattern: str) -> tuple[str, str | None] | None:
+        address = TerraformAddress.parse(name_path_pattern)
+        if address is None:
+            return None
+        module_dir = ""
+        for module_name in address.module_names:
+            module_dir = self._resolve_local_module_dir(module_dir, module_name)
+        return address.name_path, module_dir or None
+
+    def _resolve_local_module_dir(self, module_dir: str, module_name: str) -> str:
+        """
+        :param module_dir: the relative path of the directory of the module containing the module call ("" for the root module)
+        :param module_name: the name of the module call
+        :return: the relative path of the directory of the called module
+        :raises ValueError: if the module call was not found or does not refer to a local module within the repository
+        """
+        abs_module_dir = os.path.join(self.repository_root_path, module_dir)
+        if not os.path.isdir(abs_module_dir):
+            raise ValueError(f"Module call '{module_name}' not found: the module directory '{module_dir}' does not exist")
+        module_dir_contents = []
+        for filename in sorted(os.listdir(abs_module_dir)):
+            if filename.endswith(".tf"):
+                with open(os.path.join(abs_module_dir, filename), encoding=self._encoding) as f:
+                    module_dir_contents.append(f.read())
+        source = find_local_module_source(module_dir_contents, module_name)
+        if source is None:
+            raise ValueError(f"Module call '{module_name}' not found in module directory '{module_dir or '.'}'")
+        if not source.startswith(("./", "../")):
+            raise ValueError(f"Module '{module_name}' has the non-local source '{source}', which cannot be resolved")
+        called_module_dir = os.path.normpath(os.path.join(module_dir, source))
+        if called_module_dir == ".." or called_module_dir.startswith(".." + os.sep):
+            raise ValueError(f"Module '{module_name}' has the source '{source}', which is outside of the repository")
+        return "" if called_module_dir == "." else called_module_dir
+
+    @override
+    def get_symbol_info_position(self, file_contents: str, line: int, column: int) -> tuple[int, int]:
+        # the hover information for a block's type (e.g. `resource`) is a generic description of the block type,
+        # whereas the provider documentation (including the arguments) is provided for the first label
+        lines = file_contents.splitlines()
+        if line < len(lines) and (match := DOCUMENTED_BLOCK_TYPE_PATTERN.match(lines[line], column)):
+            return line, match.end()
+        return line, column
+
+    @override
+    def request_formatting_edits(self, relative_file_path: str) -> list[ls_types.TextEdit] | None:
+        try:
+            edits = super().request_formatting_edits(relative_file_path)
+        except SolidLSPException as e:
+            # e.g. for HCL files which are not supported by terraform-ls
+            log.debug(f"terraform-ls failed to format {relative_file_path} ({e}); falling back to `terraform fmt`")
+            edits = None
+        if edits is not None:
+            return edits
+        return self._request_formatting_edits_via_terraform_fmt(relative_file_path)
+
+    def _request_formatting_edits_via_terraform_fmt(self, relative_file_path: str) -> list[ls_types.TextEdit] | None:
+        terraform_cmd = shutil.which("terraform")
+        if terraform_cmd is None:
+            return None
+        with self.open_file(relative_file_path, open_in_ls=False) as file_buffer:
+            contents = file_buffer.contents
+        completed_process = subprocess.run(
+            [terraform_cmd, "fmt", "-"], input=contents, capture_output=True, text=True, timeout=30, **subprocess_kwargs()
+        )
+        if completed_process.returncode != 0:
+            log.warning(f"terraform fmt failed to format {relative_file_path}: {completed_process.stderr.strip()}")
+            return None
+        formatted_contents = completed_process.stdout
+        if formatted_contents == contents:
+            return []
+        lines = TextUtils.split_lines(contents)
+        end = ls_types.Position(line=len(lines) - 1, character=len(lines[-1]))
+        return [ls_types.TextEdit(range=ls_types.Range(start=ls_types.Position(line=0, character=0), end=end), newText=formatted_contents)]
+
+    @override
+    def _document_symbols_cache_fingerprint(self) -> Hashable:
+        native_hcl_parser_version = 1
+        request_document_symbols_override_version = 1
+        # the symbols determined in degraded mode (native HCL parser only) must not be reused once terraform-ls is available
+        return native_hcl_parser_version, request_document_symbols_override_version, self.is_degraded()
+
+    @override
+    def request_document_symbols(self, relative_file_path: str, file_buffer: LSPFileBuffer | None = None) -> DocumentSymbols:
+        """
+        Override to add the Terraform-specific kinds (`tf_kind`) of the symbols, since terraform-ls reports all blocks as classes.
+        """
+        document_symbols = super().request_document_symbols(relative_file_path, file_buffer=file_buffer)
+
+        # NOTE: When changing this method, also update the cache fingerprint method above
+
+        for symbol in document_symbols.iter_symbols():
+            tf_kind = self._determine_tf_kind(symbol)
+            if tf_kind is not None:
+                symbol["tf_kind"] = tf_kind
+        return document_symbols
+
+    @staticmethod
+    def _determine_tf_kind(symbol: ls_types.UnifiedSymbolInformation) -> str | None:
+        parent = symbol.get("parent")
+        if parent is not None and parent["kind"] == SymbolKind.Class and parent["name"] == "locals":
+            return "local"
+        # blocks are top-level blocks except for data blocks, which may also be nested in `check` blocks
+        if symbol["kind"] != SymbolKind.Class or (parent is not None and not parent["name"].startswith("check ")):
+            return None
+        block_type, _, labels = symbol["name"].partition(" ")
+        if block_type in LABELLED_TF_KINDS and labels.startswith('"'):
+            if parent is not None and block_type != "data":
+                return None
+            return block_type
+        if block_type in UNLABELLED_TF_KINDS and not labels and parent is None:
+            return block_type
+        return None
+
+    @override
+    def _request_document_symbols(
+        self, relative_file_path: str, file_data: LSPFileBuffer | None
+    ) -> list[SymbolInformation] | list[DocumentSymbol] | None:
+        if self.is_degraded():
+            symbols = None
+        elif relative_file_path.endswith(TERRAFORM_LS_FILE_SUFFIXES):
+            return super()._request_document_symbols(relative_file_path, file_data)
+        else:
+            # terraform-ls does not support other HCL files (e.g. Packer or Nomad files), failing or returning no symbols
+            # for them, in which case we fall back to the native HCL parser
+            try:
+                symbols = super()._request_document_symbols(relative_file_path, file_data)
+            except SolidLSPException as e:
+                log.debug(f"terraform-ls failed to determine the symbols in {relative_file_path} ({e}); using the native HCL parser")
+                symbols = None
+        if symbols:
+            return symbols
+        with self._open_file_context(relative_file_path, file_buffer=file_data, open_in_ls=False) as fd:
+            return parse_hcl_document_symbols(fd.contents)
+
+    def _create_dependency_provider(self) -> LanguageServerDependencyProvider:
+        return self.DependencyProvider(self._custom_settings, self._ls_resources_dir)
+
+    class DependencyProvider(LanguageServerDependencyProviderSinglePath):
+        """
+        Resolves the terraform-ls executable: a custom executable configured via `ls_path` or the environment variable
+        `SERENA_TERRAFORM_LS_PATH` is used as is; otherwise, unless a version is configured explicitly, a sufficiently
+        recent system-wide installation is preferred; otherwise the configured version is downloaded (if necessary).
+        """
+
+        def _get_or_install_core_dependency(self) -> str:
+            custom_path = os.environ.get(TERRAFORM_LS_PATH_ENV_VAR)
+            if custom_path:
+                if not os.path.isfile(custom_path):
+                    raise FileNotFoundError(f"terraform-ls executable {custom_path} (set via {TERRAFORM_LS_PATH_ENV_VAR}) not found")
+                log.info(f"Using terraform-ls executable {custom_path} (set via {TERRAFORM_LS_PATH_ENV_VAR})")
+                return custom_path
+            terraform_ls_version = self._custom_settings.get("terraform_ls_version", None)
+            if terraform_ls_version is None and self._custom_settings.get("use_system_terraform_ls", True):
+                system_executable = TerraformLS.find_system_executable()
+                if system_executable is not None:
+                    executable_path, version = system_executable
+                    log.info(f"Using system-wide terraform-ls {version} at {executable_path}")
+                    return executable_path
+            return TerraformLS._install(self._ls_resources_dir, terraform_ls_version or DEFAULT_TERRAFORM_LS_VERSION)
+
+        def _create_launch_command(self, core_path: str) -> list[str]:
+            return [core_path, "serve"]
 
     def _create_base_initialize_params(self) -> dict:
         """
@@ -228,13 +790,25 @@ class TerraformLS(SolidLanguageServer):
                     "synchronization": {"didSave": True, "dynamicRegistration": True},
                     "completion": {"dynamicRegistration": True, "completionItem": {"snippetSupport": True}},
                     "definition": {"dynamicRegistration": True},
+                    "codeAction": {
+                        "dynamicRegistration": True,
+                        "codeActionLiteralSupport": {
+                            "codeActionKind": {"valueSet": ["", "quickfix", "refactor", "source", "source.formatAll"]}
+                        },
+                        "resolveSupport": {"properties": ["edit"]},
+                    },
                     "documentSymbol": {
                         "dynamicRegistration": True,
                         "hierarchicalDocumentSymbolSupport": True,
                         "symbolKind": {"valueSet": list(range(1, 27))},
                     },
                 },
-                "workspace": {"workspaceFolders": True, "didChangeConfiguration": {"dynamicRegistration": True}},
+                "workspace": {
+                    "workspaceFolders": True,
+                    "didChangeConfiguration": {"dynamicRegistration": True},
+                    "symbol": {"dynamicRegistration": True, "symbolKind": {"valueSet": list(range(1, 27))}},
+                    "workspaceEdit": {"documentChanges": True, "resourceOperations": ["create", "rename", "delete"]},
+                },
             },
         }
         return result
@@ -256,6 +830,11 @@ class TerraformLS(SolidLanguageServer):
         self.server.on_notification("$/progress", do_nothing)
         self.server.on_notification("textDocument/publishDiagnostics", do_nothing)
 
+        if self.is_degraded():
+            log.info("Starting in degraded mode without terraform-ls (using the native HCL parser)")
+            self.server.start()
+            return
+
         log.info("Starting terraform-ls server process")
         self.server.start()
         initialize_params = self._create_initialize_params()
@@ -267,6 +846,7 @@ class TerraformLS(SolidLanguageServer):
         assert "textDocumentSync" in init_response["capabilities"]
         assert "completionProvider" in init_response["capabilities"]
         assert "definitionProvider" in init_response["capabilities"]
+        self._workspace_symbol_provider = bool(init_response["capabilities"].get("workspaceSymbolProvider"))
 
         self.server.notify.initialized({})
 
@@ -0,0 +1,117 @@
+"""
+TFLint language server for Terraform files, which provides lint diagnostics (e.g. deprecated syntax, unused declarations
+or provider-specific problems such as invalid instance types) but no symbols.
+It is intended to complement terraform-ls (i.e. to be configured alongside ``terraform`` in the project's language servers),
+which continues to serve all symbol-based requests.
+
+Requires TFLint to be installed and available on the PATH (or configured via ``ls_path``),
+see https://github.com/terraform-linters/tflint.
+The rules applied are configured via the project's ``.tflint.hcl`` file.
+"""
+
+import logging
+import shutil
+from typing import Any
+
+from overrides import override
+
+from solidlsp.ls import LanguageServerDependencyProvider, LanguageServerDependencyProviderSinglePath, LSPFileBuffer, SolidLanguageServer
+from solidlsp.ls_config import LanguageServerConfig
+from solidlsp.lsp_protocol_handler.lsp_types import DocumentSymbol, SymbolInformation
+from solidlsp.settings import SolidLSPSettings
+
+log = logging.getLogger(__name__)
+
+
+class TFLintLanguageServer(SolidLanguageServer):
+    """
+    Provides Terraform lint diagnostics using ``tflint --langserver``.
+
+    You can pass the following entries in ``ls_specific_settings["tflint"]``:
+        - ls_path: Path to the tflint executable (default: the executable found on the PATH).
+    """
+
+    def __init__(self, config: LanguageServerConfig, repository_root_path: str, solidlsp_settings: SolidLSPSettings):
+        """
+        Creates a TFLintLanguageServer instance. This class is not meant to be instantiated directly.
+        Use LanguageServer.create() instead.
+        """
+        super().__init__(config, repository_root_path, None, "terraform", solidlsp_settings)
+
+    @override
+    def is_ignored_dirname(self, dirname: str) -> bool:
+        return super().is_ignored_dirname(dirname) or dirname in [".terraform", ".terragrunt-cache"]
+
+    @classmethod
+    @override
+    def provides_symbols(cls) -> bool:
+        return False
+
+    @override
+    def _request_document_symbols(
+        self, relative_file_path: str, file_data: LSPFileBuffer | None
+    ) -> list[SymbolInformation] | list[DocumentSymbol] | None:
+        # TFLint does not support document symbols
+        return []
+
+    def _create_dependency_provider(self) -> LanguageServerDependencyProvider:
+        return self.DependencyProvider(self._custom_settings, self._ls_resources_dir)
+
+    class DependencyProvider(LanguageServerDependencyProviderSinglePath):
+        def _get_or_install_core_dependency(self) -> str:
+            tflint_path = shutil.which("tflint")
+            if not tflint_path:
+                raise FileNotFoundError(
+                    "tflint is not installed on your system. Please install it (e.g. via `brew install tflint`) "
+                    "as described at https://github.com/terraform-linters/tflint#installation"
+                )
+            log.info(f"Using system-installed tflint at {tflint_path}")
+            return tflint_path
+
+        def _create_launch_command(self, core_path: str) -> list[str]:
+            return [core_path, "--langserver"]
+
+    def _create_base_initialize_params(self) -> dict:
+        """
+        Returns the initialize params for the TFLint language server.
+        """
+        initialize_params = {
+            "locale": "en",
+            "capabilities": {
+                "textDocument": {
+                    "synchronization": {"didSave": True, "dynamicRegistration": True},
+                    "publishDiagnostics": {"relatedInformation": True},
+                },
+                "workspace": {
+                    "workspaceFolders": True,
+                    "didChangeConfiguration": {"dynamicRegistration": True},
+                    "didChangeWatchedFiles": {"dynamicRegistration": True},
+                },
+            },
+        }
+        return initialize_params
+
+    def _start_server(self) -> None:
+        """
+        Starts the TFLint language server and initializes the LSP connection.
+        """
+
+        def do_nothing(params: Any) -> None:
+            return
+
+        def window_log_message(msg: dict) -> None:
+            log.info(f"LSP: window/logMessage: {msg}")
+
+        self.server.on_request("client/registerCapability", do_nothing)
+        self.server.on_notification("window/logMessage", window_log_message)
+        self.server.on_notification("textDocument/publishDiagnostics", do_nothing)
+
+        log.info("Starting tflint language server process")
+        self.server.start()
+        initialize_params = self._create_initialize_params()
+
+        log.info("Sending initialize request from LSP client to LSP server and awaiting response")
+        init_response = self.server.send.initialize(initialize_params)
+        assert "textDocumentSync" in init_response["capabilities"]
+
+        self.server.notify.initialized({})
@@ -7,9 +7,9 @@ import pathlib
 import shutil
 import threading
 from abc import ABC, abstractmethod
-from collections import defaultdict
+from collections import OrderedDict, defaultdict
 from collections.abc import Callable, Hashable, Iterator
-from contextlib import contextmanager
+from contextlib import ExitStack, contextmanager
 from copy import copy
 from dataclasses import dataclass
 from pathlib import Path, PurePath
@@ -55,7 +55,8 @@ from solidlsp.lsp_protocol_handler.server import (
     StringDict,
 )
 from solidlsp.settings import SolidLSPSettings
-from solidlsp.util.cache import load_cache, save_cache
+from solidlsp.util.cache import SymbolCacheStats, get_cache_num_entries, load_cache, save_cache
+from solidlsp.util.request_cache import PositionRequestCache
 
 RawDocumentSymbol = Union[DocumentSymbol, SymbolInformation]
 """
@@ -123,6 +124,8 @@ class LSPFileBuffer:
         """
         if self._is_open_in_ls:
             return
+        # note: the contents must be (re-)read before the file is considered open, such that no change is notified
+        contents = self.contents
         self._is_open_in_ls = True
         self.language_server.server.notify.did_open_text_document(
             {  # ty: ignore[invalid-argument-type]  # dict built from LSPConstants keys; shape matches the TypedDict
@@ -130,7 +133,7 @@ class LSPFileBuffer:
                     LSPConstants.URI: self.uri,
                     LSPConstants.LANGUAGE_ID: self.language_id,
                     LSPConstants.VERSION: 0,
-                    LSPConstants.TEXT: self.contents,
+                    LSPConstants.TEXT: contents,
                 }
             }
         )
@@ -149,20 +152,91 @@ class LSPFileBuffer:
         """Ensure that the file is opened in the language server."""
         self._open_in_ls()
 
+    def is_open_in_ls(self) -> bool:
+        """:return: whether the file is open in the language server"""
+        return self._is_open_in_ls
+
+    def is_modified_on_disk(self) -> bool:
+        """:return: whether the file was modified on disk since its contents were last read"""
+        return self._read_file_modified_date is None or self.abs_path.stat().st_mtime > self._read_file_modified_date
+
+    def _notify_change(self, old_contents: str, new_contents: str) -> None:
+        """
+        Notifies the language server (if the file is open in it) of a change of the file's contents via an incremental
+        didChange notification, which replaces the range of the old contents in which the old and new contents differ.
+        """
+        if not self._is_open_in_ls or old_contents == new_contents:
+            return
+        start_offset = len(os.path.commonprefix([old_contents, new_contents]))
+        max_suffix_length = min(len(old_contents), len(new_contents)) - start_offset
+        suffix_length = 0
+        while suffix_length < max_suffix_length and old_contents[-1 - suffix_length] == new_contents[-1 - suffix_length]:
+            suffix_length += 1
+
+        def position(offset: int) -> ls_types.Position:
+            line_start_offset = old_contents.rfind("\n", 0, offset) + 1
+            return ls_types.Position(line=old_contents.count("\n", 0, offset), character=offset - line_start_offset)
+
+        self.version += 1
+        self.language_server.clear_position_request_cache()
+        self.language_server.server.notify.did_change_text_document(
+            {  # ty: ignore[invalid-argument-type]  # dict built from LSPConstants keys; shape matches the TypedDict
+                LSPConstants.TEXT_DOCUMENT: {
+                    LSPConstants.VERSION: self.version,
+                    LSPConstants.URI: self.uri,
+                },
+                LSPConstants.CONTENT_CHANGES: [
+                    {
+                        LSPConstants.RANGE: {"start": position(start_offset), "end": position(len(old_contents) - suffix_length)},
+                        "text": new_contents[start_offset : len(new_contents) - suffix_length],
+                    }
+                ],
+            }
+        )
+
+    def update_contents(self, new_contents: str) -> None:
+        """
+        Sets new contents for the file buffer, notifying the language server of the change (if the file is open in it).
+        Persistence of the change to disk must be handled separately.
+
+        :param new_contents: the new contents to set
+        """
+        old_contents = self.contents
+        self.contents = new_contents
+        self._notify_change(old_contents, new_contents)
+
+    def transfer_to(self, language_server: "SolidLanguageServer") -> None:
+        """
+        Transfers the buffer to another language server (which replaces the buffer's current server, e.g. after a crash),
+        re-opening the file in the new server if it was open in the current one.
+
+        :param language_server: the language server to which to transfer the buffer
+        """
+        was_open_in_ls = self._is_open_in_ls
+        self.language_server = language_server
+        self._is_open_in_ls = False
+        if was_open_in_ls:
+            self._open_in_ls()
+
     @property
     def contents(self) -> str:
         file_modified_date = self.abs_path.stat().st_mtime
 
         # if contents are cached, check if they are stale (file modification since last read) and invalidate if so
+        stale_contents = None
         if self._contents is not None:
             assert self._read_file_modified_date is not None
             if file_modified_date > self._read_file_modified_date:
+                stale_contents = self._contents
                 self._contents = None
 
         if self._contents is None:
             self._read_file_modified_date = file_modified_date
             self._contents = FileUtils.read_file(str(self.abs_path), self.encoding)
             self._content_hash = None
+            # a file which is kept open in the language server may have been changed on disk by other means
+            if stale_contents is not None:
+                self._notify_change(stale_contents, self._contents)
 
         return self._contents
 
@@ -208,7 +282,22 @@ class SymbolBody(ToStringMixin):
     def _tostring_excludes(self) -> list[str]:
         return ["_lines"]
 
-    def get_text(self) -> str:
+    def get_leading_comment_start_line(self) -> int:
+        """
+        :return: the 0-based index of the first line of the block of comment lines (e.g. a doc comment) immediately preceding
+            the symbol, or the symbol's start line if there is no such block
+        """
+        return TextUtils.find_leading_comment_start_position(self._lines, self._start_line, self._start_col)[0]
+
+    def get_text(self, include_leading_comments: bool = False) -> str:
+        """
+        :param include_leading_comments: whether to include the block of comment lines (e.g. a doc comment) immediately
+            preceding the symbol
+        :return: the text of the symbol
+        """
+        start_line, start_col = self._start_line, self._start_col
+        if include_leading_comments:
+            start_line, start_col = TextUtils.find_leading_comment_start_position(self._lines, start_line, start_col)
         end_line = self._end_line
         end_col = self._end_col
         if end_line >= len(self._lines):
@@ -231,10 +320,10 @@ class SymbolBody(ToStringMixin):
                 )
 
         # extract relevant lines
-        symbol_body = "\n".join(self._lines[self._start_line : end_line + 1])
+        symbol_body = "\n".join(self._lines[start_line : end_line + 1])
 
         # remove leading content from the first line
-        symbol_body = symbol_body[self._start_col :]
+        symbol_body = symbol_body[start_col:]
 
         # remove trailing content from the last line
         last_line = self._lines[end_line]
@@ -331,6 +420,17 @@ class SolidLanguageServer(ABC):
     change :meth:`_document_symbols_cache_fingerprint` instead.
     """
     DOCUMENT_SYMBOL_CACHE_FILENAME = "document_symbols.pkl"
+    POSITION_REQUEST_CACHE_TTL = 10.0
+    """
+    the time (in seconds) for which the results of hover, definition and reference requests are cached (per document version).
+    Can be overridden via the LS-specific setting `position_request_cache_ttl`; a non-positive value disables the cache.
+    """
+    KEEP_OPEN_FILES = 0
+    """
+    the number of recently used files which are kept open in the language server after use (such that they need not be
+    re-parsed upon the next request), where the least recently used files are closed first.
+    Can be overridden via the LS-specific setting `keep_open_files`; 0 disables keeping files open.
+    """
 
     # Directories that should always be ignored regardless of language:
     # VCS internals, virtual environments, caches, and serena's own data.
@@ -393,6 +493,15 @@ class SolidLanguageServer(ABC):
         """
         return False
 
+    @classmethod
+    def provides_symbols(cls) -> bool:
+        """
+        Return whether this language server provides symbols (and thus supports symbol-based requests).
+        Language servers which do not (e.g. linters providing only diagnostics) complement other language servers
+        for the same file types and are not used for symbol-based requests.
+        """
+        return True
+
     @classmethod
     def ls_resources_dir(cls, solidlsp_settings: SolidLSPSettings, mkdir: bool = True) -> str:
         """
@@ -500,6 +609,16 @@ class SolidLanguageServer(ABC):
         default language identifier to be passed to the language server in `textDocument/didOpen` notifications.
         """
         self.open_file_buffers: dict[str, LSPFileBuffer] = {}
+        self._idle_file_buffers: OrderedDict[str, LSPFileBuffer] = OrderedDict()
+        """
+        the subset of open file buffers which are no longer in use but kept open, in the order of their last use
+        """
+        self._file_buffers_lock = threading.RLock()
+        """
+        guards the bookkeeping of the file buffers (`open_file_buffers`, `_idle_file_buffers` and the buffers' reference counts),
+        since files may be opened concurrently (e.g. when retrieving the symbols of several files in parallel)
+        """
+        self._keep_open_files: int = self._custom_settings.get("keep_open_files", self.KEEP_OPEN_FILES)
         self.ls_id = self.get_language_server_id()
         """
         identifies the language server (not to be confused with the language_id passed to the language server)
@@ -528,6 +647,12 @@ class SolidLanguageServer(ABC):
         """maps relative file paths to a tuple of (file_content_hash, document_symbols)"""
         self._document_symbols_cache_is_modified: bool = False
         self._load_document_symbols_cache()
+        self._document_symbols_cache_num_hits = 0
+        self._document_symbols_cache_num_misses = 0
+        # * short-lived cache for the results of position-based requests (hover, definition, references)
+        self._position_request_cache = PositionRequestCache(
+            ttl=self._custom_settings.get("position_request_cache_ttl", self.POSITION_REQUEST_CACHE_TTL)
+        )
 
         self.server_started = False
         if config.trace_lsp_communication:
@@ -709,6 +834,18 @@ class SolidLanguageServer(ABC):
         with self._published_diagnostics_condition:
             return self._published_diagnostics_generation_by_uri.get(key, -1)
 
+    def _get_relevant_published_diagnostics_generation(self, uri: str, published_uri: str) -> int:
+        """
+        Determines the generation after which published diagnostics are relevant for a request concerning the given file.
+        If the file is already open in the language server (e.g. because it is kept open) and unchanged on disk,
+        opening it will not trigger a new publication, so the diagnostics published last are still relevant.
+        """
+        generation = self._get_published_diagnostics_generation(published_uri)
+        fb = self.open_file_buffers.get(uri)
+        if generation >= 0 and fb is not None and fb.is_open_in_ls() and not fb.is_modified_on_disk():
+            return generation - 1
+        return generation
+
     def _wait_for_published_diagnostics(
         self,
         uri: str,
@@ -863,6 +1000,55 @@ class SolidLanguageServer(ABC):
 
         return self._filter_diagnostics(diagnostics, start_line, end_line, min_severity)
 
+    def request_published_diagnostics_for_files(
+        self,
+        relative_file_paths: list[str],
+        timeout: float = 5.0,
+        min_severity: int = 4,
+    ) -> tuple[dict[str, list[ls_types.Diagnostic]], list[str]]:
+        """
+        Opens the given files, such that the language server analyses them, and collects the diagnostics received for them
+        through ``textDocument/publishDiagnostics``.
+        In contrast to requesting the diagnostics of each file individually, the files are analysed concurrently,
+        and the total waiting time is bounded by the timeout, making this suitable for many files (e.g. an entire project).
+
+        :param relative_file_paths: the relative paths of the files to retrieve diagnostics for
+        :param timeout: the maximum total time to wait for diagnostics to be published
+        :param min_severity: minimum LSP severity to include, where 1=Error, 2=Warning, 3=Information, 4=Hint.
+            Diagnostics with lower-or-equal numeric severity are returned.
+        :return: a pair `(diagnostics_by_file, timed_out_files)`, where `diagnostics_by_file` maps relative file paths
+            to the files' diagnostics (omitting files without diagnostics) and `timed_out_files` are the relative paths of the files
+            for which no diagnostics were published before the timeout (i.e. files whose diagnostics are unknown)
+        """
+        published_uris: dict[str, str] = {}
+        generations: dict[str, int] = {}
+        for relative_file_path in relative_file_paths:
+            uri = self._validate_text_document_diagnostics_request(relative_file_path, 0, -1, min_severity)
+            published_uris[relative_file_path] = self._get_published_diagnostics_uri(uri)
+            generations[relative_file_path] = self._get_relevant_published_diagnostics_generation(uri, published_uris[relative_file_path])
+
+        result: dict[str, list[ls_types.Diagnostic]] = {}
+        timed_out_files: list[str] = []
+        with ExitStack() as stack:
+            # keep all documents open while waiting, such that the language server can analyse them concurrently
+            for relative_file_path in relative_file_paths:
+                stack.enter_context(self.open_file(relative_file_path))
+            deadline = monotonic() + timeout
+            for relative_file_path, published_uri in published_uris.items():
+                diagnostics = self._wait_for_relevant_published_diagnostics(
+                    uri=published_uri,
+                    after_generation=generations[relative_file_path],
+                    timeout=max(deadline - monotonic(), 0.0),
+                )
+                if diagnostics:
+                    diagnostics = self._filter_diagnostics(diagnostics, 0, -1, min_severity)
+                    if diagnostics:
+                        result[relative_file_path] = diagnostics
+                elif diagnostics is None and self._get_published_diagnostics_generation(published_uri) <= generations[relative_file_path]:
+                    # nothing (not even an empty payload) was published for the file
+                    timed_out_files.append(relative_file_path)
+        return result, timed_out_files
+
     def _get_published_diagnostics_uri(self, request_uri: str) -> str:
         """
         Gets the URI under which published diagnostics should be looked up.
@@ -948,7 +1134,7 @@ class SolidLanguageServer(ABC):
         """
         uri = self._validate_text_document_diagnostics_request(relative_file_path, start_line, end_line, min_severity)
         published_uri = self._get_published_diagnostics_uri(uri)
-        diagnostics_before_request = self._get_published_diagnostics_generation(published_uri)
+        diagnostics_before_request = self._get_relevant_published_diagnostics_generation(uri, published_uri)
         ret: list[ls_types.Diagnostic] | None = None
         pull_diagnostics_failed = False
 
@@ -1144,8 +1330,16 @@ class SolidLanguageServer(ABC):
 
     def set_request_timeout(self, timeout: float | None) -> None:
         """
-        :param timeout: the timeout, in seconds, for requests to the language server.
+        Sets the timeout for requests to the language server, which is capped at the value of the language server-specific
+        setting `request_timeout` (if any).
+        Requests that time out are cancelled and raise a `LanguageServerRequestTimeoutError`.
+
+        :param timeout: the timeout, in seconds, for requests to the language server. If None, no timeout is applied
+            (other than the one configured via `request_timeout`).
         """
+        configured_timeout = self._custom_settings.get("request_timeout")
+        if configured_timeout is not None:
+            timeout = configured_timeout if timeout is None else min(timeout, configured_timeout)
         self.server.set_request_timeout(timeout)
 
     def get_ignore_spec(self) -> pathspec.PathSpec:
@@ -1258,36 +1452,94 @@ class SolidLanguageServer(ABC):
             absolute_file_path = absolute_file_path.resolve()
         uri = absolute_file_path.as_uri()
 
-        if uri in self.open_file_buffers:
-            fb = self.open_file_buffers[uri]
-            assert fb.uri == uri
-            assert fb.ref_count >= 1
+        with self._file_buffers_lock:
+            if uri in self.open_file_buffers:
+                fb = self.open_file_buffers[uri]
+                assert fb.uri == uri
+                assert fb.ref_count >= 1 or uri in self._idle_file_buffers
 
-            fb.ref_count += 1
-            if open_in_ls:
-                fb.ensure_open_in_ls()
-        else:
-            version = 0
-            language_id = self._get_language_id_for_file(relative_file_path)
-            fb = LSPFileBuffer(
-                abs_path=absolute_file_path,
-                uri=uri,
-                encoding=self._encoding,
-                version=version,
-                language_id=language_id,
-                ref_count=1,
-                language_server=self,
-                open_in_ls=open_in_ls,
-            )
-            self.open_file_buffers[uri] = fb
+                self._idle_file_buffers.pop(uri, None)
+                fb.ref_count += 1
+                if open_in_ls:
+                    fb.ensure_open_in_ls()
+            else:
+                version = 0
+                language_id = self._get_language_id_for_file(relative_file_path)
+                fb = LSPFileBuffer(
+                    abs_path=absolute_file_path,
+                    uri=uri,
+                    encoding=self._encoding,
+                    version=version,
+                    language_id=language_id,
+                    ref_count=1,
+                    language_server=self,
+                    open_in_ls=open_in_ls,
+                )
+                self.open_file_buffers[uri] = fb
 
         try:
             yield fb
         finally:
-            fb.ref_count -= 1
-            if fb.ref_count == 0:
-                fb.close()
-                del self.open_file_buffers[uri]
+            # note: the buffer may have been transferred to another language server in the meantime
+            language_server = fb.language_server
+            with language_server._file_buffers_lock:
+                fb.ref_count -= 1
+                if fb.ref_count == 0:
+                    language_server._release_file_buffer(fb)
+
+    def _release_file_buffer(self, fb: LSPFileBuffer) -> None:
+        """
+        Releases a file buffer which is no longer in use, closing it or, if enabled, keeping it open
+        (closing the least recently used buffers beyond the configured number of files to keep open).
+        """
+        if self._keep_open_files > 0 and fb.is_open_in_ls():
+            self._idle_file_buffers[fb.uri] = fb
+            while len(self._idle_file_buffers) > self._keep_open_files:
+                _, lru_fb = self._idle_file_buffers.popitem(last=False)
+                self._close_file_buffer(lru_fb)
+        else:
+            self._close_file_buffer(fb)
+
+    def _close_file_buffer(self, fb: LSPFileBuffer) -> None:
+        fb.close()
+        del self.open_file_buffers[fb.uri]
+
+    def sync_kept_open_files(self, relative_file_paths: list[str]) -> None:
+        """
+        Synchronises the files which are kept open in the language server (see `KEEP_OPEN_FILES`) with their state on disk,
+        notifying the language server of changes and closing files which no longer exist.
+
+        :param relative_file_paths: the relative paths of the files which were changed or deleted
+        """
+        for relative_file_path in relative_file_paths:
+            uri = self._resolve_file_uri(relative_file_path)
+            with self._file_buffers_lock:
+                fb = self._idle_file_buffers.get(uri)
+                if fb is None:
+                    continue
+                if fb.abs_path.exists():
+                    _ = fb.contents  # notifies the language server of changes
+                else:
+                    del self._idle_file_buffers[uri]
+                    self._close_file_buffer(fb)
+
+    def adopt_open_file_buffers(self, previous_language_server: "SolidLanguageServer") -> None:
+        """
+        Adopts the file buffers which are still open in a language server this server replaces (e.g. after a crash),
+        replaying the didOpen notifications for files that were open in the previous server.
+
+        :param previous_language_server: the language server being replaced
+        """
+        open_file_buffers = dict(previous_language_server.open_file_buffers)
+        previous_language_server.open_file_buffers.clear()
+        if open_file_buffers:
+            log.info("Re-opening %d file(s) of the replaced language server", len(open_file_buffers))
+        for uri, fb in open_file_buffers.items():
+            self.open_file_buffers[uri] = fb
+            fb.transfer_to(self)
+        for fb in previous_language_server._idle_file_buffers.values():
+            self._release_file_buffer(fb)
+        previous_language_server._idle_file_buffers.clear()
 
     @contextmanager
     def _open_file_context(
@@ -1333,6 +1585,7 @@ class SolidLanguageServer(ABC):
 
         file_buffer = self.open_file_buffers[uri]
         file_buffer.version += 1
+        self._position_request_cache.clear()
 
         new_contents, new_l, new_c = TextUtils.insert_text_at_position(file_buffer.contents, line, column, text_to_be_inserted)
         file_buffer.contents = new_contents
@@ -1375,6 +1628,7 @@ class SolidLanguageServer(ABC):
 
         file_buffer = self.open_file_buffers[uri]
         file_buffer.version += 1
+        self._position_request_cache.clear()
         new_contents, deleted_text = TextUtils.delete_text_between_positions(
             file_buffer.contents, start_line=start["line"], start_col=start["character"], end_line=end["line"], end_col=end["character"]
         )
@@ -1417,21 +1671,27 @@ class SolidLanguageServer(ABC):
             # arm indexing tracking before didOpen so that the subsequent wait can
             # observe the indexing progress triggered by opening the file
             self.language_server._pre_open_for_cross_file_references()
-            with self.language_server.open_file(self.relative_file_path):
+            with self.language_server.open_file(self.relative_file_path) as file_buffer:
                 self.language_server._wait_for_cross_file_references_if_needed()
-                try:
-                    response = self.send_request()
-                except Exception as e:
-                    mapped_exception = self.map_exception(e)
-                    if mapped_exception is not None:
-                        raise mapped_exception from e
-                    raise
+                cache_key = PositionRequestCache.create_key(
+                    file_buffer.uri, file_buffer.content_hash, self.request_name, self.line, self.column
+                )
+                result = self.language_server._position_request_cache.get_or_compute(cache_key, self._send_and_normalize_request)
 
-            result = self.normalize_response(response)
             if t0 is not None:
                 self.log_perf_result(t0, result)
             return result
 
+        def _send_and_normalize_request(self) -> list[ls_types.Location]:
+            try:
+                response = self.send_request()
+            except Exception as e:
+                mapped_exception = self.map_exception(e)
+                if mapped_exception is not None:
+                    raise mapped_exception from e
+                raise
+            return self.normalize_response(response)
+
         def _ensure_server_started(self) -> None:
             if not self.language_server.server_started:
                 log.error("%s called before language server started", self.request_name)
@@ -1905,10 +2165,12 @@ class SolidLanguageServer(ABC):
                 if file_hash == file_data.content_hash:
                     log.debug("Returning cached document symbols for %s", relative_file_path)
                     log.debug("perf: document_symbols_cache HIT path=%s", relative_file_path)
+                    self._document_symbols_cache_num_hits += 1
                     return document_symbols
 
                 log.debug("Cached document symbol content for %s has changed", relative_file_path)
                 log.debug("perf: document_symbols_cache STALE path=%s", relative_file_path)
+            self._document_symbols_cache_num_misses += 1
 
             # no cached result: request the root symbols from the language server
             root_symbols = self._request_document_symbols(relative_file_path, file_data)
@@ -2151,6 +2413,37 @@ class SolidLanguageServer(ABC):
                 full_result.extend(process_directory(root))
             return full_result
 
+    def iter_source_files(self, within_relative_path: str | None = None) -> Iterator[str]:
+        """
+        Iterates over the relative paths of the (non-ignored) source files in the project or within the given path,
+        i.e. the files whose symbols are included in the result of `request_full_symbol_tree`.
+
+        :param within_relative_path: pass a relative path to only consider files within this path (which may also be a file)
+        :return: an iterator over relative file paths
+        """
+        if within_relative_path:
+            within_abs_path = os.path.join(self.repository_root_path, within_relative_path)
+            if not os.path.exists(within_abs_path):
+                raise FileNotFoundError(f"File or directory not found: {within_abs_path}")
+            if self.is_ignored_path(within_relative_path):
+                raise ValueError(f"Explicitly requested files in '{within_relative_path}' while the path is ignored")
+            if os.path.isfile(within_abs_path):
+                yield within_relative_path
+                return
+            root_abs_paths = [within_abs_path]
+        else:
+            root_abs_paths = self.config.get_absolute_workspace_folders(self.repository_root_path)
+
+        for root_abs_path in root_abs_paths:
+            for dir_abs_path, dir_names, file_names in os.walk(root_abs_path):
+                dir_rel_path = os.path.relpath(dir_abs_path, self.repository_root_path)
+                # prune ignored directories (in place, such that os.walk does not descend into them)
+                dir_names[:] = sorted(d for d in dir_names if not self.is_ignored_path(os.path.normpath(os.path.join(dir_rel_path, d))))
+                for file_name in sorted(file_names):
+                    file_rel_path = os.path.normpath(os.path.join(dir_rel_path, file_name))
+                    if not self.is_ignored_path(file_rel_path):
+                        yield file_rel_path
+
     @staticmethod
     def _get_range_from_file_content(file_content: str) -> ls_types.Range:
         """
@@ -2248,7 +2541,8 @@ class SolidLanguageServer(ABC):
             Can be used for optimizing number of file reads in downstream code
         """
         with self._open_file_context(relative_file_path, file_buffer=file_buffer) as fb:
-            return self._request_hover(fb, line, column)
+            cache_key = PositionRequestCache.create_key(fb.uri, fb.content_hash, "request_hover", line, column)
+            return self._position_request_cache.get_or_compute(cache_key, lambda: self._request_hover(fb, line, column))
 
     def _request_hover(self, file_buffer: LSPFileBuffer, line: int, column: int) -> ls_types.Hover | None:
         """
@@ -2275,6 +2569,18 @@ class SolidLanguageServer(ABC):
             return None
         return ls_types.Hover(**response)  # type: ignore
 
+    def get_symbol_info_position(self, file_contents: str, line: int, column: int) -> tuple[int, int]:
+        """
+        Determines the position at which to request hover information (see :meth:`request_hover`) for a symbol,
+        which, by default, is the symbol's position.
+
+        :param file_contents: the contents of the file containing the symbol
+        :param line: the line of the symbol's position
+        :param column: the column of the symbol's position
+        :return: the line and column at which to request hover information
+        """
+        return line, column
+
     def request_signature_help(self, relative_file_path: str, line: int, column: int) -> ls_types.SignatureHelp | None:
         """
         Raise a [textDocument/signatureHelp](https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#textDocument_signatureHelp)
@@ -3049,10 +3355,82 @@ class SolidLanguageServer(ABC):
                     e,
                 )
 
+    def clear_position_request_cache(self) -> None:
+        """
+        Clears the cached results of position-based requests (hover, definition, references),
+        which should be done whenever files were changed outside of the language server.
+        """
+        self._position_request_cache.clear()
+
     def save_cache(self) -> None:
         self._save_raw_document_symbols_cache()
         self._save_document_symbols_cache()
 
+    @classmethod
+    def get_symbol_cache_files(cls, cache_dir: Path) -> tuple[Path, Path]:
+        """
+        :return: the paths of the raw and the high-level document symbols cache files in the given directory
+        """
+        return cache_dir / cls.RAW_DOCUMENT_SYMBOL_CACHE_FILENAME, cache_dir / cls.DOCUMENT_SYMBOL_CACHE_FILENAME
+
+    @classmethod
+    def _get_symbol_cache_size_on_disk(cls, cache_dir: Path) -> int:
+        return sum(path.stat().st_size for path in cls.get_symbol_cache_files(cache_dir) if path.exists())
+
+    @classmethod
+    def read_persisted_symbol_cache_stats(cls, cache_dir: Path) -> SymbolCacheStats:
+        """
+        Determines the statistics of the symbol caches persisted in the given directory (without starting a language server).
+        As no requests are served, the numbers of cache hits and misses are zero.
+
+        :param cache_dir: the language server-specific cache directory
+        :return: the statistics
+        """
+        raw_cache_file, cache_file = cls.get_symbol_cache_files(cache_dir)
+        return SymbolCacheStats(
+            num_entries=get_cache_num_entries(str(cache_file)),
+            num_raw_entries=get_cache_num_entries(str(raw_cache_file)),
+            size_on_disk=cls._get_symbol_cache_size_on_disk(cache_dir),
+        )
+
+    def get_symbol_cache_stats(self) -> SymbolCacheStats:
+        """
+        :return: the statistics of the document symbol caches, including the cache hits and misses since the start
+        """
+        return SymbolCacheStats(
+            num_entries=len(self._document_symbols_cache),
+            num_raw_entries=len(self._raw_document_symbols_cache),
+            size_on_disk=self._get_symbol_cache_size_on_disk(self.cache_dir),
+            num_hits=self._document_symbols_cache_num_hits,
+            num_misses=self._document_symbols_cache_num_misses,
+        )
+
+    def invalidate_symbol_cache(self, relative_file_path: str) -> None:
+        """
+        Removes the cached document symbols of the given file (e.g. because the file was changed, moved or deleted).
+
+        :param relative_file_path: the relative path of the file
+        """
+        for cache_key in {relative_file_path, os.path.normpath(relative_file_path)}:
+            if self._raw_document_symbols_cache.pop(cache_key, None) is not None:
+                self._raw_document_symbols_cache_is_modified = True
+            if self._document_symbols_cache.pop(cache_key, None) is not None:
+                self._document_symbols_cache_is_modified = True
+
+    def clear_symbol_cache(self) -> None:
+        """
+        Clears the (raw and high-level) document symbol caches, both in memory and on disk,
+        such that symbols are subsequently re-requested from the language server.
+        """
+        log.info("Clearing document symbol caches in %s", self.cache_dir)
+        self._raw_document_symbols_cache = {}
+        self._raw_document_symbols_cache_is_modified = False
+        self._document_symbols_cache = {}
+        self._document_symbols_cache_is_modified = False
+        for cache_file in self.get_symbol_cache_files(self.cache_dir):
+            cache_file.unlink(missing_ok=True)
+        self._position_request_cache.clear()
+
     def request_workspace_symbol(self, query: str) -> list[ls_types.UnifiedSymbolInformation] | None:
         """
         Raise a [workspace/symbol](https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#workspace_symbol) request to the Language Server
@@ -3080,6 +3458,72 @@ class SolidLanguageServer(ABC):
 
         return ret
 
+    def supports_workspace_symbol_search(self) -> bool:
+        """
+        Determines whether `workspace/symbol` requests can be used to narrow down the files to be searched for symbols
+        (see `request_files_with_top_level_symbols`).
+        This requires the response to comprise all top-level symbols (of the files covered, see `is_covered_by_workspace_symbols`)
+        whose names contain the query, without truncation, which is not the case for many language servers
+        (which apply fuzzy matching or limit the number of results). Should be overridden by subclasses for which this applies.
+
+        :return: whether workspace symbol search is supported
+        """
+        return False
+
+    def is_top_level_symbol_name(self, name_pattern: str) -> bool:
+        """
+        Determines whether the given name pattern can only match the names of top-level symbols (e.g. because of a prefix
+        which is specific to top-level symbols). May be overridden by subclasses supporting workspace symbol search.
+
+        :param name_pattern: the name pattern (which may contain glob-style wildcards)
+        :return: whether matching symbols are necessarily top-level symbols
+        """
+        return False
+
+    def resolve_symbol_address(self, name_path_pattern: str) -> tuple[str, str | None] | None:
+        """
+        Resolves a language-specific symbol address (e.g. a Terraform address such as `module.vpc.aws_subnet.private`),
+        which can be used in place of a name path pattern. May be overridden by subclasses.
+
+        :param name_path_pattern: the name path pattern, which may be an address
+        :return: None if the pattern is not an address (i.e. it is to be used as it is); otherwise a pair containing the name
+            path pattern matching the addressed symbol and the relative path of the directory to which the search can be
+            restricted (or None if the search cannot be restricted)
+        :raises ValueError: if the pattern is an address which cannot be resolved
+        """
+        return None
+
+    def is_covered_by_workspace_symbols(self, relative_file_path: str) -> bool:
+        """
+        :param relative_file_path: the relative path of a source file
+        :return: whether the symbols of the file are included in the results of `workspace/symbol` requests
+        """
+        return True
+
+    def request_files_with_top_level_symbols(self, query: str) -> set[str] | None:
+        """
+        Determines the files containing top-level symbols whose names contain the given query via a `workspace/symbol` request.
+
+        :param query: the query
+        :return: the relative paths of the files, or None if workspace symbol search is not supported or the request failed
+        """
+        if not self.supports_workspace_symbol_search():
+            return None
+        try:
+            symbols = self.request_workspace_symbol(query)
+        except SolidLSPException as e:
+            log.warning(f"Failed to search workspace symbols for '{query}': {e}")
+            return None
+        if symbols is None:
+            return None
+        relative_paths = set()
+        for symbol in symbols:
+            abs_path = PathUtils.uri_to_path(symbol["location"]["uri"])
+            relative_path = PathUtils.get_relative_path(abs_path, self.repository_root_path)
+            if relative_path is not None:
+                relative_paths.add(relative_path)
+        return relative_paths
+
     def request_rename_symbol_edit(
         self,
         relative_file_path: str,
@@ -3106,24 +3550,86 @@ class SolidLanguageServer(ABC):
         with self.open_file(relative_file_path):
             return self.server.send.rename(params)
 
+    def request_formatting_edits(self, relative_file_path: str) -> list[ls_types.TextEdit] | None:
+        """
+        Retrieves the edits which format the given file via a
+        [textDocument/formatting](https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#textDocument_formatting)
+        request. Does not apply the edits.
+
+        :param relative_file_path: the relative path of the file
+        :return: the edits (empty if the file is formatted already), or None if the language server cannot format the file
+        """
+        with self.open_file(relative_file_path) as file_buffer:
+            # the options are defaults only; language servers typically apply their language's conventions
+            params: lsp_types.DocumentFormattingParams = {
+                "textDocument": {"uri": file_buffer.uri},
+                "options": {"tabSize": 4, "insertSpaces": True},
+            }
+            edits = self.server.send.formatting(params)
+        return cast(list[ls_types.TextEdit] | None, edits)
+
+    def request_code_actions(self, relative_file_path: str, start_line: int = 0, end_line: int = -1) -> list[lsp_types.CodeAction]:
+        """
+        Retrieves the code actions (e.g. quick fixes or formatting) which the language server offers for a range of lines
+        via a [textDocument/codeAction](https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#textDocument_codeAction)
+        request. The diagnostics published for the lines are passed as context, such that quick fixes for them are included.
+        Only code actions which perform a workspace edit (which is resolved via `codeAction/resolve` if necessary) are returned;
+        commands, which would have to be executed by the language server, and disabled code actions are omitted.
+        Does not apply the code actions; to apply one, apply its workspace edit.
+
+        :param relative_file_path: the relative path of the file
+        :param start_line: the first 0-based line of the range
+        :param end_line: the last 0-based line of the range; -1 for the last line of the file
+        :return: the code actions, each with a workspace edit
+        """
+        with self.open_file(relative_file_path) as file_buffer:
+            # an empty file consists of a single empty line
+            lines = TextUtils.split_lines(file_buffer.contents) or [""]
+            if end_line == -1 or end_line >= len(lines):
+                end_line = len(lines) - 1
+            diagnostics = self.get_cached_published_text_document_diagnostics(relative_file_path, start_line, end_line) or []
+            params: lsp_types.CodeActionParams = {
+                "textDocument": {"uri": file_buffer.uri},
+                "range": {"start": {"line": start_line, "character": 0}, "end": {"line": end_line, "character": len(lines[end_line])}},
+                "context": {
+                    "diagnostics": cast(list[lsp_types.Diagnostic], diagnostics),
+                    "triggerKind": lsp_types.CodeActionTriggerKind.Invoked,
+                },
+            }
+            response = self.server.send.code_action(params)
+
+        code_actions: list[lsp_types.CodeAction] = []
+        for item in response or []:
+            if isinstance(item.get("command"), str) or "disabled" in item:
+                # a plain command (rather than a code action) or a code action which cannot currently be applied
+                continue
+            code_action = cast(lsp_types.CodeAction, item)
+            if "edit" not in code_action and "data" in code_action:
+                try:
+                    code_action = self.server.send.resolve_code_action(code_action)
+                except SolidLSPException as e:
+                    log.warning(f"Failed to resolve code action {code_action['title']!r}: {e}")
+                    continue
+            if "edit" in code_action:
+                code_actions.append(code_action)
+        return code_actions
+
     def apply_text_edits_to_file(self, relative_path: str, edits: list[ls_types.TextEdit]) -> None:
         """
-        Apply a list of text edits to a file.
+        Apply a list of text edits to a file (see :meth:`TextUtils.apply_text_edits`), notifying the language server
+        of the resulting change.
 
         :param relative_path: The relative path of the file to edit
-        :param edits: List of TextEdit dictionaries to apply
+        :param edits: List of TextEdit dictionaries to apply (whose ranges refer to the current contents of the file)
+        :raises OverlappingTextEditsError: if the ranges of the edits overlap (in which case the file is left unchanged)
         """
-        with self.open_file(relative_path):
-            # Sort edits by position (latest first) to avoid position shifts
-            sorted_edits = sorted(edits, key=lambda e: (e["range"]["start"]["line"], e["range"]["start"]["character"]), reverse=True)
-
-            for edit in sorted_edits:
-                start_pos = ls_types.Position(line=edit["range"]["start"]["line"], character=edit["range"]["start"]["character"])
-                end_pos = ls_types.Position(line=edit["range"]["end"]["line"], character=edit["range"]["end"]["character"])
+        if not self.server_started:
+            log.error("apply_text_edits_to_file called before Language Server started")
+            raise SolidLSPException("Language Server not started")
 
-                # Delete the old text and insert the new text
-                self.delete_text_between_positions(relative_path, start_pos, end_pos)
-                self.insert_text_at_position(relative_path, start_pos["line"], start_pos["character"], edit["newText"])
+        with self.open_file(relative_path) as file_buffer:
+            new_contents = TextUtils.apply_text_edits(file_buffer.contents, edits)
+            file_buffer.update_contents(new_contents)
 
     def start(self) -> "SolidLanguageServer":
         """
@@ -3166,6 +3672,15 @@ class SolidLanguageServer(ABC):
     def is_running(self) -> bool:
         return self.server.is_running()
 
+    def get_version_info(self) -> dict[str, str]:
+        """
+        Provides the versions of the language server and of the external tools it relies on (for informational purposes).
+        Subclasses should override this method if the versions can be determined.
+
+        :return: a mapping from component names (e.g. the name of the language server executable) to versions
+        """
+        return {}
+
     def _create_initialize_params_builder(self) -> InitializeParamsBuilder:
         return DefaultInitializeParamsBuilder(self)
 
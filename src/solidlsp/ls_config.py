@@ -19,12 +19,15 @@ log = logging.getLogger(__name__)
 
 
 class FilenameMatcher:
-    def __init__(self, *file_extensions: str, case_sensitive: bool = True) -> None:
+    def __init__(self, *file_extensions: str, case_sensitive: bool = True, excluded_filenames: Iterable[str] = ()) -> None:
         """
         :param file_extensions: file extensions, e.g., `.py, .yml`
         :param case_sensitive: whether the file extensions are case-sensitive.
+        :param excluded_filenames: names of files which are never matched, even if they have one of the extensions
+            (e.g. generated files)
         """
         self._file_extensions = list(set(file_extensions)) if case_sensitive else list(set(ext.lower() for ext in file_extensions))
+        self._excluded_filenames = frozenset(excluded_filenames if case_sensitive else (fn.lower() for fn in excluded_filenames))
         self._case_sensitive = case_sensitive
         # Snapshot of the initial configuration, used by ``reset``. Relevant for matchers that are
         # per-language singletons (``Language.get_source_fn_matcher`` is ``@cache``d): extensions added
@@ -69,6 +72,8 @@ class FilenameMatcher:
     def is_relevant_filename(self, fn: str) -> bool:
         if not self._case_sensitive:
             fn = fn.lower()
+        if os.path.basename(fn) in self._excluded_filenames:
+            return False
         for ext in self._file_extensions:
             if fn.endswith(ext):
                 return True
@@ -275,6 +280,12 @@ class LanguageServerId(str, Enum):
     relies on the same vscode-css-languageservice engine and is enabled at startup
     via the somesass.css.* feature toggles (which default to off upstream).
     """
+    TFLINT = "tflint"
+    """TFLint language server (experimental) using ``tflint --langserver``.
+    Provides lint diagnostics for Terraform files but no symbols; it complements terraform-ls and
+    is therefore to be configured alongside ``terraform`` in project.yml.
+    Requires ``tflint`` in PATH.
+    """
     ANGULAR = "angular"
     """Angular Language Server (experimental) using the official @angular/language-server
     (ngserver). Supports *.ts and *.html files (Angular templates can be external or inline).
@@ -321,6 +332,7 @@ class LanguageServerId(str, Enum):
             self.HTML,
             self.SCSS,
             self.ANGULAR,
+            self.TFLINT,
         }
 
     def is_programming_language(self) -> bool:
@@ -355,6 +367,12 @@ class LanguageServerId(str, Enum):
         """
         return self.get_ls_class().supports_implementation_request()
 
+    def provides_symbols(self) -> bool:
+        """
+        Return whether the language server provides symbols (see ``SolidLanguageServer.provides_symbols``).
+        """
+        return self.get_ls_class().provides_symbols()
+
     # NOTE: Caching results in a singleton per enum item, which is a precondition for persistent configuration of the matcher.
     @cache
     def get_source_fn_matcher(self) -> FilenameMatcher:
@@ -468,7 +486,8 @@ class LanguageServerId(str, Enum):
             case self.ELM:
                 return FilenameMatcher(".elm")
             case self.TERRAFORM:
-                return FilenameMatcher(".tf", ".tfvars", ".tfstate")
+                # the dependency lock file is excluded, as it would be matched by the (default) additional suffix `.hcl`
+                return FilenameMatcher(".tf", ".tfvars", ".tfstate", excluded_filenames=(".terraform.lock.hcl",))
             case self.SWIFT:
                 return FilenameMatcher(".swift")
             case self.BASH:
@@ -579,6 +598,8 @@ class LanguageServerId(str, Enum):
                 # Microsoft's CSS LS, so we route plain CSS through Some Sass too. The CSS feature
                 # toggles default off upstream and are flipped on at initialization time.
                 return FilenameMatcher(".scss", ".sass", ".css")
+            case self.TFLINT:
+                return FilenameMatcher(".tf")
             case self.ANGULAR:
                 # Angular templates can be standalone .html files or inline templates
                 # within .ts component files; the dual-server architecture handles both.
@@ -867,6 +888,10 @@ class LanguageServerId(str, Enum):
                 from solidlsp.language_servers.some_sass_language_server import SomeSassLanguageServer
 
                 return SomeSassLanguageServer
+            case self.TFLINT:
+                from solidlsp.language_servers.tflint_ls import TFLintLanguageServer
+
+                return TFLintLanguageServer
             case self.ANGULAR:
                 from solidlsp.language_servers.angular_language_server import AngularLanguageServer
 
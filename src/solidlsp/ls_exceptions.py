@@ -52,6 +52,18 @@ class SolidLSPException(Exception):
         return s
 
 
+class LanguageServerRequestTimeoutError(SolidLSPException, TimeoutError):
+    """
+    Raised when the language server does not respond to a request within the configured request timeout.
+    The request is cancelled (via `$/cancelRequest`) before this exception is raised.
+    """
+
+    def __init__(self, method: str, timeout: float) -> None:
+        self.method = method
+        self.timeout = timeout
+        super().__init__(f"Language server did not respond to request {method} within {timeout} seconds")
+
+
 class InvalidTextLocationError(SolidLSPException):
     """
     Raised when a symbol's LSP range refers to a text location that does not exist
@@ -61,6 +73,13 @@ class InvalidTextLocationError(SolidLSPException):
     """
 
 
+class OverlappingTextEditsError(SolidLSPException):
+    """
+    Raised when the text edits to be applied to a file (e.g. the edits of a workspace edit) have overlapping ranges,
+    such that the result of applying them would be ambiguous.
+    """
+
+
 class MetalsStaleLockError(SolidLSPException):
     """
     Raised when a stale Metals H2 database lock is detected and the user
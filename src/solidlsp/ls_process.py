@@ -15,7 +15,7 @@ from typing import IO, Any, AnyStr, cast
 from sensai.util.string import ToStringMixin
 
 from solidlsp.ls_config import LanguageServerId
-from solidlsp.ls_exceptions import SolidLSPException
+from solidlsp.ls_exceptions import LanguageServerRequestTimeoutError, SolidLSPException
 from solidlsp.ls_request import LanguageServerRequest
 from solidlsp.lsp_protocol_handler.lsp_requests import LspNotification
 from solidlsp.lsp_protocol_handler.lsp_types import ErrorCodes, LSPErrorCodes
@@ -345,11 +345,37 @@ class LanguageServerInterface(ABC):
 
         self._send_payload(make_request(method, request_id, params))
 
+        # if the server terminated before the request could be registered, the pending requests were already cancelled,
+        # and we would otherwise wait for a response in vain
+        if not self.is_running():
+            with self._response_handlers_lock:
+                is_pending = self._pending_requests.pop(request_id, None) is not None
+            if is_pending:
+                request.on_error(LanguageServerTerminatedException(f"Language server is not running; cannot process {method}", self.ls_id))
+
         log.debug("Waiting for response to request %s with params:\n%s", method, params)
-        result = request.get_result(timeout=self._request_timeout)
+        try:
+            result = request.get_result(timeout=self._request_timeout)
+        except TimeoutError as e:
+            assert self._request_timeout is not None
+            self._cancel_timed_out_request(request_id)
+            raise LanguageServerRequestTimeoutError(method, self._request_timeout) from e
         log.debug("Completed: %s", request)
         return result
 
+    def _cancel_timed_out_request(self, request_id: int) -> None:
+        """
+        Removes a request to which no response was received in time from the pending requests (such that a late
+        response is discarded) and asks the server to cancel its processing.
+        """
+        with self._response_handlers_lock:
+            request = self._pending_requests.pop(request_id, None)
+        log.warning("Request timed out after %s seconds, cancelling: %s", self._request_timeout, request)
+        try:
+            self.send_notification("$/cancelRequest", {"id": request_id})
+        except Exception as e:
+            log.warning("Failed to send cancellation for request %d: %s", request_id, e)
+
     def send_request(self, method: str, params: dict | None = None) -> PayloadLike:
         """
         Send request to the server, register the request id, and wait for the response.
@@ -841,3 +867,34 @@ class TCPLanguageServer(LanguageServerInterface):
             # to detect the broken connection and restart the language server.
             self._sock = None
             self._file = None
+
+
+class UnavailableLanguageServer(LanguageServerInterface):
+    """
+    Stands in for a language server which is unavailable (e.g. because it could not be installed), such that functionality
+    which does not depend on the language server (e.g. symbols determined by a native parser) remains usable.
+    Notifications are discarded, and requests fail with an error stating why the language server is unavailable.
+    """
+
+    def __init__(self, ls_id: LanguageServerId, reason: str) -> None:
+        """
+        :param ls_id: the language server identifier
+        :param reason: the reason why the language server is unavailable
+        """
+        super().__init__(ls_id, lambda _line: logging.INFO)
+        self.reason = reason
+        self._running = False
+
+    def is_running(self) -> bool:
+        return self._running
+
+    def _start(self) -> None:
+        self._running = True
+
+    def _stop(self, timeout: float) -> None:
+        self._running = False
+
+    def _send_payload(self, payload: StringDict) -> None:
+        if "method" in payload and "id" in payload:
+            message = f"{payload['method']} is not supported, since the language server is unavailable ({self.reason})"
+            self._response_handler(make_error_response(payload["id"], LSPError(ErrorCodes.MethodNotFound, message)))
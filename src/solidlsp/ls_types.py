@@ -235,6 +235,14 @@ class UnifiedSymbolInformation(TypedDict):
     Added for Serena, not part of the LSP.
     """
 
+    tf_kind: NotRequired[str]
+    """
+    The Terraform-specific kind of the symbol, if it represents a Terraform block or local value: one of
+    "resource", "data", "ephemeral", "module", "variable", "output", "provider", "terraform", "locals" (the block)
+    or "local" (an individual value).
+    Added by the Terraform language server, not part of the LSP.
+    """
+
 
 class MarkupKind(Enum):
     """Describes the content type that a client supports in various
@@ -333,7 +341,7 @@ class WorkspaceEdit(TypedDict):
     changes: NotRequired[dict[DocumentUri, list[TextEdit]]]
     """ Holds changes to existing resources. """
     documentChanges: NotRequired[list]
-    """ Document changes array for versioned edits. """
+    """ Document changes array for versioned edits and file operations (create, rename, delete); preferred over `changes`. """
 
 
 class Diagnostic(TypedDict):
@@ -2,11 +2,13 @@
 This file contains various utility functions like I/O operations, handling paths, etc.
 """
 
+import codecs
 import gzip
 import hashlib
 import logging
 import os
 import platform
+import re
 import shutil
 import subprocess
 import tarfile
@@ -21,8 +23,8 @@ from urllib.parse import urlparse
 import charset_normalizer
 import requests
 
-from solidlsp.ls_exceptions import InvalidTextLocationError, SolidLSPException
-from solidlsp.ls_types import UnifiedSymbolInformation
+from solidlsp.ls_exceptions import InvalidTextLocationError, OverlappingTextEditsError, SolidLSPException
+from solidlsp.ls_types import Position, TextEdit, UnifiedSymbolInformation
 
 log = logging.getLogger(__name__)
 
@@ -316,6 +318,73 @@ class TextUtils:
         end_idx = TextUtils.get_index_from_line_col(text, end_line, end_col)
         return text[start_idx:end_idx]
 
+    COMMENT_LINE_PREFIXES = ("#", "//", "/*")
+    """
+    prefixes of (stripped) lines which are considered to be comment lines (single-line comments and first lines of block comments);
+    lines starting with `*` are considered to be comment lines only if they continue a block comment (see `_find_block_comment_start_line`)
+    """
+
+    @classmethod
+    def find_leading_comment_start_line(cls, lines: list[str], line: int) -> int:
+        """
+        :param lines: the lines of a text
+        :param line: the 0-based index of a line
+        :return: the 0-based index of the first line of the block of comment lines immediately preceding the given line
+            (without empty lines in between), or `line` if there is no such block
+        """
+        start_line = line
+        while start_line > 0:
+            stripped_line = lines[start_line - 1].lstrip()
+            if stripped_line.startswith(cls.COMMENT_LINE_PREFIXES):
+                start_line -= 1
+            elif stripped_line.startswith("*"):
+                block_comment_start_line = cls._find_block_comment_start_line(lines, start_line - 1)
+                if block_comment_start_line is None:
+                    break
+                start_line = block_comment_start_line
+            else:
+                break
+        return start_line
+
+    @staticmethod
+    def _find_block_comment_start_line(lines: list[str], line: int) -> int | None:
+        """
+        :param lines: the lines of a text
+        :param line: the 0-based index of a line starting with `*`
+        :return: the 0-based index of the line starting the block comment (with `/*`) which the given line continues
+            (e.g. ` * @param x` or ` */`), or None if the line is not part of a block comment (e.g. a Markdown list item
+            or the continuation of a multiplication)
+        """
+        while line >= 0:
+            stripped_line = lines[line].lstrip()
+            if stripped_line.startswith("/*"):
+                return line
+            if not stripped_line.startswith("*"):
+                return None
+            line -= 1
+        return None
+
+    @classmethod
+    def find_leading_comment_start_position(cls, lines: list[str], line: int, col: int) -> tuple[int, int]:
+        """
+        Determines the start position of the block of comment lines (e.g. a doc comment) immediately preceding
+        the entity (e.g. a symbol definition) starting at the given position.
+
+        :param lines: the lines of a text
+        :param line: the 0-based line of the entity's start position
+        :param col: the 0-based column of the entity's start position
+        :return: the 0-based (line, column) position at which the comment block starts (at the same column as the entity,
+            unless the comment is indented less), or the given position if there is no comment block or the entity
+            is preceded by other content in its line
+        """
+        if lines[line][:col].strip():
+            return line, col
+        start_line = cls.find_leading_comment_start_line(lines, line)
+        if start_line == line:
+            return line, col
+        first_line = lines[start_line]
+        return start_line, min(col, len(first_line) - len(first_line.lstrip()))
+
     @classmethod
     def get_text_in_lines_range(cls, text: str, start_line: int, end_line: int) -> str:
         """
@@ -324,6 +393,61 @@ class TextUtils:
         lines = cls.split_lines(text, with_ends=True)
         return "".join(lines[start_line : end_line + 1])
 
+    @staticmethod
+    def apply_text_edits(text: str, edits: list[TextEdit]) -> str:
+        """
+        Applies the given text edits (e.g. the edits of a workspace edit), whose ranges all refer to the original text,
+        as specified by the LSP: the edits must not overlap, and multiple insertions at the same position appear
+        in the order of the edits. Columns beyond the end of a line refer to the end of the line, and the position
+        one line past the last line (at column 0) refers to the end of the text; as in :meth:`insert_text_at_position`,
+        a missing trailing newline is added when inserting at the latter position.
+
+        :param text: the original text
+        :param edits: the edits to apply
+        :return: the modified text
+        """
+        lines = TextUtils.split_lines(text, with_ends=True)
+        line_start_indices = [0]
+        for line in lines:
+            line_start_indices.append(line_start_indices[-1] + len(line))
+
+        def get_index(position: Position) -> int:
+            line, col = position["line"], position["character"]
+            if line < len(lines):
+                line_length = len(lines[line].rstrip("\r\n"))
+                return line_start_indices[line] + min(col, line_length)
+            if line == len(lines) and col == 0:
+                return len(text)
+            raise InvalidTextLocationError(f"{line=}, {col=}")
+
+        # determine the index ranges, sorting by position (retaining the order of the edits for equal positions)
+        index_edits: list[tuple[int, int, str, bool]] = []
+        for edit in edits:
+            start, end = edit["range"]["start"], edit["range"]["end"]
+            start_idx, end_idx = get_index(start), get_index(end)
+            if end_idx < start_idx:
+                raise InvalidTextLocationError(f"The end of range {edit['range']} precedes its start")
+            # insertions in the line after the last line of a text without trailing newline require the newline to be added
+            requires_trailing_newline = start["line"] == len(lines) and lines[-1] != "" and edit["newText"] != ""
+            index_edits.append((start_idx, end_idx, edit["newText"], requires_trailing_newline))
+        index_edits.sort(key=lambda e: (e[0], e[1]))
+
+        # build the modified text, checking for overlaps
+        parts = []
+        idx = 0
+        trailing_newline_added = False
+        for start_idx, end_idx, new_text, requires_trailing_newline in index_edits:
+            if start_idx < idx:
+                raise OverlappingTextEditsError(f"Text edit for range {start_idx}-{end_idx} overlaps with a preceding edit")
+            parts.append(text[idx:start_idx])
+            idx = end_idx
+            if requires_trailing_newline and not trailing_newline_added:
+                parts.append("\n")
+                trailing_newline_added = True
+            parts.append(new_text)
+        parts.append(text[idx:])
+        return "".join(parts)
+
     @staticmethod
     def split_lines(text: str, with_ends: bool = False) -> list[str]:
         """
@@ -382,6 +506,11 @@ class FileUtils:
     Utility functions for file operations.
     """
 
+    UTF8_BOM = "\ufeff"
+    """
+    the byte order mark as it appears in text decoded as UTF-8 (written by some editors, notably on Windows)
+    """
+
     @staticmethod
     def read_file(file_path: str, encoding: str) -> str:
         """
@@ -389,17 +518,27 @@ class FileUtils:
         If decoding fails, tries to detect the encoding using charset_normalizer.
 
         Line endings are normalized to LF (universal newlines), irrespective of the encoding
-        used to decode the file.
+        used to decode the file. A leading UTF-8 byte order mark is removed (see :meth:`write_file` for its restoration).
 
         Raises FileNotFoundError if the file does not exist.
         """
+        return FileUtils.read_file_with_encoding(file_path, encoding)[0]
+
+    @classmethod
+    def read_file_with_encoding(cls, file_path: str, encoding: str) -> tuple[str, str]:
+        """
+        Like :meth:`read_file`, but additionally returns the encoding that was actually used to decode the file,
+        which differs from the given encoding if the file could not be decoded with it.
+
+        :return: a tuple (contents, encoding)
+        """
         if not os.path.exists(file_path):
             log.error(f"Failed to read '{file_path}': File does not exist.")
             raise FileNotFoundError(f"File read '{file_path}' failed: File does not exist.")
         try:
             try:
                 with open(file_path, encoding=encoding) as inp_file:
-                    return inp_file.read()
+                    return inp_file.read().removeprefix(cls.UTF8_BOM), encoding
             except UnicodeDecodeError as ude:
                 results = charset_normalizer.from_path(file_path)
                 match = results.best()
@@ -409,13 +548,96 @@ class FileUtils:
                     )
                     # Decoding the raw bytes bypasses the universal-newline translation that the
                     # open() call above applies, so normalize explicitly to keep both paths equivalent.
-                    decoded = match.raw.decode(match.encoding)
-                    return decoded.replace("\r\n", "\n").replace("\r", "\n")
+                    decoded = match.raw.decode(match.encoding).removeprefix(cls.UTF8_BOM)
+                    return decoded.replace("\r\n", "\n").replace("\r", "\n"), match.encoding
                 raise ude
         except Exception as exc:
             log.error(f"Failed to read '{file_path}' with encoding '{encoding}': {exc}")
             raise exc
 
+    @staticmethod
+    def has_utf8_bom(file_path: str) -> bool:
+        """
+        :param file_path: the path of the file
+        :return: whether the file exists and starts with a UTF-8 byte order mark
+        """
+        try:
+            with open(file_path, "rb") as f:
+                return f.read(len(codecs.BOM_UTF8)) == codecs.BOM_UTF8
+        except OSError:
+            return False
+
+    @staticmethod
+    def detect_encoding(file_path: str, encoding: str) -> str:
+        """
+        Determines the encoding with which :meth:`read_file` decodes the given file.
+
+        :param file_path: the path of the file
+        :param encoding: the expected encoding of the file
+        :return: the given encoding if the file does not exist or can be decoded with it; otherwise the encoding detected
+            by charset_normalizer (or the given encoding if none could be detected)
+        """
+        try:
+            with open(file_path, "rb") as f:
+                raw_contents = f.read()
+        except OSError:
+            return encoding
+        try:
+            raw_contents.decode(encoding)
+            return encoding
+        except UnicodeDecodeError:
+            match = charset_normalizer.from_bytes(raw_contents).best()
+            return match.encoding if match else encoding
+
+    @staticmethod
+    def detect_line_ending(file_path: str, encoding: str) -> str | None:
+        """
+        :param file_path: the path of the file
+        :param encoding: the encoding of the file
+        :return: the line ending of the file's first line break ("\n", "\r\n" or "\r") or None if the file does not exist
+            or contains no line break
+        """
+        try:
+            with open(file_path, encoding=encoding, errors="replace", newline="") as f:
+                contents = f.read()
+        except OSError:
+            return None
+        match = re.search(r"\r\n|\n|\r", contents)
+        return match.group(0) if match is not None else None
+
+    @classmethod
+    def write_file(cls, file_path: str, contents: str, encoding: str, newline: str | None = None) -> None:
+        """
+        Writes the given contents to the file at the given path.
+        If the file already exists and starts with a UTF-8 byte order mark, the byte order mark is retained,
+        such that the removal in :meth:`read_file` is transparent. Likewise, an existing file retains its line ending
+        (which :meth:`read_file` normalises to LF) and, if it cannot be decoded with the given encoding, the encoding
+        detected when reading it (see :meth:`detect_encoding`).
+
+        :param file_path: the path of the file
+        :param contents: the contents to write (a leading byte order mark is ignored)
+        :param encoding: the encoding with which to write the file
+        :param newline: the line ending to use (as in :func:`open`) if the file does not exist yet or contains no line break
+        """
+        contents = contents.removeprefix(cls.UTF8_BOM)
+        if (existing_encoding := cls.detect_encoding(file_path, encoding)) != encoding:
+            log.info(f"Writing {file_path} with its detected encoding '{existing_encoding}' instead of '{encoding}'")
+            encoding = existing_encoding
+        try:
+            contents.encode(encoding)
+        except UnicodeEncodeError as e:
+            # (checked in advance, because the file would otherwise be truncated)
+            raise ValueError(f"Cannot write {file_path}, because the contents cannot be encoded with encoding '{encoding}': {e}") from e
+        if (existing_newline := cls.detect_line_ending(file_path, encoding)) is not None:
+            newline = existing_newline
+        # CRLF line breaks in the given contents would otherwise be translated to CR + newline
+        contents = contents.replace("\r\n", "\n")
+        # (with the utf-8-sig codec, the byte order mark is written implicitly)
+        if cls.has_utf8_bom(file_path) and codecs.lookup(encoding).name == "utf-8":
+            contents = cls.UTF8_BOM + contents
+        with open(file_path, "w", encoding=encoding, newline=newline) as f:
+            f.write(contents)
+
     @staticmethod
     def download_file(url: str, target_path: str) -> None:
         """
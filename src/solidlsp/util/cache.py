@@ -1,4 +1,6 @@
 import logging
+import os
+from dataclasses import dataclass
 from typing import Any, Optional
 
 from sensai.util.pickle import dump_pickle, load_pickle
@@ -21,3 +23,59 @@ def load_cache(path: str, version: Any) -> Optional[Any]:
 def save_cache(path: str, version: Any, obj: Any) -> None:
     data = {"__cache_version": version, "obj": obj}
     dump_pickle(data, path)
+
+
+@dataclass(kw_only=True)
+class SymbolCacheStats:
+    """
+    Statistics on the document symbol caches of a language server
+    """
+
+    num_entries: int
+    """
+    the number of files for which (high-level) document symbols are cached
+    """
+    num_raw_entries: int
+    """
+    the number of files for which the raw document symbols (as returned by the language server) are cached
+    """
+    size_on_disk: int
+    """
+    the total size of the persisted cache files in bytes
+    """
+    num_hits: int = 0
+    """
+    the number of document symbol requests which were answered from the cache (since the language server was started)
+    """
+    num_misses: int = 0
+    """
+    the number of document symbol requests which could not be answered from the cache (since the language server was started)
+    """
+
+    def get_hit_ratio(self) -> float | None:
+        """
+        :return: the fraction of document symbol requests which were answered from the cache or None if there were no requests
+        """
+        num_requests = self.num_hits + self.num_misses
+        if num_requests == 0:
+            return None
+        return self.num_hits / num_requests
+
+    def to_string(self) -> str:
+        result = f"{self.num_entries} entries ({self.num_raw_entries} raw), {self.size_on_disk / (1024 * 1024):.1f} MB on disk"
+        hit_ratio = self.get_hit_ratio()
+        if hit_ratio is not None:
+            result += f", {self.num_hits} hits/{self.num_misses} misses (hit ratio {hit_ratio:.0%})"
+        return result
+
+
+def get_cache_num_entries(path: str) -> int:
+    """
+    :param path: the path of a cache file saved via `save_cache`
+    :return: the number of entries in the cached mapping (0 if the file does not exist or does not contain a mapping)
+    """
+    if not os.path.exists(path):
+        return 0
+    data = load_pickle(path)
+    obj = data.get("obj") if isinstance(data, dict) else None
+    return len(obj) if isinstance(obj, dict) else 0
@@ -0,0 +1,364 @@
+"""
+A lightweight parser for HCL files (e.g. Packer, Nomad or Waypoint configurations), which determines the document symbols
+(blocks and attributes) of files that are not supported by terraform-ls, as well as the attributes of individual blocks
+(for attribute-level editing)
+"""
+
+import bisect
+from dataclasses import dataclass
+from enum import Enum
+
+from solidlsp.lsp_protocol_handler.lsp_types import DocumentSymbol, Position, Range, SymbolKind
+
+
+class _TokenType(Enum):
+    IDENTIFIER = "identifier"
+    STRING = "string"
+    NUMBER = "number"
+    HEREDOC = "heredoc"
+    PUNCTUATION = "punctuation"
+    NEWLINE = "newline"
+
+
+@dataclass
+class _Token:
+    type: _TokenType
+    text: str
+    start: int
+    end: int
+    """the offset after the token's last character"""
+
+
+@dataclass
+class HclAttribute:
+    name: str
+    start: int
+    """the offset of the attribute's name"""
+    value_start: int
+    """the offset of the first character of the attribute's value (expression)"""
+    value_end: int
+    """the offset after the last character of the attribute's value"""
+
+
+@dataclass
+class HclBlockBody:
+    attributes: list[HclAttribute]
+    """the attributes which are directly contained in the block's body (i.e. not the ones of nested blocks)"""
+    opening_brace_offset: int
+    closing_brace_offset: int | None
+    """the offset of the closing brace, which is None if the block is not terminated"""
+
+
+_OPENING_BRACKETS = "{[("
+_CLOSING_BRACKETS = "}])"
+
+
+class _Tokenizer:
+    """
+    Splits HCL content into tokens, skipping comments and whitespace (except for newlines, which terminate attributes).
+    Template interpolations within strings and heredocs are part of the respective token.
+    """
+
+    def __init__(self, content: str):
+        self._content = content
+        self._pos = 0
+
+    def tokenize(self) -> list[_Token]:
+        tokens = []
+        content = self._content
+        while self._pos < len(content):
+            c = content[self._pos]
+            start = self._pos
+            if c == "\n":
+                self._pos += 1
+                tokens.append(_Token(_TokenType.NEWLINE, c, start, self._pos))
+            elif c.isspace():
+                self._pos += 1
+            elif c == "#" or content.startswith("//", self._pos):
+                self._skip_to_end_of_line()
+            elif content.startswith("/*", self._pos):
+                end = content.find("*/", self._pos + 2)
+                self._pos = len(content) if end == -1 else end + 2
+            elif c == '"':
+                self._skip_string()
+                tokens.append(_Token(_TokenType.STRING, content[start : self._pos], start, self._pos))
+            elif content.startswith("<<", self._pos) and self._skip_heredoc():
+                tokens.append(_Token(_TokenType.HEREDOC, content[start : self._pos], start, self._pos))
+            elif c.isalpha() or c == "_":
+                self._pos += 1
+                while self._pos < len(content) and (content[self._pos].isalnum() or content[self._pos] in "_-"):
+                    self._pos += 1
+                tokens.append(_Token(_TokenType.IDENTIFIER, content[start : self._pos], start, self._pos))
+            elif c.isdigit():
+                self._pos += 1
+                while self._pos < len(content) and (content[self._pos].isalnum() or content[self._pos] in "._"):
+                    self._pos += 1
+                tokens.append(_Token(_TokenType.NUMBER, content[start : self._pos], start, self._pos))
+            else:
+                # two-character operators are kept together, such that e.g. `==` is not mistaken for an assignment
+                length = 2 if content[self._pos : self._pos + 2] in ("==", "!=", "<=", ">=", "=>", "&&", "||") else 1
+                self._pos += length
+                tokens.append(_Token(_TokenType.PUNCTUATION, content[start : self._pos], start, self._pos))
+        return tokens
+
+    def _skip_to_end_of_line(self) -> None:
+        end = self._content.find("\n", self._pos)
+        self._pos = len(self._content) if end == -1 else end
+
+    def _skip_string(self) -> None:
+        """
+        Skips a quoted string (starting at the opening quote), including template interpolations.
+        An unterminated string ends at the end of the line.
+        """
+        content = self._content
+        self._pos += 1
+        while self._pos < len(content):
+            c = content[self._pos]
+            if c == "\\":
+                self._pos += 2
+            elif c == '"':
+                self._pos += 1
+                return
+            elif c == "\n":
+                return
+            elif content[self._pos : self._pos + 2] in ("${", "%{"):
+                self._pos += 2
+                self._skip_template()
+            else:
+                self._pos += 1
+
+    def _skip_template(self) -> None:
+        """
+        Skips the remainder of a template interpolation or directive (after the opening `${` or `%{`).
+        """
+        content = self._content
+        depth = 1
+        while self._pos < len(content) and depth > 0:
+            c = content[self._pos]
+            if c == '"':
+                self._skip_string()
+                continue
+            if c == "{":
+                depth += 1
+            elif c == "}":
+                depth -= 1
+            self._pos += 1
+
+    def _skip_heredoc(self) -> bool:
+        """
+        Skips a heredoc (starting at `<<`) if there is one at the current position.
+
+        :return: whether a heredoc was skipped
+        """
+        content = self._content
+        pos = self._pos + 2
+        if pos < len(content) and content[pos] == "-":
+            pos += 1
+        marker_start = pos
+        while pos < len(content) and (content[pos].isalnum() or content[pos] == "_"):
+            pos += 1
+        marker = content[marker_start:pos]
+        if marker and content.startswith("\r\n", pos):
+            pos += 1
+        if not marker or not content.startswith("\n", pos):
+            return False
+        while pos < len(content):
+            line_start = pos + 1
+            line_end = content.find("\n", line_start)
+            if line_end == -1:
+                line_end = len(content)
+            pos = line_end
+            if content[line_start:line_end].strip() == marker:
+                break
+        # the carriage return of a CRLF line ending is not part of the heredoc
+        self._pos = pos - 1 if content.endswith("\r", 0, pos) else pos
+        return True
+
+
+class _Parser:
+    """
+    Determines the document symbols from the tokens of an HCL file.
+    Blocks (e.g. `source "amazon-ebs" "ubuntu" { ... }`) are represented as classes (whose names include the labels,
+    as in the symbols provided by terraform-ls) and attributes (e.g. `region = "eu-west-1"`) as symbols whose kind
+    reflects the type of the assigned value.
+    """
+
+    def __init__(self, content: str, tokens: list[_Token]):
+        self._tokens = tokens
+        self._index = 0
+        self._line_offsets = [0] + [i + 1 for i, c in enumerate(content) if c == "\n"]
+
+    def parse(self) -> list[DocumentSymbol]:
+        symbols = self._parse_body()
+        # continue after stray closing braces at the top level
+        while self._peek() is not None:
+            self._index += 1
+            symbols.extend(self._parse_body())
+        return symbols
+
+    def parse_block_body(self) -> HclBlockBody | None:
+        """
+        Parses a single block, determining the attributes of its body (nested blocks are skipped)
+
+        :return: the block body or None if the content does not start with a block
+        """
+        # skip the block type and labels
+        while (token := self._peek()) is not None and token.type in (_TokenType.IDENTIFIER, _TokenType.STRING, _TokenType.NEWLINE):
+            self._index += 1
+        if token is None or token.text != "{":
+            return None
+        opening_brace_offset = token.start
+        self._index += 1
+        attributes = []
+        while (token := self._peek()) is not None and token.text != "}":
+            next_token = self._tokens[self._index + 1] if self._index + 1 < len(self._tokens) else None
+            if token.type == _TokenType.IDENTIFIER and next_token is not None and next_token.text == "=":
+                self._index += 2
+                value_token = self._peek()
+                value_end = self._skip_expression()
+                if value_token is not None and value_end is not None:
+                    attributes.append(HclAttribute(token.text, token.start, value_token.start, value_end))
+            elif token.type == _TokenType.NEWLINE:
+                self._index += 1
+            elif token.type == _TokenType.IDENTIFIER:
+                # nested block
+                self._parse_attribute_or_block()
+            else:
+                self._skip_expression()
+        closing_brace_offset = token.start if token is not None else None
+        return HclBlockBody(attributes, opening_brace_offset, closing_brace_offset)
+
+    def _peek(self) -> _Token | None:
+        return self._tokens[self._index] if self._index < len(self._tokens) else None
+
+    def _position(self, offset: int) -> Position:
+        line = bisect.bisect_right(self._line_offsets, offset) - 1
+        return Position(line=line, character=offset - self._line_offsets[line])
+
+    def _range(self, start: int, end: int) -> Range:
+        return Range(start=self._position(start), end=self._position(end))
+
+    def _parse_body(self) -> list[DocumentSymbol]:
+        """
+        Parses the attributes and blocks of a body up to the closing brace of the enclosing block (which is not consumed)
+        or the end of the file.
+        """
+        symbols = []
+        while (token := self._peek()) is not None:
+            if token.type == _TokenType.NEWLINE:
+                self._index += 1
+            elif token.text == "}":
+                break
+            elif token.type == _TokenType.IDENTIFIER:
+                symbol = self._parse_attribute_or_block()
+                if symbol is not None:
+                    symbols.append(symbol)
+            else:
+                # unexpected token: skip it (along with a bracketed expression it may open)
+                self._skip_expression()
+        return symbols
+
+    def _parse_attribute_or_block(self) -> DocumentSymbol | None:
+        name_token = self._tokens[self._index]
+        self._index += 1
+        token = self._peek()
+        if token is not None and token.text == "=":
+            self._index += 1
+            value_token = self._peek()
+            end = self._skip_expression()
+            if value_token is None or end is None:
+                end = token.end
+            return DocumentSymbol(
+                name=name_token.text,
+                kind=self._get_attribute_kind(value_token),
+                range=self._range(name_token.start, end),
+                selectionRange=self._range(name_token.start, name_token.end),
+            )
+
+        # block: the type is followed by labels (strings or identifiers) and the body
+        labels = []
+        while token is not None and token.type in (_TokenType.STRING, _TokenType.IDENTIFIER):
+            labels.append(token)
+            self._index += 1
+            token = self._peek()
+        if token is None or token.text != "{":
+            # neither an attribute nor a block; skip the remainder of the line
+            self._skip_expression()
+            return None
+        self._index += 1
+        children = self._parse_body()
+        closing_token = self._peek()
+        if closing_token is not None:
+            self._index += 1
+            end = closing_token.end
+        else:
+            end = self._tokens[-1].end
+        selection_end = labels[-1].end if labels else name_token.end
+        return DocumentSymbol(
+            name=" ".join([name_token.text, *(label.text for label in labels)]),
+            kind=SymbolKind.Class,
+            range=self._range(name_token.start, end),
+            selectionRange=self._range(name_token.start, selection_end),
+            children=children,
+        )
+
+    def _skip_expression(self) -> int | None:
+        """
+        Skips tokens up to the end of the current expression, i.e. up to a newline or a closing brace outside of brackets
+        (neither of which is consumed).
+
+        :return: the end offset of the last skipped token (if any)
+        """
+        depth = 0
+        end = None
+        while (token := self._peek()) is not None:
+            if depth == 0 and (token.type == _TokenType.NEWLINE or token.text == "}"):
+                break
+            if token.text in _OPENING_BRACKETS:
+                depth += 1
+            elif token.text in _CLOSING_BRACKETS:
+                # note: stray closing brackets (at depth 0) are skipped
+                depth = max(depth - 1, 0)
+            end = token.end
+            self._index += 1
+        return end
+
+    @staticmethod
+    def _get_attribute_kind(value_token: _Token | None) -> SymbolKind:
+        if value_token is None:
+            return SymbolKind.Variable
+        if value_token.type in (_TokenType.STRING, _TokenType.HEREDOC):
+            return SymbolKind.String
+        if value_token.type == _TokenType.NUMBER:
+            return SymbolKind.Number
+        if value_token.text in ("true", "false"):
+            return SymbolKind.Boolean
+        if value_token.text == "[":
+            return SymbolKind.Array
+        if value_token.text == "{":
+            return SymbolKind.Object
+        return SymbolKind.Variable
+
+
+def parse_hcl_document_symbols(content: str) -> list[DocumentSymbol]:
+    """
+    Determines the document symbols of an HCL file, i.e. its blocks (with nested blocks and attributes as children)
+    and top-level attributes. Syntax errors are tolerated: unparseable parts of the file are skipped.
+
+    :param content: the content of the HCL file
+    :return: the root symbols
+    """
+    tokens = _Tokenizer(content).tokenize()
+    return _Parser(content, tokens).parse()
+
+
+def parse_hcl_block_body(content: str) -> HclBlockBody | None:
+    """
+    Determines the attributes of a single block (e.g. `resource "aws_instance" "web" { ... }`), including the locations of
+    their values, such that individual attributes can be read or replaced without affecting the remainder of the block.
+
+    :param content: the content of the block (starting with the block type)
+    :return: the block body or None if the content does not start with a block
+    """
+    tokens = _Tokenizer(content).tokenize()
+    return _Parser(content, tokens).parse_block_body()
@@ -0,0 +1,76 @@
+import copy
+import logging
+import threading
+import time
+from collections.abc import Callable, Hashable
+from typing import Any, TypeVar
+
+log = logging.getLogger(__name__)
+
+T = TypeVar("T")
+
+
+class PositionRequestCache:
+    """
+    A short-lived, in-memory cache for the results of position-based requests (e.g. hover, definition, references).
+
+    Entries are keyed by (uri, document version, method, line, column), where the document version is
+    the content hash of the file buffer (the LSP version number is reset whenever a file is reopened).
+    Since results may depend on the contents of other documents (e.g. references), entries expire after a
+    short time, and the cache is to be cleared whenever a document is changed via the language server.
+    """
+
+    def __init__(self, ttl: float, max_entries: int = 1000, clock: Callable[[], float] = time.monotonic) -> None:
+        """
+        :param ttl: the time (in seconds) after which entries expire; if non-positive, caching is disabled
+        :param max_entries: the maximum number of entries; the oldest entries are evicted first
+        :param clock: the function returning the current time (in seconds)
+        """
+        self.ttl = ttl
+        self.max_entries = max_entries
+        self._clock = clock
+        self._entries: dict[Hashable, tuple[float, Any]] = {}
+        """maps keys to tuples (expiry time, result)"""
+        self._lock = threading.Lock()
+
+    @property
+    def is_enabled(self) -> bool:
+        return self.ttl > 0
+
+    @staticmethod
+    def create_key(uri: str, document_version: str, method: str, line: int, column: int) -> Hashable:
+        return uri, document_version, method, line, column
+
+    def get_or_compute(self, key: Hashable, compute: Callable[[], T]) -> T:
+        """
+        :param key: the key, as created via `create_key`
+        :param compute: the function computing the result (i.e. sending the request) in case of a cache miss
+        :return: (a copy of) the cached result or the newly computed result
+        """
+        if not self.is_enabled:
+            return compute()
+        now = self._clock()
+        with self._lock:
+            entry = self._entries.get(key)
+            if entry is not None and entry[0] > now:
+                log.debug("perf: position_request_cache HIT key=%s", key)
+                return copy.deepcopy(entry[1])
+        log.debug("perf: position_request_cache MISS key=%s", key)
+        result = compute()
+        with self._lock:
+            self._entries.pop(key, None)
+            self._entries[key] = (self._clock() + self.ttl, copy.deepcopy(result))
+            self._evict(now)
+        return result
+
+    def _evict(self, now: float) -> None:
+        if len(self._entries) <= self.max_entries:
+            return
+        self._entries = {k: v for k, v in self._entries.items() if v[0] > now}
+        while len(self._entries) > self.max_entries:
+            # entries are ordered by insertion time, so the first entry is the oldest
+            del self._entries[next(iter(self._entries))]
+
+    def clear(self) -> None:
+        with self._lock:
+            self._entries.clear()
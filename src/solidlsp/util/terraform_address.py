@@ -0,0 +1,160 @@
+"""
+Support for Terraform addresses (e.g. `aws_instance.web`, `var.region` or `module.vpc.aws_subnet.private`),
+which are translated into name path patterns matching the symbols reported by terraform-ls
+"""
+
+import os
+import re
+from dataclasses import dataclass
+from typing import Optional
+
+from solidlsp.util.hcl import parse_hcl_block_body
+
+_NAME = r"[A-Za-z_*?][\w*?-]*"
+_RESOURCE_TYPE = r"[a-z*?][a-z0-9*?]*_[\w*?-]+"
+_INSTANCE_KEY = r"(?:\[[^\]]*\])?"
+_MODULE_CALL_PATTERN = re.compile(rf"module\.([A-Za-z_][\w-]*){_INSTANCE_KEY}\.")
+_OBJECT_PATTERNS = [
+    (re.compile(rf"var\.({_NAME})"), 'variable "{0}"'),
+    (re.compile(rf"local\.({_NAME})"), "locals/{0}"),
+    (re.compile(rf"output\.({_NAME})"), 'output "{0}"'),
+    (re.compile(rf"module\.({_NAME}){_INSTANCE_KEY}"), 'module "{0}"'),
+    (re.compile(rf"data\.({_RESOURCE_TYPE})\.({_NAME}){_INSTANCE_KEY}"), 'data "{0}" "{1}"'),
+    (re.compile(rf"(?:resource\.)?({_RESOURCE_TYPE})\.({_NAME}){_INSTANCE_KEY}"), 'resource "{0}" "{1}"'),
+]
+_STRING_LITERAL_PATTERN = re.compile(r'"([^"$%]*)"')
+_MODULE_BLOCK_PATTERN = re.compile(r'^\s*module\s+"([^"]+)"\s*\{', re.MULTILINE)
+
+
+@dataclass
+class TerraformAddress:
+    module_names: list[str]
+    """
+    the names of the (nested) module calls containing the object, e.g. ["vpc"] for `module.vpc.aws_subnet.private`
+    """
+    name_path: str
+    """
+    the absolute name path of the object within its module, e.g. `/resource "aws_subnet" "private"`
+    """
+
+    @classmethod
+    def parse(cls, address: str) -> Optional["TerraformAddress"]:
+        """
+        Parses a Terraform address, which may contain the glob-style wildcards `*` and `?` in the names of the object
+        (but not in the names of the module calls). Instance keys (e.g. `[0]` or `["eu"]`) are ignored.
+
+        :param address: the address, e.g. `aws_instance.web`, `resource.aws_instance.web`, `data.aws_ami.ubuntu`, `var.region`,
+            `local.tags`, `output.vpc_id`, `module.vpc` or `module.vpc.aws_subnet.private`
+        :return: the parsed address or None if the given string is not a Terraform address
+        """
+        module_names = []
+        pos = 0
+        while (match := _MODULE_CALL_PATTERN.match(address, pos)) is not None:
+            module_names.append(match.group(1))
+            pos = match.end()
+        name_path = cls._match_object(address[pos:])
+        if name_path is None:
+            return None
+        return cls(module_names=module_names, name_path=name_path)
+
+    @staticmethod
+    def _match_object(address: str) -> str | None:
+        for pattern, name_path_template in _OBJECT_PATTERNS:
+            if (match := pattern.fullmatch(address)) is not None:
+                return "/" + name_path_template.format(*match.groups())
+        return None
+
+
+def find_local_module_source(module_dir_contents: list[str], module_name: str) -> str | None:
+    """
+    :param module_dir_contents: the contents of the Terraform files of a module
+    :param module_name: the name of a module call (i.e. of a `module` block) in the module
+    :return: the value of the module call's `source` argument or None if the module call was not found
+    """
+    module_block_pattern = re.compile(rf'^\s*module\s+"{re.escape(module_name)}"\s*\{{', re.MULTILINE)
+    for contents in module_dir_contents:
+        if (match := module_block_pattern.search(contents)) is not None:
+            module_source = _find_module_source(contents, match.start())
+            return module_source[0] if module_source is not None else None
+    return None
+
+
+def _find_module_source(contents: str, module_block_offset: int) -> tuple[str, int] | None:
+    """
+    :param contents: the contents of a Terraform file
+    :param module_block_offset: the offset at which a `module` block starts
+    :return: the value of the `source` argument in the block's body along with the argument's offset, or None if the block
+        has no `source` argument whose value is a string literal
+    """
+    block_body = parse_hcl_block_body(contents[module_block_offset:])
+    if block_body is None:
+        return None
+    for attribute in block_body.attributes:
+        if attribute.name == "source":
+            value = contents[module_block_offset + attribute.value_start : module_block_offset + attribute.value_end]
+            if (match := _STRING_LITERAL_PATTERN.fullmatch(value)) is None:
+                return None
+            return match.group(1), module_block_offset + attribute.start
+    return None
+
+
+@dataclass
+class LocalModuleSource:
+    """
+    The local `source` argument of a module call, i.e. a source referring to a directory in the same repository
+    """
+
+    module_name: str
+    """the name of the module call, i.e. of the `module` block"""
+    source: str
+    """the value of the `source` argument, e.g. `../modules/vpc`"""
+    line: int
+    """the 0-based line of the `source` argument"""
+
+    def resolve(self, module_dir: str) -> str:
+        """
+        :param module_dir: the relative path of the directory of the module containing the module call ("" for the root module)
+        :return: the normalised relative path of the directory the source refers to ("." for the root directory)
+        """
+        return os.path.normpath(os.path.join(module_dir, self.source))
+
+
+def find_local_module_sources(contents: str) -> list[LocalModuleSource]:
+    """
+    :param contents: the contents of a Terraform file
+    :return: the local sources of the module calls in the file (in the order of the module calls)
+    """
+    module_sources = []
+    for match in _MODULE_BLOCK_PATTERN.finditer(contents):
+        module_source = _find_module_source(contents, match.start())
+        if module_source is None or not module_source[0].startswith(("./", "../")):
+            continue
+        source, offset = module_source
+        line = contents.count("\n", 0, offset)
+        module_sources.append(LocalModuleSource(module_name=match.group(1), source=source, line=line))
+    return module_sources
+
+
+def find_local_module_call(module_dir_contents: list[str], module_dir: str, called_module_dir: str) -> str | None:
+    """
+    :param module_dir_contents: the contents of the Terraform files of a module
+    :param module_dir: the relative path of the module's directory ("" for the root module)
+    :param called_module_dir: the relative path of the directory of another module
+    :return: the name of the first module call in the module whose (local) source refers to the other module
+        or None if the module does not call the other module
+    """
+    for contents in module_dir_contents:
+        for module_source in find_local_module_sources(contents):
+            if module_source.resolve(module_dir) == os.path.normpath(called_module_dir or "."):
+                return module_source.module_name
+    return None
+
+
+def format_moved_block(from_address: str, to_address: str) -> str:
+    """
+    :param from_address: the previous address of a resource or module call
+    :param to_address: the new address
+    :return: a `moved` block, which tells Terraform to move the existing object to the new address (instead of
+        destroying and re-creating it)
+    """
+    return f"moved {{\n  from = {from_address}\n  to   = {to_address}\n}}\n"
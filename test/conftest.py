@@ -4,7 +4,7 @@ import platform
 import re
 import shutil as _sh
 import subprocess
-from collections.abc import Iterator
+from collections.abc import Callable, Iterator
 from contextlib import contextmanager
 from pathlib import Path
 from typing import Any
@@ -14,12 +14,15 @@ from _pytest.mark import Mark, MarkDecorator
 from sensai.util.logging import configure
 
 from serena.agent import SerenaAgent
+from serena.code_editor import CodeEditor
 from serena.config.serena_config import SerenaConfig, SerenaPaths
 from serena.constants import SERENA_MANAGED_DIR_NAME
 from serena.project import Project
+from serena.symbol import PositionInFile
 from serena.util.file_system import GitignoreParser
 from solidlsp.ls import SolidLanguageServer
 from solidlsp.ls_config import LanguageServerConfig, LanguageServerId
+from solidlsp.ls_utils import TextUtils
 from solidlsp.settings import SolidLSPSettings
 
 from .solidlsp.clojure import is_clojure_cli_available
@@ -134,6 +137,59 @@ def create_default_serena_config():
     return SerenaConfig().with_headless_mode_overrides()
 
 
+class InMemoryCodeEditor(CodeEditor):
+    """
+    Code editor operating on a dictionary of file contents (without a language server), whose symbols are
+    determined by an optional symbol finder
+    """
+
+    class EditedFile(CodeEditor.EditedFile):
+        def __init__(self, files: dict[str, str], relative_path: str) -> None:
+            super().__init__(relative_path)
+            self._files = files
+
+        def get_contents(self) -> str:
+            return self._files[self.relative_path]
+
+        def set_contents(self, contents: str) -> None:
+            self._files[self.relative_path] = contents
+
+        def delete_text_between_positions(self, start_pos: PositionInFile, end_pos: PositionInFile) -> None:
+            self._files[self.relative_path], _ = TextUtils.delete_text_between_positions(
+                self.get_contents(), start_pos.line, start_pos.col, end_pos.line, end_pos.col
+            )
+
+        def insert_text_at_position(self, pos: PositionInFile, text: str) -> None:
+            self._files[self.relative_path], _, _ = TextUtils.insert_text_at_position(self.get_contents(), pos.line, pos.col, text)
+
+    def __init__(self, files: dict[str, str], symbol_finder: Callable[[str, str], Any] | None = None) -> None:
+        """
+        :param files: the contents of the files, which are modified in place, keyed by relative path
+        :param symbol_finder: a function which, given the contents of a file and a name path, returns the symbol
+            (providing the body positions); if None, operations requiring symbols are not supported
+        """
+        self.files = files
+        self.num_saves = 0
+        self._symbol_finder = symbol_finder
+
+    @contextmanager
+    def _open_file_context(self, relative_path: str) -> Iterator[CodeEditor.EditedFile]:
+        if relative_path not in self.files:
+            raise FileNotFoundError(relative_path)
+        yield self.EditedFile(self.files, relative_path)
+
+    def _save_edited_file(self, edited_file: CodeEditor.EditedFile) -> None:
+        self.num_saves += 1
+
+    def _find_unique_symbol(self, name_path: str, relative_file_path: str, occurrence_index: int | None = None) -> Any:
+        if self._symbol_finder is None:
+            raise AssertionError("No symbol finder was configured")
+        return self._symbol_finder(self.files[relative_file_path], name_path)
+
+    def rename_symbol(self, name_path: str, relative_path: str, new_name: str, occurrence_index: int | None = None) -> str:
+        raise AssertionError("Renaming symbols is not supported")
+
+
 def _create_default_project(ls_id: LanguageServerId, repo_root_override: str | None = None) -> Project:
     repo_path = str(get_repo_path(ls_id)) if repo_root_override is None else repo_root_override
     return Project.load(repo_path, serena_config=create_default_serena_config())
@@ -0,0 +1,87 @@
+from collections.abc import Sequence
+from dataclasses import dataclass, field
+from enum import Enum
+
+import pytest
+
+from serena.config.config_validation import ConfigValidationError, get_config_field_types, validate_config_dict
+
+
+class _Backend(Enum):
+    LSP = "LSP"
+    JETBRAINS = "JetBrains"
+
+
+@dataclass
+class _Config:
+    name: str
+    excluded_tools: Sequence[str] = ()
+    read_only: bool = False
+    timeout: float = 10.0
+    max_chars: int = 1000
+    backend: _Backend = _Backend.LSP
+    budget: float | None = None
+    settings: dict[str, int] = field(default_factory=dict)
+    _internal: str = ""
+
+
+class TestValidateConfigDict:
+    FIELD_TYPES = get_config_field_types(_Config)
+
+    def test_internal_fields_are_not_allowed_keys(self) -> None:
+        assert "_internal" not in self.FIELD_TYPES
+        assert "excluded_tools" in self.FIELD_TYPES
+
+    def test_valid_config(self) -> None:
+        data = {
+            "name": "test",
+            "excluded_tools": ["execute_shell_command"],
+            "read_only": True,
+            "timeout": 5,
+            "max_chars": 100,
+            "backend": "JetBrains",
+            "budget": None,
+            "settings": {"a": 1},
+        }
+        validate_config_dict(data, self.FIELD_TYPES, "config.yml")
+
+    def test_unknown_key_suggests_close_match_and_lists_allowed_keys(self) -> None:
+        with pytest.raises(ConfigValidationError) as exc_info:
+            validate_config_dict({"name": "test", "exclude_tools": []}, self.FIELD_TYPES, "config.yml")
+        msg = str(exc_info.value)
+        assert "config.yml" in msg
+        assert "unknown key 'exclude_tools' (did you mean 'excluded_tools'?)" in msg
+        assert "Allowed keys: backend, budget, excluded_tools, max_chars, name, read_only, settings, timeout" in msg
+
+    def test_legacy_keys_are_accepted(self) -> None:
+        validate_config_dict({"name": "test", "jetbrains": True}, self.FIELD_TYPES, "config.yml", legacy_keys={"jetbrains"})
+
+    @pytest.mark.parametrize(
+        "key, value, expected_type",
+        [
+            ("excluded_tools", "execute_shell_command", "a list of str values"),
+            ("read_only", "yes", "bool"),
+            ("max_chars", True, "int"),
+            ("timeout", "fast", "float"),
+            ("backend", 1, "one of 'LSP', 'JetBrains'"),
+            ("budget", "high", "float or null"),
+            ("settings", {"a": "b"}, "a mapping"),
+        ],
+    )
+    def test_value_of_wrong_type(self, key: str, value: object, expected_type: str) -> None:
+        with pytest.raises(ConfigValidationError) as exc_info:
+            validate_config_dict({"name": "test", key: value}, self.FIELD_TYPES, "config.yml")
+        msg = str(exc_info.value)
+        assert f"invalid value for '{key}': expected {expected_type}, got {value!r}" in msg
+        assert "Allowed keys" not in msg
+
+    def test_line_numbers_are_reported(self, tmp_path) -> None:
+        yaml_path = tmp_path / "config.yml"
+        yaml_path.write_text("name: test\n\nread_only: yes please\nexclude_tools: []\n")
+        data = {"name": "test", "read_only": "yes please", "exclude_tools": []}
+        with pytest.raises(ConfigValidationError) as exc_info:
+            validate_config_dict(data, self.FIELD_TYPES, str(yaml_path))
+        assert exc_info.value.problems == [
+            "line 3: invalid value for 'read_only': expected bool, got 'yes please'",
+            "line 4: unknown key 'exclude_tools' (did you mean 'excluded_tools'?)",
+        ]
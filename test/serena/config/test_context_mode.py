@@ -1,7 +1,8 @@
 import pytest
 
 from interprompt.jinja_template import JinjaTemplate
-from serena.config.context_mode import SerenaAgentContext
+from serena.config.config_validation import ConfigValidationError
+from serena.config.context_mode import SerenaAgentContext, SerenaAgentMode
 
 GROK_EXCLUDED_TOOLS = {
     "create_text_file",
@@ -58,3 +59,26 @@ def test_builtin_contexts_load_and_prompt_templates_render(context_name: str):
     assert context.name == context_name
     assert isinstance(context.excluded_tools, list)
     assert rendered_prompt == "" or rendered_prompt.strip()
+
+
+def test_context_with_unknown_key_is_rejected(tmp_path):
+    context_path = tmp_path / "my-context.yml"
+    context_path.write_text('description: "My context"\nprompt: ""\nexclude_tools:\n  - execute_shell_command\n')
+
+    with pytest.raises(ConfigValidationError) as exc_info:
+        SerenaAgentContext.from_yaml(context_path)
+
+    msg = str(exc_info.value)
+    assert str(context_path) in msg
+    assert "line 3: unknown key 'exclude_tools' (did you mean 'excluded_tools'?)" in msg
+    assert "Allowed keys:" in msg
+
+
+def test_mode_with_invalid_yaml_is_rejected(tmp_path):
+    mode_path = tmp_path / "my-mode.yml"
+    mode_path.write_text('description: "My mode"\nprompt: "unterminated\n')
+
+    with pytest.raises(ConfigValidationError, match="invalid YAML") as exc_info:
+        SerenaAgentMode.from_yaml(mode_path)
+
+    assert str(mode_path) in str(exc_info.value)
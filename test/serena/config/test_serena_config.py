@@ -6,8 +6,12 @@ from copy import deepcopy
 from pathlib import Path
 
 import pytest
+from click.testing import CliRunner
 
 from serena.agent import SerenaAgent
+from serena.cli import TopLevelCommands
+from serena.config.config_validation import ConfigValidationError
+from serena.config.context_mode import SerenaAgentContext
 from serena.config.serena_config import (
     DEFAULT_PROJECT_SERENA_FOLDER_LOCATION,
     LanguageBackend,
@@ -16,7 +20,7 @@ from serena.config.serena_config import (
     SerenaConfig,
     SerenaConfigError,
 )
-from serena.constants import PROJECT_TEMPLATE_FILE, SERENA_MANAGED_DIR_NAME
+from serena.constants import DEFAULT_CONTEXT, PROJECT_TEMPLATE_FILE, SERENA_MANAGED_DIR_NAME
 from serena.project import MemoryManager, Project
 from solidlsp.ls_config import LanguageServerId
 from test.conftest import create_default_serena_config
@@ -483,6 +487,22 @@ class TestProjectSerenaDataFolder:
         project = self._make_project(config)
         assert project.path_to_serena_data_folder() == str(custom_serena)
 
+    def test_missing_entries_are_added_to_existing_gitignore(self):
+        """The .gitignore file created by an earlier version (without the history and backups folders) is completed."""
+        existing_serena = self.project_path / SERENA_MANAGED_DIR_NAME
+        existing_serena.mkdir()
+        gitignore_path = existing_serena / ".gitignore"
+        gitignore_path.write_text("/cache\n/project.local.yml\n# custom entry\n/notes", encoding="utf-8")
+
+        self._make_project(SerenaConfig().with_headless_mode_overrides())
+
+        lines = gitignore_path.read_text(encoding="utf-8").splitlines()
+        assert lines == ["/cache", "/project.local.yml", "# custom entry", "/notes", "/history", "/backups"]
+
+        # the entries are not added again
+        self._make_project(SerenaConfig().with_headless_mode_overrides())
+        assert gitignore_path.read_text(encoding="utf-8").splitlines() == lines
+
 
 class TestProjectConfigYamlValidation:
     def test_ignored_paths_globs_starting_with_star_require_quotes(self):
@@ -502,6 +522,65 @@ class TestProjectConfigYamlValidation:
         finally:
             shutil.rmtree(project_dir)
 
+    def test_unknown_key_is_rejected_with_line_and_allowed_keys(self):
+        project_dir = Path(tempfile.mkdtemp())
+        try:
+            serena_dir = project_dir / SERENA_MANAGED_DIR_NAME
+            serena_dir.mkdir(parents=True)
+            (serena_dir / "project.yml").write_text('project_name: "demo"\nlanguages: ["python"]\nexclude_tools:\n- execute_shell_command\n')
+
+            with pytest.raises(ConfigValidationError) as exc_info:
+                ProjectConfig.load(project_dir, create_default_serena_config())
+
+            msg = str(exc_info.value)
+            assert "project.yml" in msg
+            assert "line 3: unknown key 'exclude_tools' (did you mean 'excluded_tools'?)" in msg
+            assert "Allowed keys:" in msg
+        finally:
+            shutil.rmtree(project_dir)
+
+    def test_value_of_wrong_type_is_rejected(self):
+        project_dir = Path(tempfile.mkdtemp())
+        try:
+            serena_dir = project_dir / SERENA_MANAGED_DIR_NAME
+            serena_dir.mkdir(parents=True)
+            (serena_dir / "project.yml").write_text('project_name: "demo"\nlanguages: ["python"]\nread_only: "sometimes"\n')
+
+            with pytest.raises(ConfigValidationError, match="line 3: invalid value for 'read_only': expected bool"):
+                ProjectConfig.load(project_dir, create_default_serena_config())
+        finally:
+            shutil.rmtree(project_dir)
+
+    def test_tool_parameter_defaults_are_loaded(self):
+        project_dir = Path(tempfile.mkdtemp())
+        try:
+            serena_dir = project_dir / SERENA_MANAGED_DIR_NAME
+            serena_dir.mkdir(parents=True)
+            (serena_dir / "project.yml").write_text(
+                'project_name: "demo"\nlanguages: ["terraform"]\n'
+                "tool_parameter_defaults:\n  find_symbol:\n    include_body: true\n    max_answer_chars: 5000\n"
+            )
+
+            config = ProjectConfig.load(project_dir, create_default_serena_config())
+
+            assert config.tool_parameter_defaults == {"find_symbol": {"include_body": True, "max_answer_chars": 5000}}
+        finally:
+            shutil.rmtree(project_dir)
+
+    def test_invalid_tool_parameter_defaults_are_rejected(self):
+        project_dir = Path(tempfile.mkdtemp())
+        try:
+            serena_dir = project_dir / SERENA_MANAGED_DIR_NAME
+            serena_dir.mkdir(parents=True)
+            (serena_dir / "project.yml").write_text(
+                'project_name: "demo"\nlanguages: ["terraform"]\ntool_parameter_defaults:\n  find_symbol: true\n'
+            )
+
+            with pytest.raises(ConfigValidationError, match="line 3: invalid value for 'tool_parameter_defaults': expected a mapping"):
+                ProjectConfig.load(project_dir, create_default_serena_config())
+        finally:
+            shutil.rmtree(project_dir)
+
 
 class TestSerenaConfigFromConfigFileRobustness:
     """Tests that ``SerenaConfig.from_config_file`` does not abort the whole
@@ -597,6 +676,83 @@ class TestSerenaConfigFromConfigFileRobustness:
         assert any("must be quoted" in msg and str(bad_project.resolve()) in msg for msg in caplog.messages), caplog.messages
 
 
+class TestSerenaConfigDefaultContext:
+    """Tests that the ``default_context`` configured in ``serena_config.yml`` is used by the CLI commands unless
+    a context is specified explicitly.
+    """
+
+    def setup_method(self):
+        self.test_dir = Path(tempfile.mkdtemp())
+        self.config_file_path = self.test_dir / "serena_config.yml"
+        self.custom_context_path = self.test_dir / "custom-context.yml"
+        self.custom_context_path.write_text("prompt: Custom context prompt\n")
+
+    def teardown_method(self):
+        shutil.rmtree(self.test_dir)
+
+    def _use_config_file(self, monkeypatch, content: str) -> None:
+        self.config_file_path.write_text(content)
+        monkeypatch.setattr(
+            SerenaConfig,
+            "_determine_config_file_path",
+            classmethod(lambda cls: str(self.config_file_path)),
+        )
+
+    def _invoke_and_get_context(self, monkeypatch, command_name: str, args: list[str]) -> str:
+        """
+        Invokes the given CLI command with the MCP server factory and the agent replaced by fakes.
+
+        :return: the name of (or path to) the context which the command passed on
+        """
+        contexts: list[str] = []
+
+        class FakeMCPFactory:
+            def __init__(self, context: str, **kwargs):
+                contexts.append(context)
+
+            def create_mcp_server(self, **kwargs):
+                return None
+
+            def run_mcp_server(self, server):
+                pass
+
+        class FakeAgent:
+            def __init__(self, context: SerenaAgentContext, **kwargs):
+                contexts.append(context.name)
+
+            def create_system_prompt(self) -> str:
+                return "system prompt"
+
+        monkeypatch.setattr("serena.mcp.SerenaMCPFactory", FakeMCPFactory)
+        monkeypatch.setattr("serena.agent.SerenaAgent", FakeAgent)
+        # start-mcp-server adds logging handlers, which must not outlive the test
+        root_handlers = list(logging.getLogger().handlers)
+        try:
+            result = CliRunner().invoke(getattr(TopLevelCommands, command_name), args)
+        finally:
+            logging.getLogger().handlers = root_handlers
+        assert result.exit_code == 0, result.output
+        assert len(contexts) == 1
+        return contexts[0]
+
+    def test_default_context_is_loaded_from_config_file(self, monkeypatch):
+        self._use_config_file(monkeypatch, "projects: []\ndefault_context: ide\n")
+        assert SerenaConfig.from_config_file(generate_if_missing=False).default_context == "ide"
+
+        self._use_config_file(monkeypatch, "projects: []\n")
+        assert SerenaConfig.from_config_file(generate_if_missing=False).default_context == DEFAULT_CONTEXT
+
+    def test_default_context_is_used_if_no_context_is_specified(self, monkeypatch):
+        self._use_config_file(monkeypatch, f"projects: []\ndefault_context: {self.custom_context_path}\n")
+        assert self._invoke_and_get_context(monkeypatch, "start_mcp_server", []) == str(self.custom_context_path)
+        assert self._invoke_and_get_context(monkeypatch, "print_system_prompt", []) == "custom-context"
+
+    def test_context_option_takes_precedence_over_default_context(self, monkeypatch):
+        self._use_config_file(monkeypatch, f"projects: []\ndefault_context: {self.custom_context_path}\n")
+        assert self._invoke_and_get_context(monkeypatch, "start_mcp_server", ["--context", "agent"]) == "agent"
+        assert self._invoke_and_get_context(monkeypatch, "print_system_prompt", ["--context", "agent"]) == "agent"
+
+
 class TestGetRegisteredProjectWithDanglingProject:
     """A registered project whose root directory was deleted (e.g. a removed git
     worktree) must not break lookup/activation of other, valid projects.
@@ -715,3 +871,44 @@ class TestProjectConfigActivationCommand:
         data["activation_command_timeout"] = -10
         with pytest.raises(ValueError, match="activation_command_timeout must be positive"):
             ProjectConfig._from_dict(data, local_override_keys=[])
+
+
+class TestProjectConfigBackupRetention:
+    def _base_data(self) -> dict:
+        data, _ = ProjectConfig._load_yaml_dict(PROJECT_TEMPLATE_FILE)
+        data["project_name"] = "test"
+        data["languages"] = ["python"]
+        return data
+
+    def test_backup_retention_parsed_from_dict(self):
+        data = self._base_data()
+        assert ProjectConfig._from_dict(data, local_override_keys=[]).backup_retention == 20
+        data["backup_retention"] = 0
+        assert ProjectConfig._from_dict(data, local_override_keys=[]).backup_retention == 0
+
+    @pytest.mark.parametrize("backup_retention", [-1, 2.5, "many"])
+    def test_invalid_backup_retention_raises(self, backup_retention):
+        data = self._base_data()
+        data["backup_retention"] = backup_retention
+        with pytest.raises(ValueError, match="backup_retention must be a non-negative integer"):
+            ProjectConfig._from_dict(data, local_override_keys=[])
+
+
+class TestProjectConfigEncoding:
+    def _base_data(self) -> dict:
+        data, _ = ProjectConfig._load_yaml_dict(PROJECT_TEMPLATE_FILE)
+        data["project_name"] = "test"
+        data["languages"] = ["python"]
+        return data
+
+    @pytest.mark.parametrize("encoding, expected_encoding", [("latin-1", "latin-1"), ("utf-16", "utf-16"), (None, "utf-8")])
+    def test_encoding_parsed_from_dict(self, encoding, expected_encoding):
+        data = self._base_data()
+        data["encoding"] = encoding
+        assert ProjectConfig._from_dict(data, local_override_keys=[]).encoding == expected_encoding
+
+    def test_invalid_encoding_raises(self):
+        data = self._base_data()
+        data["encoding"] = "utf-42"
+        with pytest.raises(ValueError, match="encoding must be the name of a Python codec"):
+            ProjectConfig._from_dict(data, local_override_keys=[])
@@ -0,0 +1,94 @@
+import logging
+
+import pytest
+
+from serena.api import (
+    SerenaConfig,
+    Tool,
+    ToolMarkerDoesNotRequireActiveProject,
+    ToolMarkerOptional,
+    ToolRegistry,
+    ToolTransport,
+    call_tool,
+    create_agent,
+    describe_tools,
+    get_tool_registry,
+    register_tool,
+)
+
+
+class GreetTool(Tool, ToolMarkerOptional, ToolMarkerDoesNotRequireActiveProject):
+    """
+    Greets the user (custom tool for testing).
+    """
+
+    def apply(self, name: str) -> str:
+        """
+        Greets the person with the given name.
+
+        :param name: the name of the person to greet
+        """
+        return f"Hello, {name}!"
+
+
+@pytest.fixture
+def tool_registry(monkeypatch: pytest.MonkeyPatch) -> ToolRegistry:
+    """The tool registry, where registrations performed in the test are reverted afterwards"""
+    registry = get_tool_registry()
+    monkeypatch.setattr(registry, "_tool_dict", dict(registry._tool_dict))
+    return registry
+
+
+def _create_headless_config() -> SerenaConfig:
+    serena_config = SerenaConfig(log_level=logging.ERROR).with_headless_mode_overrides()
+    serena_config.included_optional_tools = ["greet"]
+    return serena_config
+
+
+class TestPublicApi:
+    def test_register_tool(self, tool_registry: ToolRegistry) -> None:
+        assert register_tool(GreetTool) is GreetTool
+        assert tool_registry.get_tool_class_by_name("greet") is GreetTool
+        assert "greet" in tool_registry.get_tool_names_optional()
+
+    def test_marker_names(self) -> None:
+        assert GreetTool.get_marker_names() == ["does_not_require_active_project", "optional"]
+
+    def test_register_tool_rejects_duplicate_names(self, tool_registry: ToolRegistry) -> None:
+        register_tool(GreetTool)
+        with pytest.raises(ValueError, match="Duplicate tool name"):
+            register_tool(GreetTool)
+
+    def test_custom_tool_can_be_called_and_described(self, tool_registry: ToolRegistry) -> None:
+        register_tool(GreetTool)
+        agent = create_agent(serena_config=_create_headless_config())
+        try:
+            assert call_tool(agent, "greet", name="Ada") == "Hello, Ada!"
+            descriptors = {d.name: d for d in describe_tools(agent)}
+            greet = descriptors["greet"]
+            assert "Greets the person with the given name" in greet.description
+            assert list(greet.input_schema["properties"]) == ["name"]
+            assert not greet.can_edit
+        finally:
+            agent.shutdown()
+
+    def test_transport_run_shuts_down_agent(self, tool_registry: ToolRegistry) -> None:
+        register_tool(GreetTool)
+
+        class RecordingTransport(ToolTransport):
+            def __init__(self) -> None:
+                self.results: list[str] = []
+
+            def serve(self, agent) -> None:  # type: ignore[no-untyped-def]
+                self.results.append(call_tool(agent, "greet", name="Grace"))
+
+        agent = create_agent(serena_config=_create_headless_config())
+        shutdown_calls = []
+        original_shutdown = agent.shutdown
+        agent.shutdown = lambda: (shutdown_calls.append(True), original_shutdown())  # type: ignore[method-assign]
+
+        transport = RecordingTransport()
+        transport.run(agent)
+
+        assert transport.results == ["Hello, Grace!"]
+        assert shutdown_calls == [True]
@@ -0,0 +1,55 @@
+import pytest
+
+from solidlsp import ls_types
+from test.conftest import InMemoryCodeEditor
+
+MAIN_TF = 'resource "aws_instance" "web" {\n  ami = var.ami\n}\n'
+VARIABLES_TF = 'variable "ami" {\n  type = string\n}\n'
+
+
+def _edit(start_line: int, start_col: int, end_line: int, end_col: int, new_text: str) -> ls_types.TextEdit:
+    return {
+        "range": {"start": {"line": start_line, "character": start_col}, "end": {"line": end_line, "character": end_col}},
+        "newText": new_text,
+    }
+
+
+def _create_code_editor() -> InMemoryCodeEditor:
+    return InMemoryCodeEditor({"main.tf": MAIN_TF, "variables.tf": VARIABLES_TF})
+
+
+def test_edits_across_files_are_applied() -> None:
+    code_editor = _create_code_editor()
+    code_editor.apply_text_edits(
+        {
+            # ranges refer to the original contents, regardless of the order of the edits
+            "main.tf": [_edit(1, 12, 1, 15, "image_id"), _edit(0, 25, 0, 28, "app")],
+            "variables.tf": [_edit(0, 10, 0, 13, "image_id"), _edit(3, 0, 3, 0, 'variable "zone" {}\n')],
+        }
+    )
+    assert code_editor.files["main.tf"] == 'resource "aws_instance" "app" {\n  ami = var.image_id\n}\n'
+    assert code_editor.files["variables.tf"] == 'variable "image_id" {\n  type = string\n}\nvariable "zone" {}\n'
+
+
+@pytest.mark.parametrize(
+    "variables_tf_edits",
+    [
+        pytest.param([_edit(0, 10, 0, 13, "image_id"), _edit(0, 11, 0, 12, "x")], id="overlapping"),
+        pytest.param([_edit(7, 0, 7, 1, "x")], id="out of range"),
+        pytest.param([_edit(1, 2, 0, 0, "x")], id="end before start"),
+    ],
+)
+def test_invalid_edit_prevents_all_changes(variables_tf_edits: list[ls_types.TextEdit]) -> None:
+    code_editor = _create_code_editor()
+    with pytest.raises(ValueError) as exc_info:
+        code_editor.apply_text_edits({"main.tf": [_edit(1, 12, 1, 15, "image_id")], "variables.tf": variables_tf_edits})
+    assert "variables.tf" in str(exc_info.value)
+    assert code_editor.files == {"main.tf": MAIN_TF, "variables.tf": VARIABLES_TF}
+
+
+def test_missing_file_prevents_all_changes() -> None:
+    code_editor = _create_code_editor()
+    with pytest.raises(ValueError) as exc_info:
+        code_editor.apply_text_edits({"main.tf": [_edit(1, 12, 1, 15, "image_id")], "outputs.tf": [_edit(0, 0, 0, 0, "x")]})
+    assert "outputs.tf" in str(exc_info.value)
+    assert code_editor.files["main.tf"] == MAIN_TF
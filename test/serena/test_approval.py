@@ -0,0 +1,108 @@
+from unittest.mock import MagicMock
+
+import pytest
+
+from serena.agent import SerenaAgent
+from serena.approval import DESTRUCTIVE_SHELL_COMMAND_PATTERNS, ApprovalPolicy, PendingOperationRegistry
+from serena.config.serena_config import SerenaConfig
+from serena.tools import ApprovePendingOperationTool
+
+
+class TestApprovalPolicy:
+    def test_empty_policy_requires_no_approval(self):
+        policy = ApprovalPolicy()
+        assert policy.is_empty()
+        assert policy.get_approval_reason("delete_lines", {"relative_path": "a.py"}) is None
+        assert policy.get_approval_reason("execute_shell_command", {"command": "rm -rf build"}) is None
+
+    def test_tool_names(self):
+        policy = ApprovalPolicy(tool_names=["delete_lines"])
+        assert policy.get_approval_reason("delete_lines", {}) is not None
+        assert policy.get_approval_reason("replace_lines", {}) is None
+
+    def test_shell_command_patterns(self):
+        policy = ApprovalPolicy(shell_command_patterns=[r"\brm\b", r"git\s+push"])
+        assert policy.get_approval_reason("execute_shell_command", {"command": "rm -rf build"}) is not None
+        assert policy.get_approval_reason("execute_shell_command", {"command": "cd src && git  push origin"}) is not None
+        assert policy.get_approval_reason("execute_shell_command", {"command": "git status"}) is None
+        assert policy.get_approval_reason("execute_shell_command", {"command": "npm run format"}) is None
+        # patterns apply to shell commands only
+        assert policy.get_approval_reason("search_for_pattern", {"command": "rm"}) is None
+
+    def test_destructive_shell_command_patterns(self):
+        policy = ApprovalPolicy(destructive_shell_command_patterns=DESTRUCTIVE_SHELL_COMMAND_PATTERNS)
+        for command in [
+            "terraform apply",
+            "terraform destroy -auto-approve",
+            "cd infra && terraform -chdir=prod apply",
+            "terragrunt apply",
+            "terragrunt run-all destroy",
+        ]:
+            assert policy.get_approval_reason("execute_shell_command", {"command": command}) is not None
+        for command in ["terraform plan", "terraform plan -destroy", "terraform validate", "terragrunt run-all plan"]:
+            assert policy.get_approval_reason("execute_shell_command", {"command": command}) is None
+
+    @pytest.mark.parametrize("confirm_destructive_operations", [True, False])
+    def test_confirm_destructive_operations(self, confirm_destructive_operations: bool):
+        serena_config = SerenaConfig(confirm_destructive_operations=confirm_destructive_operations)
+        policy = ApprovalPolicy.from_serena_config(serena_config, destructive_tool_names=["delete_lines", "execute_shell_command"])
+        assert policy.is_empty() != confirm_destructive_operations
+        assert (policy.get_approval_reason("delete_lines", {}) is not None) == confirm_destructive_operations
+        terraform_apply_reason = policy.get_approval_reason("execute_shell_command", {"command": "terraform apply"})
+        assert (terraform_apply_reason is not None) == confirm_destructive_operations
+        # shell commands which are not destructive can be executed right away
+        assert policy.get_approval_reason("execute_shell_command", {"command": "terraform plan"}) is None
+        assert policy.get_approval_reason("read_file", {}) is None
+
+    def test_configured_patterns_are_compiled_once(self):
+        serena_config = SerenaConfig(shell_command_approval_patterns=[r"\brm\b"])
+        regexes = serena_config.shell_command_approval_regexes
+        policy = ApprovalPolicy.from_serena_config(serena_config)
+        assert policy.get_approval_reason("execute_shell_command", {"command": "rm -rf build"}) is not None
+        assert all(a is b for a, b in zip(policy._shell_command_patterns, regexes, strict=True))
+
+    def test_approval_of_pending_operation_is_not_deferred(self):
+        policy = SerenaAgent._create_approval_policy(SerenaConfig(confirm_destructive_operations=True))
+        assert policy.get_approval_reason("delete_lines", {}) is not None
+        # the approve_pending_operation tool can edit, but deferring it would prevent any pending operation from being executed
+        assert policy.get_approval_reason("approve_pending_operation", {"operation_id": "abc"}) is None
+
+
+class TestPendingOperationRegistry:
+    def test_add_and_pop(self):
+        registry = PendingOperationRegistry()
+        op = registry.add("delete_lines", {"relative_path": "a.py", "start_line": 1, "end_line": 2}, "reason")
+        assert op.operation_id in op.get_approval_request_message()
+        assert "delete_lines(relative_path='a.py'" in op.get_description()
+        assert registry.get_operations() == [op]
+        assert registry.get(op.operation_id) is op
+        assert registry.get("unknown") is None
+
+        popped = registry.pop(op.operation_id)
+        assert popped is op
+        assert registry.get_operations() == []
+
+        # an operation can be approved only once
+        with pytest.raises(ValueError):
+            registry.pop(op.operation_id)
+
+    def test_session_id_omitted_from_description(self):
+        registry = PendingOperationRegistry()
+        op = registry.add("execute_shell_command", {"command": "ls", "session_id": "abc"}, "reason")
+        assert "session_id" not in op.get_description()
+        assert op.apply_kwargs["session_id"] == "abc"
+
+
+class TestApprovePendingOperationTool:
+    def test_operation_is_applied_with_checks_but_without_approval_policy(self):
+        registry = PendingOperationRegistry()
+        op = registry.add("execute_shell_command", {"command": "rm -rf build", "session_id": "abc"}, "reason")
+        agent = MagicMock()
+        agent.get_pending_operations.return_value = registry
+        approved_tool = agent.get_tool_by_name.return_value
+        approved_tool.apply_checked.return_value = "done"
+
+        assert ApprovePendingOperationTool(agent).apply(op.operation_id) == "done"
+        agent.get_tool_by_name.assert_called_once_with("execute_shell_command")
+        approved_tool.apply_checked.assert_called_once_with({"command": "rm -rf build"}, "abc", approved=True)
+        assert registry.get_operations() == []
@@ -0,0 +1,66 @@
+from collections.abc import Callable
+from types import SimpleNamespace
+
+from serena.background_indexer import BackgroundIndexer
+
+
+class _FakeLanguageServerManager:
+    def __init__(self, failing_paths: tuple[str, ...] = ()) -> None:
+        self.indexed_paths: list[str] = []
+        self.num_cache_saves = 0
+        self._failing_paths = failing_paths
+
+    def get_language_server(self, relative_path: str) -> SimpleNamespace:
+        return SimpleNamespace(request_document_symbols=self._request_document_symbols)
+
+    def _request_document_symbols(self, relative_path: str) -> None:
+        if relative_path in self._failing_paths:
+            raise RuntimeError("request failed")
+        self.indexed_paths.append(relative_path)
+
+    def save_all_caches(self) -> None:
+        self.num_cache_saves += 1
+
+
+def _create_indexer(
+    language_server_manager: _FakeLanguageServerManager, num_files: int
+) -> tuple[BackgroundIndexer, list[Callable[[], None]]]:
+    project = SimpleNamespace(
+        project_name="test",
+        language_server_manager=language_server_manager,
+        gather_source_files=lambda: [f"module{i}/main.tf" for i in range(num_files)],
+    )
+    task_queue: list[Callable[[], None]] = []
+    return BackgroundIndexer(project, task_queue.append), task_queue  # type: ignore
+
+
+def test_files_are_indexed_in_batches() -> None:
+    language_server_manager = _FakeLanguageServerManager(failing_paths=("module3/main.tf",))
+    indexer, task_queue = _create_indexer(language_server_manager, BackgroundIndexer.BATCH_SIZE + 5)
+    indexer.start()
+
+    num_batches = 0
+    while task_queue:
+        # each batch issues the next batch only upon completion, such that other tasks issued in the meantime come first
+        assert len(task_queue) == 1
+        task_queue.pop(0)()
+        num_batches += 1
+    assert num_batches == 2
+    assert len(language_server_manager.indexed_paths) == BackgroundIndexer.BATCH_SIZE + 4
+    assert "module3/main.tf" not in language_server_manager.indexed_paths
+    assert language_server_manager.num_cache_saves == 1
+    assert indexer.is_done()
+
+
+def test_cancelled_indexing_stops_after_current_batch() -> None:
+    language_server_manager = _FakeLanguageServerManager()
+    indexer, task_queue = _create_indexer(language_server_manager, 3 * BackgroundIndexer.BATCH_SIZE)
+    indexer.start()
+    task_queue.pop(0)()
+    indexer.cancel()
+    task_queue.pop(0)()
+
+    assert task_queue == []
+    assert len(language_server_manager.indexed_paths) == BackgroundIndexer.BATCH_SIZE
+    assert language_server_manager.num_cache_saves == 0
+    assert indexer.is_done()
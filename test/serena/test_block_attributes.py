@@ -0,0 +1,100 @@
+from types import SimpleNamespace
+
+import pytest
+
+from serena.agent import ActiveModes, SerenaAgent
+from serena.config.context_mode import SerenaAgentContext
+from serena.config.serena_config import SerenaConfig
+from serena.symbol import PositionInFile
+from serena.tools import TERRAFORM_TOOLS
+from solidlsp.ls_config import LanguageServerId
+from solidlsp.util.hcl import parse_hcl_document_symbols
+from test.conftest import InMemoryCodeEditor
+
+MAIN_TF = """resource "aws_instance" "web" {
+  # the AMI
+  ami           = "ami-123"
+  instance_type = "t2.micro" # cheap
+
+  root_block_device {
+    volume_size = 8
+  }
+}
+
+resource "null_resource" "empty" {}
+
+  variable "region" {
+  }
+
+variable "zone" { default = "a" }
+"""
+
+
+class _FakeSymbol:
+    def __init__(self, start: PositionInFile, end: PositionInFile) -> None:
+        self._start = start
+        self._end = end
+
+    def get_body_start_position_or_raise(self) -> PositionInFile:
+        return self._start
+
+    def get_body_end_position_or_raise(self) -> PositionInFile:
+        return self._end
+
+
+def _find_hcl_symbol(contents: str, name_path: str) -> _FakeSymbol:
+    """Finds a symbol via the native HCL parser"""
+    symbols = parse_hcl_document_symbols(contents)
+    for name in name_path.split("/"):
+        symbol = next(s for s in symbols if s["name"] == name)
+        symbols = symbol.get("children", [])
+    start, end = symbol["range"]["start"], symbol["range"]["end"]
+    return _FakeSymbol(PositionInFile(start["line"], start["character"]), PositionInFile(end["line"], end["character"]))
+
+
+def _create_code_editor() -> InMemoryCodeEditor:
+    return InMemoryCodeEditor({"main.tf": MAIN_TF}, symbol_finder=_find_hcl_symbol)
+
+
+WEB = 'resource "aws_instance" "web"'
+
+
+class TestBlockAttributes:
+    def test_get_attribute(self) -> None:
+        code_editor = _create_code_editor()
+        assert code_editor.get_block_attribute(WEB, "main.tf", "instance_type") == '"t2.micro"'
+        assert code_editor.get_block_attribute(WEB + "/root_block_device", "main.tf", "volume_size") == "8"
+        with pytest.raises(ValueError):
+            code_editor.get_block_attribute(WEB, "main.tf", "volume_size")
+
+    def test_replace_attribute_preserving_comments(self) -> None:
+        code_editor = _create_code_editor()
+        assert not code_editor.set_block_attribute(WEB, "main.tf", "instance_type", ' "t3.micro"\n')
+        assert code_editor.files["main.tf"] == MAIN_TF.replace('"t2.micro"', '"t3.micro"')
+
+    def test_add_attribute_after_last_attribute(self) -> None:
+        code_editor = _create_code_editor()
+        assert code_editor.set_block_attribute(WEB, "main.tf", "monitoring", "true")
+        assert code_editor.files["main.tf"] == MAIN_TF.replace("# cheap\n", "# cheap\n  monitoring = true\n")
+
+    def test_add_attribute_to_empty_block(self) -> None:
+        code_editor = _create_code_editor()
+        code_editor.set_block_attribute('variable "region"', "main.tf", "default", '"eu-west-1"')
+        assert code_editor.files["main.tf"] == MAIN_TF.replace('"region" {\n  }', '"region" {\n    default = "eu-west-1"\n  }')
+        code_editor.set_block_attribute('resource "null_resource" "empty"', "main.tf", "count", "0")
+        assert '"empty" {\n  count = 0\n}\n' in code_editor.files["main.tf"]
+
+    def test_add_attribute_to_single_line_block_fails(self) -> None:
+        code_editor = _create_code_editor()
+        with pytest.raises(ValueError):
+            code_editor.set_block_attribute('variable "zone"', "main.tf", "type", "string")
+        assert code_editor.files["main.tf"] == MAIN_TF
+
+
+@pytest.mark.parametrize("language_server, is_exposed", [(LanguageServerId.TERRAFORM, True), (LanguageServerId.PYTHON, False)])
+def test_tools_are_exposed_for_terraform_projects_only(language_server: LanguageServerId, is_exposed: bool) -> None:
+    project = SimpleNamespace(project_config=SimpleNamespace(language_servers=[language_server]))
+    context = SerenaAgentContext(name="test", prompt="")
+    tool_set = SerenaAgent._create_base_toolset(SerenaConfig(), context, ActiveModes(), project)  # type: ignore[arg-type]
+    for tool_class in TERRAFORM_TOOLS:
+        assert tool_set.includes_name(tool_class.get_name_from_cls()) == is_exposed
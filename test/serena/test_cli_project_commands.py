@@ -10,8 +10,8 @@ import pytest
 from click import Command, Option
 from click.testing import CliRunner
 
-from serena.cli import ProjectCommands, TopLevelCommands, find_project_root
-from serena.config.serena_config import ProjectConfig
+from serena.cli import ProjectCommands, TopLevelCommands, _is_configured_project, find_project_root
+from serena.config.serena_config import ProjectConfig, SerenaConfig
 
 pytestmark = pytest.mark.filterwarnings("ignore::UserWarning")
 
@@ -240,6 +240,31 @@ class TestProjectIndex:
             shutil.rmtree(dir2, ignore_errors=True)
 
 
+class TestProjectListAndRemove:
+    """Tests for 'project list' and 'project remove' commands."""
+
+    def test_list_and_remove(self, cli_runner, temp_project_dir):
+        project_name = f"test-project-{os.path.basename(temp_project_dir)}"
+        result = cli_runner.invoke(ProjectCommands.create, [temp_project_dir, "--name", project_name, "--language", "python"])
+        assert result.exit_code == 0, f"Command failed: {result.output}"
+
+        result = cli_runner.invoke(ProjectCommands.list)
+        assert result.exit_code == 0, f"Command failed: {result.output}"
+        assert f"{project_name}: {Path(temp_project_dir).resolve()} (language servers: python [ok])" in result.output
+
+        result = cli_runner.invoke(ProjectCommands.remove, [project_name])
+        assert result.exit_code == 0, f"Command failed: {result.output}"
+        result = cli_runner.invoke(ProjectCommands.list)
+        assert project_name not in result.output
+        # the project configuration is kept
+        assert os.path.exists(os.path.join(temp_project_dir, ".serena", "project.yml"))
+
+    def test_remove_unknown_project(self, cli_runner):
+        result = cli_runner.invoke(ProjectCommands.remove, ["non-existent-project-name"])
+        assert result.exit_code != 0
+        assert "not found" in result.output
+
+
 class TestProjectCreateHelper:
     """Tests for _create_project helper method."""
 
@@ -337,6 +362,28 @@ class TestFindProjectRoot:
         finally:
             os.chdir(original_cwd)
 
+    def test_start_directory(self, temp_project_dir):
+        """Test that the search can start from a given directory (e.g. a module subdirectory) instead of the CWD."""
+        os.makedirs(os.path.join(temp_project_dir, ".git"))
+        module_dir = os.path.join(temp_project_dir, "modules", "network")
+        os.makedirs(module_dir)
+
+        result = find_project_root(root=temp_project_dir, start=module_dir)
+        assert result is not None
+        assert os.path.samefile(result, temp_project_dir)
+
+    def test_subdirectory_with_own_project_configuration_is_not_resolved(self, temp_project_dir):
+        """Test that a subdirectory which is a project in its own right is not replaced by the enclosing project's root."""
+        os.makedirs(os.path.join(temp_project_dir, ".git"))
+        module_dir = os.path.join(temp_project_dir, "modules", "network")
+        os.makedirs(os.path.join(module_dir, ".serena"))
+        serena_config = SerenaConfig(gui_log_window=False, web_dashboard=False)
+        assert not _is_configured_project(serena_config, os.path.dirname(module_dir))
+
+        with open(os.path.join(module_dir, ".serena", "project.yml"), "w") as f:
+            f.write("project_name: network\n")
+        assert _is_configured_project(serena_config, module_dir)
+
     def test_git_worktree_not_hijacked_by_ancestor_serena(self, temp_project_dir):
         """A git worktree nested under a Serena project must resolve to the worktree.
 
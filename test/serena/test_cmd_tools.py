@@ -0,0 +1,118 @@
+from pathlib import Path
+from types import SimpleNamespace
+from unittest.mock import MagicMock
+
+import pytest
+
+from serena.tools import cmd_tools
+from serena.tools.cmd_tools import CheckPoliciesTool, RunPreCommitTool, RunTerragruntTool
+from serena.util.shell import ShellCommandResult
+
+
+@pytest.fixture
+def executed_commands(monkeypatch: pytest.MonkeyPatch) -> list[tuple[list[str], str, dict[str, str]]]:
+    executed_commands: list[tuple[list[str], str, dict[str, str]]] = []
+
+    def execute_command(args: list[str], cwd: str, capture_stderr: bool, env: dict[str, str]) -> ShellCommandResult:
+        executed_commands.append((args, cwd, env))
+        return ShellCommandResult(stdout="[]", stderr="", return_code=0, cwd=cwd)
+
+    monkeypatch.setattr(cmd_tools, "execute_command", execute_command)
+    monkeypatch.setenv("AWS_SECRET_ACCESS_KEY", "secret")
+    return executed_commands
+
+
+def _create_agent(project_root: Path) -> MagicMock:
+    agent = MagicMock()
+    agent.get_active_project_or_raise.return_value = SimpleNamespace(project_root=str(project_root))
+    agent.serena_config = SimpleNamespace(shell_env_allowed_patterns=[], shell_env_denied_patterns=["AWS_*"])
+    return agent
+
+
+class TestCheckPoliciesTool:
+    @pytest.fixture
+    def tool(self, tmp_path: Path) -> CheckPoliciesTool:
+        (tmp_path / "policy").mkdir()
+        tool = CheckPoliciesTool(_create_agent(tmp_path))
+        tool._limit_length = lambda result, max_answer_chars: result
+        return tool
+
+    def test_paths_are_separated_from_options(self, tool: CheckPoliciesTool, executed_commands: list) -> None:
+        tool.apply(["main.tf", "--update=https://example.com/policies"])
+        assert executed_commands[0][0][-3:] == ["--", "main.tf", "--update=https://example.com/policies"]
+
+    @pytest.mark.parametrize("relative_paths, policy_dir", [(["../main.tf"], ""), (["/etc/passwd"], ""), (["main.tf"], "../policy")])
+    def test_paths_outside_of_project_are_rejected(
+        self, tool: CheckPoliciesTool, executed_commands: list, relative_paths: list[str], policy_dir: str
+    ) -> None:
+        with pytest.raises(ValueError, match="outside of the project root"):
+            tool.apply(relative_paths, policy_dir=policy_dir)
+        assert executed_commands == []
+
+
+class TestRunPreCommitTool:
+    @pytest.fixture
+    def tool(self, tmp_path: Path) -> RunPreCommitTool:
+        (tmp_path / ".pre-commit-config.yaml").write_text("repos: []\n", encoding="utf-8")
+        agent = _create_agent(tmp_path)
+        project = agent.get_active_project_or_raise.return_value
+        project.validate_relative_path = MagicMock()
+        return RunPreCommitTool(agent)
+
+    def test_option_like_paths_are_rejected(self, tool: RunPreCommitTool, executed_commands: list) -> None:
+        with pytest.raises(ValueError, match="must not start with '-'"):
+            tool.apply(relative_paths=["main.tf", "--config=/tmp/x.yaml"])
+        assert executed_commands == []
+
+    def test_paths_are_validated(self, tool: RunPreCommitTool, executed_commands: list) -> None:
+        tool.project.validate_relative_path.side_effect = ValueError("points outside the project root")  # type: ignore[attr-defined]
+        with pytest.raises(ValueError, match="outside the project root"):
+            tool.apply(relative_paths=["../main.tf"])
+        tool.project.validate_relative_path.assert_called_once_with("../main.tf")  # type: ignore[attr-defined]
+        assert executed_commands == []
+
+
+class TestRunTerragruntTool:
+    @pytest.fixture
+    def tool(self, tmp_path: Path) -> RunTerragruntTool:
+        (tmp_path / "live" / "prod").mkdir(parents=True)
+        tool = RunTerragruntTool(_create_agent(tmp_path))
+        tool._limit_length = lambda result, max_answer_chars: result
+        return tool
+
+    def test_command_is_run_non_interactively_in_unit_directory(
+        self, tool: RunTerragruntTool, executed_commands: list, tmp_path: Path
+    ) -> None:
+        tool.apply("plan", relative_path="live/prod", args=["-var-file=prod.tfvars; rm -rf /"])
+        assert len(executed_commands) == 1
+        args, cwd, env = executed_commands[0]
+        # the arguments are passed as they are (without a shell interpreting them)
+        assert args == ["terragrunt", "plan", "-var-file=prod.tfvars; rm -rf /"]
+        assert cwd == str(tmp_path / "live" / "prod")
+        assert env["TG_NON_INTERACTIVE"] == "true"
+        assert "AWS_SECRET_ACCESS_KEY" not in env
+
+    def test_run_all(self, tool: RunTerragruntTool, executed_commands: list) -> None:
+        tool.apply("validate", relative_path="live", all_units=True)
+        assert executed_commands[0][0] == ["terragrunt", "run-all", "validate"]
+
+    @pytest.mark.parametrize("command", ["apply", "destroy", "plan && terragrunt apply"])
+    def test_modifying_commands_are_rejected(self, tool: RunTerragruntTool, executed_commands: list, command: str) -> None:
+        with pytest.raises(ValueError):
+            tool.apply(command, relative_path="live/prod")
+        assert executed_commands == []
+
+    @pytest.mark.parametrize(
+        "args", [["-out=/tmp/plan"], ["--out=../x"], ["-var-file=prod.tfvars", "--terragrunt-tfpath=/tmp/terraform"]]
+    )
+    def test_output_and_terragrunt_args_are_rejected(self, tool: RunTerragruntTool, executed_commands: list, args: list[str]) -> None:
+        with pytest.raises(ValueError, match="Unsupported arguments"):
+            tool.apply("plan", relative_path="live/prod", args=args)
+        assert executed_commands == []
+
+    def test_directory_outside_of_project_is_rejected(self, tool: RunTerragruntTool, executed_commands: list) -> None:
+        with pytest.raises(ValueError):
+            tool.apply("plan", relative_path="..")
+        with pytest.raises(FileNotFoundError):
+            tool.apply("plan", relative_path="live/staging")
+        assert executed_commands == []
@@ -0,0 +1,46 @@
+import pytest
+
+from serena.code_editor import CodeEditor
+
+RESOURCE = 'resource "aws_instance" "web" {\n  ami = "ami-123"\n}\n'
+
+
+@pytest.mark.parametrize(
+    "body, indentation, expected_body",
+    [
+        pytest.param(RESOURCE, "", RESOURCE, id="unchanged"),
+        pytest.param(
+            "\n" + RESOURCE,
+            "  ",
+            '\n  resource "aws_instance" "web" {\n    ami = "ami-123"\n  }\n',
+            id="indented",
+        ),
+        pytest.param(
+            '    ingress {\n      from_port = 443\n\n    }\n',
+            "  ",
+            '  ingress {\n    from_port = 443\n\n  }\n',
+            id="dedented",
+        ),
+        pytest.param(
+            "def f(self):\n        return 1\n",
+            "    ",
+            "    def f(self):\n        return 1\n",
+            id="absolutely indented",
+        ),
+        pytest.param(
+            "def f(self):\n    return 1",
+            "    ",
+            "    def f(self):\n        return 1",
+            id="relatively indented",
+        ),
+        pytest.param(
+            'resource "aws_instance" "web" {\n    ami = "ami-123"\n  }\n',
+            "  ",
+            '  resource "aws_instance" "web" {\n    ami = "ami-123"\n  }\n',
+            id="absolutely indented with closing line",
+        ),
+        pytest.param("\n\n", "  ", "\n\n", id="blank"),
+    ],
+)
+def test_indent_like_anchor(body: str, indentation: str, expected_body: str) -> None:
+    assert CodeEditor._indent_like_anchor(body, indentation) == expected_body
@@ -0,0 +1,38 @@
+import sys
+
+import pytest
+
+from serena import doctor
+from serena.config.serena_config import SerenaConfig
+from serena.doctor import CheckStatus, Doctor, ExternalCommand
+from solidlsp.ls_config import LanguageServerId
+
+
+def test_missing_project_configuration(tmp_path) -> None:
+    serena_config = SerenaConfig().with_headless_mode_overrides()
+    result, project = Doctor._check_project(serena_config, str(tmp_path))
+    assert result.status == CheckStatus.ERROR
+    assert project is None
+    assert result.hint is not None and "serena project create" in result.hint
+
+
+class TestExternalCommands:
+    @pytest.fixture
+    def external_command(self, monkeypatch: pytest.MonkeyPatch) -> ExternalCommand:
+        command = ExternalCommand(name="python-for-doctor-test", version_args=["--version"], hint="Install it")
+        monkeypatch.setitem(doctor.EXTERNAL_COMMANDS, LanguageServerId.TERRAFORM, [command])
+        return command
+
+    def test_command_not_found(self, external_command: ExternalCommand, monkeypatch: pytest.MonkeyPatch) -> None:
+        monkeypatch.setattr(doctor.shutil, "which", lambda name: None)
+        results = list(Doctor(None)._check_external_commands([LanguageServerId.TERRAFORM, LanguageServerId.PYTHON]))
+        assert len(results) == 1
+        assert results[0].status == CheckStatus.ERROR
+        assert results[0].hint == "Install it"
+
+    def test_command_version(self, external_command: ExternalCommand, monkeypatch: pytest.MonkeyPatch) -> None:
+        monkeypatch.setattr(doctor.shutil, "which", lambda name: sys.executable)
+        results = list(Doctor(None)._check_external_commands([LanguageServerId.TERRAFORM]))
+        assert len(results) == 1
+        assert results[0].status == CheckStatus.OK
+        assert "Python 3" in results[0].message
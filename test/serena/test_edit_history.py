@@ -0,0 +1,83 @@
+import pytest
+
+from serena.edit_history import EditHistory
+from solidlsp.lsp_protocol_handler.lsp_types import FileChangeType
+
+MAIN_TF = 'resource "aws_instance" "web" {\n  ami = "ami-123"\n}\n'
+
+
+def _create_edit_history(tmp_path) -> EditHistory:
+    (tmp_path / "main.tf").write_text(MAIN_TF, encoding="utf-8")
+    return EditHistory(str(tmp_path / ".serena" / "history"), str(tmp_path), "utf-8")
+
+
+def _edit(tmp_path, edit_history: EditHistory, relative_path: str, after: str | None) -> None:
+    """Performs a mutation of a file and records it"""
+    path = tmp_path / relative_path
+    before = path.read_text(encoding="utf-8") if path.exists() else None
+    if after is None:
+        path.unlink()
+    else:
+        path.write_text(after, encoding="utf-8")
+    edit_history.record_mutation(relative_path, before, after)
+
+
+def test_undo_restores_all_files_of_transaction(tmp_path) -> None:
+    edit_history = _create_edit_history(tmp_path)
+    with edit_history.transaction("rename_symbol"):
+        _edit(tmp_path, edit_history, "main.tf", MAIN_TF.replace("web", "app"))
+        _edit(tmp_path, edit_history, "main.tf", MAIN_TF.replace("web", "api"))
+        _edit(tmp_path, edit_history, "outputs.tf", 'output "id" {}\n')
+    _edit(tmp_path, edit_history, "main.tf", MAIN_TF.replace("web", "api").replace("123", "456"))
+
+    transactions = edit_history.get_transactions()
+    assert [transaction.tool_name for transaction in transactions] == ["rename_symbol", "<unknown>"]
+    # several mutations of the same file within a transaction are combined
+    assert [mutation.before for mutation in transactions[0].mutations] == [MAIN_TF, None]
+
+    edit_history.undo(transactions[1])
+    assert (tmp_path / "main.tf").read_text(encoding="utf-8") == MAIN_TF.replace("web", "api")
+    changes = edit_history.undo(transactions[0])
+    assert changes == [("outputs.tf", FileChangeType.Deleted), ("main.tf", FileChangeType.Changed)]
+    assert (tmp_path / "main.tf").read_text(encoding="utf-8") == MAIN_TF
+    assert not (tmp_path / "outputs.tf").exists()
+    assert edit_history.get_transactions() == []
+
+
+def test_undo_is_refused_if_file_was_changed_since(tmp_path) -> None:
+    edit_history = _create_edit_history(tmp_path)
+    _edit(tmp_path, edit_history, "main.tf", MAIN_TF.replace("web", "app"))
+    (tmp_path / "main.tf").write_text("changed by other means\n", encoding="utf-8")
+    with pytest.raises(ValueError):
+        edit_history.undo(edit_history.get_transactions()[-1])
+    assert (tmp_path / "main.tf").read_text(encoding="utf-8") == "changed by other means\n"
+    assert len(edit_history.get_transactions()) == 1
+
+
+def test_transactions_of_other_sessions_are_distinguished(tmp_path) -> None:
+    previous_edit_history = _create_edit_history(tmp_path)
+    _edit(tmp_path, previous_edit_history, "main.tf", MAIN_TF.replace("web", "app"))
+    edit_history = EditHistory(str(tmp_path / ".serena" / "history"), str(tmp_path), "utf-8")
+    with edit_history.transaction("delete_symbol"):
+        _edit(tmp_path, edit_history, "main.tf", None)
+    with edit_history.transaction("find_symbol"):
+        pass  # transactions without mutations are not recorded
+
+    assert len(edit_history.get_transactions()) == 2
+    session_transactions = edit_history.get_transactions(current_session_only=True)
+    assert [transaction.tool_name for transaction in session_transactions] == ["delete_symbol"]
+    assert edit_history.undo(session_transactions[0]) == [("main.tf", FileChangeType.Created)]
+    assert (tmp_path / "main.tf").read_text(encoding="utf-8") == MAIN_TF.replace("web", "app")
+
+
+def test_transaction_provides_mutated_files(tmp_path) -> None:
+    edit_history = _create_edit_history(tmp_path)
+    with edit_history.transaction("replace_content") as transaction:
+        with edit_history.transaction("nested") as nested_transaction:
+            # nested contexts are merged into the outermost transaction
+            assert nested_transaction is transaction
+            _edit(tmp_path, edit_history, "outputs.tf", 'output "id" {}\n')
+        # mutations which cancel each other out are not recorded
+        _edit(tmp_path, edit_history, "main.tf", MAIN_TF.replace("web", "app"))
+        _edit(tmp_path, edit_history, "main.tf", MAIN_TF)
+    assert transaction.get_relative_paths() == ["outputs.tf"]
@@ -0,0 +1,41 @@
+from test.conftest import InMemoryCodeEditor
+
+MAIN_TF = 'resource "aws_instance" "web" {\n  ami           = "ami-123"\n  instance_type = "t2.micro"\n}\n'
+
+
+def test_edits_in_preview_mode_are_reverted_and_previewed() -> None:
+    code_editor = InMemoryCodeEditor({"main.tf": MAIN_TF})
+    with code_editor.preview_mode() as previews:
+        code_editor.replace_lines("main.tf", 2, 2, '  instance_type = "t3.micro"\n')
+        assert previews == []
+    assert code_editor.files == {"main.tf": MAIN_TF}
+    assert code_editor.num_saves == 0
+
+    assert len(previews) == 1
+    assert previews[0].relative_path == "main.tf"
+    assert previews[0].num_locations == 1
+    assert previews[0].description.splitlines()[2:] == [
+        "@@ -2,3 +2,3 @@",
+        '   ami           = "ami-123"',
+        '-  instance_type = "t2.micro"',
+        '+  instance_type = "t3.micro"',
+        " }",
+    ]
+
+
+def test_successive_edits_are_previewed_cumulatively() -> None:
+    code_editor = InMemoryCodeEditor({"main.tf": MAIN_TF})
+    with code_editor.preview_mode() as previews:
+        code_editor.insert_at_line("main.tf", 0, "# instances\n")
+        code_editor.delete_lines("main.tf", 2, 2)
+    assert code_editor.files == {"main.tf": MAIN_TF}
+    assert [preview.relative_path for preview in previews] == ["main.tf"]
+    assert "+# instances" in previews[0].description
+    assert '-  ami           = "ami-123"' in previews[0].description
+
+
+def test_edits_outside_of_preview_mode_are_saved() -> None:
+    code_editor = InMemoryCodeEditor({"main.tf": MAIN_TF})
+    code_editor.delete_lines("main.tf", 1, 1)
+    assert code_editor.files == {"main.tf": MAIN_TF.replace('  ami           = "ami-123"\n', "")}
+    assert code_editor.num_saves == 1
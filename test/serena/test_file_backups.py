@@ -0,0 +1,40 @@
+import os
+
+import pytest
+
+from serena.file_backups import FileBackups
+
+
+def test_backups_are_retained_up_to_limit(tmp_path) -> None:
+    (tmp_path / "modules" / "vpc").mkdir(parents=True)
+    main_tf = tmp_path / "modules" / "vpc" / "main.tf"
+    file_backups = FileBackups(str(tmp_path / ".serena" / "backups"), str(tmp_path), retention=2)
+
+    for i in range(3):
+        main_tf.write_text(f"# version {i}\n", encoding="utf-8")
+        backup_path = file_backups.backup(os.path.join("modules", "vpc", "main.tf"))
+        assert backup_path is not None
+        with open(backup_path, encoding="utf-8") as f:
+            assert f.read() == f"# version {i}\n"
+
+    snapshot_dirs = file_backups.get_snapshot_dirs()
+    assert len(snapshot_dirs) == 2
+    assert os.path.dirname(os.path.dirname(os.path.dirname(backup_path))) == snapshot_dirs[-1]
+
+
+def test_no_backups_if_disabled_or_file_missing(tmp_path) -> None:
+    (tmp_path / "main.tf").write_text("# main\n", encoding="utf-8")
+    assert FileBackups(str(tmp_path / "backups"), str(tmp_path), retention=0).backup("main.tf") is None
+    file_backups = FileBackups(str(tmp_path / "backups"), str(tmp_path), retention=5)
+    assert file_backups.backup("variables.tf") is None
+    assert file_backups.get_snapshot_dirs() == []
+
+
+@pytest.mark.parametrize("relative_path", ["../outside.tf", os.path.join("modules", "..", "..", "outside.tf")])
+def test_paths_outside_of_project_are_rejected(tmp_path, relative_path: str) -> None:
+    (tmp_path / "project").mkdir()
+    (tmp_path / "outside.tf").write_text("# outside\n", encoding="utf-8")
+    file_backups = FileBackups(str(tmp_path / "project" / ".serena" / "backups"), str(tmp_path / "project"), retention=5)
+    with pytest.raises(ValueError):
+        file_backups.backup(relative_path)
+    assert file_backups.get_snapshot_dirs() == []
@@ -1,13 +1,17 @@
+import json
+import os
 from pathlib import Path
 from unittest.mock import MagicMock
 
 import pytest
 
+from serena.code_editor import CodeEditor, JetBrainsCodeEditor
 from serena.config.serena_config import SerenaConfig
 from serena.constants import DEFAULT_SOURCE_FILE_ENCODING
 from serena.project import Project
-from serena.tools import ReadFileTool
+from serena.tools import CreateDirectoryTool, DeleteFileTool, FindReferencingCodeSnippetsTool, MoveFileTool, ReadFileTool
 from solidlsp.ls_utils import TextUtils
+from solidlsp.lsp_protocol_handler.lsp_types import FileChangeType
 
 
 @pytest.fixture
@@ -75,3 +79,252 @@ class TestReadFileToolLineNumbering:
         (tmp_path / "file.txt").write_text(content, newline="", encoding=DEFAULT_SOURCE_FILE_ENCODING)
 
         assert read_file_tool.apply("file.txt") == "\n".join(TextUtils.split_lines(content))
+
+
+@pytest.mark.parametrize(
+    ("contents", "line", "allow_end_of_file", "expected"),
+    [
+        ("a\nb\nc\n", 1, False, 1),
+        ("a\nb\nc\n", -1, False, 2),
+        ("a\nb\nc", -1, False, 2),
+        ("a\nb\nc\n", -3, False, 0),
+        ("a\nb\nc\n", -1, True, 3),
+        ("a\nb\nc", -1, True, 3),
+        ("a\nb\nc\n", -2, True, 2),
+        ("", -1, True, 0),
+    ],
+)
+def test_resolve_line_index(contents: str, line: int, allow_end_of_file: bool, expected: int) -> None:
+    assert CodeEditor.resolve_line_index(contents, line, allow_end_of_file=allow_end_of_file) == expected
+
+
+def test_resolve_line_index_out_of_range() -> None:
+    with pytest.raises(ValueError, match="out of range"):
+        CodeEditor.resolve_line_index("a\nb\n", -3)
+
+
+@pytest.mark.parametrize(
+    ("pattern", "occurrence_index", "expected"),
+    [
+        (r"^resource ", 0, 0),
+        (r"^resource ", 1, 4),
+        (r"^resource ", -1, 4),
+        (r"ami\s*=", 0, 1),
+    ],
+)
+def test_find_matching_line(pattern: str, occurrence_index: int, expected: int) -> None:
+    contents = 'resource "aws_instance" "a" {\n  ami = "x"\n}\n\nresource "aws_instance" "b" {\n}\n'
+    assert CodeEditor.find_matching_line(contents, pattern, occurrence_index) == expected
+
+
+def test_find_matching_line_errors() -> None:
+    with pytest.raises(ValueError, match="No line matches"):
+        CodeEditor.find_matching_line("a\nb\n", "c")
+    with pytest.raises(ValueError, match="2 lines match"):
+        CodeEditor.find_matching_line("a\na\n", "a", occurrence_index=2)
+
+
+
+class FileCodeEditor(JetBrainsCodeEditor):
+    """A code editor which edits the files directly (without involving the JetBrains plugin)."""
+
+    def _save_edited_file(self, edited_file: CodeEditor.EditedFile) -> None:
+        CodeEditor._save_edited_file(self, edited_file)
+
+
+class TestDeleteLinesWithExpectedContent:
+    CONTENT = 'resource "a" "x" {\n  ami = "1"\n}\nresource "a" "y" {\n}\n'
+
+    @pytest.fixture
+    def code_editor(self, tmp_path: Path) -> CodeEditor:
+        (tmp_path / "main.tf").write_text(self.CONTENT, encoding="utf-8")
+        project = Project.load(str(tmp_path), serena_config=SerenaConfig(gui_log_window=False, web_dashboard=False))
+        return FileCodeEditor(project)
+
+    def test_deletes_if_content_matches(self, code_editor: CodeEditor, tmp_path: Path) -> None:
+        code_editor.delete_lines("main.tf", 0, 2, expected_first_line='resource "a" "x" {', expected_last_line="  }  ")
+        assert (tmp_path / "main.tf").read_text(encoding="utf-8") == 'resource "a" "y" {\n}\n'
+
+    def test_stale_indices_are_rejected(self, code_editor: CodeEditor, tmp_path: Path) -> None:
+        with pytest.raises(ValueError, match="does not have the expected content"):
+            code_editor.delete_lines("main.tf", 1, 3, expected_first_line='resource "a" "x" {')
+        with pytest.raises(ValueError, match="does not have the expected content"):
+            code_editor.delete_lines("main.tf", 3, -1, expected_first_line='resource "a" "y" {', expected_last_line="]")
+        assert (tmp_path / "main.tf").read_text(encoding="utf-8") == self.CONTENT
+
+
+class TestLineEndingPreservation:
+    @pytest.fixture
+    def code_editor(self, tmp_path: Path) -> CodeEditor:
+        (tmp_path / "crlf.tf").write_bytes(b'resource "a" "x" {\r\n  ami = "1"\r\n}\r\n')
+        (tmp_path / "no_final_newline.tf").write_bytes(b'resource "a" "x" {\n  ami = "1"\n}')
+        project = Project.load(str(tmp_path), serena_config=SerenaConfig(gui_log_window=False, web_dashboard=False))
+        return FileCodeEditor(project)
+
+    def test_crlf_and_final_newline_are_retained(self, code_editor: CodeEditor, tmp_path: Path) -> None:
+        code_editor.replace_lines("crlf.tf", 2, 2, "}")
+        assert (tmp_path / "crlf.tf").read_bytes() == b'resource "a" "x" {\r\n  ami = "1"\r\n}\r\n'
+        code_editor.insert_at_line("crlf.tf", 1, '  tags = {}\n')
+        assert (tmp_path / "crlf.tf").read_bytes() == b'resource "a" "x" {\r\n  tags = {}\r\n  ami = "1"\r\n}\r\n'
+
+    def test_missing_final_newline_is_retained(self, code_editor: CodeEditor, tmp_path: Path) -> None:
+        code_editor.delete_lines("no_final_newline.tf", 2, 2)
+        assert (tmp_path / "no_final_newline.tf").read_bytes() == b'resource "a" "x" {\n  ami = "1"'
+        code_editor.replace_lines("no_final_newline.tf", 1, 1, '  ami = "2"\n}\n')
+        assert (tmp_path / "no_final_newline.tf").read_bytes() == b'resource "a" "x" {\n  ami = "2"\n}'
+
+
+class TestFindReferencingCodeSnippetsTool:
+    @pytest.fixture
+    def tool(self, tmp_path: Path) -> FindReferencingCodeSnippetsTool:
+        project = Project.load(str(tmp_path), serena_config=SerenaConfig(gui_log_window=False, web_dashboard=False))
+        agent = MagicMock()
+        agent.get_active_project_or_raise.return_value = project
+        agent.get_context.return_value.default_output_format = "json"
+        tool = FindReferencingCodeSnippetsTool(agent)
+        tool._limit_length = lambda result, max_answer_chars, shortened_result_factories: result
+        return tool
+
+    def test_finds_whole_word_usages(self, tool: FindReferencingCodeSnippetsTool, tmp_path: Path) -> None:
+        (tmp_path / "locals.tf").write_text(
+            "locals {\n  env = \"prod\"\n  env-suffix = \"x\"\n  environment = local.env\n}\n",
+            encoding="utf-8",
+        )
+        (tmp_path / "main.tf").write_text('tags = { Env = local.env, env = local.env }\n', encoding="utf-8")
+
+        result = json.loads(tool.apply("env"))
+
+        assert result == {
+            "locals.tf": [{"line": 1, "snippet": 'env = "prod"'}, {"line": 3, "snippet": "environment = local.env"}],
+            "main.tf": [{"line": 0, "snippet": "tags = { Env = local.env, env = local.env }"}],
+        }
+
+    def test_context_lines(self, tool: FindReferencingCodeSnippetsTool, tmp_path: Path) -> None:
+        (tmp_path / "main.tf").write_text("a\nb = local.key\nc\n", encoding="utf-8")
+
+        result = json.loads(tool.apply("local.key", context_lines=1))
+
+        assert result == {"main.tf": [{"line": 1, "snippet": "...   0:a\n  >   1:b = local.key\n...   2:c"}]}
+
+        with pytest.raises(ValueError, match="context_lines must be non-negative"):
+            tool.apply("local.key", context_lines=-1)
+
+
+class TestMoveFileTool:
+    @pytest.fixture
+    def tool(self, tmp_path: Path) -> MoveFileTool:
+        (tmp_path / "modules" / "vpc").mkdir(parents=True)
+        (tmp_path / "modules" / "vpc" / "main.tf").write_text(
+            'resource "aws_vpc" "main" {}\n\nmodule "subnets" {\n  source = "./subnets"\n}\n', encoding="utf-8"
+        )
+        (tmp_path / "main.tf").write_text('module "vpc" {\n  source = "./modules/vpc"\n}\n', encoding="utf-8")
+        (tmp_path / ".gitignore").write_text("generated/\n", encoding="utf-8")
+        project = Project.load(str(tmp_path), serena_config=SerenaConfig(gui_log_window=False, web_dashboard=False))
+        agent = MagicMock()
+        agent.get_active_project_or_raise.return_value = project
+        return MoveFileTool(agent)
+
+    def test_move_reports_affected_module_sources(self, tool: MoveFileTool, tmp_path: Path) -> None:
+        result = tool.apply("modules/vpc/main.tf", "modules/network/main.tf")
+
+        assert not (tmp_path / "modules" / "vpc" / "main.tf").exists()
+        assert (tmp_path / "modules" / "network" / "main.tf").read_text(encoding="utf-8").startswith('resource "aws_vpc" "main"')
+        assert 'main.tf, line 1: module "vpc" (source = "./modules/vpc")' in result
+        assert 'line 3: module "subnets" (source = "./subnets", should become "../vpc/subnets")' in result
+        # the move is undoable
+        transaction = tool.project.edit_history.get_transactions()[-1]
+        assert sorted(transaction.get_relative_paths()) == [
+            os.path.join("modules", "network", "main.tf"),
+            os.path.join("modules", "vpc", "main.tf"),
+        ]
+
+    def test_rename_within_directory_does_not_affect_module_sources(self, tool: MoveFileTool, tmp_path: Path) -> None:
+        result = tool.apply("modules/vpc/main.tf", "modules/vpc/network.tf")
+
+        assert result == "Moved modules/vpc/main.tf to modules/vpc/network.tf."
+        assert (tmp_path / "modules" / "vpc" / "network.tf").exists()
+
+    def test_existing_destination_is_rejected(self, tool: MoveFileTool, tmp_path: Path) -> None:
+        with pytest.raises(ValueError):
+            tool.apply("modules/vpc/main.tf", "main.tf")
+        assert (tmp_path / "modules" / "vpc" / "main.tf").exists()
+
+    @pytest.mark.parametrize("new_relative_path", ["generated/main.tf", ".git/main.tf", "../main.tf"])
+    def test_ignored_or_external_destination_is_rejected(self, tool: MoveFileTool, tmp_path: Path, new_relative_path: str) -> None:
+        with pytest.raises(ValueError):
+            tool.apply("modules/vpc/main.tf", new_relative_path)
+        assert (tmp_path / "modules" / "vpc" / "main.tf").exists()
+        assert not (tmp_path / new_relative_path).exists()
+
+    def test_move_binary_file(self, tool: MoveFileTool, tmp_path: Path) -> None:
+        (tmp_path / "plan.tfplan").write_bytes(b"PK\x03\x04\x00\xff\xfe")
+        num_transactions = len(tool.project.edit_history.get_transactions())
+
+        result = tool.apply("plan.tfplan", "plans/plan.tfplan")
+
+        assert result == "Moved plan.tfplan to plans/plan.tfplan. As it is not a text file, the move cannot be reverted via undo_last_edit."
+        assert (tmp_path / "plans" / "plan.tfplan").read_bytes() == b"PK\x03\x04\x00\xff\xfe"
+        # no mutation is recorded, as the edit history cannot restore the file's contents
+        assert len(tool.project.edit_history.get_transactions()) == num_transactions
+
+
+class TestDeleteFileAndCreateDirectoryTools:
+    @pytest.fixture
+    def agent(self, tmp_path: Path) -> MagicMock:
+        (tmp_path / ".gitignore").write_text("generated/\n", encoding="utf-8")
+        (tmp_path / "generated").mkdir()
+        (tmp_path / "generated" / "main.tf").write_text("", encoding="utf-8")
+        (tmp_path / "main.tf").write_text('resource "aws_vpc" "main" {}\n', encoding="utf-8")
+        project = Project.load(str(tmp_path), serena_config=SerenaConfig(gui_log_window=False, web_dashboard=False))
+        agent = MagicMock()
+        agent.get_active_project_or_raise.return_value = project
+        return agent
+
+    def test_delete_file_is_undoable(self, agent: MagicMock, tmp_path: Path) -> None:
+        tool = DeleteFileTool(agent)
+        assert tool.apply("main.tf") == "File deleted: main.tf."
+        assert not (tmp_path / "main.tf").exists()
+
+        transaction = tool.project.edit_history.get_transactions()[-1]
+        tool.project.edit_history.undo(transaction)
+        assert (tmp_path / "main.tf").read_text(encoding="utf-8") == 'resource "aws_vpc" "main" {}\n'
+
+    def test_delete_binary_file(self, agent: MagicMock, tmp_path: Path) -> None:
+        tool = DeleteFileTool(agent)
+        (tmp_path / "plan.tfplan").write_bytes(b"PK\x03\x04\x00\xff\xfe")
+        num_transactions = len(tool.project.edit_history.get_transactions())
+        assert tool.apply("plan.tfplan") == (
+            "File deleted: plan.tfplan. As it is not a text file, the deletion cannot be reverted via undo_last_edit."
+        )
+        assert not (tmp_path / "plan.tfplan").exists()
+        # no mutation is recorded, as the edit history cannot restore the file's contents
+        assert len(tool.project.edit_history.get_transactions()) == num_transactions
+
+    def test_delete_file_rejects_ignored_and_missing_files(self, agent: MagicMock, tmp_path: Path) -> None:
+        tool = DeleteFileTool(agent)
+        with pytest.raises(ValueError):
+            tool.apply("generated/main.tf")
+        assert (tmp_path / "generated" / "main.tf").exists()
+        with pytest.raises(FileNotFoundError):
+            tool.apply("outputs.tf")
+
+    def test_create_directory(self, agent: MagicMock, tmp_path: Path) -> None:
+        tool = CreateDirectoryTool(agent)
+        assert tool.apply("modules/vpc") == "Directory created: modules/vpc."
+        assert (tmp_path / "modules" / "vpc").is_dir()
+        assert tool.apply("modules/vpc") == "Directory already exists: modules/vpc."
+        with pytest.raises(ValueError):
+            tool.apply("main.tf")
+
+    def test_changed_gitignore_is_respected(self, agent: MagicMock, tmp_path: Path) -> None:
+        project = agent.get_active_project_or_raise.return_value
+        (tmp_path / ".gitignore").write_text("generated/\nbuild/\n", encoding="utf-8")
+        project.notify_file_changes([(".gitignore", FileChangeType.Changed)])
+        with pytest.raises(ValueError):
+            CreateDirectoryTool(agent).apply("build")
+
+    @pytest.mark.parametrize("relative_path", ["generated/sub", "../outside", ".git/hooks"])
+    def test_create_directory_rejects_ignored_and_external_paths(self, agent: MagicMock, tmp_path: Path, relative_path: str) -> None:
+        with pytest.raises(ValueError):
+            CreateDirectoryTool(agent).apply(relative_path)
+        assert not (tmp_path / relative_path).exists()
@@ -0,0 +1,63 @@
+import json
+from unittest.mock import MagicMock
+
+import pytest
+
+from serena.tools import GetCompletionsTool
+from solidlsp.ls_types import CompletionItemKind
+
+MAIN_TF = """resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+
+resource "aws_s3_bucket" "assets" {
+  bucket = "assets"
+}
+"""
+
+
+class TestGetCompletionsTool:
+    @pytest.fixture
+    def language_server(self) -> MagicMock:
+        language_server = MagicMock()
+        language_server.request_completions.return_value = [
+            {"completionText": "tags", "kind": CompletionItemKind.Property},
+            {"completionText": "bucket", "kind": CompletionItemKind.Property, "detail": "Optional, string"},
+            {"completionText": "lifecycle", "kind": CompletionItemKind.Class},
+        ]
+        return language_server
+
+    @pytest.fixture
+    def tool(self, language_server: MagicMock) -> GetCompletionsTool:
+        symbol_retriever = MagicMock()
+        symbol_retriever.get_language_server.return_value = language_server
+        symbol_retriever.find_unique.return_value.get_body_line_numbers_or_raise.return_value = (4, 6)
+        code_editor = MagicMock()
+        code_editor.read_file.side_effect = lambda relative_path, lines=None: (
+            MAIN_TF if lines is None else "\n".join(MAIN_TF.splitlines()[lines[0] : lines[1] + 1])
+        )
+
+        tool = GetCompletionsTool(MagicMock())
+        tool.create_language_server_symbol_retriever = lambda: symbol_retriever  # type: ignore[method-assign]
+        tool.create_code_editor = lambda: code_editor  # type: ignore[method-assign]
+        tool._limit_length = lambda result, max_answer_chars: result  # type: ignore[method-assign]
+        return tool
+
+    def test_completions_are_sorted_and_formatted(self, tool: GetCompletionsTool, language_server: MagicMock) -> None:
+        result = json.loads(tool.apply("main.tf", regex=r'"logs" \{\n()'))
+        language_server.request_completions.assert_called_once_with("main.tf", 1, 0, allow_incomplete=True)
+        assert result == [
+            {"text": "bucket", "kind": "Property", "detail": "Optional, string"},
+            {"text": "lifecycle", "kind": "Class"},
+            {"text": "tags", "kind": "Property"},
+        ]
+
+    def test_location_within_containing_symbol(self, tool: GetCompletionsTool, language_server: MagicMock) -> None:
+        tool.apply("main.tf", regex=r"bucket = ()", containing_symbol_name_path='resource "aws_s3_bucket" "assets"')
+        # the location within the symbol's body is translated to the location within the file
+        language_server.request_completions.assert_called_once_with("main.tf", 5, 11, allow_incomplete=True)
+
+    def test_ambiguous_location_is_rejected(self, tool: GetCompletionsTool, language_server: MagicMock) -> None:
+        with pytest.raises(ValueError):
+            tool.apply("main.tf", regex=r"bucket = ()")
+        language_server.request_completions.assert_not_called()
@@ -0,0 +1,178 @@
+import os
+from pathlib import Path
+from types import SimpleNamespace
+
+import pytest
+
+from serena import ls_manager
+from serena.ls_manager import LanguageServerFileChangeNotifier, LanguageServerManager
+from solidlsp.ls_config import LanguageServerId
+from solidlsp.lsp_protocol_handler.lsp_types import FileChangeType
+
+
+class _FakeLanguageServer:
+    def __init__(self, ls_id: LanguageServerId, file_suffix: str, provides_symbols: bool = True) -> None:
+        self.ls_id = ls_id
+        self._file_suffix = file_suffix
+        self._provides_symbols = provides_symbols
+        self.running = True
+        self.adopted_from: _FakeLanguageServer | None = None
+
+    def provides_symbols(self) -> bool:
+        return self._provides_symbols
+
+    def is_ignored_path(self, relative_path: str, ignore_unsupported_files: bool = True) -> bool:
+        return not relative_path.endswith(self._file_suffix)
+
+    def is_running(self) -> bool:
+        return self.running
+
+    def start(self) -> None:
+        self.running = True
+
+    def stop(self, shutdown_timeout: float = 2.0) -> None:
+        self.running = False
+
+    def adopt_open_file_buffers(self, previous_language_server: "_FakeLanguageServer") -> None:
+        self.adopted_from = previous_language_server
+
+
+class _FlakyLanguageServerFactory:
+    """Creates fake language servers, failing for the given number of attempts first"""
+
+    def __init__(self, num_failures: int) -> None:
+        self.num_failures = num_failures
+        self.num_attempts = 0
+
+    def create_language_server(self, ls_id: LanguageServerId) -> _FakeLanguageServer:
+        self.num_attempts += 1
+        if self.num_attempts <= self.num_failures:
+            raise RuntimeError("failed to start")
+        return _FakeLanguageServer(ls_id, ".tf")
+
+
+@pytest.fixture(autouse=True)
+def _no_file_change_notifier(monkeypatch: pytest.MonkeyPatch) -> None:
+    monkeypatch.setattr(ls_manager, "LanguageServerFileChangeNotifier", lambda project, manager: None)
+
+
+@pytest.fixture
+def manager() -> LanguageServerManager:
+    language_servers = [
+        _FakeLanguageServer(LanguageServerId.TFLINT, ".tf", provides_symbols=False),
+        _FakeLanguageServer(LanguageServerId.TERRAFORM, ".tf"),
+        _FakeLanguageServer(LanguageServerId.ANSIBLE, ".yml"),
+    ]
+    return LanguageServerManager({ls.ls_id: ls for ls in language_servers}, None, None)  # type: ignore
+
+
+class TestLanguageServerDispatch:
+    def test_symbol_requests_use_server_providing_symbols(self, manager: LanguageServerManager) -> None:
+        assert manager.get_language_server("main.tf").ls_id == LanguageServerId.TERRAFORM
+        assert manager.get_language_server("playbooks/site.yml").ls_id == LanguageServerId.ANSIBLE
+        assert manager.get_language_server("README.md").ls_id == LanguageServerId.TERRAFORM
+
+    def test_all_servers_for_file(self, manager: LanguageServerManager) -> None:
+        assert [ls.ls_id for ls in manager.get_language_servers("main.tf")] == [LanguageServerId.TERRAFORM, LanguageServerId.TFLINT]
+        assert [ls.ls_id for ls in manager.get_language_servers("site.yml")] == [LanguageServerId.ANSIBLE]
+
+    def test_iter_symbolic_language_servers(self, manager: LanguageServerManager) -> None:
+        assert len(list(manager.iter_language_servers())) == 3
+        assert [ls.ls_id for ls in manager.iter_language_servers(symbolic_only=True)] == [
+            LanguageServerId.TERRAFORM,
+            LanguageServerId.ANSIBLE,
+        ]
+
+
+class TestLanguageServerRestart:
+    @pytest.fixture(autouse=True)
+    def _no_real_sleep(self, monkeypatch: pytest.MonkeyPatch) -> None:
+        monkeypatch.setattr(ls_manager.time, "sleep", lambda _seconds: None)
+
+    def _create_manager(self, factory: _FlakyLanguageServerFactory) -> tuple[LanguageServerManager, _FakeLanguageServer]:
+        crashed_ls = _FakeLanguageServer(LanguageServerId.TERRAFORM, ".tf")
+        crashed_ls.running = False
+        return LanguageServerManager({crashed_ls.ls_id: crashed_ls}, factory, None), crashed_ls  # type: ignore
+
+    def test_crashed_server_is_restarted_on_access(self) -> None:
+        factory = _FlakyLanguageServerFactory(num_failures=2)
+        manager, crashed_ls = self._create_manager(factory)
+        restarted_ls = manager.get_language_server("main.tf")
+        assert factory.num_attempts == 3
+        assert restarted_ls is not crashed_ls and restarted_ls.is_running()
+        assert restarted_ls.adopted_from is crashed_ls  # type: ignore
+
+    def test_restart_gives_up_after_max_attempts(self) -> None:
+        factory = _FlakyLanguageServerFactory(num_failures=ls_manager._RESTART_MAX_ATTEMPTS)
+        manager, _ = self._create_manager(factory)
+        with pytest.raises(RuntimeError):
+            manager.restart_language_server(LanguageServerId.TERRAFORM)
+        assert factory.num_attempts == ls_manager._RESTART_MAX_ATTEMPTS
+
+    def test_hanging_server_is_stopped(self) -> None:
+        factory = _FlakyLanguageServerFactory(num_failures=0)
+        manager, previous_ls = self._create_manager(factory)
+        previous_ls.running = True
+        manager.restart_language_server(LanguageServerId.TERRAFORM)
+        assert not previous_ls.is_running()
+
+
+class _FakeProject:
+    def __init__(self, project_root: str) -> None:
+        self.project_root = project_root
+
+    def gather_source_files(self) -> list[str]:
+        return sorted(f for f in os.listdir(self.project_root) if f.endswith(".tf"))
+
+    def is_ignored_path(self, path: str, ignore_non_source_files: bool = False) -> bool:
+        return ignore_non_source_files and not path.endswith(".tf")
+
+
+class _NotifiedLanguageServer(_FakeLanguageServer):
+    def __init__(self) -> None:
+        super().__init__(LanguageServerId.TERRAFORM, ".tf")
+        self.notified_changes: list[list[dict]] = []
+        self.invalidated_symbol_cache_paths: list[str] = []
+        self.server = SimpleNamespace(
+            notify=SimpleNamespace(did_change_watched_files=lambda params: self.notified_changes.append(params["changes"]))
+        )
+
+    def clear_position_request_cache(self) -> None:
+        pass
+
+    def sync_kept_open_files(self, relative_paths: list[str]) -> None:
+        pass
+
+    def invalidate_symbol_cache(self, relative_file_path: str) -> None:
+        self.invalidated_symbol_cache_paths.append(relative_file_path)
+
+
+class TestFileChangeNotification:
+    def test_changes_made_by_serena_are_not_notified_again(self, tmp_path: Path) -> None:
+        (tmp_path / "main.tf").write_text("")
+        ls = _NotifiedLanguageServer()
+        manager = SimpleNamespace(iter_language_servers=lambda: iter([ls]))
+        notifier = LanguageServerFileChangeNotifier(_FakeProject(str(tmp_path)), manager)  # type: ignore
+
+        (tmp_path / "main.tf").write_text('variable "region" {}')
+        os.utime(tmp_path / "main.tf", (1e10, 1e10))
+        notifier.notify_file_changes([("main.tf", FileChangeType.Changed), ("README.md", FileChangeType.Changed)])
+        assert ls.notified_changes == [[{"uri": (tmp_path / "main.tf").resolve().as_uri(), "type": FileChangeType.Changed}]]
+        assert ls.invalidated_symbol_cache_paths == ["main.tf"]
+
+        # the poll considers the change to be known already
+        assert notifier.poll_and_notify() == 0
+
+    def test_files_restored_with_older_modification_time_are_notified(self, tmp_path: Path) -> None:
+        (tmp_path / "main.tf").write_text('variable "region" {}')
+        ls = _NotifiedLanguageServer()
+        manager = SimpleNamespace(iter_language_servers=lambda: iter([ls]))
+        notifier = LanguageServerFileChangeNotifier(_FakeProject(str(tmp_path)), manager)  # type: ignore
+
+        # e.g. an older version of the file extracted from an archive, which retains its modification time
+        (tmp_path / "main.tf").write_text("")
+        os.utime(tmp_path / "main.tf", (1e8, 1e8))
+        assert notifier.poll_and_notify() == 1
+        assert ls.notified_changes == [[{"uri": (tmp_path / "main.tf").resolve().as_uri(), "type": FileChangeType.Changed}]]
+        assert ls.invalidated_symbol_cache_paths == ["main.tf"]
+        assert notifier.poll_and_notify() == 0
@@ -1,11 +1,43 @@
 """Tests for the mcp.py module in serena."""
 
+import asyncio
+import logging
+import os
+import threading
+from collections.abc import Callable
+from pathlib import Path
+from types import SimpleNamespace
+from typing import Any
+
 import pytest
+from mcp.server.elicitation import AcceptedElicitation, DeclinedElicitation
+from mcp.server.fastmcp import FastMCP
+from mcp.server.fastmcp.exceptions import ToolError
 from mcp.server.fastmcp.tools.base import Tool as MCPTool
+from mcp.types import LATEST_PROTOCOL_VERSION, ToolAnnotations
+from mcp.types import Tool as MCPToolInfo
 
 from serena.agent import Tool, ToolRegistry
-from serena.config.context_mode import SerenaAgentContext
-from serena.mcp import SerenaMCPFactory
+from serena.approval import ApprovalPolicy, PendingOperationRegistry
+from serena.config.context_mode import SerenaAgentContext, SerenaAgentMode
+from serena.mcp import (
+    MCPLogForwarder,
+    MCPRootsProjectActivator,
+    MemoryResourceSubscriptions,
+    SerenaMCPFactory,
+    adapt_tools_to_protocol_version,
+    get_negotiated_protocol_version,
+    memory_name_from_resource_uri,
+    memory_resource_uri,
+)
+from serena.tools import (
+    ApprovePendingOperationTool,
+    ExecuteShellCommandTool,
+    ReadFileTool,
+    ReplaceContentTool,
+    StructuredToolResult,
+    ToolMarkerConcurrent,
+)
 
 make_tool = SerenaMCPFactory.make_mcp_tool
 
@@ -15,11 +47,15 @@ class MockAgent:
     def __init__(self):
         self.project_config = None
         self.serena_config = None
+        self.active_project = None
 
     @staticmethod
     def get_context() -> SerenaAgentContext:
         return SerenaAgentContext.load_default()
 
+    def get_active_project(self):  # type: ignore[no-untyped-def]
+        return self.active_project
+
 
 class BaseMockTool(Tool):
     """A mock Tool class for testing."""
@@ -76,11 +112,445 @@ def test_make_tool_execution() -> None:
     mcp_tool = make_tool(mock_tool)
 
     # Execute the MCP tool function
-    result = mcp_tool.fn(name="Alice", age=30)
+    result = asyncio.run(mcp_tool.fn(name="Alice", age=30))
 
     assert result == "Hello Alice, you are 30 years old!"
 
 
+def test_make_tool_project_parameter_defaults() -> None:
+    """Test that the active project's parameter defaults are exposed in the schema and apply to parameters which are not given."""
+    mock_tool = BasicTool()
+    tool_parameter_defaults = {"basic": {"age": 42}}
+    mock_tool.agent.active_project = SimpleNamespace(project_config=SimpleNamespace(tool_parameter_defaults=tool_parameter_defaults))
+    mcp_tool = make_tool(mock_tool)
+
+    assert mcp_tool.parameters["properties"]["age"]["default"] == 42
+    assert asyncio.run(mcp_tool.run({"name": "Alice"})) == "Hello Alice, you are 42 years old!"
+    # explicitly given values take precedence, even if they are equal to the original default
+    assert asyncio.run(mcp_tool.run({"name": "Alice", "age": 0})) == "Hello Alice, you are 0 years old!"
+
+
+class StructuredResultTool(BaseMockTool):
+    """A mock Tool class providing structured results."""
+
+    STRUCTURED_OUTPUT_SCHEMA = {"type": "array", "items": {"type": "string"}}
+
+    def apply(self, names: list[str], shorten: bool = False) -> str:
+        """Lists names.
+
+        :param names: the names
+        :param shorten: whether to return a shortened result (without structured data)
+        """
+        if shorten:
+            return f"{len(names)} names"
+        return StructuredToolResult(", ".join(names), names)
+
+    def apply_ex(self, log_call: bool = True, catch_exceptions: bool = True, **kwargs) -> str:  # type: ignore[no-untyped-def]
+        return self.apply(**kwargs)
+
+
+def test_make_tool_structured_output() -> None:
+    """Test that the structured data of tool results is provided as structured content."""
+    mcp_tool = make_tool(StructuredResultTool())
+
+    assert mcp_tool.output_schema is not None
+    assert mcp_tool.output_schema["properties"]["result"]["type"] == "string"
+    assert mcp_tool.output_schema["properties"]["data"]["items"] == {"type": "string"}
+
+    unstructured_content, structured_content = asyncio.run(mcp_tool.run({"names": ["a", "b"]}, convert_result=True))
+    assert unstructured_content[0].text == "a, b"
+    assert structured_content == {"result": "a, b", "data": ["a", "b"]}
+
+    _, structured_content = asyncio.run(mcp_tool.run({"names": ["a", "b"], "shorten": True}, convert_result=True))
+    assert structured_content == {"result": "2 names"}
+
+
+def test_make_tool_structured_output_disabled() -> None:
+    """Test that no output schema is provided if structured output is disabled."""
+    mcp_tool = make_tool(StructuredResultTool(), structured_output=False)
+
+    assert mcp_tool.output_schema is None
+
+
+def test_make_tool_rejects_unknown_parameters() -> None:
+    """Test that the MCP tool disallows additional properties and rejects calls with unrecognized parameters."""
+    mcp_tool = make_tool(BasicTool())
+
+    assert mcp_tool.parameters["additionalProperties"] is False
+    with pytest.raises(ToolError, match="Unrecognized parameter\\(s\\) for tool 'basic': nmae"):
+        asyncio.run(mcp_tool.run({"nmae": "Alice", "age": 30}))
+
+
+class ApprovalMockAgent(MockAgent):
+    @staticmethod
+    def get_approval_policy() -> ApprovalPolicy:
+        return ApprovalPolicy(tool_names=["approval_required"])
+
+    @staticmethod
+    def pop_exposed_tools_changed_flag() -> bool:
+        return False
+
+
+class ApprovalRequiredTool(Tool):
+    """A mock Tool class whose calls require approval."""
+
+    def __init__(self):
+        super().__init__(ApprovalMockAgent())
+
+    def apply(self, path: str) -> str:
+        """Deletes the given path.
+
+        :param path: the path to delete
+        """
+        return f"Deleted {path}"
+
+    def apply_ex(self, log_call: bool = True, catch_exceptions: bool = True, mcp_ctx=None, approved: bool = False, **kwargs) -> str:
+        """Mock implementation of apply_ex, reporting whether the call was approved."""
+        return f"approved={approved}"
+
+
+class MockElicitationContext:
+    """A mock MCP request context for a client which supports elicitation, answering with a fixed response."""
+
+    def __init__(self, approve: bool | None):
+        """
+        :param approve: the user's response; None if the user declines to respond
+        """
+        self._approve = approve
+        self.session = self
+
+    @staticmethod
+    def check_client_capability(capability) -> bool:
+        return True
+
+    async def elicit(self, message: str, schema):
+        if self._approve is None:
+            return DeclinedElicitation()
+        return AcceptedElicitation(data=schema(approve=self._approve))
+
+
+@pytest.mark.parametrize("approve", [True, False, None])
+def test_make_tool_elicits_approval(approve: bool | None) -> None:
+    """Test that calls requiring approval are confirmed by the user via elicitation if the client supports it."""
+    mcp_tool = make_tool(ApprovalRequiredTool())
+    context = MockElicitationContext(approve)
+
+    if approve:
+        assert asyncio.run(mcp_tool.run({"path": "main.tf"}, context)) == "approved=True"  # type: ignore
+    else:
+        with pytest.raises(ToolError, match="did not approve"):
+            asyncio.run(mcp_tool.run({"path": "main.tf"}, context))  # type: ignore
+
+
+class PendingOperationsMockAgent(ApprovalMockAgent):
+    def __init__(self):
+        super().__init__()
+        self.pending_operations = PendingOperationRegistry()
+
+    def get_pending_operations(self) -> PendingOperationRegistry:
+        return self.pending_operations
+
+
+@pytest.mark.parametrize("approve", [True, False])
+def test_make_tool_elicits_approval_of_pending_operation(approve: bool) -> None:
+    """Test that the approval of a pending operation is confirmed by the user via elicitation if the client supports it."""
+    agent = PendingOperationsMockAgent()
+    operation = agent.pending_operations.add("approval_required", {"path": "main.tf"}, "reason")
+    tool = ApprovePendingOperationTool(agent)  # type: ignore[arg-type]
+    tool.apply_ex = lambda log_call=True, catch_exceptions=True, approved=False, **kwargs: f"approved={approved}"  # type: ignore
+    mcp_tool = make_tool(tool)
+    context = MockElicitationContext(approve)
+
+    if approve:
+        assert asyncio.run(mcp_tool.run({"operation_id": operation.operation_id}, context)) == "approved=True"  # type: ignore
+    else:
+        with pytest.raises(ToolError, match="did not approve the operation approval_required"):
+            asyncio.run(mcp_tool.run({"operation_id": operation.operation_id}, context))  # type: ignore
+
+
+def test_make_mcp_mode_prompt() -> None:
+    """Test that mode prompts are exposed as MCP prompts with an optional task argument."""
+
+    class MockPromptAgent:
+        @staticmethod
+        def create_mode_prompt(mode: SerenaAgentMode, task: str = "") -> str:
+            return f"{mode.prompt} {task}".strip()
+
+    factory = SerenaMCPFactory("stdio")
+    factory.agent = MockPromptAgent()  # type: ignore
+    mode = SerenaAgentMode(name="planning", prompt="Do not write code.", description="Analysis and planning")
+    prompt = factory._make_mcp_mode_prompt(mode)
+
+    assert prompt.name == "mode_planning"
+    assert prompt.description is not None and "Analysis and planning" in prompt.description
+    assert prompt.arguments is not None and [(a.name, a.required) for a in prompt.arguments] == [("task", False)]
+    messages = asyncio.run(prompt.render({"task": "Plan the refactoring."}))
+    assert messages[0].content.text == "Do not write code. Plan the refactoring."  # type: ignore
+
+
+def test_server_state_is_updated_in_event_loop() -> None:
+    """Test that updates of the MCP server's state triggered in other threads (e.g. by tool calls) are performed in the event loop."""
+    factory = SerenaMCPFactory("stdio")
+    calling_threads: list[threading.Thread] = []
+
+    def update() -> None:
+        calling_threads.append(threading.current_thread())
+
+    # without a running server, the update is performed directly
+    thread = threading.Thread(target=factory._call_in_event_loop, args=(update,))
+    thread.start()
+    thread.join()
+    assert calling_threads == [thread]
+
+    async def run_server() -> None:
+        factory._event_loop = asyncio.get_running_loop()
+        factory._call_in_event_loop(update)
+        other_thread = threading.Thread(target=factory._call_in_event_loop, args=(update,))
+        other_thread.start()
+        await asyncio.to_thread(other_thread.join)
+        await asyncio.sleep(0)
+
+    asyncio.run(run_server())
+    assert calling_threads == [thread, threading.main_thread(), threading.main_thread()]
+
+
+@pytest.mark.parametrize("memory_name", ["deployment_runbook", "global/conventions", "infra/networking"])
+def test_memory_resource_uri(memory_name: str) -> None:
+    """Test that memory names (including topics) are mapped to URIs matching the template memory://{name} and back."""
+    uri = memory_resource_uri(memory_name)
+    assert "/" not in uri.removeprefix("memory://")
+    assert memory_name_from_resource_uri(uri) == memory_name
+    assert memory_name_from_resource_uri("file:///tmp/x.md") is None
+
+
+class MockSession:
+    """A mock MCP session recording resource update notifications."""
+
+    def __init__(self, fail: bool = False):
+        self.updated_uris: list[str] = []
+        self.log_messages: list[tuple[str, str, str | None]] = []
+        self._fail = fail
+
+    async def send_resource_updated(self, uri) -> None:
+        if self._fail:
+            raise ConnectionError("client disconnected")
+        self.updated_uris.append(str(uri))
+
+    async def send_log_message(self, level: str, data: str, logger: str | None = None) -> None:
+        if self._fail:
+            raise ConnectionError("client disconnected")
+        self.log_messages.append((level, data, logger))
+
+
+def test_memory_resource_subscriptions(tmp_path: Path) -> None:
+    """Test that subscribers are notified about changed memories only and that unreachable subscribers are removed."""
+    subscriptions = MemoryResourceSubscriptions(lambda name: tmp_path / f"{name}.md")
+    runbook_path = tmp_path / "runbook.md"
+    runbook_path.write_text("v1")
+    session = MockSession()
+    failing_session = MockSession(fail=True)
+
+    async def run() -> None:
+        subscriptions.subscribe("runbook", session)  # type: ignore
+        subscriptions.subscribe("runbook", failing_session)  # type: ignore
+        subscriptions.subscribe("other", session)  # type: ignore
+        await subscriptions.notify_changes()
+        assert session.updated_uris == []
+
+        runbook_path.write_text("v2")
+        os.utime(runbook_path, ns=(0, runbook_path.stat().st_mtime_ns + 1_000_000))
+        await subscriptions.notify_changes()
+        assert session.updated_uris == ["memory://runbook"]
+        await subscriptions.notify_changes()
+        assert session.updated_uris == ["memory://runbook"]
+
+        # creating a memory is a change, too
+        (tmp_path / "other.md").write_text("new")
+        await subscriptions.notify_changes()
+        assert session.updated_uris == ["memory://runbook", "memory://other"]
+
+        subscriptions.unsubscribe("other", session)  # type: ignore
+        subscriptions.unsubscribe("runbook", session)  # type: ignore
+        assert subscriptions.get_subscribed_memory_names() == []
+
+    asyncio.run(run())
+
+
+def test_log_forwarder() -> None:
+    """Test that log records are forwarded to sessions according to their levels and that unreachable sessions are removed."""
+    forwarder = MCPLogForwarder()
+
+    def emit(logger_name: str, level: int, message: str) -> None:
+        forwarder.handle(logging.LogRecord(logger_name, level, __file__, 0, message, None, None))
+
+    async def run() -> None:
+        info_session, error_session, failing_session = MockSession(), MockSession(), MockSession(fail=True)
+        forwarder.set_level_for_session(info_session, "info")  # type: ignore
+        forwarder.set_level_for_session(error_session, "error")  # type: ignore
+        forwarder.set_level_for_session(failing_session, "debug")  # type: ignore
+
+        # records are emitted from other threads (e.g. the language server's)
+        thread = threading.Thread(target=emit, args=("solidlsp.ls", logging.INFO, "Starting language server"))
+        thread.start()
+        thread.join()
+        emit("serena.agent", logging.ERROR, "Language server failed to start")
+        emit("mcp.server.lowlevel", logging.ERROR, "Internal error")  # ignored (MCP library)
+        await asyncio.sleep(0.1)
+
+        assert info_session.log_messages == [
+            ("info", "Starting language server", "solidlsp.ls"),
+            ("error", "Language server failed to start", "serena.agent"),
+        ]
+        assert error_session.log_messages == [("error", "Language server failed to start", "serena.agent")]
+
+        emit("serena.agent", logging.DEBUG, "Only for the failing session")
+        await asyncio.sleep(0.1)
+        assert failing_session not in forwarder._session_levels
+
+    asyncio.run(run())
+
+
+class MockRootsAgent:
+    """A mock agent recording issued tasks (which are executed on demand)."""
+
+    def __init__(self) -> None:
+        self.active_project: SimpleNamespace | None = None
+        self.issued_tasks: list[Callable[[], Any]] = []
+
+    def get_active_project(self) -> SimpleNamespace | None:
+        return self.active_project
+
+    def issue_task(self, task: Callable[[], Any], name: str | None = None) -> None:
+        self.issued_tasks.append(task)
+
+    def activate_project_from_path_or_name(self, project_root: str) -> None:
+        self.active_project = SimpleNamespace(project_root=project_root)
+
+    def run_issued_tasks(self) -> None:
+        for task in self.issued_tasks:
+            task()
+        self.issued_tasks.clear()
+
+
+class MockRootsSession:
+    """A mock MCP session of a client providing roots."""
+
+    def __init__(self, root_paths: list[Path]):
+        self.root_paths = root_paths
+        self.num_roots_requests = 0
+
+    def check_client_capability(self, capability) -> bool:  # type: ignore[no-untyped-def]
+        return True
+
+    async def list_roots(self) -> SimpleNamespace:
+        self.num_roots_requests += 1
+        return SimpleNamespace(roots=[SimpleNamespace(uri=path.as_uri()) for path in self.root_paths])
+
+
+def test_roots_project_activator(tmp_path: Path) -> None:
+    """Test that the project at the client's root is activated and that projects activated by other means are not replaced."""
+    agent = MockRootsAgent()
+    activator = MCPRootsProjectActivator(agent)  # type: ignore
+    session = MockRootsSession([tmp_path / "project1", tmp_path / "project2"])
+
+    async def run() -> None:
+        # the project at the first root is activated
+        await activator.update_project(session)  # type: ignore
+        agent.run_issued_tasks()
+        assert agent.active_project.project_root == str(tmp_path / "project1")
+
+        # the roots are requested again only after they have changed
+        await activator.update_project(session)  # type: ignore
+        assert session.num_roots_requests == 1
+        session.root_paths = [tmp_path / "project2"]
+        activator.mark_roots_changed()
+        await activator.update_project(session)  # type: ignore
+        agent.run_issued_tasks()
+        assert agent.active_project.project_root == str(tmp_path / "project2")
+
+        # a project activated by other means is not replaced
+        agent.activate_project_from_path_or_name(str(tmp_path / "project3"))
+        session.root_paths = [tmp_path / "project1"]
+        activator.mark_roots_changed()
+        await activator.update_project(session)  # type: ignore
+        assert agent.issued_tasks == []
+
+    asyncio.run(run())
+
+
+def test_roots_project_activator_with_multiple_sessions(tmp_path: Path) -> None:
+    """Test that the roots are requested from each session and that one session's roots do not replace another session's project."""
+    agent = MockRootsAgent()
+    activator = MCPRootsProjectActivator(agent)  # type: ignore
+    session1 = MockRootsSession([tmp_path / "project1"])
+    session2 = MockRootsSession([tmp_path / "project2"])
+
+    async def run() -> None:
+        await activator.update_project(session1)  # type: ignore
+        agent.run_issued_tasks()
+        assert agent.active_project.project_root == str(tmp_path / "project1")
+
+        # the project activated from the first session's root is not replaced by the second session's root
+        await activator.update_project(session2)  # type: ignore
+        assert session2.num_roots_requests == 1
+        assert agent.issued_tasks == []
+
+        # but it is replaced upon a change of the first session's roots
+        session1.root_paths = [tmp_path / "project3"]
+        activator.mark_roots_changed()
+        await activator.update_project(session1)  # type: ignore
+        agent.run_issued_tasks()
+        assert agent.active_project.project_root == str(tmp_path / "project3")
+
+    asyncio.run(run())
+
+
+@pytest.mark.parametrize(
+    "protocol_version, has_annotations, has_output_schema",
+    [
+        ("2024-11-05", False, False),
+        ("2025-03-26", True, False),
+        ("2025-06-18", True, True),
+    ],
+)
+def test_adapt_tools_to_protocol_version(protocol_version: str, has_annotations: bool, has_output_schema: bool) -> None:
+    """Test that tool metadata not defined in the negotiated protocol version is removed."""
+    tool = MCPToolInfo(
+        name="find_symbol",
+        title="Find Symbol",
+        inputSchema={"type": "object"},
+        outputSchema={"type": "object"},
+        annotations=ToolAnnotations(readOnlyHint=True),
+    )
+
+    (adapted_tool,) = adapt_tools_to_protocol_version([tool], protocol_version)
+
+    assert (adapted_tool.annotations is not None) == has_annotations
+    assert (adapted_tool.outputSchema is not None) == has_output_schema
+    assert (adapted_tool.title is not None) == has_output_schema
+    assert adapted_tool.inputSchema == tool.inputSchema
+    # the given tool (which may be shared by all sessions) remains unchanged
+    assert tool.annotations is not None and tool.outputSchema is not None and tool.title is not None
+
+
+class MockInitializedSession:
+    def __init__(self, protocol_version: str | None):
+        self.client_params = None if protocol_version is None else type("InitParams", (), {"protocolVersion": protocol_version})()
+
+
+@pytest.mark.parametrize(
+    "requested_version, negotiated_version",
+    [
+        ("2024-11-05", "2024-11-05"),
+        ("2025-03-26", "2025-03-26"),
+        ("1999-01-01", LATEST_PROTOCOL_VERSION),
+        (None, LATEST_PROTOCOL_VERSION),
+    ],
+)
+def test_get_negotiated_protocol_version(requested_version: str | None, negotiated_version: str) -> None:
+    assert get_negotiated_protocol_version(MockInitializedSession(requested_version)) == negotiated_version  # type: ignore
+
+
 def test_make_tool_no_params() -> None:
     """Test make_tool with a function that has no parameters."""
 
@@ -283,6 +753,25 @@ def is_test_mock_class(tool_class: type) -> bool:
     )
 
 
+@pytest.mark.parametrize(
+    "tool_class, read_only, destructive, open_world",
+    [
+        (ReadFileTool, True, False, False),
+        (ReplaceContentTool, False, True, False),
+        (ExecuteShellCommandTool, False, True, True),
+        (ApprovePendingOperationTool, False, True, True),
+    ],
+)
+def test_make_tool_annotations(tool_class: type[Tool], read_only: bool, destructive: bool, open_world: bool) -> None:
+    """Test that the tool markers are mapped to the MCP tool annotations."""
+    annotations = make_tool(tool_class(MockAgent())).annotations
+
+    assert annotations is not None
+    assert annotations.readOnlyHint is read_only
+    assert annotations.destructiveHint is destructive
+    assert annotations.openWorldHint is open_world
+
+
 @pytest.mark.parametrize("tool_class", ToolRegistry().get_all_tool_classes())
 def test_make_tool_all_tools(tool_class) -> None:
     """Test that make_tool works for all tools in the codebase."""
@@ -298,3 +787,15 @@ def test_make_tool_all_tools(tool_class) -> None:
 
     # The description should be a string (either from docstring or default)
     assert isinstance(mcp_tool.description, str)
+
+
+@pytest.mark.parametrize("tool_class", ToolRegistry().get_all_tool_classes())
+def test_editing_tools_are_not_marked_as_concurrent(tool_class) -> None:
+    """Test that tools which can edit files are never executed concurrently with other tools."""
+    assert not (tool_class.can_edit() and issubclass(tool_class, ToolMarkerConcurrent))
+
+
+def test_http_app_with_sse_fallback_serves_both_transports() -> None:
+    app = SerenaMCPFactory.create_http_app_with_sse_fallback(FastMCP("test"))
+    paths = {route.path for route in app.routes}  # type: ignore[attr-defined]
+    assert {"/mcp", "/sse", "/messages"} <= paths
@@ -8,6 +8,7 @@ import os
 
 import pytest
 
+from serena.memories.memory_metadata import MemoryMetadata, MemoryType, split_front_matter, with_front_matter
 from serena.memories.memory_reference_analysis import (
     HIGH_CONFIDENCE_NAME_LENGTH,
     MAX_STALE_REFERENCE_CANDIDATES,
@@ -269,6 +270,52 @@ def _write(manager: MemoryManager, name: str, content: str) -> None:
     manager.save_memory(name, content, is_tool_context=False)
 
 
+class TestMemoryMetadata:
+    def test_front_matter_round_trip(self) -> None:
+        metadata = MemoryMetadata(type=MemoryType.RUNBOOK, tags=["deployment", "onboarding"], source_tool="write_memory")
+        content = with_front_matter("# Deployment\n\nRun `terraform apply`.\n", metadata)
+
+        assert content.startswith("---\ntype: runbook\n")
+        parsed_metadata, body = split_front_matter(content)
+        assert parsed_metadata == metadata
+        assert body == "# Deployment\n\nRun `terraform apply`.\n"
+
+    def test_existing_front_matter_is_replaced(self) -> None:
+        content = with_front_matter("body", MemoryMetadata(type=MemoryType.OTHER))
+        content = with_front_matter(content, MemoryMetadata(type=MemoryType.ARCHITECTURE))
+
+        assert content.count("---\n") == 2
+        parsed_metadata, body = split_front_matter(content)
+        assert parsed_metadata is not None and parsed_metadata.type == MemoryType.ARCHITECTURE
+        assert body == "body"
+
+    @pytest.mark.parametrize(
+        "content",
+        [
+            "# No front matter",
+            "---\nnot closed",
+            "---\n- a list\n---\nbody",
+            "---\ntype: unknown_type\n---\nbody",
+            "---\ntype: [unbalanced\n---\nbody",
+        ],
+    )
+    def test_content_without_valid_front_matter_is_unchanged(self, content: str) -> None:
+        assert split_front_matter(content) == (None, content)
+
+    def test_list_memories_filters_by_metadata(self, fs_manager: MemoryManager) -> None:
+        fs_manager.save_memory("core", "# Core", is_tool_context=False, metadata=MemoryMetadata(type=MemoryType.ARCHITECTURE))
+        fs_manager.save_memory(
+            "deploy", "# Deploy", is_tool_context=False, metadata=MemoryMetadata(type=MemoryType.RUNBOOK, tags=["prod"])
+        )
+        _write(fs_manager, "notes", "# Notes without front matter")
+
+        assert fs_manager.list_memories(memory_type=MemoryType.RUNBOOK).get_full_list() == ["deploy"]
+        assert fs_manager.list_memories(tags=["prod"]).get_full_list() == ["deploy"]
+        assert fs_manager.list_memories(memory_type=MemoryType.ARCHITECTURE, tags=["prod"]).get_full_list() == []
+        assert fs_manager.list_project_memories().get_full_list() == ["core", "deploy", "notes"]
+        assert fs_manager.get_memory_metadata("notes") is None
+
+
 class TestListMemoriesFollowsSymlinks:
     """Regression: memories reachable only through a directory symlink (e.g. a monorepo whose
     ``.serena/memories`` symlinks each submodule's memory folder, making them addressable as
@@ -0,0 +1,90 @@
+from collections.abc import Iterator
+from pathlib import Path
+from unittest.mock import MagicMock
+
+import pytest
+
+from serena.project import Project
+from serena.tools.symbol_tools import MoveSymbolTool
+from solidlsp.ls_config import LanguageServerId
+from test.conftest import language_server_tests_enabled, project_with_ls_context
+
+LOGS_BLOCK = """# bucket for application logs
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+"""
+ROOT_MAIN_TF = (
+    """module "storage" {
+  source = "./modules/storage"
+}
+
+"""
+    + LOGS_BLOCK
+    + """
+resource "aws_s3_bucket" "assets" {
+  bucket = "assets"
+}
+"""
+)
+
+
+@pytest.fixture
+def project(tmp_path: Path) -> Iterator[Project]:
+    (tmp_path / "main.tf").write_text(ROOT_MAIN_TF)
+    (tmp_path / "modules" / "storage").mkdir(parents=True)
+    (tmp_path / "modules" / "storage" / "main.tf").write_text("")
+    (tmp_path.parent / "other.tf").write_text("")
+    with project_with_ls_context(LanguageServerId.TERRAFORM, repo_root_override=str(tmp_path)) as project:
+        yield project
+
+
+@pytest.fixture
+def tool(project: Project) -> MoveSymbolTool:
+    agent = MagicMock()
+    agent.get_active_project_or_raise.return_value = project
+    return MoveSymbolTool(agent)
+
+
+@pytest.mark.skipif(
+    not language_server_tests_enabled(LanguageServerId.TERRAFORM), reason="Terraform tests are disabled (terraform CLI not available)"
+)
+@pytest.mark.terraform
+class TestMoveSymbolTool:
+    def test_move_to_file_in_same_directory(self, tool: MoveSymbolTool, tmp_path: Path) -> None:
+        result = tool.apply("aws_s3_bucket.logs", "main.tf", target_relative_path="storage.tf")
+        assert result == "Moved aws_s3_bucket.logs to aws_s3_bucket.logs in storage.tf"
+        assert (tmp_path / "storage.tf").read_text() == LOGS_BLOCK
+        assert "logs" not in (tmp_path / "main.tf").read_text()
+        assert "moved" not in (tmp_path / "main.tf").read_text()
+
+    def test_move_into_called_module(self, tool: MoveSymbolTool, tmp_path: Path) -> None:
+        result = tool.apply("aws_s3_bucket.assets", "main.tf", target_relative_path="modules/storage/main.tf")
+        assert result == (
+            "Moved aws_s3_bucket.assets to module.storage.aws_s3_bucket.assets in modules/storage/main.tf; added a moved block to main.tf"
+        )
+        assert (tmp_path / "modules" / "storage" / "main.tf").read_text() == 'resource "aws_s3_bucket" "assets" {\n  bucket = "assets"\n}\n'
+        main_tf = (tmp_path / "main.tf").read_text()
+        assert '"assets" {' not in main_tf
+        assert main_tf.endswith("moved {\n  from = aws_s3_bucket.assets\n  to   = module.storage.aws_s3_bucket.assets\n}\n")
+
+    @pytest.mark.parametrize("target_relative_path", ["../other.tf", ".git/main.tf"])
+    def test_target_outside_of_project_or_ignored_is_rejected(
+        self, tool: MoveSymbolTool, tmp_path: Path, target_relative_path: str
+    ) -> None:
+        with pytest.raises(ValueError):
+            tool.apply("aws_s3_bucket.logs", "main.tf", target_relative_path=target_relative_path)
+        assert (tmp_path / "main.tf").read_text() == ROOT_MAIN_TF
+        assert (tmp_path.parent / "other.tf").read_text() == ""
+
+    def test_files_are_restored_if_a_write_fails(self, tool: MoveSymbolTool, project: Project, tmp_path: Path) -> None:
+        code_editor = tool.create_ls_code_editor()
+        code_editor.append_to_file = MagicMock(side_effect=OSError("disk full"))  # type: ignore[method-assign]
+        tool.create_ls_code_editor = lambda: code_editor  # type: ignore[method-assign]
+        with pytest.raises(OSError, match="disk full"):
+            tool.apply("aws_s3_bucket.logs", "main.tf", target_relative_path="storage.tf")
+        assert (tmp_path / "main.tf").read_text() == ROOT_MAIN_TF
+        assert not (tmp_path / "storage.tf").exists()
+        # the source file was backed up, and the restoration cancels out the recorded mutations
+        assert len(project.file_backups.get_snapshot_dirs()) == 1
+        assert project.edit_history.get_transactions() == []
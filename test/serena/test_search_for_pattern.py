@@ -26,6 +26,7 @@ def test_search_for_pattern_snippet_stage(tmp_path):
     project = Project.load(str(tmp_path), serena_config=SerenaConfig(gui_log_window=False, web_dashboard=False))
     agent = MagicMock()
     agent.get_active_project_or_raise.return_value = project
+    agent.get_context.return_value.default_output_format = "json"
     tool = SearchForPatternTool(agent)
 
     def run(cap: int) -> str:
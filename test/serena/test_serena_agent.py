@@ -8,6 +8,7 @@ from contextlib import contextmanager
 from copy import copy
 from dataclasses import dataclass
 from typing import Literal, cast
+from unittest.mock import MagicMock
 
 import pytest
 from _pytest.mark import Mark, MarkDecorator, ParameterSet
@@ -25,11 +26,14 @@ from serena.tools import (
     FindReferencingSymbolsTool,
     FindSymbolTool,
     GetDiagnosticsForFileTool,
+    GetDiagnosticsTool,
+    GetSymbolInfoTool,
     InitialInstructionsTool,
     ReplaceContentTool,
     ReplaceInFilesTool,
     ReplaceSymbolBodyTool,
     SafeDeleteSymbol,
+    StructuredToolResult,
     Tool,
 )
 from solidlsp.ls_config import LanguageServerId
@@ -882,6 +886,84 @@ class TestSerenaAgent:
         serena_config = SerenaConfig().with_headless_mode_overrides()
         SerenaAgent(project=project, serena_config=serena_config)
 
+    def test_set_tools_activated(self):
+        agent = SerenaAgent(serena_config=SerenaConfig().with_headless_mode_overrides())
+        callback_calls = []
+        agent.register_exposed_tools_changed_callback(lambda: callback_calls.append(True))
+
+        def get_exposed_tool_names() -> set[str]:
+            return {tool.get_name() for tool in agent.get_exposed_tool_instances()}
+
+        try:
+            # activate an optional tool
+            assert "remove_project" not in get_exposed_tool_names()
+            agent.set_tools_activated(["remove_project"], activated=True)
+            assert "remove_project" in get_exposed_tool_names()
+            assert "remove_project" in agent.get_active_tool_names()
+            assert callback_calls == [True]
+            assert agent.pop_exposed_tools_changed_flag()
+
+            # deactivate it again along with a default tool
+            agent.set_tools_activated(["remove_project", "read_file"], activated=False)
+            assert get_exposed_tool_names().isdisjoint({"remove_project", "read_file"})
+            assert callback_calls == [True, True]
+
+            # a default tool can be re-activated only after it was deactivated
+            agent.set_tools_activated(["read_file"], activated=True)
+            assert "read_file" in get_exposed_tool_names()
+
+            with pytest.raises(ValueError, match="cannot be deactivated"):
+                agent.set_tools_activated(["deactivate_tools"], activated=False)
+            with pytest.raises(ValueError, match="Unknown tools"):
+                agent.set_tools_activated(["non_existent_tool"], activated=True)
+            with pytest.raises(ValueError, match="Only optional tools"):
+                agent.set_tools_activated(["find_symbol"], activated=True)
+        finally:
+            agent.on_shutdown(timeout=5)
+
+    def test_explicitly_excluded_tools_cannot_be_activated(self):
+        serena_config = SerenaConfig(excluded_tools=["remove_project"]).with_headless_mode_overrides()
+        agent = SerenaAgent(serena_config=serena_config)
+        try:
+            with pytest.raises(ValueError, match="cannot be activated: remove_project"):
+                agent.set_tools_activated(["remove_project"], activated=True)
+            assert "remove_project" not in agent.get_active_tool_names()
+        finally:
+            agent.on_shutdown(timeout=5)
+
+    def test_reload_context_and_modes(self, tmp_path):
+        context_path = tmp_path / "reloadable-context.yml"
+        mode_path = tmp_path / "reloadable-mode.yml"
+        context_path.write_text("prompt: Original context prompt\n")
+        mode_path.write_text("prompt: Original mode prompt\n")
+        serena_config = SerenaConfig(default_modes=[str(mode_path)]).with_headless_mode_overrides()
+        agent = SerenaAgent(serena_config=serena_config, context=SerenaAgentContext.from_yaml(context_path))
+        callback_calls = []
+        agent.register_exposed_tools_changed_callback(lambda: callback_calls.append(True))
+
+        try:
+            assert "read_file" in agent.get_active_tool_names()
+            assert "remove_project" not in agent.get_active_tool_names()
+
+            # edit the definitions: the context excludes a default tool, the mode includes an optional tool
+            context_path.write_text("prompt: Updated context prompt\nexcluded_tools:\n  - read_file\n")
+            mode_path.write_text("prompt: Original mode prompt\nincluded_optional_tools:\n  - remove_project\n")
+            result = agent.reload_context_and_modes()
+
+            assert "read_file" not in agent.get_active_tool_names()
+            assert "remove_project" in agent.get_active_tool_names()
+            assert "Newly exposed tools: remove_project" in result
+            assert "No longer exposed tools: read_file" in result
+            assert callback_calls == [True]
+            assert agent.pop_exposed_tools_changed_flag()
+            assert "Updated context prompt" in agent.create_system_prompt()
+
+            # reloading unchanged definitions does not notify listeners
+            agent.reload_context_and_modes()
+            assert callback_calls == [True]
+        finally:
+            agent.on_shutdown(timeout=5)
+
     @pytest.mark.python
     @pytest.mark.skipif(not language_server_tests_enabled(LanguageServerId.PYTHON), reason="python tests are disabled in this environment")
     def test_grok_context_restricts_toolset_and_prompt(self, serena_config):
@@ -952,6 +1034,16 @@ class TestSerenaAgent:
         for symbol in symbols:
             self._assert_symbol_info_present(serena_agent, symbol, case.symbol_name)
 
+    @pytest.mark.parametrize("serena_agent,case", FIND_SYMBOL_REFERENCES_CASES, indirect=["serena_agent"])
+    def test_get_symbol_info(self, serena_agent: SerenaAgent, case: FindSymbolCase) -> None:
+        find_symbol_tool = serena_agent.get_tool(FindSymbolTool)
+        symbols = json.loads(find_symbol_tool.apply(name_path_pattern=case.symbol_name))
+        symbol = next(s for s in symbols if case.expected_file in s["relative_path"])
+
+        symbol_info_tool = serena_agent.get_tool(GetSymbolInfoTool)
+        result = symbol_info_tool.apply(name_path_pattern=symbol["name_path"], relative_path=symbol["relative_path"])
+        self._assert_symbol_info_present(serena_agent, json.loads(result), case.symbol_name)
+
     @pytest.mark.parametrize("serena_agent,case", FIND_REFERENCE_CASES, indirect=["serena_agent"])
     def test_find_symbol_references(self, serena_agent: SerenaAgent, case: FindReferenceCase) -> None:
         # Find the symbol location first
@@ -988,6 +1080,14 @@ class TestSerenaAgent:
             f"Expected to find reference to {case.symbol_name} in {case.reference_file}. refs={refs}"
         )
 
+    def test_find_symbol_references_rejects_negative_context_lines(self) -> None:
+        agent = MagicMock()
+        find_refs_tool = FindReferencingSymbolsTool(agent)
+        with pytest.raises(ValueError, match="context_lines must be non-negative"):
+            find_refs_tool.apply(name_path="create_user", relative_path="services.py", context_lines=-1)
+        # the parameters are validated before the language server is accessed
+        agent.get_active_project_or_raise.assert_not_called()
+
     @pytest.mark.parametrize("serena_agent,case", FIND_DEFINING_SYMBOL_REGEX_CASES, indirect=["serena_agent"])
     def test_find_declaration(self, serena_agent: SerenaAgent, case: RegexDefiningSymbolCase) -> None:
         tool = serena_agent.get_tool(FindDeclarationTool)
@@ -1047,6 +1147,17 @@ class TestSerenaAgent:
         diagnostics_in_range = json.loads(result)
         diagnostic_case.without_second_symbol().assert_matches(diagnostics_in_range)
 
+    @pytest.mark.parametrize("serena_agent,diagnostic_case", DIAGNOSTIC_CASES, indirect=["serena_agent"])
+    def test_get_diagnostics(self, serena_agent: SerenaAgent, diagnostic_case: DiagnosticCase) -> None:
+        diagnostics_tool = serena_agent.get_tool(GetDiagnosticsTool)
+        for relative_path in [diagnostic_case.relative_path, os.path.dirname(diagnostic_case.relative_path)]:
+            result = diagnostics_tool.apply(relative_path=relative_path, min_severity=1)
+            grouped_diagnostics = json.loads(result)
+            diagnostic_case.assert_matches(grouped_diagnostics)
+            # the structured data provides the same diagnostics as a flat list
+            assert isinstance(result, StructuredToolResult)
+            assert {d["relative_path"] for d in result.structured_data} == set(grouped_diagnostics)
+
     @pytest.mark.parametrize("serena_agent,case", FIND_IMPLEMENTATION_CASES, indirect=["serena_agent"])
     def test_find_symbol_implementations(self, serena_agent: SerenaAgent, case: FindImplementationCase) -> None:
         agent = serena_agent
@@ -1380,6 +1491,15 @@ class TestPromptProvision:
         else:
             assert match is None, f"Expected no project activation message in result:\n{result}"
 
+    @pytest.mark.parametrize("serena_agent", [LanguageServerId.TERRAFORM], indirect=True)
+    def test_config_overview_reports_language_server_versions_and_cache_stats(self, serena_agent: SerenaAgent) -> None:
+        serena_agent.get_tool(FindSymbolTool).apply("aws_instance")
+        overview = serena_agent.get_current_config_overview()
+        assert "Language servers: terraform (running)" in overview
+        assert re.search(r"^  terraform versions: terraform-ls \S+, Terraform CLI v\d", overview, re.MULTILINE), overview
+        assert re.search(r"^  terraform symbol cache: \d+ entries .*, \d+ hits/\d+ misses", overview, re.MULTILINE), overview
+        assert re.search(r"^Terraform workspace: \S+$", overview, re.MULTILINE), overview
+
     @pytest.mark.parametrize("serena_agent", [LanguageServerId.PYTHON], indirect=True)
     def test_initial_instructions_provide_project_activation_message_once_per_session(self, serena_agent: SerenaAgent) -> None:
         """
@@ -0,0 +1,38 @@
+from serena.memories.session_record import SessionRecord
+
+
+class TestSessionRecord:
+    def test_edited_files(self):
+        record = SessionRecord()
+        assert not record.is_modified()
+        record.add_edited_file("main.tf")
+        record.add_edited_file("modules/vpc/main.tf")
+        record.add_edited_file("main.tf")
+        assert record.get_edited_files() == ["main.tf", "modules/vpc/main.tf"]
+        assert record.is_modified()
+
+        content = record.create_memory_content()
+        assert "- main.tf\n- modules/vpc/main.tf\n" in content
+        assert "(not summarised)" in content
+        assert not record.is_modified()
+
+        # adding an already recorded file does not modify the record
+        record.add_edited_file("main.tf")
+        assert not record.is_modified()
+
+    def test_summary(self):
+        record = SessionRecord()
+        record.set_summary("Refactored the VPC module.", "Run terraform plan for staging.")
+        assert record.is_modified()
+        content = record.create_memory_content()
+        assert "Refactored the VPC module." in content
+        assert "Run terraform plan for staging." in content
+        assert "## Files Edited\n\n(none)\n" in content
+
+    def test_change_summary(self):
+        record = SessionRecord()
+        record.set_summary("Added an S3 bucket for logs.", "")
+        record.add_edited_file("storage.tf")
+        assert record.create_change_summary() == "## Summary\n\nAdded an S3 bucket for logs.\n\n## Files Edited\n\n- storage.tf\n"
+        # creating the change summary does not affect the persistence state
+        assert record.is_modified()
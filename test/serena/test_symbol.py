@@ -4,7 +4,18 @@ import pytest
 
 from serena.jetbrains.jetbrains_types import SymbolDTO, SymbolDTOKey
 from serena.project import Project
-from serena.symbol import LanguageServerSymbol, LanguageServerSymbolRetriever, NamePathComponent, NamePathMatcher
+from serena.symbol import (
+    LanguageServerSymbol,
+    LanguageServerSymbolRetriever,
+    NamePathComponent,
+    NamePathMatcher,
+    SymbolKindFilter,
+    SymbolSearchFilePrioritizer,
+    SymbolStatistics,
+    TerraformBlockType,
+    TerraformResourceFilter,
+)
+from solidlsp.ls_types import SymbolKind
 from test.solidlsp.conftest import PYTHON_BACKEND_LANGUAGES
 
 
@@ -232,6 +243,91 @@ class TestSymbolNameMatching:
         error_msg = self._create_assertion_error_message(name_path_pattern, symbol_name_path_components, False, expected, result)
         assert result == expected, error_msg
 
+    @pytest.mark.parametrize(
+        "name_path_pattern, symbol_name_path_parts, is_substring_match, expected",
+        [
+            pytest.param("get_*", ["get_user"], False, True, id="W: 'get_*' matches ['get_user']"),
+            pytest.param("get_*", ["set_user"], False, False, id="W: 'get_*' does not match ['set_user']"),
+            pytest.param("get_?", ["get_a"], False, True, id="W: 'get_?' matches ['get_a']"),
+            pytest.param("get_?", ["get_ab"], False, False, id="W: 'get_?' does not match ['get_ab']"),
+            pytest.param(
+                "module/*/vpc", ["module", "network", "vpc"], False, True, id="W: 'module/*/vpc' matches ['module', 'network', 'vpc']"
+            ),
+            pytest.param(
+                "/*/vpc", ["module", "network", "vpc"], False, False, id="W: '/*/vpc' does not match (wildcard is a single component)"
+            ),
+            pytest.param("*/get_*", ["UserService", "get_user"], False, True, id="W: '*/get_*' matches ['UserService', 'get_user']"),
+            pytest.param(
+                "aws_s3_*", ['resource "aws_s3_bucket" "logs"'], True, True, id="W: 'aws_s3_*' matches Terraform resource as substring"
+            ),
+            pytest.param(
+                "aws_s3_*", ['resource "aws_s3_bucket" "logs"'], False, False, id="W: 'aws_s3_*' does not match Terraform resource exactly"
+            ),
+        ],
+    )
+    def test_match_wildcard_pattern(self, name_path_pattern, symbol_name_path_parts, is_substring_match, expected):
+        """Tests matching of patterns containing glob-style wildcards."""
+        symbol_name_path_components = [NamePathComponent(part) for part in symbol_name_path_parts]
+        result = NamePathMatcher(name_path_pattern, is_substring_match).matches_reversed_components(reversed(symbol_name_path_components))
+        error_msg = self._create_assertion_error_message(name_path_pattern, symbol_name_path_parts, is_substring_match, expected, result)
+        assert result == expected, error_msg
+
+    @pytest.mark.parametrize(
+        "name_path_pattern, symbol_name_path_parts, is_substring_match, expected",
+        [
+            pytest.param("get_.*", ["get_user"], False, True, id="X: 'get_.*' matches ['get_user']"),
+            pytest.param("get_", ["get_user"], False, False, id="X: 'get_' does not match ['get_user'] (full match required)"),
+            pytest.param("user", ["get_user"], True, True, id="X: 'user' matches ['get_user'] as substring"),
+            pytest.param(
+                "module/.*prod.*", ["module", "vpc_prod_eu"], False, True, id="X: 'module/.*prod.*' matches ['module', 'vpc_prod_eu']"
+            ),
+            pytest.param("module/.*prod.*", ["module", "vpc_staging"], False, False, id="X: 'module/.*prod.*' does not match staging"),
+            pytest.param('resource "aws_s3_.*"', ['resource "aws_s3_bucket" "logs"'], True, True, id="X: regex substring match"),
+            pytest.param("(get|set)_user", ["set_user"], False, True, id="X: '(get|set)_user' matches ['set_user']"),
+            pytest.param(r"/src\/.*\.ts", ["src/main.ts"], False, True, id="X: escaped separator matches '/' within a name"),
+            pytest.param(r"src\/.*", ["src", "main"], False, False, id="X: escaped separator does not separate components"),
+            pytest.param("module/", ["module"], False, True, id="X: trailing separator is ignored"),
+            pytest.param("item[0-9]", ["item7"], False, True, id="X: trailing character class matches"),
+            pytest.param("item(?:[7])", ["item7"], False, True, id="X: trailing digit class in a group matches"),
+        ],
+    )
+    def test_match_regex_pattern(self, name_path_pattern, symbol_name_path_parts, is_substring_match, expected):
+        """Tests matching of patterns whose components are regular expressions."""
+        symbol_name_path_components = [NamePathComponent(part) for part in symbol_name_path_parts]
+        matcher = NamePathMatcher(name_path_pattern, is_substring_match, regex_matching=True)
+        result = matcher.matches_reversed_components(reversed(symbol_name_path_components))
+        error_msg = self._create_assertion_error_message(name_path_pattern, symbol_name_path_parts, is_substring_match, expected, result)
+        assert result == expected, error_msg
+
+    def test_trailing_digits_in_brackets_are_overload_index_for_regex_pattern(self):
+        matcher = NamePathMatcher("item[7]", False, regex_matching=True)
+        assert not matcher.matches_reversed_components(iter([NamePathComponent("item7")]))
+        assert matcher.matches_reversed_components(iter([NamePathComponent("item", overload_idx=7)]))
+
+    def test_invalid_regex_pattern(self):
+        with pytest.raises(ValueError, match="Invalid regular expression"):
+            NamePathMatcher("module/(prod", False, regex_matching=True)
+
+    def test_no_top_level_name_query_for_regex_pattern(self):
+        matcher = NamePathMatcher('/resource "aws_s3_.*"', False, regex_matching=True)
+        assert matcher.get_top_level_name_query(lambda name: name.startswith("resource ")) is None
+
+    @pytest.mark.parametrize(
+        "name_path_pattern, is_substring_match, expected_query",
+        [
+            pytest.param('resource "aws_instance" "web"', False, 'resource "aws_instance" "web"', id="top-level name"),
+            pytest.param('resource "aws_instance" "web"/ami', False, 'resource "aws_instance" "web"', id="child of top-level name"),
+            pytest.param('resource "aws_s3_*"', False, 'resource "aws_s3_', id="wildcard uses longest literal part"),
+            pytest.param("/vpc", True, "vpc", id="absolute pattern"),
+            pytest.param("vpc", False, None, id="relative pattern may match nested symbols"),
+            pytest.param('resource "aws', True, None, id="substring matching of a single component"),
+            pytest.param("/*", False, None, id="no literal part"),
+        ],
+    )
+    def test_top_level_name_query(self, name_path_pattern, is_substring_match, expected_query):
+        matcher = NamePathMatcher(name_path_pattern, is_substring_match)
+        assert matcher.get_top_level_name_query(lambda name: name.startswith("resource ")) == expected_query
+
 
 @pytest.mark.python
 class TestLanguageServerSymbolRetriever:
@@ -243,6 +339,151 @@ class TestLanguageServerSymbolRetriever:
         assert "Create a new user and store it" in create_user_method_symbol_info
 
 
+def _make_symbol(name: str, kind: SymbolKind, children: list[dict] | None = None) -> dict:
+    symbol_root: dict = {"name": name, "kind": kind, "children": children or []}
+    for child in symbol_root["children"]:
+        child["parent"] = symbol_root
+    return symbol_root
+
+
+class TestSymbolKindFilter:
+    def test_parse_kinds(self):
+        kind_filter = SymbolKindFilter(include_kinds=[12, "Class", "type_parameter", "6"])
+        assert kind_filter.get_lsp_include_kinds() == [SymbolKind.Function, SymbolKind.Class, SymbolKind.TypeParameter, SymbolKind.Method]
+        assert not kind_filter.requires_post_filtering()
+        with pytest.raises(ValueError, match="Unknown symbol kind 'klass'"):
+            SymbolKindFilter(include_kinds=["klass"])
+
+    def test_terraform_block_types(self):
+        resource = LanguageServerSymbol(_make_symbol('resource "aws_instance" "web"', SymbolKind.Class))
+        data = LanguageServerSymbol(_make_symbol('data "aws_ami" "ubuntu"', SymbolKind.Class))
+        locals_root = _make_symbol("locals", SymbolKind.Class, [_make_symbol("env", SymbolKind.String)])
+        local_value = next(LanguageServerSymbol(locals_root).iter_children())
+        python_class = LanguageServerSymbol(_make_symbol("resource", SymbolKind.Class))
+
+        assert TerraformBlockType.of_symbol(resource) == TerraformBlockType.RESOURCE
+        assert TerraformBlockType.of_symbol(data) == TerraformBlockType.DATA
+        assert TerraformBlockType.of_symbol(local_value) == TerraformBlockType.LOCAL
+        assert TerraformBlockType.of_symbol(python_class) is None
+        terraform_block = LanguageServerSymbol({**_make_symbol("terraform", SymbolKind.Class), "tf_kind": "terraform"})
+        assert TerraformBlockType.of_symbol(terraform_block) == TerraformBlockType.TERRAFORM
+        assert terraform_block.to_dict(kind=True, name_path=False) == {"kind": "Class", "tf_kind": "terraform"}
+        locals_block = LanguageServerSymbol({**locals_root, "tf_kind": "locals"})
+        assert TerraformBlockType.of_symbol(locals_block) == TerraformBlockType.LOCALS
+        ephemeral = LanguageServerSymbol({**_make_symbol('ephemeral "random_password" "db"', SymbolKind.Class), "tf_kind": "ephemeral"})
+        assert TerraformBlockType.of_symbol(ephemeral) == TerraformBlockType.EPHEMERAL
+
+        kind_filter = SymbolKindFilter(include_kinds=["resource", "local"])
+        assert kind_filter.requires_post_filtering()
+        assert kind_filter.get_lsp_include_kinds() is None
+        assert kind_filter.apply([resource, data, local_value, python_class]) == [resource, local_value]
+
+        kind_filter = SymbolKindFilter(exclude_kinds=["data", SymbolKind.String.value])
+        assert kind_filter.get_lsp_exclude_kinds() == [SymbolKind.String]
+        assert kind_filter.apply([resource, data, local_value]) == [resource]
+
+    def test_names_of_both_lsp_kinds_and_terraform_block_types(self):
+        variable_block = LanguageServerSymbol({**_make_symbol('variable "region"', SymbolKind.Class), "tf_kind": "variable"})
+        module_block = LanguageServerSymbol({**_make_symbol('module "vpc"', SymbolKind.Class), "tf_kind": "module"})
+        python_variable = LanguageServerSymbol(_make_symbol("MAX_RETRIES", SymbolKind.Variable))
+        python_module = LanguageServerSymbol(_make_symbol("utils", SymbolKind.Module))
+        symbols = [variable_block, module_block, python_variable, python_module]
+
+        kind_filter = SymbolKindFilter(include_kinds=["variable"])
+        assert kind_filter.requires_post_filtering()
+        assert kind_filter.get_lsp_include_kinds() is None
+        assert kind_filter.apply(symbols) == [variable_block, python_variable]
+        kind_filter = SymbolKindFilter(exclude_kinds=["Module"])
+        assert kind_filter.get_lsp_exclude_kinds() is None
+        assert kind_filter.apply(symbols) == [variable_block, python_variable]
+        # integer kinds refer to LSP symbol kinds only
+        kind_filter = SymbolKindFilter(include_kinds=[SymbolKind.Variable.value])
+        assert [s for s in symbols if kind_filter.is_included(s)] == [python_variable]
+
+    def test_terraform_addresses(self):
+        def get_address(name: str) -> str | None:
+            return TerraformBlockType.get_address(LanguageServerSymbol(_make_symbol(name, SymbolKind.Class)))
+
+        assert get_address('resource "aws_instance" "web"') == "aws_instance.web"
+        assert get_address('data "aws_ami" "ubuntu"') == "data.aws_ami.ubuntu"
+        assert get_address('ephemeral "random_password" "db"') == "ephemeral.random_password.db"
+        assert get_address('variable "region"') == "var.region"
+        assert get_address('module "vpc"') == "module.vpc"
+        assert get_address('provider "aws"') is None
+        assert get_address("locals") is None
+        locals_root = _make_symbol("locals", SymbolKind.Class, [_make_symbol("env", SymbolKind.String)])
+        assert TerraformBlockType.get_address(next(LanguageServerSymbol(locals_root).iter_children())) == "local.env"
+
+
+class TestTerraformResourceFilter:
+    def test_filter_by_resource_type_and_provider(self):
+        bucket = LanguageServerSymbol(_make_symbol('resource "aws_s3_bucket" "logs"', SymbolKind.Class))
+        instance = LanguageServerSymbol(_make_symbol('resource "aws_instance" "web"', SymbolKind.Class))
+        subnet = LanguageServerSymbol(_make_symbol('data "azurerm_subnet" "main"', SymbolKind.Class))
+        provider = LanguageServerSymbol(_make_symbol('provider "aws"', SymbolKind.Class))
+        variable = LanguageServerSymbol(_make_symbol('variable "region"', SymbolKind.Class))
+        symbols = [bucket, instance, subnet, provider, variable]
+
+        assert TerraformResourceFilter.get_resource_type(subnet) == "azurerm_subnet"
+        assert TerraformResourceFilter.get_resource_type(variable) is None
+        assert TerraformResourceFilter.get_provider(provider) == "aws"
+
+        assert TerraformResourceFilter().apply(symbols) == symbols
+        assert TerraformResourceFilter(resource_type="aws_s3_*").apply(symbols) == [bucket]
+        assert TerraformResourceFilter(resource_type="azurerm_subnet").apply(symbols) == [subnet]
+        assert TerraformResourceFilter(provider="aws").apply(symbols) == [bucket, instance, provider]
+        assert TerraformResourceFilter(resource_type="aws_instance", provider="aws").apply(symbols) == [instance]
+
+
+class TestSymbolStatistics:
+    def test_aggregation(self):
+        statistics = SymbolStatistics()
+        statistics.add_file(
+            "main.tf",
+            [
+                LanguageServerSymbol(_make_symbol('resource "aws_s3_bucket" "logs"', SymbolKind.Class)),
+                LanguageServerSymbol(_make_symbol('resource "aws_s3_bucket" "data"', SymbolKind.Class)),
+                LanguageServerSymbol(_make_symbol('data "aws_ami" "ubuntu"', SymbolKind.Class)),
+                LanguageServerSymbol(_make_symbol("locals", SymbolKind.Class, [_make_symbol("env", SymbolKind.String)])),
+            ],
+        )
+        statistics.add_file(
+            "modules/vpc/variables.tf",
+            [LanguageServerSymbol(_make_symbol('variable "cidr"', SymbolKind.Class))],
+        )
+
+        result = statistics.to_dict(max_files=1, max_resource_types=1)
+        assert result["num_files"] == 2
+        assert result["num_symbols"] == 6
+        assert result["symbols_by_kind_by_dir"] == {
+            ".": {"resource": 2, "data": 1, "Class": 1, "local": 1},
+            "modules/vpc": {"variable": 1},
+        }
+        assert result["largest_files"] == [{"relative_path": "main.tf", "num_symbols": 5}]
+        assert result["top_resource_types"] == {"aws_s3_bucket": 2}
+
+    def test_no_resource_types(self):
+        statistics = SymbolStatistics()
+        statistics.add_file("src/app.py", [LanguageServerSymbol(_make_symbol("App", SymbolKind.Class))])
+        assert "top_resource_types" not in statistics.to_dict()
+
+
+class TestSymbolSearchFilePrioritizer:
+    @pytest.mark.parametrize(
+        "name_path_pattern, relative_path, expected",
+        [
+            ('variable "region"', "variables.tf", True),
+            ('output "vpc_id"', "modules/vpc/outputs.tf", True),
+            ('resource "aws_instance" "web"', "main.tf", False),
+            ("UserService/get_user", "src/user_service.py", True),
+            ("UserService/get_user", "src/order_service.py", False),
+            ("MyClass", "test/resources/my_class.py", True),
+        ],
+    )
+    def test_is_hinted(self, name_path_pattern: str, relative_path: str, expected: bool) -> None:
+        assert SymbolSearchFilePrioritizer(name_path_pattern).is_hinted(relative_path) == expected
+
+
 class TestSymbolDictTypes:
     @staticmethod
     def check_key_type(dict_type: type, key_type: type):
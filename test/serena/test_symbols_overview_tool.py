@@ -0,0 +1,56 @@
+import json
+from pathlib import Path
+from types import SimpleNamespace
+from unittest.mock import MagicMock
+
+import pytest
+
+from serena.symbol import LanguageServerSymbol, SymbolKindFilter
+from serena.tools import GetSymbolsOverviewTool
+from solidlsp.ls_types import SymbolKind
+
+
+def _make_terraform_block(name: str, tf_kind: str) -> LanguageServerSymbol:
+    symbol_root: dict = {"name": name, "kind": SymbolKind.Class, "children": [], "tf_kind": tf_kind}
+    return LanguageServerSymbol(symbol_root)  # type: ignore[arg-type]
+
+
+class TestGetSymbolsOverviewTool:
+    @pytest.fixture
+    def tool(self, tmp_path: Path) -> GetSymbolsOverviewTool:
+        (tmp_path / "main.tf").write_text("", encoding="utf-8")
+        symbols = [
+            _make_terraform_block('resource "aws_instance" "web"', "resource"),
+            _make_terraform_block('variable "region"', "variable"),
+            _make_terraform_block('output "instance_id"', "output"),
+        ]
+        symbol_retriever = MagicMock()
+        symbol_retriever.can_analyze_file.return_value = True
+        symbol_retriever.get_symbol_overview.return_value = {"main.tf": symbols}
+
+        agent = MagicMock()
+        agent.get_active_project_or_raise.return_value = SimpleNamespace(project_root=str(tmp_path))
+        tool = GetSymbolsOverviewTool(agent)
+        tool.create_language_server_symbol_retriever = lambda: symbol_retriever  # type: ignore[method-assign]
+        tool._limit_length = lambda result, max_answer_chars, shortened_result_factories=None: result  # type: ignore[method-assign]
+        return tool
+
+    @pytest.mark.parametrize(
+        "kind_filter, expected_names",
+        [
+            (None, ['resource "aws_instance" "web"', 'variable "region"', 'output "instance_id"']),
+            (SymbolKindFilter(include_kinds=["resource", "output"]), ['resource "aws_instance" "web"', 'output "instance_id"']),
+            (SymbolKindFilter(include_kinds=["variable"]), ['variable "region"']),
+            (SymbolKindFilter(exclude_kinds=["variable"]), ['resource "aws_instance" "web"', 'output "instance_id"']),
+            (SymbolKindFilter(include_kinds=["Function"]), []),
+        ],
+    )
+    def test_kind_filter(self, tool: GetSymbolsOverviewTool, kind_filter: SymbolKindFilter | None, expected_names: list[str]) -> None:
+        symbol_dicts = tool.get_symbol_overview("main.tf", kind_filter=kind_filter)
+        assert [d["name"] for d in symbol_dicts] == expected_names
+
+    def test_kinds_are_passed_by_apply(self, tool: GetSymbolsOverviewTool) -> None:
+        result = tool.apply("main.tf", include_kinds=["output"], exclude_kinds=["resource"])
+        assert json.loads(result) == {"Class": [{"name": 'output "instance_id"', "tf_kind": "output"}]}
+        with pytest.raises(ValueError, match="Unknown symbol kind 'klass'"):
+            tool.apply("main.tf", include_kinds=["klass"])
@@ -124,3 +124,38 @@ def test_task_executor_cancellation_via_task_info(executor):
         pass
     end_time = time.time()
     assert (end_time - start_time) < 9, "Cancelled task did not stop in time"
+
+
+def test_task_executor_concurrent_tasks(executor):
+    """
+    Tests that consecutive concurrent tasks run concurrently, while a non-concurrent task waits for their completion
+    """
+    start_time = time.time()
+    concurrent_task1 = executor.issue_task(Task(1).run, name="task1", concurrent=True)
+    concurrent_task2 = executor.issue_task(Task(1).run, name="task2", concurrent=True)
+    exclusive_task = Task(0)
+    exclusive_future = executor.issue_task(exclusive_task.run, name="task3")
+    time.sleep(0.5)
+    assert len(executor.get_current_tasks()) == 3
+    assert not exclusive_task.did_run
+    assert concurrent_task1.result() is True
+    assert concurrent_task2.result() is True
+    assert exclusive_future.result() is True
+    assert (time.time() - start_time) < 1.9, "Concurrent tasks were not executed concurrently"
+
+
+def test_task_executor_concurrent_task_waits_for_preceding_task(executor):
+    """
+    Tests that a concurrent task is not started before a preceding non-concurrent task has completed
+    """
+    exclusive_future = executor.issue_task(Task(1).run, name="task1")
+    concurrent_task = Task(0)
+    concurrent_future = executor.issue_task(concurrent_task.run, name="task2", concurrent=True)
+    time.sleep(0.5)
+    assert not concurrent_task.did_run
+    assert exclusive_future.result() is True
+    assert concurrent_future.result() is True
+    time.sleep(0.2)  # completion is registered after the result has been set
+    last_task_info = executor.get_last_executed_task()
+    assert last_task_info is not None
+    assert "task2" in last_task_info.name
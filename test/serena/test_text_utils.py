@@ -3,7 +3,7 @@ from collections.abc import Callable
 import pytest
 
 from serena.util.file_proxy import FileCollection, FileProxy
-from serena.util.text_utils import GlobMatcher, LineType, MultiFileContentReplacer, search_files, search_text
+from serena.util.text_utils import ContentReplacer, GlobMatcher, LineType, MultiFileContentReplacer, search_files, search_text
 
 
 class TestSearchText:
@@ -151,6 +151,17 @@ class TestSearchText:
         assert matched_lines == expected_matched_lines
         assert matches[0].num_matched_lines == len(expected_matched_lines)
 
+    def test_matched_lines_to_dict(self):
+        """Test the JSON-serializable representation of a match."""
+        content = "a = 1\nb = 2\nc = 3\n"
+
+        matches = search_text("b =", content=content, source_file_path="vars.py", context_lines_before=1)
+
+        assert matches[0].to_dict() == {
+            "relative_path": "vars.py",
+            "lines": [{"line": 0, "text": "a = 1", "type": "prefix"}, {"line": 1, "text": "b = 2", "type": "match"}],
+        }
+
     def test_search_text_with_multiline_match(self):
         """Test searching with multiline pattern matching."""
         content = """
@@ -656,3 +667,41 @@ class TestMultiFileContentReplacer:
         occ = replacer.find_occurrences([(path, content)], "old_pkg", "new_pkg")[0]
         with pytest.raises(AssertionError):
             replacer.apply_to_content("completely different content", [occ])
+
+
+class TestContentReplacer:
+    CONTENT = 'resource "aws_instance" "web" {\n  tags = {\n    Name    = "${var.prefix}-web"\n  }\n}\n'
+
+    def test_literal_mode_does_not_require_escaping(self):
+        replacer = ContentReplacer(mode="literal", allow_multiple_occurrences=False)
+        updated = replacer.replace(self.CONTENT, '"${var.prefix}-web"', '"${var.prefix}-app"')
+        assert updated == self.CONTENT.replace("-web", "-app")
+
+    def test_fuzzy_mode_tolerates_whitespace_differences(self):
+        replacer = ContentReplacer(mode="fuzzy", allow_multiple_occurrences=False)
+        updated = replacer.replace(self.CONTENT, 'tags = {\nName = "${var.prefix}-web"', 'tags = {\n    Name = "${var.prefix}-app"')
+        assert updated == 'resource "aws_instance" "web" {\n  tags = {\n    Name = "${var.prefix}-app"\n  }\n}\n'
+
+    def test_fuzzy_mode_requires_matching_line_structure(self):
+        replacer = ContentReplacer(mode="fuzzy", allow_multiple_occurrences=False)
+        with pytest.raises(ValueError):
+            replacer.replace(self.CONTENT, "tags = { Name", "")
+        with pytest.raises(ValueError):
+            replacer.replace(self.CONTENT, "tags = {\n\nName", "")
+
+    @pytest.mark.parametrize(
+        "occurrence_index, expected_content",
+        [
+            pytest.param(0, "x = 2\nx = 1\nx = 1\n", id="first"),
+            pytest.param(1, "x = 1\nx = 2\nx = 1\n", id="second"),
+            pytest.param(-1, "x = 1\nx = 1\nx = 2\n", id="last"),
+        ],
+    )
+    def test_occurrence_index(self, occurrence_index: int, expected_content: str):
+        replacer = ContentReplacer(mode="literal", allow_multiple_occurrences=False, occurrence_index=occurrence_index)
+        assert replacer.replace("x = 1\nx = 1\nx = 1\n", "x = 1", "x = 2") == expected_content
+
+    def test_occurrence_index_out_of_range(self):
+        replacer = ContentReplacer(mode="literal", allow_multiple_occurrences=False, occurrence_index=3)
+        with pytest.raises(ValueError, match="out of range"):
+            replacer.replace("x = 1\nx = 1\nx = 1\n", "x = 1", "x = 2")
@@ -1,10 +1,11 @@
 import logging
+from types import SimpleNamespace
 
 import pytest
 
 from serena.config.serena_config import SerenaConfig
 from serena.mcp import SerenaMCPFactory
-from serena.tools.tools_base import ToolRegistry
+from serena.tools.tools_base import Tool, ToolRegistry
 
 
 @pytest.mark.parametrize("context", ("chatgpt", "codex", "oaicompat-agent"))
@@ -37,3 +38,37 @@ def test_all_tool_parameters_have_type(context):
                     issues.append(f"Tool {tool.get_name()!r} parameter {pname!r} missing 'type'")
         if issues:
             raise AssertionError("\n".join(issues))
+
+
+class GreetingTool(Tool):
+    """
+    Greets a person (for testing).
+    """
+
+    def apply(self, name: str, greeting: str = "Hello", max_answer_chars: int = -1) -> str:
+        """
+        :param name: the name of the person to greet
+        :param greeting: the greeting
+        :param max_answer_chars: unused
+        """
+        return f"{greeting}, {name}!"
+
+
+def test_project_tool_parameter_defaults():
+    """Tests that a project's overrides of tool parameter defaults apply only to parameters which are not set explicitly."""
+    project_config = SimpleNamespace(tool_parameter_defaults={"greeting": {"greeting": "Hi", "unknown_param": 1, "name": "Bob"}})
+    agent = SimpleNamespace(get_active_project=lambda: SimpleNamespace(project_config=project_config))
+    tool = GreetingTool(agent)  # type: ignore
+
+    # overrides of unknown or required parameters are ignored
+    assert tool.get_parameter_defaults() == {"greeting": "Hi"}
+    # parameters which are not given are overridden
+    assert tool._apply_parameter_defaults({"name": "Ada"}) == {"name": "Ada", "greeting": "Hi"}
+    # explicitly set values take precedence (even if they are equal to the original default)
+    assert tool._apply_parameter_defaults({"name": "Ada", "greeting": "Hello"}) == {"name": "Ada", "greeting": "Hello"}
+    assert tool._apply_parameter_defaults({"name": "Ada", "greeting": "Hey"}) == {"name": "Ada", "greeting": "Hey"}
+    # overrides for other tools or without an active project do not apply
+    project_config.tool_parameter_defaults = {"other_tool": {"greeting": "Hi"}}
+    assert tool._apply_parameter_defaults({"name": "Ada"}) == {"name": "Ada"}
+    agent.get_active_project = lambda: None
+    assert tool._apply_parameter_defaults({"name": "Ada"}) == {"name": "Ada"}
@@ -0,0 +1,131 @@
+from pathlib import Path
+from types import SimpleNamespace
+
+import pytest
+
+from serena.code_editor import LanguageServerCodeEditor
+from solidlsp.lsp_protocol_handler.lsp_types import FileChangeType
+
+
+class _FakeSymbolRetriever:
+    def __init__(self, project_root: Path) -> None:
+        self.notified_changes: list[tuple[str, FileChangeType]] = []
+        self.project = SimpleNamespace(
+            project_root=str(project_root),
+            project_config=SimpleNamespace(encoding="utf-8"),
+            line_ending=SimpleNamespace(newline_str="\n"),
+            notify_file_changes=self.notified_changes.extend,
+            edit_history=None,
+            is_ignored_path=lambda path: Path(path).parts[0] == ".terraform",
+        )
+
+
+@pytest.fixture
+def symbol_retriever(tmp_path: Path) -> _FakeSymbolRetriever:
+    (tmp_path / "main.tf").write_text('resource "aws_instance" "web" {}\n')
+    (tmp_path / "old.tf").write_text("")
+    return _FakeSymbolRetriever(tmp_path)
+
+
+def _uri(symbol_retriever: _FakeSymbolRetriever, relative_path: str) -> str:
+    return Path(symbol_retriever.project.project_root, relative_path).as_uri()
+
+
+class TestWorkspaceEditConversion:
+    def test_document_changes_are_preferred_over_changes(self, symbol_retriever: _FakeSymbolRetriever) -> None:
+        code_editor = LanguageServerCodeEditor(symbol_retriever)  # type: ignore
+        uri = _uri(symbol_retriever, "main.tf")
+        edit = {"newText": "app", "range": {"start": {"line": 0, "character": 25}, "end": {"line": 0, "character": 28}}}
+        operations = code_editor._workspace_edit_to_edit_operations(
+            {
+                "changes": {uri: [edit]},
+                "documentChanges": [{"textDocument": {"uri": uri, "version": 3}, "edits": [edit]}],
+            }
+        )
+        assert len(operations) == 1
+        assert isinstance(operations[0], LanguageServerCodeEditor.EditOperationFileTextEdits)
+
+    def test_legacy_changes(self, symbol_retriever: _FakeSymbolRetriever) -> None:
+        code_editor = LanguageServerCodeEditor(symbol_retriever)  # type: ignore
+        operations = code_editor._workspace_edit_to_edit_operations({"changes": {_uri(symbol_retriever, "main.tf"): []}})
+        assert len(operations) == 1
+
+    def test_file_operations(self, symbol_retriever: _FakeSymbolRetriever, tmp_path: Path) -> None:
+        code_editor = LanguageServerCodeEditor(symbol_retriever)  # type: ignore
+        num_operations = code_editor._apply_workspace_edit(
+            {
+                "documentChanges": [
+                    {"kind": "create", "uri": _uri(symbol_retriever, "modules/vpc/main.tf")},
+                    {"kind": "create", "uri": _uri(symbol_retriever, "main.tf"), "options": {"ignoreIfExists": True}},
+                    {"kind": "rename", "oldUri": _uri(symbol_retriever, "old.tf"), "newUri": _uri(symbol_retriever, "new.tf")},
+                    {"kind": "delete", "uri": _uri(symbol_retriever, "new.tf")},
+                ]
+            }
+        )
+        assert num_operations == 4
+        assert (tmp_path / "modules" / "vpc" / "main.tf").read_text() == ""
+        assert (tmp_path / "main.tf").read_text() == 'resource "aws_instance" "web" {}\n'
+        assert not (tmp_path / "old.tf").exists() and not (tmp_path / "new.tf").exists()
+        assert symbol_retriever.notified_changes == [
+            (str(Path("modules/vpc/main.tf")), FileChangeType.Created),
+            ("old.tf", FileChangeType.Deleted),
+            ("new.tf", FileChangeType.Created),
+            ("new.tf", FileChangeType.Deleted),
+        ]
+
+    def test_create_existing_file_fails(self, symbol_retriever: _FakeSymbolRetriever) -> None:
+        code_editor = LanguageServerCodeEditor(symbol_retriever)  # type: ignore
+        with pytest.raises(FileExistsError):
+            code_editor._apply_workspace_edit({"documentChanges": [{"kind": "create", "uri": _uri(symbol_retriever, "main.tf")}]})
+
+    @pytest.mark.parametrize(
+        "document_change",
+        [
+            {"kind": "delete", "uri": "../outside.tf"},
+            {"kind": "create", "uri": "modules/../../outside.tf"},
+            {"kind": "rename", "oldUri": "old.tf", "newUri": "../outside.tf"},
+            {"kind": "delete", "uri": ".terraform/modules.json"},
+            {"textDocument": {"uri": "../outside.tf", "version": 1}, "edits": []},
+        ],
+    )
+    def test_files_outside_of_project_or_ignored_are_rejected(
+        self, symbol_retriever: _FakeSymbolRetriever, tmp_path: Path, document_change: dict
+    ) -> None:
+        # the paths in the parametrisation are relative to the project root
+        document_change = {
+            key: _uri(symbol_retriever, value) if key.endswith(("uri", "Uri")) else value for key, value in document_change.items()
+        }
+        if "textDocument" in document_change:
+            document_change["textDocument"] = {"uri": _uri(symbol_retriever, document_change["textDocument"]["uri"]), "version": 1}
+        code_editor = LanguageServerCodeEditor(symbol_retriever)  # type: ignore
+        with pytest.raises(ValueError):
+            code_editor._apply_workspace_edit(
+                {"documentChanges": [{"kind": "delete", "uri": _uri(symbol_retriever, "main.tf")}, document_change]}
+            )
+        # no operation is applied if any operation is rejected
+        assert (tmp_path / "main.tf").exists()
+
+    def test_preview(self, symbol_retriever: _FakeSymbolRetriever, tmp_path: Path, monkeypatch: pytest.MonkeyPatch) -> None:
+        code_editor = LanguageServerCodeEditor(symbol_retriever)  # type: ignore
+        monkeypatch.setattr(code_editor, "read_file", lambda relative_path: (tmp_path / relative_path).read_text())
+        edit = {"newText": "app", "range": {"start": {"line": 0, "character": 25}, "end": {"line": 0, "character": 28}}}
+        operations = code_editor._workspace_edit_to_edit_operations(
+            {
+                "documentChanges": [
+                    {"textDocument": {"uri": _uri(symbol_retriever, "main.tf"), "version": 3}, "edits": [edit]},
+                    {"kind": "rename", "oldUri": _uri(symbol_retriever, "old.tf"), "newUri": _uri(symbol_retriever, "new.tf")},
+                ]
+            }
+        )
+        previews = [operation.preview() for operation in operations]
+        assert previews[0].num_locations == 1
+        assert previews[0].to_string() == (
+            "main.tf (1 location(s)):\n"
+            "--- a/main.tf\n+++ b/main.tf\n@@ -1 +1 @@\n"
+            '-resource "aws_instance" "web" {}\n'
+            '+resource "aws_instance" "app" {}'
+        )
+        assert previews[1].to_string() == "old.tf: renamed to new.tf"
+        # nothing was changed
+        assert (tmp_path / "main.tf").read_text() == 'resource "aws_instance" "web" {}\n'
+        assert (tmp_path / "old.tf").exists()
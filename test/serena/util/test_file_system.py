@@ -3,9 +3,10 @@ import shutil
 import tempfile
 from pathlib import Path
 
+import pytest
 from pathspec import PathSpec
 
-from serena.util.file_system import GitignoreParser, GitignoreSpec, match_path
+from serena.util.file_system import GitignoreParser, GitignoreSpec, is_path_within_directory, match_path
 
 
 class TestGitignoreParser:
@@ -753,3 +754,29 @@ class TestGitignoreParserPermissionError:
         finally:
             # Restore permissions so teardown can clean up
             os.chmod(unreadable, old_mode)
+
+
+class TestIsPathWithinDirectory:
+    def test_paths_within_and_outside(self, tmp_path):
+        root = tmp_path / "root"
+        (root / "sub").mkdir(parents=True)
+        (tmp_path / "other").mkdir()
+
+        assert is_path_within_directory(str(root), str(root))
+        assert is_path_within_directory(str(root / "sub"), str(root))
+        assert not is_path_within_directory(str(tmp_path / "other"), str(root))
+        assert not is_path_within_directory(str(root / ".." / "other"), str(root))
+        assert not is_path_within_directory(str(tmp_path / "root2"), str(root))
+
+    def test_symlink_escaping_directory(self, tmp_path):
+        root = tmp_path / "root"
+        root.mkdir()
+        outside = tmp_path / "outside"
+        outside.mkdir()
+        link = root / "link"
+        try:
+            link.symlink_to(outside, target_is_directory=True)
+        except OSError:
+            pytest.skip("symlinks not supported")
+
+        assert not is_path_within_directory(str(link), str(root))
@@ -0,0 +1,87 @@
+import asyncio
+import gzip
+import zlib
+from typing import Any
+
+import pytest
+
+from serena.util.http_compression import ResponseCompressionMiddleware, negotiate_encoding
+
+
+@pytest.mark.parametrize(
+    "accept_encoding, expected",
+    [
+        ("gzip, deflate, br", "gzip"),
+        ("zstd, gzip", "zstd"),
+        ("gzip;q=1.0, zstd;q=0.5", "gzip"),
+        ("zstd;q=0, gzip", "gzip"),
+        ("*", "zstd"),
+        ("identity", None),
+        ("", None),
+    ],
+)
+def test_negotiate_encoding(accept_encoding: str, expected: str | None) -> None:
+    assert negotiate_encoding(accept_encoding, ["zstd", "gzip"]) == expected
+
+
+def _create_app(chunks: list[bytes], content_type: bytes = b"application/json"):
+    async def app(scope, receive, send) -> None:
+        await send({"type": "http.response.start", "status": 200, "headers": [(b"content-type", content_type)]})
+        for i, chunk in enumerate(chunks):
+            await send({"type": "http.response.body", "body": chunk, "more_body": i < len(chunks) - 1})
+
+    return app
+
+
+def _run(app, accept_encoding: str = "gzip", minimum_size: int = 100) -> list[dict[str, Any]]:
+    messages: list[dict[str, Any]] = []
+
+    async def send(message) -> None:
+        messages.append(message)
+
+    async def receive() -> dict[str, Any]:
+        return {"type": "http.request", "body": b""}
+
+    scope = {"type": "http", "headers": [(b"accept-encoding", accept_encoding.encode())]}
+    middleware = ResponseCompressionMiddleware(app, minimum_size=minimum_size, encodings=["gzip"])
+    asyncio.run(middleware(scope, receive, send))
+    return messages
+
+
+def test_large_response_is_compressed() -> None:
+    body = b'{"result": "' + b"x" * 1000 + b'"}'
+    start, body_message = _run(_create_app([body]))
+    headers = dict(start["headers"])
+    assert headers[b"content-encoding"] == b"gzip"
+    assert int(headers[b"content-length"]) == len(body_message["body"]) < len(body)
+    assert gzip.decompress(body_message["body"]) == body
+
+
+def test_small_response_is_not_compressed() -> None:
+    start, body_message = _run(_create_app([b'{"result": "ok"}']))
+    assert b"content-encoding" not in dict(start["headers"])
+    assert body_message["body"] == b'{"result": "ok"}'
+
+
+def test_response_is_not_compressed_if_not_accepted() -> None:
+    start, _ = _run(_create_app([b"x" * 1000]), accept_encoding="identity")
+    assert b"content-encoding" not in dict(start["headers"])
+
+
+def test_streamed_chunks_are_decodable_immediately() -> None:
+    chunks = [bytes([ord("a") + i]) * 200 + b"\n" for i in range(3)]
+    start, *body_messages = _run(_create_app(chunks, content_type=b"text/plain"))
+    assert dict(start["headers"])[b"content-encoding"] == b"gzip"
+    decompressor = zlib.decompressobj(16 + zlib.MAX_WBITS)
+    for chunk, message in zip(chunks, body_messages, strict=True):
+        # each chunk is flushed, so it can be decoded without waiting for the rest of the stream
+        assert decompressor.decompress(message["body"]) == chunk
+    assert not body_messages[-1]["more_body"]
+    assert decompressor.eof
+
+
+def test_server_sent_events_are_not_compressed() -> None:
+    events = [b"event: message\ndata: " + bytes([ord("a") + i]) * 200 + b"\n\n" for i in range(3)]
+    start, *body_messages = _run(_create_app(events, content_type=b"text/event-stream"))
+    assert b"content-encoding" not in dict(start["headers"])
+    assert [message["body"] for message in body_messages] == events
@@ -0,0 +1,70 @@
+import pytest
+
+from serena.util.ls_diagnostics import GroupedDiagnostics, is_syntax_error_diagnostic
+from solidlsp import ls_types
+
+
+def _diagnostic(message: str, severity: int = 1, code: str | None = None) -> ls_types.Diagnostic:
+    diagnostic: ls_types.Diagnostic = {
+        "message": message,
+        "range": {"start": {"line": 0, "character": 0}, "end": {"line": 0, "character": 1}},
+        "severity": severity,
+    }
+    if code is not None:
+        diagnostic["code"] = code
+    return diagnostic
+
+
+@pytest.mark.parametrize(
+    "message",
+    [
+        "Argument or block definition required",
+        "Unclosed configuration block",
+        "Invalid expression",
+        "Expected expression",
+        "Unexpected indentation",
+        "SyntaxError: invalid syntax",
+    ],
+)
+def test_syntax_error_messages_are_detected(message: str) -> None:
+    assert is_syntax_error_diagnostic(_diagnostic(message))
+
+
+def test_syntax_error_detection_by_code() -> None:
+    assert is_syntax_error_diagnostic(_diagnostic("Something went wrong", code="parse-error"))
+
+
+@pytest.mark.parametrize(
+    "diagnostic",
+    [
+        _diagnostic('Reference to undeclared resource "aws_s3_bucket.missing"'),
+        _diagnostic('Unexpected keyword argument "foo"'),
+        _diagnostic("Invalid expression", severity=2),
+    ],
+)
+def test_other_diagnostics_are_not_syntax_errors(diagnostic: ls_types.Diagnostic) -> None:
+    assert not is_syntax_error_diagnostic(diagnostic)
+
+
+def test_grouped_diagnostics_as_list() -> None:
+    grouped_diagnostics = GroupedDiagnostics()
+    grouped_diagnostics.add("main.tf", "aws_s3_bucket.logs", _diagnostic("Invalid expression", code="E1"))
+    grouped_diagnostics.add("main.tf", "<file>", _diagnostic("Deprecated attribute", severity=2))
+
+    assert grouped_diagnostics.get_list() == [
+        {
+            "relative_path": "main.tf",
+            "severity": "Error",
+            "name_path": "aws_s3_bucket.logs",
+            "message": "Invalid expression",
+            "range": {"start": {"line": 0, "character": 0}, "end": {"line": 0, "character": 1}},
+            "code": "E1",
+        },
+        {
+            "relative_path": "main.tf",
+            "severity": "Warning",
+            "name_path": "<file>",
+            "message": "Deprecated attribute",
+            "range": {"start": {"line": 0, "character": 0}, "end": {"line": 0, "character": 1}},
+        },
+    ]
@@ -0,0 +1,56 @@
+import json
+
+import pytest
+
+from serena.util.output_format import OutputFormat
+
+
+class TestOutputFormat:
+    DATA = {"dirs": ["src", "test"], "files": ["main.tf"]}
+
+    def test_from_name(self) -> None:
+        assert OutputFormat.from_name("Markdown") == OutputFormat.MARKDOWN
+        assert OutputFormat.from_name(" plain ") == OutputFormat.PLAIN
+        with pytest.raises(ValueError, match="valid formats"):
+            OutputFormat.from_name("yaml")
+
+    def test_json(self) -> None:
+        assert json.loads(OutputFormat.JSON.format(self.DATA)) == self.DATA
+
+    def test_markdown(self) -> None:
+        assert OutputFormat.MARKDOWN.format(self.DATA).split("\n") == [
+            "- **dirs**:",
+            "  - src",
+            "  - test",
+            "- **files**:",
+            "  - main.tf",
+        ]
+
+    def test_plain(self) -> None:
+        assert OutputFormat.PLAIN.format(self.DATA).split("\n") == [
+            "dirs:",
+            "  src",
+            "  test",
+            "files:",
+            "  main.tf",
+        ]
+
+    def test_multiline_text_is_rendered_as_code_block(self) -> None:
+        data = [{"name_path": "foo", "body": 'resource "a" "b" {\n}'}]
+        assert OutputFormat.MARKDOWN.format(data).split("\n") == [
+            "- name_path: foo",
+            "  - **body**:",
+            "    ```",
+            '    resource "a" "b" {',
+            "    }",
+            "    ```",
+        ]
+
+    def test_list_item_without_scalar_entries(self) -> None:
+        data = [{"children": ["a"], "refs": ["b"]}]
+        assert OutputFormat.MARKDOWN.format(data).split("\n") == [
+            "- **children**:",
+            "    - a",
+            "  - **refs**:",
+            "    - b",
+        ]
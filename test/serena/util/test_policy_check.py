@@ -0,0 +1,51 @@
+import json
+
+import pytest
+
+from serena.util.policy_check import parse_conftest_output
+
+CONFTEST_OUTPUT = [
+    {
+        "filename": "main.tf",
+        "namespace": "terraform.tags",
+        "successes": 2,
+        "failures": [{"msg": 'aws_s3_bucket.logs is missing the required tag "owner"', "metadata": {"query": "data.terraform.tags.deny"}}],
+        "warnings": [{"msg": "aws_instance.web uses a previous generation instance type"}],
+    },
+    {
+        "filename": "plan.json",
+        "namespace": "terraform.encryption",
+        "successes": 1,
+        "exceptions": [{"msg": "data.terraform.encryption.exception[_][_] == \"unencrypted_bucket\""}],
+    },
+]
+
+
+def test_parse_conftest_output() -> None:
+    result = parse_conftest_output(json.dumps(CONFTEST_OUTPUT))
+    assert not result.is_passed()
+    assert result.num_successes == 3
+    assert [(v.relative_path, v.severity, v.policy) for v in result.violations] == [
+        ("main.tf", "failure", "data.terraform.tags.deny"),
+        ("main.tf", "warning", None),
+    ]
+    result_dict = result.to_dict()
+    assert result_dict["violations"][1] == {
+        "relative_path": "main.tf",
+        "severity": "warning",
+        "message": "aws_instance.web uses a previous generation instance type",
+        "namespace": "terraform.tags",
+    }
+    assert len(result_dict["exceptions"]) == 1
+
+
+def test_warnings_only_pass() -> None:
+    output = [{"filename": "main.tf", "namespace": "main", "successes": 0, "warnings": [{"msg": "deprecated syntax"}]}]
+    result = parse_conftest_output(json.dumps(output))
+    assert result.is_passed()
+    assert "exceptions" not in result.to_dict()
+
+
+def test_invalid_output() -> None:
+    with pytest.raises(ValueError):
+        parse_conftest_output("conftest: command not found")
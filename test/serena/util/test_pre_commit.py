@@ -0,0 +1,54 @@
+from serena.util.pre_commit import parse_pre_commit_output
+
+PRE_COMMIT_OUTPUT = """[INFO] Initializing environment for https://github.com/antonbabenko/pre-commit-terraform.
+Terraform fmt............................................................Failed
+- hook id: terraform_fmt
+- files were modified by this hook
+
+main.tf
+
+Terraform validate with tflint...........................................Failed
+- hook id: terraform_tflint
+- exit code: 2
+
+Command 'tflint --init' successfully done:
+1 issue(s) found:
+
+Warning: variable "unused" is declared but not used (terraform_unused_declarations)
+
+Terraform docs.......................................(no files to check)Skipped
+check yaml...............................................................Passed
+"""
+
+
+def test_parse_pre_commit_output() -> None:
+    results = parse_pre_commit_output(PRE_COMMIT_OUTPUT)
+    assert [(r.name, r.status) for r in results] == [
+        ("Terraform fmt", "Failed"),
+        ("Terraform validate with tflint", "Failed"),
+        ("Terraform docs", "Skipped"),
+        ("check yaml", "Passed"),
+    ]
+
+    fmt, tflint, docs, check_yaml = results
+    assert fmt.hook_id == "terraform_fmt"
+    assert fmt.files_modified
+    assert fmt.to_dict() == {
+        "name": "Terraform fmt",
+        "status": "Failed",
+        "hook_id": "terraform_fmt",
+        "files_modified": True,
+        "output": "main.tf",
+    }
+
+    assert tflint.exit_code == 2
+    assert not tflint.files_modified
+    assert tflint.output[0] == "Command 'tflint --init' successfully done:"
+    assert "terraform_unused_declarations" in tflint.to_dict()["output"]
+
+    assert docs.skip_reason == "no files to check"
+    assert check_yaml.to_dict() == {"name": "check yaml", "status": "Passed"}
+
+
+def test_parse_empty_output() -> None:
+    assert parse_pre_commit_output("") == []
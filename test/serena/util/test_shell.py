@@ -0,0 +1,29 @@
+import sys
+
+from serena.util.shell import ShellEnvironmentFilter, execute_command
+
+
+class TestShellEnvironmentFilter:
+    def test_denied_patterns(self):
+        env_filter = ShellEnvironmentFilter(denied_patterns=["AWS_*", "GOOGLE_*", "ARM_*"])
+        environ = {"PATH": "/usr/bin", "TF_LOG": "DEBUG", "AWS_SECRET_ACCESS_KEY": "secret", "ARM_CLIENT_SECRET": "secret"}
+        assert env_filter.apply(environ) == {"PATH": "/usr/bin", "TF_LOG": "DEBUG"}
+
+    def test_allowed_patterns_take_precedence(self):
+        env_filter = ShellEnvironmentFilter(allowed_patterns=["AWS_REGION"], denied_patterns=["AWS_*"])
+        assert env_filter.is_propagated("AWS_REGION")
+        assert not env_filter.is_propagated("AWS_ACCESS_KEY_ID")
+
+    def test_matching_is_case_insensitive(self):
+        env_filter = ShellEnvironmentFilter(denied_patterns=["aws_*"])
+        assert not env_filter.is_propagated("AWS_PROFILE")
+        assert not env_filter.is_propagated("aws_profile")
+        assert env_filter.is_propagated("PATH")
+
+
+def test_execute_command_does_not_interpret_shell_syntax(tmp_path):
+    argument = "$(touch injected); echo `touch injected` && touch injected"
+    result = execute_command([sys.executable, "-c", "import sys; print(sys.argv[1])", argument], cwd=str(tmp_path), capture_stderr=True)
+    assert result.return_code == 0
+    assert result.stdout.strip() == argument
+    assert not (tmp_path / "injected").exists()
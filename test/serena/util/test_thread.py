@@ -0,0 +1,38 @@
+import threading
+import time
+
+import pytest
+
+from serena.util.thread import IdleTimeoutWatchdog
+
+
+class TestIdleTimeoutWatchdog:
+    def test_fires_when_idle(self):
+        fired = threading.Event()
+        watchdog = IdleTimeoutWatchdog(0.2, fired.set, check_interval=0.02)
+        watchdog.start()
+        assert fired.wait(timeout=5)
+
+    def test_does_not_fire_during_activity(self):
+        fired = threading.Event()
+        watchdog = IdleTimeoutWatchdog(0.2, fired.set, check_interval=0.02)
+        watchdog.start()
+        with watchdog.activity():
+            time.sleep(0.5)
+            assert not fired.is_set()
+            assert watchdog.get_idle_time() == 0.0
+        # the idle period starts anew once the activity has ended
+        assert not fired.is_set()
+        assert fired.wait(timeout=5)
+        watchdog.stop()
+
+    def test_stop(self):
+        fired = threading.Event()
+        watchdog = IdleTimeoutWatchdog(0.2, fired.set, check_interval=0.02)
+        watchdog.start()
+        watchdog.stop()
+        assert not fired.wait(timeout=0.5)
+
+    def test_invalid_timeout(self):
+        with pytest.raises(ValueError):
+            IdleTimeoutWatchdog(0, lambda: None)
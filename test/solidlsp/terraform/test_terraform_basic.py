@@ -10,6 +10,7 @@ import pytest
 from solidlsp import SolidLanguageServer
 from solidlsp.ls_config import LanguageServerId
 from solidlsp.ls_types import SymbolKind
+from solidlsp.ls_utils import TextUtils
 from test.conftest import language_server_tests_enabled
 from test.solidlsp.conftest import format_symbol_for_assert, has_malformed_name, request_all_symbols
 
@@ -72,3 +73,36 @@ class TestLanguageServerBasics:
                 f"Found malformed symbols: {[format_symbol_for_assert(sym) for sym in malformed_symbols]}",
                 pytrace=False,
             )
+
+    @pytest.mark.parametrize("language_server", [LanguageServerId.TERRAFORM], indirect=True)
+    def test_request_formatting_edits(self, language_server: SolidLanguageServer) -> None:
+        """Test that formatting edits are provided and that the formatting is idempotent."""
+        file_path = "variables.tf"
+        edits = language_server.request_formatting_edits(file_path)
+        assert edits is not None, "terraform-ls should provide formatting for Terraform files"
+        with language_server.open_file(file_path) as file_buffer:
+            formatted_contents = TextUtils.apply_text_edits(file_buffer.contents, edits)
+            file_buffer.update_contents(formatted_contents)
+            assert not language_server.request_formatting_edits(file_path)
+
+    @pytest.mark.parametrize("language_server", [LanguageServerId.TERRAFORM], indirect=True)
+    def test_tf_kinds(self, language_server: SolidLanguageServer) -> None:
+        """Test that the symbols are annotated with their Terraform-specific kinds."""
+        tf_kind_by_name = {s["name"]: s.get("tf_kind") for s in language_server.request_document_symbols("main.tf").iter_symbols()}
+        assert tf_kind_by_name["terraform"] == "terraform"
+        assert tf_kind_by_name['provider "aws"'] == "provider"
+        assert tf_kind_by_name['resource "aws_instance" "web_server"'] == "resource"
+        assert tf_kind_by_name["ami"] is None
+        root_symbols = language_server.request_document_symbols("data.tf").root_symbols
+        assert {s.get("tf_kind") for s in root_symbols} == {"data"}
+
+    @pytest.mark.parametrize("language_server", [LanguageServerId.TERRAFORM], indirect=True)
+    def test_resolve_symbol_address(self, language_server: SolidLanguageServer) -> None:
+        """Test that Terraform addresses are resolved to name path patterns matching the symbols."""
+        resolved_address = language_server.resolve_symbol_address("aws_instance.web_server")
+        assert resolved_address is not None
+        name_path_pattern, within_relative_path = resolved_address
+        assert within_relative_path is None
+        symbols = language_server.request_document_symbols("main.tf").root_symbols
+        assert name_path_pattern.lstrip("/") in [s["name"] for s in symbols]
+        assert language_server.resolve_symbol_address('resource "aws_instance" "web_server"') is None
@@ -0,0 +1,60 @@
+"""
+Tests for the Terraform settings which determine the files that are part of a project
+(`additional_file_suffixes` and `ignored_dirnames`).
+"""
+
+from pathlib import Path
+
+import pytest
+
+from solidlsp.ls_config import LanguageServerId
+from test.conftest import language_server_tests_enabled, start_ls_context
+
+
+def _create_repo(repo_path: Path) -> None:
+    (repo_path / "main.tf").write_text('resource "aws_s3_bucket" "logs" {\n  bucket = "logs"\n}\n')
+    (repo_path / "live").mkdir()
+    (repo_path / "live" / "terragrunt.hcl").write_text('include "root" {\n  path = find_in_parent_folders()\n}\n')
+    (repo_path / "image.pkr.hcl").write_text('source "amazon-ebs" "base" {\n  region = "eu-central-1"\n}\n')
+
+
+@pytest.mark.skipif(
+    not language_server_tests_enabled(LanguageServerId.TERRAFORM), reason="Terraform tests are disabled (terraform CLI not available)"
+)
+@pytest.mark.terraform
+class TestTerraformFileSettings:
+    def test_defaults(self, tmp_path: Path) -> None:
+        repo_path = tmp_path / "repo"
+        repo_path.mkdir()
+        _create_repo(repo_path)
+        with start_ls_context(LanguageServerId.TERRAFORM, repo_path=str(repo_path), solidlsp_dir=tmp_path / "solidlsp") as ls:
+            assert not ls.is_ignored_path("live/terragrunt.hcl")
+            assert not ls.is_ignored_path("image.pkr.hcl")
+            assert ls.is_ignored_path(".terraform.lock.hcl")
+            assert ls.is_ignored_path("live/.terragrunt-cache/abc/main.tf")
+            assert ls.is_ignored_path(".terraform/modules/vpc/main.tf")
+            assert not ls.is_ignored_path("vendor/main.tf")
+
+            # HCL files which are not supported by terraform-ls are parsed natively
+            symbol_names = [s["name"] for s in ls.request_document_symbols("live/terragrunt.hcl").iter_symbols()]
+            assert 'include "root"' in symbol_names
+
+    def test_custom_settings(self, tmp_path: Path) -> None:
+        repo_path = tmp_path / "repo"
+        repo_path.mkdir()
+        _create_repo(repo_path)
+        ls_specific_settings = {
+            LanguageServerId.TERRAFORM: {"additional_file_suffixes": ["terragrunt.hcl"], "ignored_dirnames": ["vendor"]},
+        }
+        with start_ls_context(
+            LanguageServerId.TERRAFORM,
+            repo_path=str(repo_path),
+            ls_specific_settings=ls_specific_settings,
+            solidlsp_dir=tmp_path / "solidlsp",
+        ) as ls:
+            assert not ls.is_ignored_path("live/terragrunt.hcl")
+            assert ls.is_ignored_path("image.pkr.hcl")
+            assert ls.is_ignored_path("vendor/main.tf")
+            # the always-ignored directories remain ignored
+            assert ls.is_ignored_path("live/.terragrunt-cache/abc/main.tf")
+            assert not ls.is_ignored_path("main.tf")
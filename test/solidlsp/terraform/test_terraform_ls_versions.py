@@ -0,0 +1,167 @@
+import os
+from types import SimpleNamespace
+
+import pytest
+
+from solidlsp.language_servers import terraform_ls
+from solidlsp.language_servers.terraform_ls import (
+    DEFAULT_TERRAFORM_LS_VERSION,
+    INITIAL_TERRAFORM_LS_VERSION,
+    TERRAFORM_LS_PATH_ENV_VAR,
+    TerraformLS,
+)
+from solidlsp.settings import SolidLSPSettings
+
+
+@pytest.mark.terraform
+class TestTerraformLSVersions:
+    def test_configured_version(self, tmp_path) -> None:
+        assert TerraformLS.get_configured_version(SolidLSPSettings(solidlsp_dir=str(tmp_path))) == DEFAULT_TERRAFORM_LS_VERSION
+        settings = SolidLSPSettings(solidlsp_dir=str(tmp_path), ls_specific_settings={"terraform": {"terraform_ls_version": "0.38.0"}})
+        assert TerraformLS.get_configured_version(settings) == "0.38.0"
+
+    def test_installed_versions(self, tmp_path) -> None:
+        settings = SolidLSPSettings(solidlsp_dir=str(tmp_path))
+        assert TerraformLS.get_installed_versions(settings) == []
+
+        binary_name = TerraformLS._get_binary_name()
+        resources_dir = TerraformLS.ls_resources_dir(settings)
+        versioned_install_dirs = [os.path.join(resources_dir, f"terraform-ls-{version}") for version in ("0.38.0", "0.9.0")]
+        for install_dir in (resources_dir, *versioned_install_dirs):
+            os.makedirs(install_dir, exist_ok=True)
+            with open(os.path.join(install_dir, binary_name), "w") as f:
+                f.write("")
+        os.makedirs(os.path.join(resources_dir, "terraform-ls-0.37.0"))  # incomplete installation
+
+        # versions are sorted numerically
+        assert TerraformLS.get_installed_versions(settings) == ["0.9.0", INITIAL_TERRAFORM_LS_VERSION, "0.38.0"]
+        # an installed version is not downloaded again
+        assert TerraformLS.install(settings, "0.38.0") == os.path.join(resources_dir, "terraform-ls-0.38.0", binary_name)
+
+    def test_unverifiable_download_is_refused(self, tmp_path, monkeypatch: pytest.MonkeyPatch) -> None:
+        def urlopen(*args, **kwargs):
+            raise OSError("network unavailable")
+
+        monkeypatch.setattr(terraform_ls.urllib.request, "urlopen", urlopen)
+        settings = SolidLSPSettings(solidlsp_dir=str(tmp_path))
+        with pytest.raises(RuntimeError, match="unverified"):
+            TerraformLS.install(settings, "0.38.0")
+        assert TerraformLS.get_installed_versions(settings) == []
+
+    @pytest.mark.parametrize("download_succeeds", [True, False])
+    def test_forced_reinstallation(self, tmp_path, monkeypatch: pytest.MonkeyPatch, download_succeeds: bool) -> None:
+        settings = SolidLSPSettings(solidlsp_dir=str(tmp_path))
+        resources_dir = TerraformLS.ls_resources_dir(settings)
+        executable_path = os.path.join(resources_dir, "terraform-ls-0.38.0", TerraformLS._get_binary_name())
+        os.makedirs(os.path.dirname(executable_path))
+        with open(executable_path, "w") as f:
+            f.write("installed")
+
+        class Dependencies:
+            @staticmethod
+            def get_single_dep_for_current_platform() -> SimpleNamespace:
+                return SimpleNamespace(url="https://releases.hashicorp.com/terraform-ls.zip", sha256="0" * 64)
+
+            @staticmethod
+            def install(target_dir: str) -> None:
+                if not download_succeeds:
+                    raise RuntimeError("download failed")
+                with open(os.path.join(target_dir, TerraformLS._get_binary_name()), "w") as f:
+                    f.write("downloaded")
+
+        monkeypatch.setattr(TerraformLS, "_create_runtime_dependencies", classmethod(lambda cls, version: Dependencies()))
+        if download_succeeds:
+            assert TerraformLS.install(settings, "0.38.0", force=True) == executable_path
+        else:
+            with pytest.raises(RuntimeError, match="download failed"):
+                TerraformLS.install(settings, "0.38.0", force=True)
+        # the existing installation is replaced only by a successful download, and the download directory is removed
+        with open(executable_path) as f:
+            assert f.read() == ("downloaded" if download_succeeds else "installed")
+        assert sorted(os.listdir(resources_dir)) == ["terraform-ls-0.38.0"]
+
+    def test_custom_executable(self, tmp_path, monkeypatch: pytest.MonkeyPatch) -> None:
+        executable_path = str(tmp_path / "terraform-ls-approved")
+        with open(executable_path, "w") as f:
+            f.write("")
+        monkeypatch.delenv(TERRAFORM_LS_PATH_ENV_VAR, raising=False)
+        settings = SolidLSPSettings(solidlsp_dir=str(tmp_path))
+        assert TerraformLS.get_custom_executable_path(settings) is None
+
+        # the environment variable bypasses the download
+        monkeypatch.setenv(TERRAFORM_LS_PATH_ENV_VAR, executable_path)
+        assert TerraformLS.get_custom_executable_path(settings) == executable_path
+        provider = TerraformLS.DependencyProvider(SolidLSPSettings.CustomLSSettings({}), str(tmp_path / "resources"))
+        assert provider.create_launch_command() == [executable_path, "serve"]
+
+        # ls_path takes precedence over the environment variable
+        provider = TerraformLS.DependencyProvider(SolidLSPSettings.CustomLSSettings({"ls_path": "/opt/terraform-ls"}), str(tmp_path))
+        assert provider.create_launch_command() == ["/opt/terraform-ls", "serve"]
+
+    @pytest.mark.skipif(os.name == "nt", reason="uses a shell script as fake executable")
+    @pytest.mark.parametrize("version, is_used", [("0.36.5", True), ("0.30.0", False)])
+    def test_system_executable(self, tmp_path, monkeypatch: pytest.MonkeyPatch, version: str, is_used: bool) -> None:
+        executable_path = str(tmp_path / "terraform-ls")
+        with open(executable_path, "w") as f:
+            f.write(f"#!/bin/sh\necho '{version}'\necho 'platform: linux/amd64'\n")
+        os.chmod(executable_path, 0o755)
+        monkeypatch.setattr(terraform_ls.shutil, "which", lambda name: executable_path if name == "terraform-ls" else None)
+        monkeypatch.delenv(TERRAFORM_LS_PATH_ENV_VAR, raising=False)
+
+        assert TerraformLS.find_system_executable() == ((executable_path, version) if is_used else None)
+        if is_used:
+            provider = TerraformLS.DependencyProvider(SolidLSPSettings.CustomLSSettings({}), str(tmp_path / "resources"))
+            assert provider.create_launch_command() == [executable_path, "serve"]
+
+    @pytest.mark.skipif(os.name == "nt", reason="uses a shell script as fake executable")
+    def test_used_version(self, tmp_path, monkeypatch: pytest.MonkeyPatch) -> None:
+        # the used version is part of the symbol cache version, such that an upgrade invalidates the cached symbols
+        executable_path = str(tmp_path / "terraform-ls")
+        with open(executable_path, "w") as f:
+            f.write("#!/bin/sh\necho '0.37.0'\n")
+        os.chmod(executable_path, 0o755)
+        monkeypatch.setattr(terraform_ls.shutil, "which", lambda name: executable_path if name == "terraform-ls" else None)
+        monkeypatch.delenv(TERRAFORM_LS_PATH_ENV_VAR, raising=False)
+
+        def used_version(settings: dict) -> str:
+            return TerraformLS.determine_used_version(SolidLSPSettings.CustomLSSettings(settings))
+
+        assert used_version({}) == "0.37.0"
+        assert used_version({"use_system_terraform_ls": False}) == DEFAULT_TERRAFORM_LS_VERSION
+        assert used_version({"terraform_ls_version": "0.38.0"}) == "0.38.0"
+        assert used_version({"terraform_ls_version": "0.38.0", "ls_path": executable_path}) == "0.37.0"
+        assert used_version({"ls_path": str(tmp_path / "missing")}) == "unknown"
+
+    @pytest.mark.skipif(os.name == "nt", reason="uses a shell script as fake executable")
+    @pytest.mark.parametrize(
+        "script, expected_version",
+        [
+            ("echo 'Terraform v1.9.5'\necho 'on linux_amd64'\n", "v1.9.5"),
+            ("exit 1\n", None),
+            (None, None),
+        ],
+    )
+    def test_terraform_cli_version(
+        self, tmp_path, monkeypatch: pytest.MonkeyPatch, script: str | None, expected_version: str | None
+    ) -> None:
+        executable_path = str(tmp_path / "terraform")
+        if script is not None:
+            with open(executable_path, "w") as f:
+                f.write(f"#!/bin/sh\n{script}")
+            os.chmod(executable_path, 0o755)
+        monkeypatch.setattr(
+            terraform_ls.shutil, "which", lambda name: executable_path if name == "terraform" and script is not None else None
+        )
+        assert TerraformLS.determine_terraform_cli_version() == expected_version
+
+
+@pytest.mark.terraform
+def test_workspace(tmp_path, monkeypatch: pytest.MonkeyPatch) -> None:
+    monkeypatch.delenv("TF_WORKSPACE", raising=False)
+    assert TerraformLS.determine_workspace(str(tmp_path)) == "default"
+    (tmp_path / ".terraform").mkdir()
+    (tmp_path / ".terraform" / "environment").write_text("staging\n")
+    assert TerraformLS.determine_workspace(str(tmp_path)) == "staging"
+    # the environment variable takes precedence
+    monkeypatch.setenv("TF_WORKSPACE", "prod")
+    assert TerraformLS.determine_workspace(str(tmp_path)) == "prod"
@@ -0,0 +1,88 @@
+"""Unit tests: code actions requested via ``request_code_actions`` are filtered to those which perform a workspace edit,
+resolving the edits of code actions that do not include them.
+
+No language markers: these use a local test double and run in catch-all.
+"""
+
+import threading
+from collections import OrderedDict
+from unittest.mock import MagicMock
+
+from solidlsp.ls import SolidLanguageServer
+
+EDIT = {
+    "changes": {"file:///main.tf": [{"range": {"start": {"line": 0, "character": 0}, "end": {"line": 0, "character": 2}}, "newText": ""}]}
+}
+DIAGNOSTIC = {"range": {"start": {"line": 1, "character": 0}, "end": {"line": 1, "character": 5}}, "message": "Required attribute missing"}
+
+
+class DummyLanguageServer(SolidLanguageServer):
+    def _start_server(self) -> None:
+        raise AssertionError("Not used in this test")
+
+    def _create_base_initialize_params(self) -> dict:
+        return {}
+
+
+def _create_language_server(tmp_path, code_action_response: list[dict]) -> DummyLanguageServer:
+    (tmp_path / "main.tf").write_text('resource "aws_instance" "web" {\n  ami = "ami-123"\n}\n', encoding="utf-8")
+
+    send = MagicMock()
+    send.code_action.return_value = code_action_response
+    send.resolve_code_action.side_effect = lambda code_action: {**code_action, "edit": EDIT}
+    server = MagicMock()
+    server.send = send
+
+    language_server = object.__new__(DummyLanguageServer)
+    language_server.repository_root_path = str(tmp_path)
+    language_server.server_started = True
+    language_server.open_file_buffers = {}
+    language_server._idle_file_buffers = OrderedDict()
+    language_server._file_buffers_lock = threading.RLock()
+    language_server._keep_open_files = 0
+    language_server._encoding = "utf-8"
+    language_server.language_id = "terraform"
+    language_server.server = server
+    language_server._published_diagnostics_condition = threading.Condition()
+    language_server._published_diagnostics = {}
+    return language_server
+
+
+def test_only_code_actions_with_edits_are_returned(tmp_path) -> None:
+    language_server = _create_language_server(
+        tmp_path,
+        [
+            {"title": "Format document", "kind": "source.formatAll", "edit": EDIT},
+            {"title": "Add required attributes", "kind": "quickfix", "data": {"id": 1}},
+            {"title": "Run command", "command": "terraform-ls.init"},
+            {"title": "Disabled", "kind": "quickfix", "edit": EDIT, "disabled": {"reason": "not applicable"}},
+            {"title": "Command only", "command": {"title": "Init", "command": "terraform-ls.init"}},
+        ],
+    )
+    code_actions = language_server.request_code_actions("main.tf")
+    assert [code_action["title"] for code_action in code_actions] == ["Format document", "Add required attributes"]
+    assert code_actions[1]["edit"] == EDIT
+
+
+def test_published_diagnostics_are_passed_as_context(tmp_path) -> None:
+    language_server = _create_language_server(tmp_path, [])
+    uri = (tmp_path / "main.tf").as_uri()
+    language_server._published_diagnostics[language_server._canonicalize_published_diagnostics_uri(uri)] = [DIAGNOSTIC]
+
+    language_server.request_code_actions("main.tf", start_line=1)
+    params = language_server.server.send.code_action.call_args.args[0]
+    assert params["range"] == {"start": {"line": 1, "character": 0}, "end": {"line": 3, "character": 0}}
+    assert params["context"]["diagnostics"] == [DIAGNOSTIC]
+
+    language_server.request_code_actions("main.tf", start_line=0, end_line=0)
+    params = language_server.server.send.code_action.call_args.args[0]
+    assert params["context"]["diagnostics"] == []
+
+
+def test_code_actions_for_empty_file(tmp_path) -> None:
+    language_server = _create_language_server(tmp_path, [])
+    (tmp_path / "empty.tf").write_text("", encoding="utf-8")
+
+    assert language_server.request_code_actions("empty.tf") == []
+    params = language_server.server.send.code_action.call_args.args[0]
+    assert params["range"] == {"start": {"line": 0, "character": 0}, "end": {"line": 0, "character": 0}}
@@ -0,0 +1,124 @@
+"""Unit tests: files used by requests can be kept open in the language server (with least recently used files being
+closed first), and changes to their contents are sent as incremental didChange notifications.
+
+No language markers: these use a language server without a process and run in catch-all.
+"""
+
+import os
+import threading
+from collections import OrderedDict
+from pathlib import Path
+from unittest.mock import MagicMock
+
+from solidlsp.ls import SolidLanguageServer
+
+
+class _DummyLanguageServer(SolidLanguageServer):
+    def _start_server(self) -> None:
+        raise AssertionError("Not used in this test")
+
+    def _create_base_initialize_params(self) -> dict:
+        return {}
+
+
+def _create_language_server(root: Path, keep_open_files: int) -> _DummyLanguageServer:
+    language_server = object.__new__(_DummyLanguageServer)
+    language_server.repository_root_path = str(root)
+    language_server.server_started = True
+    language_server.open_file_buffers = {}
+    language_server._idle_file_buffers = OrderedDict()
+    language_server._file_buffers_lock = threading.RLock()
+    language_server._keep_open_files = keep_open_files
+    language_server._encoding = "utf-8"
+    language_server.language_id = "terraform"
+    language_server.server = MagicMock()
+    language_server.clear_position_request_cache = MagicMock()  # type: ignore[method-assign]
+    return language_server
+
+
+def _opened_uris(language_server: SolidLanguageServer) -> list[str]:
+    return [c.args[0]["textDocument"]["uri"] for c in language_server.server.notify.did_open_text_document.call_args_list]
+
+
+def _closed_uris(language_server: SolidLanguageServer) -> list[str]:
+    return [c.args[0]["textDocument"]["uri"] for c in language_server.server.notify.did_close_text_document.call_args_list]
+
+
+def test_files_are_closed_after_use_by_default(tmp_path: Path) -> None:
+    (tmp_path / "main.tf").write_text('resource "a" "b" {}\n', encoding="utf-8")
+    language_server = _create_language_server(tmp_path, keep_open_files=0)
+    with language_server.open_file("main.tf"):
+        pass
+    assert not language_server.open_file_buffers
+    assert len(_closed_uris(language_server)) == 1
+
+
+def test_least_recently_used_files_are_closed(tmp_path: Path) -> None:
+    for name in ("a.tf", "b.tf", "c.tf"):
+        (tmp_path / name).write_text("", encoding="utf-8")
+    language_server = _create_language_server(tmp_path, keep_open_files=2)
+    for name in ("a.tf", "b.tf", "a.tf", "c.tf"):
+        with language_server.open_file(name):
+            pass
+
+    # a.tf was opened only once (being reused when used again), and b.tf was closed as the least recently used file
+    assert _opened_uris(language_server) == [(tmp_path / name).as_uri() for name in ("a.tf", "b.tf", "c.tf")]
+    assert _closed_uris(language_server) == [(tmp_path / "b.tf").as_uri()]
+    assert set(language_server.open_file_buffers) == {(tmp_path / name).as_uri() for name in ("a.tf", "c.tf")}
+
+
+def test_changes_are_sent_incrementally(tmp_path: Path) -> None:
+    path = tmp_path / "main.tf"
+    path.write_text('variable "region" {\n  default = "eu-west-1"\n}\n', encoding="utf-8")
+    language_server = _create_language_server(tmp_path, keep_open_files=1)
+    with language_server.open_file("main.tf") as fb:
+        fb.update_contents('variable "region" {\n  default = "us-east-1"\n}\n')
+
+    params = language_server.server.notify.did_change_text_document.call_args.args[0]
+    assert params["textDocument"]["version"] == 1
+    assert params["contentChanges"] == [
+        {"range": {"start": {"line": 1, "character": 13}, "end": {"line": 1, "character": 18}}, "text": "us-ea"}
+    ]
+
+
+def test_kept_open_files_are_synchronised_with_disk(tmp_path: Path) -> None:
+    path = tmp_path / "main.tf"
+    path.write_text("a = 1\n", encoding="utf-8")
+    language_server = _create_language_server(tmp_path, keep_open_files=1)
+    with language_server.open_file("main.tf"):
+        pass
+
+    path.write_text("a = 2\n", encoding="utf-8")
+    stat = path.stat()
+    os.utime(path, (stat.st_atime, stat.st_mtime + 10))
+    language_server.sync_kept_open_files(["main.tf"])
+    params = language_server.server.notify.did_change_text_document.call_args.args[0]
+    assert params["contentChanges"][0]["text"] == "2"
+
+    path.unlink()
+    language_server.sync_kept_open_files(["main.tf"])
+    assert not language_server.open_file_buffers
+    assert _closed_uris(language_server) == [path.as_uri()]
+
+
+def test_concurrently_used_files_are_opened_and_closed_once(tmp_path: Path) -> None:
+    (tmp_path / "main.tf").write_text("a = 1\n", encoding="utf-8")
+    language_server = _create_language_server(tmp_path, keep_open_files=0)
+    barrier = threading.Barrier(8)
+
+    def use_file() -> None:
+        with language_server.open_file("main.tf"):
+            # all threads use the file at the same time
+            barrier.wait(timeout=10)
+        for _ in range(50):
+            with language_server.open_file("main.tf"):
+                pass
+
+    threads = [threading.Thread(target=use_file) for _ in range(8)]
+    for thread in threads:
+        thread.start()
+    for thread in threads:
+        thread.join()
+
+    assert not language_server.open_file_buffers
+    assert len(_opened_uris(language_server)) == len(_closed_uris(language_server))
@@ -44,3 +44,13 @@ class TestFilenameMatcherCaseSensitivity:
         assert matcher.string_contains_relevant_filename("main.py")
         # ".py" embedded in ".python" is not a complete extension occurrence.
         assert not matcher.string_contains_relevant_filename("file.python")
+
+
+def test_excluded_filenames() -> None:
+    matcher = FilenameMatcher(".tf", excluded_filenames=(".terraform.lock.hcl",))
+    matcher.add_extensions(".hcl")
+    assert matcher.is_relevant_filename("live/terragrunt.hcl")
+    assert not matcher.is_relevant_filename(".terraform.lock.hcl")
+    assert not matcher.is_relevant_filename("live/.terraform.lock.hcl")
+    # the exclusion applies to the file name only
+    assert matcher.is_relevant_filename("other.terraform.lock.hcl")
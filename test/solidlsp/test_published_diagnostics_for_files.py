@@ -0,0 +1,70 @@
+"""Unit tests: diagnostics collected for multiple files via ``request_published_diagnostics_for_files``,
+including the reporting of files for which no diagnostics were published before the timeout.
+
+No language markers: these use a local test double and run in catch-all.
+"""
+
+import threading
+from collections import OrderedDict
+from unittest.mock import MagicMock
+
+from solidlsp.ls import SolidLanguageServer
+
+DIAGNOSTIC = {
+    "range": {"start": {"line": 1, "character": 0}, "end": {"line": 1, "character": 5}},
+    "message": "Required attribute missing",
+    "severity": 1,
+}
+
+
+class DummyLanguageServer(SolidLanguageServer):
+    def _start_server(self) -> None:
+        raise AssertionError("Not used in this test")
+
+    def _create_base_initialize_params(self) -> dict:
+        return {}
+
+
+def _create_language_server(tmp_path, published_diagnostics: dict[str, list[dict]]) -> DummyLanguageServer:
+    """
+    :param published_diagnostics: the diagnostics which the language server publishes upon opening a file (by file name);
+        nothing is published for files which are not contained
+    """
+    language_server = object.__new__(DummyLanguageServer)
+
+    def did_open_text_document(params: dict) -> None:
+        uri = params["textDocument"]["uri"]
+        file_name = uri.rsplit("/", 1)[-1]
+        if file_name in published_diagnostics:
+            language_server._store_published_diagnostics({"uri": uri, "diagnostics": published_diagnostics[file_name]})
+
+    for file_name in ("main.tf", "variables.tf", "outputs.tf"):
+        (tmp_path / file_name).write_text("", encoding="utf-8")
+    server = MagicMock()
+    server.notify.did_open_text_document.side_effect = did_open_text_document
+
+    language_server.repository_root_path = str(tmp_path)
+    language_server.server_started = True
+    language_server.open_file_buffers = {}
+    language_server._idle_file_buffers = OrderedDict()
+    language_server._file_buffers_lock = threading.RLock()
+    language_server._keep_open_files = 0
+    language_server._encoding = "utf-8"
+    language_server.language_id = "terraform"
+    language_server.server = server
+    language_server._published_diagnostics_condition = threading.Condition()
+    language_server._published_diagnostics = {}
+    language_server._published_diagnostics_generation_by_uri = {}
+    language_server._published_diagnostics_generation = 0
+    return language_server
+
+
+def test_files_without_published_diagnostics_are_reported_as_timed_out(tmp_path) -> None:
+    language_server = _create_language_server(tmp_path, {"main.tf": [DIAGNOSTIC], "outputs.tf": []})
+    diagnostics_by_file, timed_out_files = language_server.request_published_diagnostics_for_files(
+        ["main.tf", "variables.tf", "outputs.tf"], timeout=0.1
+    )
+    assert list(diagnostics_by_file) == ["main.tf"]
+    assert diagnostics_by_file["main.tf"][0]["message"] == DIAGNOSTIC["message"]
+    # an empty payload was published for outputs.tf (no problems), but nothing at all for variables.tf
+    assert timed_out_files == ["variables.tf"]
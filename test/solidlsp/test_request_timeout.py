@@ -0,0 +1,68 @@
+"""Unit tests: requests to which the language server does not respond within the request timeout
+are cancelled and raise ``LanguageServerRequestTimeoutError``; requests to a terminated server fail immediately.
+
+No language markers: these use a local test double and run in catch-all.
+"""
+
+import logging
+
+import pytest
+
+from solidlsp.ls_config import LanguageServerId
+from solidlsp.ls_exceptions import LanguageServerRequestTimeoutError, SolidLSPException
+from solidlsp.ls_process import LanguageServerInterface
+
+
+class _UnresponsiveServer(LanguageServerInterface):
+    """Test double which records the payloads it is sent but never responds to requests."""
+
+    def __init__(self) -> None:
+        super().__init__(LanguageServerId.PYTHON, lambda _line: logging.INFO, request_timeout=0.05)
+        self.sent_payloads: list[dict] = []
+        self.running = True
+
+    def is_running(self) -> bool:
+        return self.running
+
+    def _start(self) -> None:
+        pass
+
+    def _stop(self, timeout: float) -> None:
+        pass
+
+    def _send_payload(self, payload: dict) -> None:
+        self.sent_payloads.append(payload)
+
+
+def test_timed_out_request_is_cancelled() -> None:
+    server = _UnresponsiveServer()
+    with pytest.raises(LanguageServerRequestTimeoutError) as exc_info:
+        server.send_request("textDocument/hover", {})
+    assert exc_info.value.method == "textDocument/hover"
+    assert exc_info.value.timeout == 0.05
+    assert isinstance(exc_info.value, SolidLSPException)
+    assert isinstance(exc_info.value, TimeoutError)
+
+    # the request is no longer pending, and the server was asked to cancel it
+    request_id = server.sent_payloads[0]["id"]
+    assert not server._pending_requests
+    assert server.sent_payloads[1]["method"] == "$/cancelRequest"
+    assert server.sent_payloads[1]["params"] == {"id": request_id}
+
+
+def test_late_response_is_discarded() -> None:
+    server = _UnresponsiveServer()
+    with pytest.raises(LanguageServerRequestTimeoutError):
+        server.send_request("textDocument/hover", {})
+    server._response_handler({"id": server.sent_payloads[0]["id"], "result": None})
+    assert not server._pending_requests
+
+
+def test_request_to_terminated_server_fails_immediately() -> None:
+    server = _UnresponsiveServer()
+    server.set_request_timeout(None)
+    server.running = False
+    with pytest.raises(SolidLSPException) as exc_info:
+        server.send_request("textDocument/hover", {})
+    assert exc_info.value.is_language_server_terminated()
+    assert not server._pending_requests
@@ -4,6 +4,7 @@ import pytest
 
 from solidlsp.ls import SymbolBodyFactory
 from solidlsp.ls_exceptions import InvalidTextLocationError
+from solidlsp.ls_utils import TextUtils
 
 
 class _StubBuffer:
@@ -87,3 +88,64 @@ def test_get_text_end_line_past_eof_with_nonzero_col_raises() -> None:
     body = _factory().create_symbol_body(_symbol(0, 0, len(LINES), 5))
     with pytest.raises(InvalidTextLocationError):
         body.get_text()
+
+
+TF_LINES = [
+    'variable "region" {}',
+    "",
+    "# The main VPC of the deployment.",
+    "# Managed by the networking team.",
+    'resource "aws_vpc" "main" {',
+    '  cidr_block = "10.0.0.0/16"',
+    "}",
+    'module "net" {',
+    "  # a nested comment",
+    "  # with two lines",
+    "  enable = true",
+    "}",
+]
+
+
+@pytest.mark.parametrize(
+    ("symbol", "expected_text", "expected_start_line"),
+    [
+        (_symbol(4, 0, 6, 1), "\n".join(TF_LINES[2:7]), 2),
+        (_symbol(10, 2, 10, 15), "# a nested comment\n  # with two lines\n  enable = true", 8),
+        # no comment lines directly above
+        (_symbol(7, 0, 11, 1), "\n".join(TF_LINES[7:12]), 7),
+        # preceded by content in the same line
+        (_symbol(10, 11, 10, 15), "true", 10),
+    ],
+)
+def test_get_text_with_leading_comments(symbol: dict, expected_text: str, expected_start_line: int) -> None:
+    body = SymbolBodyFactory(_StubBuffer(list(TF_LINES))).create_symbol_body(symbol)
+    assert body.get_text(include_leading_comments=True) == expected_text
+    assert body.get_leading_comment_start_line() == expected_start_line
+    assert not body.get_text().startswith("#")
+
+
+BLOCK_COMMENT_LINES = [
+    "const total = price",
+    "  * quantity;",
+    "/**",
+    " * Computes the total.",
+    " */",
+    "function total() {}",
+    "- item",
+    "* another item",
+    "function other() {}",
+]
+
+
+@pytest.mark.parametrize(
+    ("line", "expected_start_line"),
+    [
+        # the lines continuing a block comment are part of the comment
+        (5, 2),
+        # lines starting with `*` which are not part of a block comment (a multiplication or a Markdown list item) are not comments
+        (2, 2),
+        (8, 8),
+    ],
+)
+def test_leading_block_comments(line: int, expected_start_line: int) -> None:
+    assert TextUtils.find_leading_comment_start_line(BLOCK_COMMENT_LINES, line) == expected_start_line
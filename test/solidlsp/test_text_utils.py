@@ -1,5 +1,6 @@
 import pytest
 
+from solidlsp.ls_exceptions import OverlappingTextEditsError
 from solidlsp.ls_utils import InvalidTextLocationError, TextStepper, TextUtils
 
 
@@ -83,3 +84,71 @@ class TestTextUtils:
         with pytest.raises(InvalidTextLocationError):
             # end_line = 5 is well past the one-line-past-EOF position (3) for a 3-line file.
             TextUtils.delete_text_between_positions("a\nb\nc", 0, 0, 5, 0)
+
+
+def _edit(start_line: int, start_col: int, end_line: int, end_col: int, new_text: str) -> dict:
+    return {
+        "range": {"start": {"line": start_line, "character": start_col}, "end": {"line": end_line, "character": end_col}},
+        "newText": new_text,
+    }
+
+
+class TestApplyTextEdits:
+    TEXT = 'resource "aws_instance" "web" {\n  ami = "ami-123"\n}\n'
+
+    @pytest.mark.parametrize(
+        ("text", "edits", "expected"),
+        [
+            pytest.param(TEXT, [], TEXT, id="no_edits"),
+            pytest.param(TEXT, [_edit(0, 25, 0, 28, "app")], 'resource "aws_instance" "app" {\n  ami = "ami-123"\n}\n', id="replace"),
+            pytest.param(
+                TEXT,
+                [_edit(0, 25, 0, 28, "app"), _edit(1, 13, 1, 16, "456")],
+                'resource "aws_instance" "app" {\n  ami = "ami-456"\n}\n',
+                id="multiple_edits_in_order",
+            ),
+            pytest.param(
+                TEXT,
+                [_edit(1, 13, 1, 16, "456"), _edit(0, 25, 0, 28, "app")],
+                'resource "aws_instance" "app" {\n  ami = "ami-456"\n}\n',
+                id="multiple_edits_in_reverse_order",
+            ),
+            pytest.param("ab", [_edit(0, 1, 0, 1, "1"), _edit(0, 1, 0, 1, "2")], "a12b", id="inserts_at_same_position_keep_order"),
+            pytest.param("ab", [_edit(0, 0, 0, 1, "X"), _edit(0, 0, 0, 0, "1")], "1Xb", id="insert_before_replacement"),
+            pytest.param("ab", [_edit(0, 0, 0, 1, "X"), _edit(0, 1, 0, 2, "Y")], "XY", id="adjacent_replacements"),
+            pytest.param("a\r\nb", [_edit(1, 0, 1, 1, "c")], "a\r\nc", id="crlf_line_endings"),
+            pytest.param("ab\ncd", [_edit(0, 1, 0, 99, "X")], "aX\ncd", id="column_beyond_line_end"),
+            pytest.param("a\n", [_edit(1, 0, 1, 0, "b\nc\n")], "a\nb\nc\n", id="multi_line_insert_at_eof"),
+            pytest.param("a", [_edit(1, 0, 1, 0, "b\nc")], "a\nb\nc", id="multi_line_insert_after_last_line_without_newline"),
+            pytest.param(
+                "a", [_edit(1, 0, 1, 0, "b"), _edit(1, 0, 1, 0, "c")], "a\nbc", id="inserts_after_last_line_without_newline"
+            ),
+            pytest.param("a\nb", [_edit(1, 0, 2, 0, "")], "a\n", id="delete_through_eof"),
+            pytest.param("", [_edit(0, 0, 0, 0, "x = 1\n")], "x = 1\n", id="insert_into_empty_text"),
+        ],
+    )
+    def test_apply(self, text: str, edits: list, expected: str) -> None:
+        assert TextUtils.apply_text_edits(text, edits) == expected
+
+    @pytest.mark.parametrize(
+        "edits",
+        [
+            pytest.param([_edit(0, 0, 0, 5, "X"), _edit(0, 3, 0, 8, "Y")], id="partial_overlap"),
+            pytest.param([_edit(0, 0, 1, 0, "X"), _edit(0, 3, 0, 4, "Y")], id="contained"),
+            pytest.param([_edit(0, 2, 0, 4, "X"), _edit(0, 2, 0, 6, "Y")], id="same_start"),
+        ],
+    )
+    def test_overlapping_edits_are_rejected(self, edits: list) -> None:
+        with pytest.raises(OverlappingTextEditsError):
+            TextUtils.apply_text_edits(self.TEXT, edits)
+
+    @pytest.mark.parametrize(
+        "edits",
+        [
+            pytest.param([_edit(5, 0, 5, 0, "X")], id="line_beyond_eof"),
+            pytest.param([_edit(1, 0, 0, 0, "X")], id="end_before_start"),
+        ],
+    )
+    def test_invalid_ranges_are_rejected(self, edits: list) -> None:
+        with pytest.raises(InvalidTextLocationError):
+            TextUtils.apply_text_edits(self.TEXT, edits)
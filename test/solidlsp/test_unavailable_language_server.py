@@ -0,0 +1,82 @@
+"""Unit tests: if terraform-ls is unavailable, the Terraform language server falls back to a degraded mode,
+in which requests fail with an error stating the reason while notifications are discarded.
+
+No language markers: these use a local test double and run in catch-all.
+"""
+
+import pytest
+
+from solidlsp.language_servers.terraform_ls import TerraformLS
+from solidlsp.ls_config import LanguageServerId
+from solidlsp.ls_exceptions import SolidLSPException
+from solidlsp.ls_process import UnavailableLanguageServer
+
+REASON = "Terraform executable not found"
+
+
+def test_requests_fail_with_reason() -> None:
+    server = UnavailableLanguageServer(LanguageServerId.TERRAFORM, REASON)
+    server.start()
+    assert server.is_running()
+    with pytest.raises(SolidLSPException) as exc_info:
+        server.send.references({"textDocument": {"uri": "file:///main.tf"}, "position": {"line": 0, "character": 0}})
+    assert REASON in str(exc_info.value)
+    assert not exc_info.value.is_language_server_terminated()
+    assert not server._pending_requests
+
+    # notifications are discarded
+    server.notify.did_open_text_document({"textDocument": {"uri": "file:///main.tf", "languageId": "terraform", "version": 0, "text": ""}})
+
+    server.stop()
+    assert not server.is_running()
+
+
+def _create_language_server(native_hcl_fallback: bool) -> TerraformLS:
+    language_server = object.__new__(TerraformLS)
+    language_server.ls_id = LanguageServerId.TERRAFORM
+    language_server.language_id = "terraform"
+    language_server._unavailability_reason = REASON
+    language_server._native_hcl_fallback = native_hcl_fallback
+    return language_server
+
+
+def test_degraded_mode() -> None:
+    language_server = _create_language_server(native_hcl_fallback=True)
+    assert language_server.is_degraded()
+    server = language_server._create_language_server_interface(None)
+    assert isinstance(server, UnavailableLanguageServer)
+    assert server.reason == REASON
+
+
+@pytest.mark.parametrize(
+    "request_diagnostics",
+    [
+        lambda ls: ls.request_text_document_diagnostics("main.tf"),
+        lambda ls: ls.request_published_text_document_diagnostics("main.tf"),
+        lambda ls: ls.request_published_diagnostics_for_files(["main.tf", "variables.tf"]),
+    ],
+)
+def test_diagnostics_fail_with_reason_in_degraded_mode(request_diagnostics) -> None:
+    language_server = _create_language_server(native_hcl_fallback=True)
+    language_server.server_started = True
+    with pytest.raises(SolidLSPException, match=REASON):
+        request_diagnostics(language_server)
+
+
+def test_failed_provisioning_falls_back_to_degraded_mode(monkeypatch: pytest.MonkeyPatch) -> None:
+    def fail_to_provide(*_args: object) -> None:
+        raise RuntimeError("download failed")
+
+    monkeypatch.setattr(TerraformLS, "_get_process_launch_info", fail_to_provide)
+    language_server = _create_language_server(native_hcl_fallback=True)
+    language_server._unavailability_reason = None
+    assert not language_server.is_degraded()
+    server = language_server._create_language_server_interface(None)
+    assert isinstance(server, UnavailableLanguageServer)
+    assert "download failed" in server.reason
+    assert language_server.is_degraded()
+
+    language_server = _create_language_server(native_hcl_fallback=False)
+    language_server._unavailability_reason = None
+    with pytest.raises(RuntimeError):
+        language_server._create_language_server_interface(None)
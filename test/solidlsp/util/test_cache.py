@@ -0,0 +1,22 @@
+from solidlsp.ls import SolidLanguageServer
+from solidlsp.util.cache import SymbolCacheStats, get_cache_num_entries, save_cache
+
+
+class TestSymbolCacheStats:
+    def test_hit_ratio(self) -> None:
+        assert SymbolCacheStats(num_entries=0, num_raw_entries=0, size_on_disk=0).get_hit_ratio() is None
+        stats = SymbolCacheStats(num_entries=2, num_raw_entries=2, size_on_disk=0, num_hits=3, num_misses=1)
+        assert stats.get_hit_ratio() == 0.75
+        assert "3 hits/1 misses (hit ratio 75%)" in stats.to_string()
+
+    def test_persisted_stats(self, tmp_path) -> None:
+        raw_cache_file, cache_file = SolidLanguageServer.get_symbol_cache_files(tmp_path)
+        save_cache(str(raw_cache_file), 1, {"main.tf": ("hash1", []), "vars.tf": ("hash2", [])})
+        assert get_cache_num_entries(str(raw_cache_file)) == 2
+        assert get_cache_num_entries(str(cache_file)) == 0
+
+        stats = SolidLanguageServer.read_persisted_symbol_cache_stats(tmp_path)
+        assert stats.num_raw_entries == 2
+        assert stats.num_entries == 0
+        assert stats.size_on_disk == raw_cache_file.stat().st_size
+        assert stats.get_hit_ratio() is None
@@ -0,0 +1,113 @@
+from solidlsp.lsp_protocol_handler.lsp_types import DocumentSymbol, SymbolKind
+from solidlsp.util.hcl import parse_hcl_block_body, parse_hcl_document_symbols
+
+PACKER_TEMPLATE = """# Packer template
+packer {
+  required_plugins {
+    amazon = { version = ">= 1.0", source = "github.com/hashicorp/amazon" }
+  }
+}
+
+variable "region" {
+  default = "eu-${var.zone == "a" ? "west" : "central"}-1" // comment with { brace
+}
+
+/* block comment
+   source "ignored" "block" { } */
+source "amazon-ebs" "ubuntu" {
+  ami_name      = "ubuntu"
+  instance_type = local.instance_type
+  volume_size   = 20
+  encrypted     = true
+  tags = {
+    Name = "packer"
+  }
+  user_data = <<-EOT
+    echo "{"
+  EOT
+}
+"""
+
+
+def _get_symbol(symbols: list[DocumentSymbol], name: str) -> DocumentSymbol:
+    return next(s for s in symbols if s["name"] == name)
+
+
+class TestParseHclDocumentSymbols:
+    def test_blocks(self) -> None:
+        symbols = parse_hcl_document_symbols(PACKER_TEMPLATE)
+        assert [s["name"] for s in symbols] == ["packer", 'variable "region"', 'source "amazon-ebs" "ubuntu"']
+        assert all(s["kind"] == SymbolKind.Class for s in symbols)
+
+        source = _get_symbol(symbols, 'source "amazon-ebs" "ubuntu"')
+        assert source["range"]["start"] == {"line": 13, "character": 0}
+        assert source["range"]["end"] == {"line": 24, "character": 1}
+        assert source["selectionRange"]["end"] == {"line": 13, "character": 28}
+
+        packer = _get_symbol(symbols, "packer")
+        required_plugins = _get_symbol(packer["children"], "required_plugins")
+        assert [s["name"] for s in required_plugins["children"]] == ["amazon"]
+
+    def test_attributes(self) -> None:
+        source = _get_symbol(parse_hcl_document_symbols(PACKER_TEMPLATE), 'source "amazon-ebs" "ubuntu"')
+        kinds = {s["name"]: s["kind"] for s in source["children"]}
+        assert kinds == {
+            "ami_name": SymbolKind.String,
+            "instance_type": SymbolKind.Variable,
+            "volume_size": SymbolKind.Number,
+            "encrypted": SymbolKind.Boolean,
+            "tags": SymbolKind.Object,
+            "user_data": SymbolKind.String,
+        }
+        tags = _get_symbol(source["children"], "tags")
+        assert tags["range"]["start"]["line"] == 18
+        assert tags["range"]["end"] == {"line": 20, "character": 3}
+
+        variable = _get_symbol(parse_hcl_document_symbols(PACKER_TEMPLATE), 'variable "region"')
+        assert [s["name"] for s in variable["children"]] == ["default"]
+
+    def test_single_line_block(self) -> None:
+        symbols = parse_hcl_document_symbols('job "web" { datacenters = ["dc1"] }\n')
+        assert [s["name"] for s in symbols] == ['job "web"']
+        assert [(s["name"], s["kind"]) for s in symbols[0]["children"]] == [("datacenters", SymbolKind.Array)]
+
+    def test_syntax_errors_are_tolerated(self) -> None:
+        symbols = parse_hcl_document_symbols('broken "block"\n] oops\n}\nvalid {\n  a = (1 +\n}\n')
+        assert [s["name"] for s in symbols] == ["valid"]
+
+    def test_crlf_line_endings(self) -> None:
+        symbols = parse_hcl_document_symbols(PACKER_TEMPLATE.replace("\n", "\r\n"))
+        assert [s["name"] for s in symbols] == ["packer", 'variable "region"', 'source "amazon-ebs" "ubuntu"']
+        source = _get_symbol(symbols, 'source "amazon-ebs" "ubuntu"')
+        user_data = _get_symbol(source["children"], "user_data")
+        assert user_data["kind"] == SymbolKind.String
+        assert user_data["range"]["end"] == {"line": 23, "character": 5}
+
+
+class TestParseHclBlockBody:
+    def test_attributes(self) -> None:
+        content = PACKER_TEMPLATE[PACKER_TEMPLATE.index('source "amazon-ebs"') :]
+        block_body = parse_hcl_block_body(content)
+        assert block_body is not None
+        assert [a.name for a in block_body.attributes] == ["ami_name", "instance_type", "volume_size", "encrypted", "tags", "user_data"]
+        values = {a.name: content[a.value_start : a.value_end] for a in block_body.attributes}
+        assert values["instance_type"] == "local.instance_type"
+        assert values["tags"] == '{\n    Name = "packer"\n  }'
+        assert values["user_data"].startswith("<<-EOT") and values["user_data"].endswith("EOT")
+        assert block_body.closing_brace_offset == content.rindex("}")
+
+    def test_attributes_of_nested_blocks_are_skipped(self) -> None:
+        block_body = parse_hcl_block_body(PACKER_TEMPLATE[PACKER_TEMPLATE.index("packer {") :])
+        assert block_body is not None
+        assert block_body.attributes == []
+
+    def test_single_line_block(self) -> None:
+        content = 'job "web" { datacenters = ["dc1"] }'
+        block_body = parse_hcl_block_body(content)
+        assert block_body is not None
+        [attribute] = block_body.attributes
+        assert content[attribute.value_start : attribute.value_end] == '["dc1"]'
+        assert block_body.opening_brace_offset == 10
+
+    def test_no_block(self) -> None:
+        assert parse_hcl_block_body('region = "eu-west-1"') is None
@@ -104,3 +104,93 @@ def test_read_file_primary_path_normalizes_crlf(tmp_path: Path) -> None:
 
     assert "\r" not in content
     assert content.splitlines() == lines
+
+
+def test_read_file_strips_utf8_bom(tmp_path: Path) -> None:
+    """A leading UTF-8 BOM must not become part of the content (and thus of symbol names or regex matches)."""
+    file_path = tmp_path / "bom.tf"
+    file_path.write_bytes(b'\xef\xbb\xbfresource "a" "b" {}\n')
+
+    content, encoding = FileUtils.read_file_with_encoding(str(file_path), "utf-8")
+
+    assert content == 'resource "a" "b" {}\n'
+    assert encoding == "utf-8"
+    assert FileUtils.has_utf8_bom(str(file_path))
+
+
+def test_write_file_restores_utf8_bom(tmp_path: Path) -> None:
+    """Rewriting a file which starts with a BOM should retain the BOM (exactly once)."""
+    file_path = tmp_path / "bom.tf"
+    file_path.write_bytes(b"\xef\xbb\xbfa = 1\n")
+
+    FileUtils.write_file(str(file_path), "a = 2\n", "utf-8")
+    assert file_path.read_bytes() == b"\xef\xbb\xbfa = 2\n"
+
+    FileUtils.write_file(str(file_path), FileUtils.UTF8_BOM + "a = 3\n", "utf-8")
+    assert file_path.read_bytes() == b"\xef\xbb\xbfa = 3\n"
+
+
+def test_write_file_does_not_add_bom(tmp_path: Path) -> None:
+    file_path = tmp_path / "no_bom.tf"
+    file_path.write_bytes(b"a = 1\n")
+
+    FileUtils.write_file(str(file_path), "a = 2\n", "utf-8")
+    FileUtils.write_file(str(tmp_path / "new.tf"), "a = 1\n", "utf-8")
+
+    assert file_path.read_bytes() == b"a = 2\n"
+    assert (tmp_path / "new.tf").read_bytes() == b"a = 1\n"
+    assert not FileUtils.has_utf8_bom(str(file_path))
+
+
+@pytest.mark.parametrize("line_ending", ["\r\n", "\n", "\r"])
+def test_write_file_retains_line_ending(tmp_path: Path, line_ending: str) -> None:
+    """Rewriting a file should retain its line ending irrespective of the given newline (which applies to new files)."""
+    file_path = tmp_path / "main.tf"
+    file_path.write_bytes(f"a = 1{line_ending}b = 2{line_ending}".encode())
+
+    FileUtils.write_file(str(file_path), "a = 1\nb = 3\n", "utf-8", newline="\n" if line_ending != "\n" else "\r\n")
+
+    assert file_path.read_bytes() == f"a = 1{line_ending}b = 3{line_ending}".encode()
+    assert FileUtils.detect_line_ending(str(file_path), "utf-8") == line_ending
+
+
+def test_write_file_uses_given_newline_for_new_files(tmp_path: Path) -> None:
+    FileUtils.write_file(str(tmp_path / "new.tf"), "a = 1\r\nb = 2\n", "utf-8", newline="\r\n")
+    FileUtils.write_file(str(tmp_path / "empty.tf"), "", "utf-8")
+    FileUtils.write_file(str(tmp_path / "empty.tf"), "a = 1\n", "utf-8", newline="\r\n")
+
+    assert (tmp_path / "new.tf").read_bytes() == b"a = 1\r\nb = 2\r\n"
+    assert (tmp_path / "empty.tf").read_bytes() == b"a = 1\r\n"
+    assert FileUtils.detect_line_ending(str(tmp_path / "missing.tf"), "utf-8") is None
+
+
+@pytest.mark.parametrize("encoding", ["utf-16", "latin-1", "utf-8-sig"])
+def test_write_file_uses_given_encoding(tmp_path: Path, encoding: str) -> None:
+    file_path = tmp_path / "main.tf"
+    file_path.write_bytes('name = "Fernández"\n'.encode(encoding))
+
+    FileUtils.write_file(str(file_path), 'name = "José"\n', encoding)
+
+    assert file_path.read_bytes() == 'name = "José"\n'.encode(encoding)
+    assert FileUtils.read_file(str(file_path), encoding) == 'name = "José"\n'
+
+
+def test_write_file_retains_detected_encoding(tmp_path: Path) -> None:
+    """A file which cannot be decoded with the given encoding should retain the encoding detected when reading it."""
+    file_path = tmp_path / "main.tf"
+    file_path.write_bytes('name = "Fernández"\n'.encode("utf-16"))
+    content = FileUtils.read_file(str(file_path), "utf-8")
+
+    FileUtils.write_file(str(file_path), content.replace("Fernández", "José"), "utf-8")
+
+    assert file_path.read_bytes().decode("utf-16") == 'name = "José"\n'
+
+
+def test_write_file_does_not_truncate_file_on_encoding_error(tmp_path: Path) -> None:
+    file_path = tmp_path / "main.tf"
+    file_path.write_bytes(b"a = 1\n")
+
+    with pytest.raises(ValueError, match="cannot be encoded"):
+        FileUtils.write_file(str(file_path), 'a = "€"\n', "latin-1")
+
+    assert file_path.read_bytes() == b"a = 1\n"
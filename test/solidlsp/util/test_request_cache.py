@@ -0,0 +1,90 @@
+from solidlsp.util.request_cache import PositionRequestCache
+
+
+class _FakeClock:
+    def __init__(self) -> None:
+        self.now = 0.0
+
+    def __call__(self) -> float:
+        return self.now
+
+
+class _CountingRequest:
+    def __init__(self, result: object) -> None:
+        self.result = result
+        self.num_calls = 0
+
+    def __call__(self) -> object:
+        self.num_calls += 1
+        return self.result
+
+
+class TestPositionRequestCache:
+    KEY = PositionRequestCache.create_key("file:///main.tf", "hash1", "request_definition", 3, 7)
+
+    def test_repeated_request_is_cached(self) -> None:
+        cache = PositionRequestCache(ttl=10)
+        request = _CountingRequest([{"relativePath": "vars.tf"}])
+        assert cache.get_or_compute(self.KEY, request) == [{"relativePath": "vars.tf"}]
+        assert cache.get_or_compute(self.KEY, request) == [{"relativePath": "vars.tf"}]
+        assert request.num_calls == 1
+
+    def test_none_result_is_cached(self) -> None:
+        cache = PositionRequestCache(ttl=10)
+        request = _CountingRequest(None)
+        assert cache.get_or_compute(self.KEY, request) is None
+        assert cache.get_or_compute(self.KEY, request) is None
+        assert request.num_calls == 1
+
+    def test_key_includes_document_version_and_position(self) -> None:
+        cache = PositionRequestCache(ttl=10)
+        request = _CountingRequest([])
+        cache.get_or_compute(self.KEY, request)
+        cache.get_or_compute(PositionRequestCache.create_key("file:///main.tf", "hash2", "request_definition", 3, 7), request)
+        cache.get_or_compute(PositionRequestCache.create_key("file:///main.tf", "hash1", "request_definition", 3, 8), request)
+        cache.get_or_compute(PositionRequestCache.create_key("file:///main.tf", "hash1", "request_hover", 3, 7), request)
+        assert request.num_calls == 4
+
+    def test_entries_expire(self) -> None:
+        clock = _FakeClock()
+        cache = PositionRequestCache(ttl=10, clock=clock)
+        request = _CountingRequest([])
+        cache.get_or_compute(self.KEY, request)
+        clock.now = 9.9
+        cache.get_or_compute(self.KEY, request)
+        assert request.num_calls == 1
+        clock.now = 10.1
+        cache.get_or_compute(self.KEY, request)
+        assert request.num_calls == 2
+
+    def test_clear(self) -> None:
+        cache = PositionRequestCache(ttl=10)
+        request = _CountingRequest([])
+        cache.get_or_compute(self.KEY, request)
+        cache.clear()
+        cache.get_or_compute(self.KEY, request)
+        assert request.num_calls == 2
+
+    def test_disabled(self) -> None:
+        cache = PositionRequestCache(ttl=0)
+        request = _CountingRequest([])
+        cache.get_or_compute(self.KEY, request)
+        cache.get_or_compute(self.KEY, request)
+        assert request.num_calls == 2
+
+    def test_cached_result_cannot_be_mutated_by_caller(self) -> None:
+        cache = PositionRequestCache(ttl=10)
+        result = cache.get_or_compute(self.KEY, lambda: [{"relativePath": "vars.tf"}])
+        result.append({"relativePath": "other.tf"})
+        assert cache.get_or_compute(self.KEY, lambda: []) == [{"relativePath": "vars.tf"}]
+
+    def test_oldest_entries_are_evicted(self) -> None:
+        cache = PositionRequestCache(ttl=10, max_entries=2)
+        request = _CountingRequest([])
+        keys = [PositionRequestCache.create_key("file:///main.tf", "hash1", "request_hover", line, 0) for line in range(3)]
+        for key in keys:
+            cache.get_or_compute(key, request)
+        cache.get_or_compute(keys[2], request)
+        assert request.num_calls == 3
+        cache.get_or_compute(keys[0], request)
+        assert request.num_calls == 4
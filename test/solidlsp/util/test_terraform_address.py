@@ -0,0 +1,113 @@
+import os
+
+import pytest
+
+from solidlsp.language_servers.terraform_ls import TerraformLS
+from solidlsp.util.terraform_address import (
+    LocalModuleSource,
+    TerraformAddress,
+    find_local_module_call,
+    find_local_module_source,
+    find_local_module_sources,
+    format_moved_block,
+)
+
+
+class TestTerraformAddress:
+    @pytest.mark.parametrize(
+        "address, expected_module_names, expected_name_path",
+        [
+            pytest.param("aws_instance.web", [], '/resource "aws_instance" "web"', id="resource"),
+            pytest.param("resource.aws_instance.web", [], '/resource "aws_instance" "web"', id="resource with prefix"),
+            pytest.param("aws_instance.web[0]", [], '/resource "aws_instance" "web"', id="resource instance"),
+            pytest.param("aws_s3_*.logs", [], '/resource "aws_s3_*" "logs"', id="resource with wildcard"),
+            pytest.param("data.aws_ami.ubuntu", [], '/data "aws_ami" "ubuntu"', id="data source"),
+            pytest.param("var.region", [], '/variable "region"', id="variable"),
+            pytest.param("local.tags", [], "/locals/tags", id="local value"),
+            pytest.param("output.vpc_id", [], '/output "vpc_id"', id="output"),
+            pytest.param("module.vpc", [], '/module "vpc"', id="module call"),
+            pytest.param("module.vpc.aws_subnet.private", ["vpc"], '/resource "aws_subnet" "private"', id="resource in module"),
+            pytest.param('module.vpc["eu"].module.subnets.var.cidr', ["vpc", "subnets"], '/variable "cidr"', id="nested modules"),
+        ],
+    )
+    def test_parse(self, address: str, expected_module_names: list[str], expected_name_path: str) -> None:
+        assert TerraformAddress.parse(address) == TerraformAddress(module_names=expected_module_names, name_path=expected_name_path)
+
+    @pytest.mark.parametrize("name_path", ['resource "aws_instance" "web"', "UserService/create_user", "locals/tags", "web", "var.a.b"])
+    def test_parse_non_address(self, name_path: str) -> None:
+        assert TerraformAddress.parse(name_path) is None
+
+
+def test_find_local_module_source() -> None:
+    contents = [
+        'variable "region" {}\n',
+        '# module "vpc" { source = "./commented" }\nmodule "vpc" {\n  source = "./modules/vpc"\n  cidr   = "10.0.0.0/16"\n}\n',
+    ]
+    assert find_local_module_source(contents, "vpc") == "./modules/vpc"
+    assert find_local_module_source(contents, "dns") is None
+    # the source argument of a subsequent block is not attributed to a module call without one
+    contents = ['module "vpc" {\n  cidr = "10.0.0.0/16"\n}\n\nmodule "dns" {\n  source = "./modules/dns"\n}\n']
+    assert find_local_module_source(contents, "vpc") is None
+    assert find_local_module_source(contents, "dns") == "./modules/dns"
+
+
+def test_find_local_module_call() -> None:
+    contents = [
+        'module "dns" {\n  source = "hashicorp/dns/aws"\n}\n',
+        'module "vpc" {\n  source = "./modules/vpc"\n}\n',
+    ]
+    assert find_local_module_call(contents, "", os.path.join("modules", "vpc")) == "vpc"
+    assert find_local_module_call(contents, "", "modules") is None
+    assert find_local_module_call(['module "root" {\n  source = "../.."\n}\n'], os.path.join("modules", "vpc"), "") == "root"
+
+
+def test_find_local_module_sources() -> None:
+    contents = (
+        'module "dns" {\n  source = "hashicorp/dns/aws"\n}\n\n'
+        'module "vpc" {\n  source = "../modules/vpc"\n}\n'
+        'module "app" {\n  source = "./app"\n}\n'
+        'module "db" {\n  engine = "postgres"\n}\n'
+        'resource "aws_s3_bucket" "logs" {\n  source = "./logs"\n}\n'
+    )
+    module_sources = find_local_module_sources(contents)
+    assert module_sources == [
+        LocalModuleSource(module_name="vpc", source="../modules/vpc", line=5),
+        LocalModuleSource(module_name="app", source="./app", line=8),
+    ]
+    assert module_sources[0].resolve("envs") == os.path.join("modules", "vpc")
+    assert module_sources[1].resolve("") == "app"
+
+
+def test_format_moved_block() -> None:
+    assert format_moved_block("aws_instance.web", "module.app.aws_instance.web") == (
+        "moved {\n  from = aws_instance.web\n  to   = module.app.aws_instance.web\n}\n"
+    )
+
+
+def test_resolve_address_in_local_module(tmp_path) -> None:
+    (tmp_path / "main.tf").write_text(
+        'module "vpc" {\n  source = "./modules/vpc"\n}\n\nmodule "dns" {\n  source = "hashicorp/dns/aws"\n}\n'
+    )
+    (tmp_path / "modules" / "vpc").mkdir(parents=True)
+    (tmp_path / "modules" / "vpc" / "main.tf").write_text('module "subnets" {\n  source = "../subnets"\n}\n')
+    language_server = object.__new__(TerraformLS)
+    language_server.repository_root_path = str(tmp_path)
+    language_server._encoding = "utf-8"
+
+    assert language_server.resolve_symbol_address("var.region") == ('/variable "region"', None)
+    assert language_server.resolve_symbol_address("module.vpc.aws_subnet.private") == (
+        '/resource "aws_subnet" "private"',
+        os.path.join("modules", "vpc"),
+    )
+    assert language_server.resolve_symbol_address("module.vpc.module.subnets.var.cidr") == (
+        '/variable "cidr"',
+        os.path.join("modules", "subnets"),
+    )
+    assert language_server.resolve_symbol_address("UserService/create_user") is None
+    with pytest.raises(ValueError, match="non-local source"):
+        language_server.resolve_symbol_address("module.dns.aws_route53_zone.main")
+    with pytest.raises(ValueError, match="not found"):
+        language_server.resolve_symbol_address("module.db.aws_db_instance.main")
+    # the directory of module.subnets does not exist
+    with pytest.raises(ValueError, match="does not exist"):
+        language_server.resolve_symbol_address("module.vpc.module.subnets.module.cidr.var.cidr")